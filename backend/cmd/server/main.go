@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/macrolens/backend/config"
 	httpDelivery "github.com/macrolens/backend/internal/delivery/http"
+	"github.com/macrolens/backend/internal/domain"
 	"github.com/macrolens/backend/internal/infrastructure/cache"
+	"github.com/macrolens/backend/internal/infrastructure/feedback"
+	"github.com/macrolens/backend/internal/infrastructure/logging"
 	"github.com/macrolens/backend/internal/infrastructure/usda"
 	"github.com/macrolens/backend/internal/usecase"
 )
@@ -19,52 +29,313 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Route all logging, including the standard log package, through the leveled logger
+	logLevel, err := logging.ParseLevel(cfg.Server.LogLevel)
+	if err != nil {
+		log.Fatalf("Invalid log level: %v", err)
+	}
+	slog.SetDefault(logging.New(os.Stdout, logLevel, cfg.Server.LogFormat))
+	debug := logLevel <= slog.LevelDebug
+
 	log.Printf("Starting MacroLens Backend v1.0.0")
 	log.Printf("Environment: %s", cfg.Server.Environment)
 	log.Printf("Port: %s", cfg.Server.Port)
 	log.Printf("Cache Type: %s", cfg.Cache.Type)
 
 	// Initialize infrastructure dependencies
-	memoryCache := cache.NewMemoryCache()
+	memoryCache := cache.NewMemoryCacheWithConfig(cache.MemoryCacheConfig{
+		CleanupInterval: cfg.Cache.CleanupInterval,
+		MaxEntries:      cfg.Cache.MaxEntries,
+	})
+	// Only a primary in-memory cache is persisted. Behind Redis it is the outage
+	// fallback, and a loaded file would serve stale (even deleted) entries there.
+	persistPath := cfg.Cache.PersistPath
+	if persistPath != "" && cfg.Cache.Type != "memory" {
+		slog.Warn("Cache persistence: ignored unless the cache type is memory", "path", persistPath, "cacheType", cfg.Cache.Type)
+		persistPath = ""
+	}
+	if path := persistPath; path != "" {
+		// A bad file shouldn't block startup; the cache just starts cold
+		loaded, err := memoryCache.LoadFromFile(path)
+		if err != nil {
+			slog.Warn("Cache persistence: starting empty", "path", path, "error", err)
+		} else {
+			log.Printf("Cache persistence: loaded %d entries from %s", loaded, path)
+		}
+	}
+	nutritionCache, err := newCache(cfg, memoryCache)
+	if err != nil {
+		log.Fatalf("Failed to initialize cache: %v", err)
+	}
 	log.Printf("Cache TTL: %s", cfg.Cache.TTL)
 
-	usdaClient := usda.NewClient(cfg.USDA.APIKey, cfg.USDA.BaseURL)
-	if cfg.USDA.APIKey != "" {
-		log.Printf("USDA API configured: %s (key: configured)", cfg.USDA.BaseURL)
+	var usdaClient domain.USDAClient
+	if cfg.USDA.Mock {
+		mockClient, err := usda.NewMockUSDAClient()
+		if err != nil {
+			log.Fatalf("Failed to load mock USDA fixtures: %v", err)
+		}
+		usdaClient = mockClient
+		log.Printf("USDA API mocked: serving canned fixture foods (MACROLENS_MOCK_USDA=true)")
 	} else {
-		log.Printf("USDA API configured: %s (key: not configured)", cfg.USDA.BaseURL)
+		opts := usda.ClientOptions{
+			Timeout:    cfg.USDA.Timeout,
+			MaxRetries: cfg.USDA.MaxRetries,
+			DataTypes:  cfg.USDA.DataTypes,
+		}
+		if ttl := cfg.USDA.DetailsCacheTTL; ttl > 0 {
+			opts.DetailsCache = nutritionCache
+			opts.DetailsCacheTTL = ttl
+			opts.CacheNamespace = cfg.Cache.Namespace
+		}
+		client := usda.NewClientWithOptions(cfg.USDA.APIKey, cfg.USDA.BaseURL, opts)
+		if threshold := cfg.USDA.BreakerFailureThreshold; threshold > 0 {
+			client.SetCircuitBreaker(usda.NewCircuitBreaker(usda.CircuitBreakerConfig{
+				FailureThreshold:   threshold,
+				OpenDuration:       cfg.USDA.BreakerOpenDuration,
+				CountRetryAttempts: cfg.USDA.BreakerCountRetryAttempts,
+			}))
+		}
+		usdaClient = client
+		if cfg.USDA.APIKey != "" {
+			log.Printf("USDA API configured: %s (keys: %d configured)", cfg.USDA.BaseURL, client.KeyCount())
+		} else {
+			log.Printf("USDA API configured: %s (key: not configured)", cfg.USDA.BaseURL)
+		}
+	}
+
+	calibration, err := usecase.ParseCalibration(cfg.Matching.Calibration)
+	if err != nil {
+		log.Fatalf("Invalid matching calibration: %v", err)
+	}
+
+	algorithm, err := usecase.ParseMatchAlgorithm(cfg.Matching.Algorithm)
+	if err != nil {
+		log.Fatalf("Invalid matching algorithm: %v", err)
+	}
+
+	synonyms, err := usecase.ParseSynonyms(cfg.Matching.Synonyms)
+	if err != nil {
+		log.Fatalf("Invalid matching synonyms: %v", err)
+	}
+
+	brandAliases, err := usecase.ParseBrandAliases(cfg.Matching.BrandAliases)
+	if err != nil {
+		log.Fatalf("Invalid matching brand aliases: %v", err)
+	}
+
+	retailerStoreBrands, err := usecase.ParseRetailerTerms(cfg.Matching.RetailerStoreBrands)
+	if err != nil {
+		log.Fatalf("Invalid retailer store brands: %v", err)
+	}
+
+	retailerNoiseWords, err := usecase.ParseRetailerTerms(cfg.Matching.RetailerNoiseWords)
+	if err != nil {
+		log.Fatalf("Invalid retailer noise words: %v", err)
+	}
+
+	dataTypeBonuses := map[string]float64{
+		"Branded":        cfg.Matching.BrandedBonus,
+		"Survey (FNDDS)": cfg.Matching.SurveyBonus,
+		"SR Legacy":      cfg.Matching.SRLegacyBonus,
+		"Foundation":     cfg.Matching.FoundationBonus,
 	}
 
 	// Initialize usecase layer
 	nutritionService := usecase.NewNutritionService(
-		memoryCache,
+		nutritionCache,
 		usdaClient,
 		usecase.NutritionServiceConfig{
-			CacheTTL:               cfg.Cache.TTL,
-			MinConfidenceThreshold: cfg.Matching.MinConfidenceThreshold,
-			EnableFuzzyMatching:    cfg.Matching.EnableFuzzyMatching,
-			EnableDebugLogging:     cfg.Matching.EnableDebugLogging,
+			CacheTTL:                cfg.Cache.TTL,
+			MinConfidenceThreshold:  cfg.Matching.MinConfidenceThreshold,
+			EnableFuzzyMatching:     cfg.Matching.EnableFuzzyMatching,
+			EnableDebugLogging:      debug,
+			ReportRawConfidence:     cfg.Matching.ReportRawConfidence,
+			EnableMatchCache:        cfg.Cache.EnableMatchCache,
+			MatchCacheTTL:           cfg.Cache.MatchCacheTTL,
+			NotFoundCacheTTL:        cfg.Cache.NotFoundTTL,
+			ThinPoolSize:            cfg.Matching.ThinPoolSize,
+			ThinPoolDiscount:        cfg.Matching.ThinPoolDiscount,
+			FetchFoodDetails:        cfg.USDA.FetchFoodDetails,
+			EnableQualifierPhrases:  cfg.Matching.EnableQualifierPhrases,
+			QualifierPhrases:        cfg.Matching.QualifierPhrases,
+			CompoundFoods:           cfg.Matching.CompoundFoods,
+			CacheNamespace:          cfg.Cache.Namespace,
+			EnableHouseholdMeasures: cfg.USDA.EnableHouseholdMeasures,
+			RejectControlChars:      cfg.Server.RejectControlChars,
+			Calibration:             calibration,
+			StoreBrandGenerics:      cfg.Matching.StoreBrandGenerics,
+			ConfirmedMatchBonus:     cfg.Matching.ConfirmedMatchBonus,
+			CategoryMismatchPenalty: cfg.Matching.CategoryMismatchPenalty,
+			BrandAwareDataTypes:     cfg.USDA.BrandAwareDataTypes,
+			DataTypes:               cfg.USDA.DataTypes,
+			IncludeServingGrams:     cfg.USDA.IncludeServingGrams,
+			KeepHigherConfidence:    cfg.Cache.KeepHigherConfidence,
+			ExtraFoodTerms:          cfg.Matching.ExtraFoodTerms,
+			ExtraDescriptiveTerms:   cfg.Matching.ExtraDescriptiveTerms,
+			ExtraStopWords:          cfg.Matching.ExtraStopWords,
+			Synonyms:                synonyms,
+			SynonymWeight:           cfg.Matching.SynonymWeight,
+			NormalizePlurals:        cfg.Matching.NormalizePlurals,
+			Algorithm:               algorithm,
+			PhoneticBrandMatch:      cfg.Matching.PhoneticBrandMatch,
+			RequestTimeout:          cfg.Server.SearchTimeout,
+			FuzzyMinTokenLength:     cfg.Matching.FuzzyMinTokenLength,
+			RecencyBonus:            cfg.Matching.RecencyBonus,
+			MaxRequestCacheTTL:      cfg.Cache.MaxRequestTTL,
+			DataTypeBonuses:         dataTypeBonuses,
+			EarlyExitScore:          cfg.Matching.EarlyExitScore,
+			MaxMatchCandidates:      cfg.Matching.MaxCandidates,
+			SubstringMinLength:      cfg.Matching.SubstringMinLength,
+			PhraseBonus:             cfg.Matching.PhraseBonus,
+			BrandBonus:              cfg.Matching.BrandBonus,
+			BrandAliases:            brandAliases,
+			RetailerStoreBrands:     retailerStoreBrands,
+			RetailerNoiseWords:      retailerNoiseWords,
+			ProbabilityCalibration: usecase.ProbabilityCalibration{
+				Midpoint:  cfg.Matching.ProbabilityMidpoint,
+				Steepness: cfg.Matching.ProbabilitySteepness,
+			},
 		},
 	)
 
-	log.Printf("Matching: confidence=%.0f%%, fuzzy=%v, debug=%v",
+	log.Printf("Matching: confidence=%.0f%%, fuzzy=%v, algorithm=%s, debug=%v",
 		cfg.Matching.MinConfidenceThreshold,
 		cfg.Matching.EnableFuzzyMatching,
-		cfg.Matching.EnableDebugLogging)
+		algorithm,
+		debug)
 
 	// Create HTTP handler with dependencies
 	handler := httpDelivery.NewHandler(nutritionService)
+	if cfg.Cache.Type == "memory" {
+		// Export/import snapshots only the in-process cache
+		handler.SetCacheSnapshotter(memoryCache)
+	}
+	handler.SetCacheType(cfg.Cache.Type)
+	if stats, ok := nutritionCache.(domain.CacheStatsProvider); ok {
+		handler.SetCacheStats(stats)
+	}
+	handler.SetMaxResponseBytes(cfg.Server.MaxResponseBytes)
+	handler.SetAllowExplain(cfg.Server.AllowExplain)
+	handler.SetServerTiming(cfg.Server.TimingHeader)
+	if cfg.Server.AdminAPIKey != "" {
+		log.Printf("Admin endpoints enabled: /api/v1/cache/export, /api/v1/cache/import, /api/v1/cache/stats")
+	}
+	if window := cfg.Matching.ConfidenceHistogramWindow; window > 0 {
+		histogram := usecase.NewConfidenceHistogram(window)
+		nutritionService.SetConfidenceHistogram(histogram)
+		handler.SetConfidenceHistogram(histogram)
+		if interval := cfg.Matching.ConfidenceHistogramLogInterval; interval > 0 {
+			go logConfidenceHistogram(histogram, interval)
+		}
+		log.Printf("Confidence monitoring enabled: window=%s", window)
+	}
+	if cfg.Feedback.Enabled {
+		feedbackStore := feedback.NewMemoryStore(cfg.Feedback.BufferSize)
+		handler.SetFeedbackService(usecase.NewFeedbackService(feedbackStore, usdaClient))
+		nutritionService.SetFeedbackStore(feedbackStore)
+		log.Printf("Match feedback enabled: last %d entries kept", cfg.Feedback.BufferSize)
+	}
 
 	// Setup router
 	router := httpDelivery.SetupRouter(cfg, handler)
 
-	// Start server
-	addr := fmt.Sprintf(":%s", cfg.Server.Port)
-	log.Printf("Server listening on %s", addr)
+	// Start server; SIGINT/SIGTERM drains in-flight requests before exiting
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	if err := router.Run(addr); err != nil {
+	addr := fmt.Sprintf(":%s", cfg.Server.Port)
+	if err := runServer(ctx, addr, router, cfg.Server.ShutdownTimeout); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
+
+	if path := persistPath; path != "" {
+		if err := memoryCache.SaveToFile(path); err != nil {
+			slog.Error("Cache persistence: failed to save", "path", path, "error", err)
+		} else {
+			log.Printf("Cache persistence: saved to %s", path)
+		}
+	}
+}
+
+// runServer serves handler on addr until ctx is done, then shuts down gracefully:
+// it stops accepting connections and waits up to shutdownTimeout for in-flight
+// requests. Requests still running after that have their context cancelled, so
+// USDA calls abort cleanly instead of being cut off mid-write.
+func runServer(ctx context.Context, addr string, handler http.Handler, shutdownTimeout time.Duration) error {
+	// Every request context derives from baseCtx, so cancelling it reaches handlers
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	server := &http.Server{
+		Addr:        addr,
+		Handler:     handler,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server listening on %s", addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutdown signal received: draining in-flight requests (timeout %s)", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Shutdown timed out: cancelling remaining requests")
+		cancelRequests()
+		server.Close()
+	}
+	<-serverErr
+	log.Printf("Server stopped")
+	return nil
+}
+
+// newCache builds the cache selected by cfg.Cache.Type. For Redis, the optional
+// in-memory L1 tier and memory fallback wrap the Redis cache.
+func newCache(cfg *config.Config, memoryCache *cache.MemoryCache) (domain.CacheRepository, error) {
+	if cfg.Cache.Type != "redis" {
+		return memoryCache, nil
+	}
+
+	redisCache, err := cache.NewRedisCache(cfg.Cache.RedisURL)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Redis cache connected")
+
+	var l2 domain.CacheRepository = redisCache
+	if cfg.Cache.FallbackToMemory {
+		l2 = cache.NewFallbackCache(redisCache, memoryCache)
+		log.Printf("Cache fallback enabled: serving from memory while Redis is unavailable")
+	}
+
+	if cfg.Cache.L1Size <= 0 {
+		return l2, nil
+	}
+	log.Printf("Cache L1 tier enabled: %d entries, ttl=%s", cfg.Cache.L1Size, cfg.Cache.L1TTL)
+	return cache.NewTieredCache(cache.NewMemoryCache(), l2, cache.TieredCacheConfig{
+		L1Size: cfg.Cache.L1Size,
+		L1TTL:  cfg.Cache.L1TTL,
+	}), nil
+}
+
+// logConfidenceHistogram logs the confidence distribution every interval
+func logConfidenceHistogram(histogram *usecase.ConfidenceHistogram, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		log.Printf("Confidence distribution: %s", histogram.Snapshot())
+	}
 }
 
 func init() {
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strings"
 	"time"
@@ -9,6 +10,15 @@ import (
 	"github.com/spf13/viper"
 )
 
+// usdaDataTypes are the FDC data type names USDA search accepts
+var usdaDataTypes = map[string]bool{
+	"Branded":        true,
+	"Foundation":     true,
+	"Survey (FNDDS)": true,
+	"SR Legacy":      true,
+	"Experimental":   true,
+}
+
 // Config holds all configuration for the application
 type Config struct {
 	Server    ServerConfig
@@ -16,26 +26,134 @@ type Config struct {
 	Cache     CacheConfig
 	RateLimit RateLimitConfig
 	Matching  MatchingConfig
+	Feedback  FeedbackConfig
 }
 
 // MatchingConfig holds product matching algorithm configuration
 type MatchingConfig struct {
 	MinConfidenceThreshold float64 `mapstructure:"min_confidence_threshold"`
 	EnableFuzzyMatching    bool    `mapstructure:"enable_fuzzy_matching"`
-	EnableDebugLogging     bool    `mapstructure:"enable_debug_logging"`
+	ReportRawConfidence    bool    `mapstructure:"report_raw_confidence"`
+	ThinPoolSize           int     `mapstructure:"thin_pool_size"`
+	ThinPoolDiscount       float64 `mapstructure:"thin_pool_discount"`
+	EnableQualifierPhrases bool    `mapstructure:"enable_qualifier_phrases"` // Keep "gluten free" etc. as one token
+	Calibration            string  `mapstructure:"calibration"`              // Raw-to-calibrated curve, e.g. "0:0,50:30,80:90,100:100"
+	StoreBrandGenerics     bool    `mapstructure:"store_brand_generics"`     // "Great Value Milk" -> "whole milk", preferring generic USDA data
+	ConfirmedMatchBonus    float64 `mapstructure:"confirmed_match_bonus"`    // Bonus for fdcIds confirmed via feedback (0 disables)
+	NormalizePlurals       bool    `mapstructure:"normalize_plurals"`        // Match "strawberries" to "strawberry"
+	Algorithm              string  `mapstructure:"algorithm"`                // "token_weighted", "jaro_winkler" or "token_f1"
+	PhoneticBrandMatch     bool    `mapstructure:"phonetic_brand_match"`     // Reduced brand bonus when the brand only sounds like the description
+
+	// Shortest token fuzzy matching considers; lower it to 3 to catch typos in short words (floor 3)
+	FuzzyMinTokenLength int `mapstructure:"fuzzy_min_token_length"`
+
+	// Most a recently published USDA entry gains over older duplicates; small so it only breaks near-ties (0 disables)
+	RecencyBonus float64 `mapstructure:"recency_bonus"`
+
+	// Score bonus per USDA data type; raise Foundation above Branded to prefer lab-measured data
+	BrandedBonus    float64 `mapstructure:"branded_bonus"`
+	SurveyBonus     float64 `mapstructure:"survey_bonus"`
+	SRLegacyBonus   float64 `mapstructure:"sr_legacy_bonus"`
+	FoundationBonus float64 `mapstructure:"foundation_bonus"`
+
+	// Logistic curve reporting calibratedConfidence, a 0-1 match probability (steepness 0 disables)
+	ProbabilityMidpoint  float64 `mapstructure:"probability_midpoint"`
+	ProbabilitySteepness float64 `mapstructure:"probability_steepness"`
+
+	// Matching stops at the first candidate scoring EarlyExitScore (0 disables) and
+	// scores at most MaxCandidates USDA foods (0 scores them all)
+	EarlyExitScore float64 `mapstructure:"early_exit_score"`
+	MaxCandidates  int     `mapstructure:"max_candidates"`
+
+	// Shortest product name (in characters) earning the substring bonus, and the most
+	// a run of consecutive tokens shared with the description earns (0 disables)
+	SubstringMinLength int     `mapstructure:"substring_min_length"`
+	PhraseBonus        float64 `mapstructure:"phrase_bonus"`
+
+	// Bonus when the brand or one of its aliases appears in the description, and the
+	// alias table, e.g. "coca-cola:coke|coca cola,great value:gv" (empty keeps the built-in table)
+	BrandBonus   float64 `mapstructure:"brand_bonus"`
+	BrandAliases string  `mapstructure:"brand_aliases"`
+
+	// Per-retailer store brands and noise words stripped from product names, e.g.
+	// "costco:kirkland signature|kirkland"; each entry replaces that retailer's built-in list
+	RetailerStoreBrands string `mapstructure:"retailer_store_brands"`
+	RetailerNoiseWords  string `mapstructure:"retailer_noise_words"`
+
+	// Penalty when the product and a USDA food are different kinds of food, e.g. milk vs cake (0 disables)
+	CategoryMismatchPenalty float64 `mapstructure:"category_mismatch_penalty"`
+
+	// Extra single-word terms added to the built-in dictionaries (comma-separated in env).
+	// Stop words win over food terms, which win over descriptive terms.
+	ExtraFoodTerms        []string `mapstructure:"extra_food_terms"`
+	ExtraDescriptiveTerms []string `mapstructure:"extra_descriptive_terms"`
+	ExtraStopWords        []string `mapstructure:"extra_stop_words"`
+
+	// Compound food names kept as one token, e.g. "ice cream,hot dog" (empty keeps the built-in list)
+	CompoundFoods []string `mapstructure:"compound_foods"`
+
+	// Qualifiers kept as one token when EnableQualifierPhrases is set, e.g. "gluten free,sugar free" (empty keeps the built-in list)
+	QualifierPhrases []string `mapstructure:"qualifier_phrases"`
+
+	// Synonym table, e.g. "soda:soft drink|pop,garbanzo:chickpea" (empty keeps the built-in table)
+	Synonyms      string  `mapstructure:"synonyms"`
+	SynonymWeight float64 `mapstructure:"synonym_weight"` // Fraction of normal weight for synonym matches (0 uses the default)
+
+	// Rolling histogram of served confidences, exposed at /debug/confidence (0 window disables)
+	ConfidenceHistogramWindow      time.Duration `mapstructure:"confidence_histogram_window"`
+	ConfidenceHistogramLogInterval time.Duration `mapstructure:"confidence_histogram_log_interval"` // 0 disables periodic logging
 }
 
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
-	Port            string   `mapstructure:"port"`
-	Environment     string   `mapstructure:"environment"`
-	AllowedOrigins  []string `mapstructure:"allowed_origins"`
+	Port           string   `mapstructure:"port"`
+	Environment    string   `mapstructure:"environment"`
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+	AdminAPIKey    string   `mapstructure:"admin_api_key"`   // Enables admin endpoints (cache export/import) when set
+	APIKey         string   `mapstructure:"api_key"`         // Required as X-API-Key on /api/v1 client endpoints when set
+	TrustedProxies []string `mapstructure:"trusted_proxies"` // Proxy IPs/CIDRs whose X-Forwarded-For is believed for client IPs (empty trusts none)
+
+	RejectControlChars bool `mapstructure:"reject_control_chars"` // 400 on NUL/ESC in input instead of stripping
+	ExposeDictionaries bool `mapstructure:"expose_dictionaries"`  // Serve GET /api/v1/dictionaries for client-side preprocessing
+	MaxResponseBytes   int  `mapstructure:"max_response_bytes"`   // Drop optional search response fields above this size (0 disables)
+	MaxRequestBytes    int  `mapstructure:"max_request_bytes"`    // 413 for /api/v1 request bodies above this size (0 disables)
+	MaxImportBytes     int  `mapstructure:"max_import_bytes"`     // Separate body limit for the admin cache import (0 disables)
+	Compression        bool `mapstructure:"compression"`          // Gzip responses of 1KB or more for clients accepting gzip
+	AllowExplain       bool `mapstructure:"allow_explain"`        // Honor explain=true on searches, exposing match scoring internals
+	TimingHeader       bool `mapstructure:"timing_header"`        // Add a Server-Timing header with cache/USDA/match durations to searches
+
+	// CORS preflight response; X-API-Key is always allowed for the auth middlewares
+	CORSAllowedHeaders []string      `mapstructure:"cors_allowed_headers"` // Request headers browsers may send (empty uses the built-in list)
+	CORSMaxAge         time.Duration `mapstructure:"cors_max_age"`         // How long browsers cache a preflight response
+
+	LogFormat       string        `mapstructure:"log_format"`       // Log format: "text" or "json"
+	LogLevel        string        `mapstructure:"log_level"`        // "debug", "info", "warn" or "error"; debug enables USDA and matching detail
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"` // How long in-flight requests get to finish on SIGINT/SIGTERM (0 cancels them at once)
+	SearchTimeout   time.Duration `mapstructure:"search_timeout"`   // Bounds the cache, USDA and matching phase of a search (0 disables)
 }
 
 // USDAConfig holds USDA API configuration
 type USDAConfig struct {
-	APIKey  string `mapstructure:"api_key"`
-	BaseURL string `mapstructure:"base_url"`
+	APIKey  string        `mapstructure:"api_key"` // Comma-separated to rotate requests across several keys
+	BaseURL string        `mapstructure:"base_url"`
+	Timeout time.Duration `mapstructure:"timeout"` // Per-request timeout for USDA HTTP calls
+
+	MaxRetries int `mapstructure:"max_retries"` // Retries after a failed search on 5xx/429/transport errors (0 makes a single attempt)
+
+	DetailsCacheTTL time.Duration `mapstructure:"details_cache_ttl"` // How long food details are cached by FDC ID (0 disables)
+
+	DataTypes []string `mapstructure:"data_types"` // FDC data types searched, in priority order (empty uses Survey (FNDDS), Foundation, SR Legacy, Branded)
+
+	// Circuit breaker: fail fast during an outage, then probe for recovery (0 threshold disables)
+	BreakerFailureThreshold   int           `mapstructure:"breaker_failure_threshold"`    // Consecutive failed calls that open the breaker
+	BreakerOpenDuration       time.Duration `mapstructure:"breaker_open_duration"`        // How long the breaker fails fast before probing
+	BreakerCountRetryAttempts bool          `mapstructure:"breaker_count_retry_attempts"` // Count every failed retry attempt, not just failed calls
+
+	FetchFoodDetails        bool `mapstructure:"fetch_food_details"`        // Hydrate matches via the full-detail endpoint
+	EnableHouseholdMeasures bool `mapstructure:"enable_household_measures"` // Allow ?measure=cup scaling via food portions
+	BrandAwareDataTypes     bool `mapstructure:"brand_aware_data_types"`    // Search Branded foods first when a brand is given
+	Mock                    bool `mapstructure:"mock"`                      // Serve canned fixture foods instead of calling USDA
+	IncludeServingGrams     bool `mapstructure:"include_serving_grams"`     // Add best-effort servingGrams to responses
 }
 
 // CacheConfig holds cache-related configuration
@@ -43,11 +161,36 @@ type CacheConfig struct {
 	Type      string        `mapstructure:"type"` // "memory" or "redis"
 	RedisURL  string        `mapstructure:"redis_url"`
 	TTL       time.Duration `mapstructure:"ttl"`
+	L1Size    int           `mapstructure:"l1_size"` // In-memory L1 entries in front of Redis (0 disables tiering)
+	L1TTL     time.Duration `mapstructure:"l1_ttl"`
+
+	MaxEntries      int           `mapstructure:"max_entries"`      // Cap on in-memory cache entries, evicting least recently used (0 is unbounded)
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"` // How often expired in-memory entries are removed
+
+	PersistPath string `mapstructure:"persist_path"` // File the in-memory cache is loaded from on startup and saved to on shutdown (empty disables; memory cache type only)
+
+	EnableMatchCache bool          `mapstructure:"enable_match_cache"` // Cache fdcId per cleaned query
+	MatchCacheTTL    time.Duration `mapstructure:"match_cache_ttl"`
+
+	NotFoundTTL time.Duration `mapstructure:"not_found_ttl"` // How long a search USDA had no match for is cached (0 disables)
+
+	Namespace string `mapstructure:"namespace"` // Key prefix isolating deployments that share one Redis
+
+	FallbackToMemory     bool `mapstructure:"fallback_to_memory"`     // Serve from a local memory cache while Redis errors
+	KeepHigherConfidence bool `mapstructure:"keep_higher_confidence"` // Don't overwrite a cached result with a lower-confidence one
+
+	MaxRequestTTL time.Duration `mapstructure:"max_request_ttl"` // Longest cacheTTL a search request may ask for (0 caps it at TTL)
+}
+
+// FeedbackConfig holds match feedback collection configuration
+type FeedbackConfig struct {
+	Enabled    bool `mapstructure:"enabled"`     // Expose POST /nutrition/feedback and GET /debug/feedback
+	BufferSize int  `mapstructure:"buffer_size"` // Most recent feedback entries kept in memory
 }
 
 // RateLimitConfig holds rate limiting configuration
 type RateLimitConfig struct {
-	PerIP int `mapstructure:"per_ip"`
+	PerIP int `mapstructure:"per_ip"` // Requests per minute per client IP; 0 disables
 	USDA  int `mapstructure:"usda"`
 }
 
@@ -167,15 +310,56 @@ func bindEnvVars(v *viper.Viper) {
 	v.BindEnv("server.port", "MACROLENS_SERVER_PORT")
 	v.BindEnv("server.environment", "MACROLENS_SERVER_ENVIRONMENT")
 	v.BindEnv("server.allowed_origins", "MACROLENS_SERVER_ALLOWED_ORIGINS")
+	v.BindEnv("server.admin_api_key", "MACROLENS_SERVER_ADMIN_API_KEY")
+	v.BindEnv("server.api_key", "MACROLENS_SERVER_API_KEY")
+	v.BindEnv("server.trusted_proxies", "MACROLENS_SERVER_TRUSTED_PROXIES")
+	v.BindEnv("server.reject_control_chars", "MACROLENS_SERVER_REJECT_CONTROL_CHARS")
+	v.BindEnv("server.expose_dictionaries", "MACROLENS_SERVER_EXPOSE_DICTIONARIES")
+	v.BindEnv("server.max_response_bytes", "MACROLENS_SERVER_MAX_RESPONSE_BYTES")
+	v.BindEnv("server.max_request_bytes", "MACROLENS_SERVER_MAX_REQUEST_BYTES")
+	v.BindEnv("server.max_import_bytes", "MACROLENS_SERVER_MAX_IMPORT_BYTES")
+	v.BindEnv("server.compression", "MACROLENS_SERVER_COMPRESSION")
+	v.BindEnv("server.allow_explain", "MACROLENS_SERVER_ALLOW_EXPLAIN")
+	v.BindEnv("server.timing_header", "MACROLENS_SERVER_TIMING_HEADER")
+	v.BindEnv("server.cors_allowed_headers", "MACROLENS_SERVER_CORS_ALLOWED_HEADERS")
+	v.BindEnv("server.cors_max_age", "MACROLENS_SERVER_CORS_MAX_AGE")
+	v.BindEnv("server.shutdown_timeout", "MACROLENS_SERVER_SHUTDOWN_TIMEOUT")
+	v.BindEnv("server.search_timeout", "MACROLENS_SERVER_SEARCH_TIMEOUT")
+	v.BindEnv("server.log_format", "MACROLENS_SERVER_LOG_FORMAT")
+	v.BindEnv("server.log_level", "MACROLENS_SERVER_LOG_LEVEL")
 
 	// USDA
 	v.BindEnv("usda.api_key", "MACROLENS_USDA_API_KEY")
 	v.BindEnv("usda.base_url", "MACROLENS_USDA_BASE_URL")
+	v.BindEnv("usda.timeout", "MACROLENS_USDA_TIMEOUT")
+	v.BindEnv("usda.max_retries", "MACROLENS_USDA_MAX_RETRIES")
+	v.BindEnv("usda.details_cache_ttl", "MACROLENS_USDA_DETAILS_CACHE_TTL")
+	v.BindEnv("usda.data_types", "MACROLENS_USDA_DATA_TYPES")
+	v.BindEnv("usda.breaker_failure_threshold", "MACROLENS_USDA_BREAKER_FAILURE_THRESHOLD")
+	v.BindEnv("usda.breaker_open_duration", "MACROLENS_USDA_BREAKER_OPEN_DURATION")
+	v.BindEnv("usda.breaker_count_retry_attempts", "MACROLENS_USDA_BREAKER_COUNT_RETRY_ATTEMPTS")
+	v.BindEnv("usda.fetch_food_details", "MACROLENS_USDA_FETCH_FOOD_DETAILS")
+	v.BindEnv("usda.enable_household_measures", "MACROLENS_USDA_ENABLE_HOUSEHOLD_MEASURES")
+	v.BindEnv("usda.brand_aware_data_types", "MACROLENS_USDA_BRAND_AWARE_DATA_TYPES")
+	v.BindEnv("usda.mock", "MACROLENS_MOCK_USDA")
+	v.BindEnv("usda.include_serving_grams", "MACROLENS_USDA_INCLUDE_SERVING_GRAMS")
 
 	// Cache
 	v.BindEnv("cache.type", "MACROLENS_CACHE_TYPE")
 	v.BindEnv("cache.redis_url", "MACROLENS_CACHE_REDIS_URL")
 	v.BindEnv("cache.ttl", "MACROLENS_CACHE_TTL")
+	v.BindEnv("cache.l1_size", "MACROLENS_CACHE_L1_SIZE")
+	v.BindEnv("cache.l1_ttl", "MACROLENS_CACHE_L1_TTL")
+	v.BindEnv("cache.max_entries", "MACROLENS_CACHE_MAX_ENTRIES")
+	v.BindEnv("cache.cleanup_interval", "MACROLENS_CACHE_CLEANUP_INTERVAL")
+	v.BindEnv("cache.persist_path", "MACROLENS_CACHE_PERSIST_PATH")
+	v.BindEnv("cache.enable_match_cache", "MACROLENS_CACHE_ENABLE_MATCH_CACHE")
+	v.BindEnv("cache.match_cache_ttl", "MACROLENS_CACHE_MATCH_CACHE_TTL")
+	v.BindEnv("cache.not_found_ttl", "MACROLENS_CACHE_NOT_FOUND_TTL")
+	v.BindEnv("cache.namespace", "MACROLENS_CACHE_NAMESPACE")
+	v.BindEnv("cache.fallback_to_memory", "MACROLENS_CACHE_FALLBACK_TO_MEMORY")
+	v.BindEnv("cache.keep_higher_confidence", "MACROLENS_CACHE_KEEP_HIGHER_CONFIDENCE")
+	v.BindEnv("cache.max_request_ttl", "MACROLENS_CACHE_MAX_REQUEST_TTL")
 
 	// Rate Limit
 	v.BindEnv("ratelimit.per_ip", "MACROLENS_RATELIMIT_PER_IP")
@@ -184,7 +368,46 @@ func bindEnvVars(v *viper.Viper) {
 	// Matching
 	v.BindEnv("matching.min_confidence_threshold", "MACROLENS_MATCHING_MIN_CONFIDENCE")
 	v.BindEnv("matching.enable_fuzzy_matching", "MACROLENS_MATCHING_ENABLE_FUZZY")
-	v.BindEnv("matching.enable_debug_logging", "MACROLENS_MATCHING_DEBUG")
+	v.BindEnv("matching.report_raw_confidence", "MACROLENS_MATCHING_REPORT_RAW_CONFIDENCE")
+	v.BindEnv("matching.thin_pool_size", "MACROLENS_MATCHING_THIN_POOL_SIZE")
+	v.BindEnv("matching.thin_pool_discount", "MACROLENS_MATCHING_THIN_POOL_DISCOUNT")
+	v.BindEnv("matching.enable_qualifier_phrases", "MACROLENS_MATCHING_ENABLE_QUALIFIER_PHRASES")
+	v.BindEnv("matching.qualifier_phrases", "MACROLENS_MATCHING_QUALIFIER_PHRASES")
+	v.BindEnv("matching.calibration", "MACROLENS_MATCHING_CALIBRATION")
+	v.BindEnv("matching.store_brand_generics", "MACROLENS_MATCHING_STORE_BRAND_GENERICS")
+	v.BindEnv("matching.confirmed_match_bonus", "MACROLENS_MATCHING_CONFIRMED_MATCH_BONUS")
+	v.BindEnv("matching.category_mismatch_penalty", "MACROLENS_MATCHING_CATEGORY_MISMATCH_PENALTY")
+	v.BindEnv("matching.extra_food_terms", "MACROLENS_MATCHING_EXTRA_FOOD_TERMS")
+	v.BindEnv("matching.extra_descriptive_terms", "MACROLENS_MATCHING_EXTRA_DESCRIPTIVE_TERMS")
+	v.BindEnv("matching.extra_stop_words", "MACROLENS_MATCHING_EXTRA_STOP_WORDS")
+	v.BindEnv("matching.compound_foods", "MACROLENS_MATCHING_COMPOUND_FOODS")
+	v.BindEnv("matching.synonyms", "MACROLENS_MATCHING_SYNONYMS")
+	v.BindEnv("matching.synonym_weight", "MACROLENS_MATCHING_SYNONYM_WEIGHT")
+	v.BindEnv("matching.normalize_plurals", "MACROLENS_MATCHING_NORMALIZE_PLURALS")
+	v.BindEnv("matching.algorithm", "MACROLENS_MATCHING_ALGORITHM")
+	v.BindEnv("matching.phonetic_brand_match", "MACROLENS_MATCHING_PHONETIC_BRAND_MATCH")
+	v.BindEnv("matching.fuzzy_min_token_length", "MACROLENS_MATCHING_FUZZY_MIN_TOKEN_LENGTH")
+	v.BindEnv("matching.recency_bonus", "MACROLENS_MATCHING_RECENCY_BONUS")
+	v.BindEnv("matching.branded_bonus", "MACROLENS_MATCHING_BRANDED_BONUS")
+	v.BindEnv("matching.survey_bonus", "MACROLENS_MATCHING_SURVEY_BONUS")
+	v.BindEnv("matching.sr_legacy_bonus", "MACROLENS_MATCHING_SR_LEGACY_BONUS")
+	v.BindEnv("matching.foundation_bonus", "MACROLENS_MATCHING_FOUNDATION_BONUS")
+	v.BindEnv("matching.probability_midpoint", "MACROLENS_MATCHING_PROBABILITY_MIDPOINT")
+	v.BindEnv("matching.probability_steepness", "MACROLENS_MATCHING_PROBABILITY_STEEPNESS")
+	v.BindEnv("matching.early_exit_score", "MACROLENS_MATCHING_EARLY_EXIT_SCORE")
+	v.BindEnv("matching.max_candidates", "MACROLENS_MATCHING_MAX_CANDIDATES")
+	v.BindEnv("matching.substring_min_length", "MACROLENS_MATCHING_SUBSTRING_MIN_LENGTH")
+	v.BindEnv("matching.phrase_bonus", "MACROLENS_MATCHING_PHRASE_BONUS")
+	v.BindEnv("matching.brand_bonus", "MACROLENS_MATCHING_BRAND_BONUS")
+	v.BindEnv("matching.brand_aliases", "MACROLENS_MATCHING_BRAND_ALIASES")
+	v.BindEnv("matching.retailer_store_brands", "MACROLENS_MATCHING_RETAILER_STORE_BRANDS")
+	v.BindEnv("matching.retailer_noise_words", "MACROLENS_MATCHING_RETAILER_NOISE_WORDS")
+	v.BindEnv("matching.confidence_histogram_window", "MACROLENS_MATCHING_CONFIDENCE_HISTOGRAM_WINDOW")
+	v.BindEnv("matching.confidence_histogram_log_interval", "MACROLENS_MATCHING_CONFIDENCE_HISTOGRAM_LOG_INTERVAL")
+
+	// Feedback
+	v.BindEnv("feedback.enabled", "MACROLENS_FEEDBACK_ENABLED")
+	v.BindEnv("feedback.buffer_size", "MACROLENS_FEEDBACK_BUFFER_SIZE")
 }
 
 // setDefaults sets default configuration values
@@ -193,13 +416,51 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.port", "8080")
 	v.SetDefault("server.environment", "development")
 	v.SetDefault("server.allowed_origins", []string{"chrome-extension://*"})
+	v.SetDefault("server.trusted_proxies", []string{})
+	v.SetDefault("server.reject_control_chars", true)
+	v.SetDefault("server.expose_dictionaries", false)
+	v.SetDefault("server.max_response_bytes", 0)
+	v.SetDefault("server.max_request_bytes", 64*1024)
+	v.SetDefault("server.max_import_bytes", 64*1024*1024)
+	v.SetDefault("server.compression", true)
+	v.SetDefault("server.allow_explain", false)
+	v.SetDefault("server.timing_header", false)
+	v.SetDefault("server.cors_allowed_headers", []string{})
+	v.SetDefault("server.cors_max_age", "1h")
+	v.SetDefault("server.shutdown_timeout", "10s")
+	v.SetDefault("server.search_timeout", 0)
+	v.SetDefault("server.log_format", "text")
+	v.SetDefault("server.log_level", "info")
 
 	// USDA defaults
 	v.SetDefault("usda.base_url", "https://api.nal.usda.gov/fdc")
+	v.SetDefault("usda.timeout", "30s")
+	v.SetDefault("usda.max_retries", 2)
+	v.SetDefault("usda.details_cache_ttl", "24h")
+	v.SetDefault("usda.data_types", []string{})
+	v.SetDefault("usda.breaker_failure_threshold", 5)
+	v.SetDefault("usda.breaker_open_duration", "30s")
+	v.SetDefault("usda.breaker_count_retry_attempts", false)
+	v.SetDefault("usda.fetch_food_details", false)
+	v.SetDefault("usda.enable_household_measures", false)
+	v.SetDefault("usda.brand_aware_data_types", false)
+	v.SetDefault("usda.mock", false)
+	v.SetDefault("usda.include_serving_grams", false)
 
 	// Cache defaults
 	v.SetDefault("cache.type", "memory")
 	v.SetDefault("cache.ttl", "720h") // 30 days
+	v.SetDefault("cache.l1_size", 1000)
+	v.SetDefault("cache.l1_ttl", "5m")
+	v.SetDefault("cache.max_entries", 0)
+	v.SetDefault("cache.cleanup_interval", "10m")
+	v.SetDefault("cache.persist_path", "")
+	v.SetDefault("cache.enable_match_cache", false)
+	v.SetDefault("cache.match_cache_ttl", "2160h") // 90 days; match decisions change rarely
+	v.SetDefault("cache.not_found_ttl", "1h")      // Short, so newly indexed foods are found
+	v.SetDefault("cache.fallback_to_memory", false)
+	v.SetDefault("cache.keep_higher_confidence", false)
+	v.SetDefault("cache.max_request_ttl", 0)
 
 	// Rate limit defaults
 	v.SetDefault("ratelimit.per_ip", 100)
@@ -208,15 +469,76 @@ func setDefaults(v *viper.Viper) {
 	// Matching defaults
 	v.SetDefault("matching.min_confidence_threshold", 40.0)
 	v.SetDefault("matching.enable_fuzzy_matching", true)
-	v.SetDefault("matching.enable_debug_logging", false)
+	v.SetDefault("matching.report_raw_confidence", false)
+	v.SetDefault("matching.thin_pool_size", 0)
+	v.SetDefault("matching.thin_pool_discount", 0.0)
+	v.SetDefault("matching.enable_qualifier_phrases", false)
+	v.SetDefault("matching.qualifier_phrases", []string{})
+	v.SetDefault("matching.store_brand_generics", false)
+	v.SetDefault("matching.confirmed_match_bonus", 0.0)
+	v.SetDefault("matching.category_mismatch_penalty", 15.0)
+	v.SetDefault("matching.extra_food_terms", []string{})
+	v.SetDefault("matching.extra_descriptive_terms", []string{})
+	v.SetDefault("matching.extra_stop_words", []string{})
+	v.SetDefault("matching.compound_foods", []string{})
+	v.SetDefault("matching.synonym_weight", 0.0)
+	v.SetDefault("matching.normalize_plurals", true)
+	v.SetDefault("matching.algorithm", "token_weighted")
+	v.SetDefault("matching.phonetic_brand_match", false)
+	v.SetDefault("matching.fuzzy_min_token_length", 4)
+	v.SetDefault("matching.recency_bonus", 2.0)
+	v.SetDefault("matching.branded_bonus", 10.0)
+	v.SetDefault("matching.survey_bonus", 5.0)
+	v.SetDefault("matching.sr_legacy_bonus", 4.0)
+	v.SetDefault("matching.foundation_bonus", 3.0)
+	v.SetDefault("matching.probability_midpoint", 60.0)
+	v.SetDefault("matching.probability_steepness", 0.0)
+	v.SetDefault("matching.early_exit_score", 0.0)
+	v.SetDefault("matching.max_candidates", 0)
+	v.SetDefault("matching.substring_min_length", 6)
+	v.SetDefault("matching.phrase_bonus", 0.0)
+	v.SetDefault("matching.brand_bonus", 25.0)
+	v.SetDefault("matching.confidence_histogram_window", "0")
+	v.SetDefault("matching.confidence_histogram_log_interval", "0")
+
+	// Feedback defaults
+	v.SetDefault("feedback.enabled", false)
+	v.SetDefault("feedback.buffer_size", 1000)
 }
 
 // validate validates the configuration
 func validate(config *Config) error {
-	if config.USDA.APIKey == "" {
+	// Mock mode never calls USDA, so it runs without a key
+	if config.USDA.APIKey == "" && !config.USDA.Mock {
 		return fmt.Errorf("USDA API key is required (set MACROLENS_USDA_API_KEY)")
 	}
 
+	if config.USDA.Timeout < 0 {
+		return fmt.Errorf("USDA timeout must not be negative, got: %s", config.USDA.Timeout)
+	}
+
+	if config.USDA.MaxRetries < 0 {
+		return fmt.Errorf("USDA max retries must not be negative, got: %d", config.USDA.MaxRetries)
+	}
+
+	if config.USDA.DetailsCacheTTL < 0 {
+		return fmt.Errorf("USDA details cache TTL must not be negative, got: %s", config.USDA.DetailsCacheTTL)
+	}
+
+	for _, dataType := range config.USDA.DataTypes {
+		if !usdaDataTypes[dataType] {
+			return fmt.Errorf("USDA data type must be one of Branded, Foundation, Survey (FNDDS), SR Legacy or Experimental, got: %q", dataType)
+		}
+	}
+
+	if config.USDA.BreakerFailureThreshold < 0 {
+		return fmt.Errorf("USDA breaker failure threshold must not be negative, got: %d", config.USDA.BreakerFailureThreshold)
+	}
+
+	if config.USDA.BreakerOpenDuration < 0 {
+		return fmt.Errorf("USDA breaker open duration must not be negative, got: %s", config.USDA.BreakerOpenDuration)
+	}
+
 	if config.Cache.Type != "memory" && config.Cache.Type != "redis" {
 		return fmt.Errorf("cache type must be 'memory' or 'redis', got: %s", config.Cache.Type)
 	}
@@ -225,5 +547,131 @@ func validate(config *Config) error {
 		return fmt.Errorf("Redis URL is required when cache type is 'redis'")
 	}
 
+	if config.Matching.ThinPoolDiscount < 0 || config.Matching.ThinPoolDiscount > 1 {
+		return fmt.Errorf("thin pool discount must be between 0 and 1, got: %v", config.Matching.ThinPoolDiscount)
+	}
+
+	if config.Cache.L1Size < 0 {
+		return fmt.Errorf("cache L1 size must not be negative, got: %d", config.Cache.L1Size)
+	}
+
+	if config.Cache.MaxEntries < 0 {
+		return fmt.Errorf("cache max entries must not be negative, got: %d", config.Cache.MaxEntries)
+	}
+	if config.Cache.CleanupInterval < 0 {
+		return fmt.Errorf("cache cleanup interval must not be negative, got: %s", config.Cache.CleanupInterval)
+	}
+
+	if config.Cache.NotFoundTTL < 0 {
+		return fmt.Errorf("cache not-found TTL must not be negative, got: %s", config.Cache.NotFoundTTL)
+	}
+
+	if config.Cache.MaxRequestTTL < 0 {
+		return fmt.Errorf("cache max request TTL must not be negative, got: %s", config.Cache.MaxRequestTTL)
+	}
+
+	if config.Matching.RecencyBonus < 0 || config.Matching.RecencyBonus > 100 {
+		return fmt.Errorf("recency bonus must be between 0 and 100, got: %v", config.Matching.RecencyBonus)
+	}
+
+	for name, bonus := range map[string]float64{
+		"branded":    config.Matching.BrandedBonus,
+		"survey":     config.Matching.SurveyBonus,
+		"SR Legacy":  config.Matching.SRLegacyBonus,
+		"foundation": config.Matching.FoundationBonus,
+	} {
+		if bonus < 0 || bonus > 100 {
+			return fmt.Errorf("%s bonus must be between 0 and 100, got: %v", name, bonus)
+		}
+	}
+
+	if config.Matching.ProbabilityMidpoint < 0 || config.Matching.ProbabilityMidpoint > 100 {
+		return fmt.Errorf("probability midpoint must be between 0 and 100, got: %v", config.Matching.ProbabilityMidpoint)
+	}
+	if config.Matching.ProbabilitySteepness < 0 {
+		return fmt.Errorf("probability steepness must not be negative, got: %v", config.Matching.ProbabilitySteepness)
+	}
+
+	if config.Matching.EarlyExitScore < 0 || config.Matching.EarlyExitScore > 100 {
+		return fmt.Errorf("early exit score must be between 0 and 100, got: %v", config.Matching.EarlyExitScore)
+	}
+	if config.Matching.MaxCandidates < 0 {
+		return fmt.Errorf("max candidates must not be negative, got: %d", config.Matching.MaxCandidates)
+	}
+
+	if config.Matching.SubstringMinLength < 0 {
+		return fmt.Errorf("substring min length must not be negative, got: %d", config.Matching.SubstringMinLength)
+	}
+	if config.Matching.PhraseBonus < 0 || config.Matching.PhraseBonus > 100 {
+		return fmt.Errorf("phrase bonus must be between 0 and 100, got: %v", config.Matching.PhraseBonus)
+	}
+	if config.Matching.BrandBonus < 0 || config.Matching.BrandBonus > 100 {
+		return fmt.Errorf("brand bonus must be between 0 and 100, got: %v", config.Matching.BrandBonus)
+	}
+
+	if config.Matching.FuzzyMinTokenLength < 0 {
+		return fmt.Errorf("fuzzy min token length must not be negative, got: %d", config.Matching.FuzzyMinTokenLength)
+	}
+
+	if config.Matching.SynonymWeight < 0 || config.Matching.SynonymWeight > 1 {
+		return fmt.Errorf("synonym weight must be between 0 and 1, got: %v", config.Matching.SynonymWeight)
+	}
+
+	if config.Matching.ConfirmedMatchBonus < 0 || config.Matching.ConfirmedMatchBonus > 100 {
+		return fmt.Errorf("confirmed match bonus must be between 0 and 100, got: %v", config.Matching.ConfirmedMatchBonus)
+	}
+
+	if config.Matching.CategoryMismatchPenalty < 0 || config.Matching.CategoryMismatchPenalty > 100 {
+		return fmt.Errorf("category mismatch penalty must be between 0 and 100, got: %v", config.Matching.CategoryMismatchPenalty)
+	}
+
+	if config.Matching.ConfidenceHistogramWindow < 0 || config.Matching.ConfidenceHistogramLogInterval < 0 {
+		return fmt.Errorf("confidence histogram window and log interval must not be negative")
+	}
+
+	for _, proxy := range config.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("trusted proxy must be an IP address or CIDR, got: %q", proxy)
+		}
+	}
+
+	if config.Server.MaxResponseBytes < 0 {
+		return fmt.Errorf("max response bytes must not be negative, got: %d", config.Server.MaxResponseBytes)
+	}
+
+	if config.Server.MaxRequestBytes < 0 {
+		return fmt.Errorf("max request bytes must not be negative, got: %d", config.Server.MaxRequestBytes)
+	}
+
+	if config.Server.MaxImportBytes < 0 {
+		return fmt.Errorf("max import bytes must not be negative, got: %d", config.Server.MaxImportBytes)
+	}
+
+	if config.Server.LogFormat != "" && config.Server.LogFormat != "text" && config.Server.LogFormat != "json" {
+		return fmt.Errorf("log format must be 'text' or 'json', got: %s", config.Server.LogFormat)
+	}
+
+	switch strings.ToLower(config.Server.LogLevel) {
+	case "", "debug", "info", "warn", "warning", "error":
+	default:
+		return fmt.Errorf("log level must be 'debug', 'info', 'warn' or 'error', got: %s", config.Server.LogLevel)
+	}
+
+	if config.Server.ShutdownTimeout < 0 {
+		return fmt.Errorf("shutdown timeout must not be negative, got: %s", config.Server.ShutdownTimeout)
+	}
+
+	if config.Server.SearchTimeout < 0 {
+		return fmt.Errorf("search timeout must not be negative, got: %s", config.Server.SearchTimeout)
+	}
+
+	if config.Server.CORSMaxAge < 0 {
+		return fmt.Errorf("CORS max age must not be negative, got: %s", config.Server.CORSMaxAge)
+	}
+
+	if config.Feedback.Enabled && config.Feedback.BufferSize <= 0 {
+		return fmt.Errorf("feedback buffer size must be positive, got: %d", config.Feedback.BufferSize)
+	}
+
 	return nil
 }
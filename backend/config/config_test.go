@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -14,13 +15,45 @@ func cleanupConfigEnv(t *testing.T) {
 		"MACROLENS_SERVER_PORT",
 		"MACROLENS_SERVER_ENVIRONMENT",
 		"MACROLENS_SERVER_ALLOWED_ORIGINS",
+		"MACROLENS_SERVER_SHUTDOWN_TIMEOUT",
+		"MACROLENS_SERVER_SEARCH_TIMEOUT",
+		"MACROLENS_SERVER_LOG_FORMAT",
+		"MACROLENS_SERVER_LOG_LEVEL",
+		"MACROLENS_SERVER_API_KEY",
+		"MACROLENS_SERVER_TRUSTED_PROXIES",
+		"MACROLENS_SERVER_MAX_REQUEST_BYTES",
+		"MACROLENS_SERVER_MAX_IMPORT_BYTES",
+		"MACROLENS_SERVER_COMPRESSION",
+		"MACROLENS_SERVER_ALLOW_EXPLAIN",
+		"MACROLENS_SERVER_TIMING_HEADER",
+		"MACROLENS_SERVER_CORS_ALLOWED_HEADERS",
+		"MACROLENS_SERVER_CORS_MAX_AGE",
 		"MACROLENS_USDA_API_KEY",
 		"MACROLENS_USDA_BASE_URL",
+		"MACROLENS_USDA_TIMEOUT",
+		"MACROLENS_USDA_MAX_RETRIES",
+		"MACROLENS_USDA_DETAILS_CACHE_TTL",
+		"MACROLENS_USDA_DATA_TYPES",
+		"MACROLENS_USDA_BREAKER_FAILURE_THRESHOLD",
+		"MACROLENS_USDA_BREAKER_OPEN_DURATION",
+		"MACROLENS_USDA_BREAKER_COUNT_RETRY_ATTEMPTS",
 		"MACROLENS_CACHE_TYPE",
 		"MACROLENS_CACHE_REDIS_URL",
 		"MACROLENS_CACHE_TTL",
+		"MACROLENS_CACHE_L1_SIZE",
+		"MACROLENS_CACHE_L1_TTL",
+		"MACROLENS_CACHE_NAMESPACE",
+		"MACROLENS_CACHE_NOT_FOUND_TTL",
+		"MACROLENS_CACHE_CLEANUP_INTERVAL",
+		"MACROLENS_CACHE_MAX_REQUEST_TTL",
 		"MACROLENS_RATELIMIT_PER_IP",
 		"MACROLENS_RATELIMIT_USDA",
+		"MACROLENS_MOCK_USDA",
+		"MACROLENS_MATCHING_SR_LEGACY_BONUS",
+		"MACROLENS_MATCHING_RETAILER_STORE_BRANDS",
+		"MACROLENS_MATCHING_RETAILER_NOISE_WORDS",
+		"MACROLENS_MATCHING_ENABLE_QUALIFIER_PHRASES",
+		"MACROLENS_MATCHING_QUALIFIER_PHRASES",
 	}
 	for _, env := range envVars {
 		os.Unsetenv(env)
@@ -51,12 +84,118 @@ func TestLoad(t *testing.T) {
 		if cfg.USDA.BaseURL != "https://api.nal.usda.gov/fdc" {
 			t.Errorf("USDA.BaseURL = %s, want https://api.nal.usda.gov/fdc", cfg.USDA.BaseURL)
 		}
+		if cfg.USDA.Timeout != 30*time.Second {
+			t.Errorf("USDA.Timeout = %v, want 30s", cfg.USDA.Timeout)
+		}
+		if cfg.USDA.MaxRetries != 2 {
+			t.Errorf("USDA.MaxRetries = %d, want 2", cfg.USDA.MaxRetries)
+		}
+		if cfg.USDA.DetailsCacheTTL != 24*time.Hour {
+			t.Errorf("USDA.DetailsCacheTTL = %v, want 24h", cfg.USDA.DetailsCacheTTL)
+		}
+		if cfg.USDA.BreakerFailureThreshold != 5 || cfg.USDA.BreakerOpenDuration != 30*time.Second {
+			t.Errorf("USDA breaker = %d failures, %s open; want 5, 30s", cfg.USDA.BreakerFailureThreshold, cfg.USDA.BreakerOpenDuration)
+		}
 		if cfg.Cache.Type != "memory" {
 			t.Errorf("Cache.Type = %s, want memory", cfg.Cache.Type)
 		}
 		if cfg.Cache.TTL != 720*time.Hour {
 			t.Errorf("Cache.TTL = %v, want 720h", cfg.Cache.TTL)
 		}
+		if cfg.Cache.NotFoundTTL != time.Hour {
+			t.Errorf("Cache.NotFoundTTL = %v, want 1h", cfg.Cache.NotFoundTTL)
+		}
+		if cfg.Cache.CleanupInterval != 10*time.Minute {
+			t.Errorf("Cache.CleanupInterval = %v, want 10m", cfg.Cache.CleanupInterval)
+		}
+		if cfg.Cache.MaxRequestTTL != 0 {
+			t.Errorf("Cache.MaxRequestTTL = %v, want 0", cfg.Cache.MaxRequestTTL)
+		}
+		if cfg.Cache.L1Size != 1000 {
+			t.Errorf("Cache.L1Size = %d, want 1000", cfg.Cache.L1Size)
+		}
+		if cfg.Cache.L1TTL != 5*time.Minute {
+			t.Errorf("Cache.L1TTL = %v, want 5m", cfg.Cache.L1TTL)
+		}
+		if !cfg.Server.RejectControlChars {
+			t.Error("Server.RejectControlChars = false, want true")
+		}
+		if cfg.Server.ShutdownTimeout != 10*time.Second {
+			t.Errorf("Server.ShutdownTimeout = %v, want 10s", cfg.Server.ShutdownTimeout)
+		}
+		if cfg.Server.SearchTimeout != 0 {
+			t.Errorf("Server.SearchTimeout = %v, want 0 (disabled)", cfg.Server.SearchTimeout)
+		}
+		if cfg.Server.LogFormat != "text" {
+			t.Errorf("Server.LogFormat = %s, want text", cfg.Server.LogFormat)
+		}
+		if cfg.Server.LogLevel != "info" {
+			t.Errorf("Server.LogLevel = %s, want info", cfg.Server.LogLevel)
+		}
+		if cfg.Server.MaxRequestBytes != 64*1024 {
+			t.Errorf("Server.MaxRequestBytes = %d, want 65536", cfg.Server.MaxRequestBytes)
+		}
+		if cfg.Server.MaxImportBytes != 64*1024*1024 {
+			t.Errorf("Server.MaxImportBytes = %d, want 67108864", cfg.Server.MaxImportBytes)
+		}
+		if len(cfg.Server.TrustedProxies) != 0 {
+			t.Errorf("Server.TrustedProxies = %v, want empty", cfg.Server.TrustedProxies)
+		}
+		if !cfg.Server.Compression {
+			t.Error("Server.Compression = false, want true")
+		}
+		if cfg.Server.AllowExplain {
+			t.Error("Server.AllowExplain = true, want false")
+		}
+		if cfg.Server.TimingHeader {
+			t.Error("Server.TimingHeader = true, want false")
+		}
+		if len(cfg.Server.CORSAllowedHeaders) != 0 || cfg.Server.CORSMaxAge != time.Hour {
+			t.Errorf("Server CORS headers/max age = %v/%s, want empty/1h", cfg.Server.CORSAllowedHeaders, cfg.Server.CORSMaxAge)
+		}
+		if cfg.Matching.RecencyBonus != 2 {
+			t.Errorf("Matching.RecencyBonus = %v, want 2", cfg.Matching.RecencyBonus)
+		}
+		if cfg.Matching.BrandedBonus != 10 || cfg.Matching.SurveyBonus != 5 || cfg.Matching.SRLegacyBonus != 4 || cfg.Matching.FoundationBonus != 3 {
+			t.Errorf("Matching data type bonuses = %v/%v/%v/%v, want 10/5/4/3",
+				cfg.Matching.BrandedBonus, cfg.Matching.SurveyBonus, cfg.Matching.SRLegacyBonus, cfg.Matching.FoundationBonus)
+		}
+		if len(cfg.USDA.DataTypes) != 0 {
+			t.Errorf("USDA.DataTypes = %v, want empty", cfg.USDA.DataTypes)
+		}
+		if cfg.Matching.EnableQualifierPhrases || len(cfg.Matching.QualifierPhrases) != 0 {
+			t.Errorf("Matching qualifier phrases = %v/%v, want disabled/empty",
+				cfg.Matching.EnableQualifierPhrases, cfg.Matching.QualifierPhrases)
+		}
+		if cfg.Matching.ProbabilityMidpoint != 60 || cfg.Matching.ProbabilitySteepness != 0 {
+			t.Errorf("Matching probability calibration = %v/%v, want 60/0",
+				cfg.Matching.ProbabilityMidpoint, cfg.Matching.ProbabilitySteepness)
+		}
+		if cfg.Matching.EarlyExitScore != 0 || cfg.Matching.MaxCandidates != 0 {
+			t.Errorf("Matching early exit/max candidates = %v/%d, want 0/0",
+				cfg.Matching.EarlyExitScore, cfg.Matching.MaxCandidates)
+		}
+		if cfg.Matching.SubstringMinLength != 6 || cfg.Matching.PhraseBonus != 0 {
+			t.Errorf("Matching substring min length/phrase bonus = %d/%v, want 6/0",
+				cfg.Matching.SubstringMinLength, cfg.Matching.PhraseBonus)
+		}
+		if cfg.Matching.RetailerStoreBrands != "" || cfg.Matching.RetailerNoiseWords != "" {
+			t.Errorf("Matching retailer store brands/noise words = %q/%q, want empty",
+				cfg.Matching.RetailerStoreBrands, cfg.Matching.RetailerNoiseWords)
+		}
+		if cfg.Matching.BrandBonus != 25 || cfg.Matching.BrandAliases != "" {
+			t.Errorf("Matching brand bonus/aliases = %v/%q, want 25/empty",
+				cfg.Matching.BrandBonus, cfg.Matching.BrandAliases)
+		}
+		if cfg.Matching.FuzzyMinTokenLength != 4 {
+			t.Errorf("Matching.FuzzyMinTokenLength = %d, want 4", cfg.Matching.FuzzyMinTokenLength)
+		}
+		if cfg.Matching.CategoryMismatchPenalty != 15 {
+			t.Errorf("Matching.CategoryMismatchPenalty = %v, want 15", cfg.Matching.CategoryMismatchPenalty)
+		}
+		if cfg.Feedback.Enabled || cfg.Feedback.BufferSize != 1000 {
+			t.Errorf("Feedback = %+v, want disabled with buffer size 1000", cfg.Feedback)
+		}
 		if cfg.RateLimit.PerIP != 100 {
 			t.Errorf("RateLimit.PerIP = %d, want 100", cfg.RateLimit.PerIP)
 		}
@@ -77,6 +216,14 @@ func TestLoad(t *testing.T) {
 		os.Setenv("MACROLENS_CACHE_TYPE", "redis")
 		os.Setenv("MACROLENS_CACHE_REDIS_URL", "redis://localhost:6379")
 		os.Setenv("MACROLENS_CACHE_TTL", "24h")
+		os.Setenv("MACROLENS_CACHE_L1_SIZE", "250")
+		os.Setenv("MACROLENS_CACHE_NAMESPACE", "tenant-a")
+		os.Setenv("MACROLENS_USDA_DATA_TYPES", "SR Legacy,Foundation")
+		os.Setenv("MACROLENS_SERVER_CORS_ALLOWED_HEADERS", "Content-Type,X-Tenant")
+		os.Setenv("MACROLENS_SERVER_CORS_MAX_AGE", "10m")
+		os.Setenv("MACROLENS_SERVER_TRUSTED_PROXIES", "10.0.0.1,172.16.0.0/12")
+		os.Setenv("MACROLENS_MATCHING_ENABLE_QUALIFIER_PHRASES", "true")
+		os.Setenv("MACROLENS_MATCHING_QUALIFIER_PHRASES", "gluten free,keto friendly")
 		os.Setenv("MACROLENS_RATELIMIT_PER_IP", "200")
 		os.Setenv("MACROLENS_RATELIMIT_USDA", "2000")
 
@@ -117,6 +264,30 @@ func TestLoad(t *testing.T) {
 		if cfg.Cache.TTL != 24*time.Hour {
 			t.Errorf("Cache.TTL = %v, want 24h", cfg.Cache.TTL)
 		}
+		if cfg.Cache.L1Size != 250 {
+			t.Errorf("Cache.L1Size = %d, want 250", cfg.Cache.L1Size)
+		}
+		if cfg.Cache.Namespace != "tenant-a" {
+			t.Errorf("Cache.Namespace = %s, want tenant-a", cfg.Cache.Namespace)
+		}
+		if want := []string{"SR Legacy", "Foundation"}; !slices.Equal(cfg.USDA.DataTypes, want) {
+			t.Errorf("USDA.DataTypes = %v, want %v", cfg.USDA.DataTypes, want)
+		}
+		if want := []string{"Content-Type", "X-Tenant"}; !slices.Equal(cfg.Server.CORSAllowedHeaders, want) {
+			t.Errorf("Server.CORSAllowedHeaders = %v, want %v", cfg.Server.CORSAllowedHeaders, want)
+		}
+		if want := []string{"10.0.0.1", "172.16.0.0/12"}; !slices.Equal(cfg.Server.TrustedProxies, want) {
+			t.Errorf("Server.TrustedProxies = %v, want %v", cfg.Server.TrustedProxies, want)
+		}
+		if !cfg.Matching.EnableQualifierPhrases {
+			t.Error("Matching.EnableQualifierPhrases = false, want true")
+		}
+		if want := []string{"gluten free", "keto friendly"}; !slices.Equal(cfg.Matching.QualifierPhrases, want) {
+			t.Errorf("Matching.QualifierPhrases = %v, want %v", cfg.Matching.QualifierPhrases, want)
+		}
+		if cfg.Server.CORSMaxAge != 10*time.Minute {
+			t.Errorf("Server.CORSMaxAge = %s, want 10m", cfg.Server.CORSMaxAge)
+		}
 		if cfg.RateLimit.PerIP != 200 {
 			t.Errorf("RateLimit.PerIP = %d, want 200", cfg.RateLimit.PerIP)
 		}
@@ -138,6 +309,21 @@ func TestLoad(t *testing.T) {
 		}
 	})
 
+	t.Run("loads without API key in mock mode", func(t *testing.T) {
+		cleanupConfigEnv(t)
+		t.Cleanup(func() { cleanupConfigEnv(t) })
+
+		os.Setenv("MACROLENS_MOCK_USDA", "true")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v, want nil", err)
+		}
+		if !cfg.USDA.Mock {
+			t.Error("USDA.Mock = false, want true")
+		}
+	})
+
 	t.Run("fails validation for invalid cache type", func(t *testing.T) {
 		cleanupConfigEnv(t)
 		t.Cleanup(func() { cleanupConfigEnv(t) })
@@ -722,4 +908,75 @@ func TestValidate(t *testing.T) {
 			t.Error("validate() error = nil, want error for redis without URL")
 		}
 	})
+
+	t.Run("allows an empty API key in mock mode", func(t *testing.T) {
+		cfg := &Config{
+			USDA: USDAConfig{
+				Mock: true,
+			},
+			Cache: CacheConfig{
+				Type: "memory",
+			},
+		}
+
+		err := validate(cfg)
+		if err != nil {
+			t.Errorf("validate() error = %v, want nil in mock mode", err)
+		}
+	})
+
+	t.Run("fails for an unknown log level", func(t *testing.T) {
+		cfg := &Config{
+			Server: ServerConfig{LogLevel: "verbose"},
+			USDA:   USDAConfig{APIKey: "test-key"},
+			Cache:  CacheConfig{Type: "memory"},
+		}
+
+		if err := validate(cfg); err == nil {
+			t.Error("validate() error = nil, want error for unknown log level")
+		}
+	})
+
+	t.Run("fails for an unknown USDA data type", func(t *testing.T) {
+		cfg := &Config{
+			USDA:  USDAConfig{APIKey: "test-key", DataTypes: []string{"SR Legacy", "Legacy"}},
+			Cache: CacheConfig{Type: "memory"},
+		}
+
+		if err := validate(cfg); err == nil {
+			t.Error("validate() error = nil, want error for unknown data type")
+		}
+	})
+
+	t.Run("fails for a trusted proxy that isn't an IP or CIDR", func(t *testing.T) {
+		cfg := &Config{
+			Server: ServerConfig{TrustedProxies: []string{"10.0.0.0/8", "proxy.internal"}},
+			USDA:   USDAConfig{APIKey: "test-key"},
+			Cache:  CacheConfig{Type: "memory"},
+		}
+
+		if err := validate(cfg); err == nil {
+			t.Error("validate() error = nil, want error for an invalid trusted proxy")
+		}
+	})
+
+	t.Run("fails for enabled feedback without a buffer", func(t *testing.T) {
+		cfg := &Config{
+			USDA: USDAConfig{
+				APIKey: "test-key",
+			},
+			Cache: CacheConfig{
+				Type: "memory",
+			},
+			Feedback: FeedbackConfig{
+				Enabled:    true,
+				BufferSize: 0,
+			},
+		}
+
+		err := validate(cfg)
+		if err == nil {
+			t.Error("validate() error = nil, want error for feedback without a buffer")
+		}
+	})
 }
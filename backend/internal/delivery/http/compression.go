@@ -0,0 +1,120 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipMinBytes is the smallest response worth compressing; below it the gzip
+// header and CPU cost outweigh the savings
+const gzipMinBytes = 1024
+
+var gzipWriters = sync.Pool{
+	New: func() any { return gzip.NewWriter(io.Discard) },
+}
+
+// CompressionMiddleware gzips responses of at least gzipMinBytes for clients that
+// send Accept-Encoding: gzip. Responses that already carry a Content-Encoding,
+// such as /metrics, are passed through untouched.
+func CompressionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !acceptsGzip(c.Request) {
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Accept-Encoding")
+		writer := &gzipResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		defer writer.finish()
+
+		c.Next()
+	}
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.EqualFold(strings.TrimSpace(name), "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter holds back the body until it reaches gzipMinBytes, then
+// either switches to gzip or, if the response is finished first, writes it as is
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	buf     bytes.Buffer
+	gz      *gzip.Writer
+	decided bool // true once the body is going straight to the client
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf.Write(data)
+	if w.buf.Len() >= gzipMinBytes {
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written reports whether anything has been written, including held-back data
+func (w *gzipResponseWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// start sends the held-back body, compressed when compress is set and the
+// response is not already encoded or under way
+func (w *gzipResponseWriter) start(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	if compress && header.Get("Content-Encoding") == "" && !w.ResponseWriter.Written() {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gz = gzipWriters.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+
+	data := w.buf.Bytes()
+	w.buf.Reset()
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(data)
+	} else if len(data) > 0 {
+		_, err = w.ResponseWriter.Write(data)
+	}
+	return err
+}
+
+// finish flushes whatever the handler wrote once it returns
+func (w *gzipResponseWriter) finish() {
+	if !w.decided {
+		w.start(false)
+	}
+	if w.gz != nil {
+		w.gz.Close()
+		gzipWriters.Put(w.gz)
+		w.gz = nil
+	}
+}
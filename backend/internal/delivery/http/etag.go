@@ -0,0 +1,39 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// nutritionETag returns a weak ETag for data. Source and CachedAt say where this
+// copy came from rather than what the food is, so they're left out: a food served
+// from USDA and later from cache gets the same tag.
+func nutritionETag(data *domain.NutritionData) (string, error) {
+	stable := *data
+	stable.Source = ""
+	stable.CachedAt = time.Time{}
+
+	raw, err := json.Marshal(stable)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using the
+// weak comparison conditional GETs call for. The header may list several tags or be "*".
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
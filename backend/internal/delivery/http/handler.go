@@ -1,17 +1,54 @@
 package http
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/macrolens/backend/internal/domain"
 	"github.com/macrolens/backend/internal/usecase"
 )
 
+// Error codes returned in the "code" field of error responses so clients can
+// tell failure causes apart without parsing messages
+const (
+	CodeInvalidRequest      = "INVALID_REQUEST"
+	CodePayloadTooLarge     = "PAYLOAD_TOO_LARGE"
+	CodeProductNotFound     = "PRODUCT_NOT_FOUND"
+	CodeLowConfidence       = "LOW_CONFIDENCE"
+	CodeRateLimited         = "RATE_LIMITED"
+	CodeUnauthorized        = "UNAUTHORIZED"
+	CodeUpstreamUnavailable = "UPSTREAM_UNAVAILABLE"
+	CodeNotImplemented      = "NOT_IMPLEMENTED"
+	CodeInternalError       = "INTERNAL_ERROR"
+)
+
+// ErrorResponse is the body of every error response. Message keeps the "error"
+// key earlier clients read; RequestID matches the X-Request-ID header.
+type ErrorResponse struct {
+	Code      string `json:"code"`
+	Message   string `json:"error"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
 // Handler holds dependencies for HTTP handlers
 type Handler struct {
-	nutritionService *usecase.NutritionService
+	nutritionService    *usecase.NutritionService
+	cacheSnapshotter    domain.CacheSnapshotter
+	cacheStats          domain.CacheStatsProvider
+	feedbackService     *usecase.FeedbackService
+	confidenceHistogram *usecase.ConfidenceHistogram
+	maxResponseBytes    int  // 0 disables the response size limit
+	allowExplain        bool // Honor the explain query param on searches
+	serverTiming        bool // Add a Server-Timing header to searches
+	cacheType           string
+	startedAt           time.Time
+
+	// Names of the POST routes beside GET /nutrition/:fdcId (e.g. "search"), set by SetupRouter
+	nutritionPostRoutes map[string]bool
 }
 
 // NewHandler creates a new HTTP handler with the given nutrition service.
@@ -19,76 +56,692 @@ type Handler struct {
 func NewHandler(nutritionService *usecase.NutritionService) *Handler {
 	return &Handler{
 		nutritionService: nutritionService,
+		startedAt:        time.Now(),
 	}
 }
 
-// HealthCheck returns the health status of the API
+// SetCacheSnapshotter enables the cache export/import endpoints for the given cache
+func (h *Handler) SetCacheSnapshotter(snapshotter domain.CacheSnapshotter) {
+	h.cacheSnapshotter = snapshotter
+}
+
+// SetCacheStats enables the cache stats endpoint for the given cache
+func (h *Handler) SetCacheStats(provider domain.CacheStatsProvider) {
+	h.cacheStats = provider
+}
+
+// SetFeedbackService enables the match feedback endpoints
+func (h *Handler) SetFeedbackService(feedbackService *usecase.FeedbackService) {
+	h.feedbackService = feedbackService
+}
+
+// SetConfidenceHistogram enables the confidence distribution endpoint
+func (h *Handler) SetConfidenceHistogram(histogram *usecase.ConfidenceHistogram) {
+	h.confidenceHistogram = histogram
+}
+
+// SetMaxResponseBytes limits search response size; optional sections are dropped
+// to fit (see limitResponseSize). 0 disables the limit.
+func (h *Handler) SetMaxResponseBytes(maxBytes int) {
+	h.maxResponseBytes = maxBytes
+}
+
+// SetAllowExplain enables the explain query param on searches. The breakdown
+// exposes matching internals, so it is off unless configured.
+func (h *Handler) SetAllowExplain(allow bool) {
+	h.allowExplain = allow
+}
+
+// SetServerTiming adds a Server-Timing header breaking a search down into cache,
+// USDA and matching time. Off by default to spare production the bookkeeping.
+func (h *Handler) SetServerTiming(enable bool) {
+	h.serverTiming = enable
+}
+
+// SetCacheType names the cache backend ("memory" or "redis") reported by /health
+func (h *Handler) SetCacheType(cacheType string) {
+	h.cacheType = cacheType
+}
+
+// healthPingTimeout bounds the cache ping in HealthCheck
+const healthPingTimeout = 500 * time.Millisecond
+
+// HealthCheck returns the health status of the API, with the cache backend and its
+// entry count when known (see SetCacheType and SetCacheStats) and the uptime. A cache
+// backed by a server is pinged first and reported as cacheReachable; its size is only
+// asked for when the ping succeeds.
 func (h *Handler) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"status":  "healthy",
 		"service": "macrolens-backend",
 		"version": "1.0.0",
-	})
+		"uptime":  time.Since(h.startedAt).Round(time.Second).String(),
+	}
+	if h.cacheType != "" {
+		response["cacheType"] = h.cacheType
+	}
+	reachable := true
+	if pinger, ok := h.cacheStats.(domain.CachePinger); ok {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), healthPingTimeout)
+		defer cancel()
+		reachable = pinger.Ping(ctx) == nil
+		response["cacheReachable"] = reachable
+	}
+	if h.cacheStats != nil && reachable {
+		response["cacheSize"] = h.cacheStats.Stats().Size
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // SearchNutrition handles nutrition search requests
 // POST /api/v1/nutrition/search
-// Request body: { "productName": "...", "brand": "...", "size": "..." }
-// Response: NutritionData or error
+// Request body: { "productName": "...", "brand": "...", "size": "...", "servingSize": 240, "locale": "es-ES" }
+// locale (or the Accept-Language header) lets comma-decimal sizes like "1,5 L" be stripped cleanly.
+// servingSize (grams, optional) scales nutrients to that serving; otherwise Branded foods
+// are reported per label serving and others per 100g.
+// cacheTTL (a duration like "6h", optional) caches the result for that long instead of the
+// default, capped at the configured maximum.
+// Query params: includeTiming=true adds matchLatencyMs/usdaLatencyMs to the response;
+// measure=cup scales nutrients to a household measure when the food defines one;
+// minConfidence=70 overrides the configured confidence threshold (10-100);
+// candidates=3 adds the top matches as "candidates" (0-10, always re-matched rather than cached);
+// explain=true adds the match score breakdown as "explanation" (ignored unless enabled in config);
+// debug=true adds the "cacheKey" the search is stored under, along with the timing fields
+// Response: NutritionData; { "data", "lowConfidence": true, "warning" } for low-confidence matches; or error.
+// Responses over the configured size limit drop optional fields and set "truncated": true.
+// When enabled in config, a Server-Timing header reports cache, usda and match durations.
 func (h *Handler) SearchNutrition(c *gin.Context) {
 	// Check if service is available
 	if h.nutritionService == nil {
-		c.JSON(http.StatusNotImplemented, gin.H{
-			"error": "Nutrition search service not configured",
-		})
+		respondError(c, http.StatusNotImplemented, CodeNotImplemented, "Nutrition search service not configured")
 		return
 	}
 
 	// Parse and validate request body
 	var request domain.SearchRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request: " + err.Error(),
-		})
+		respondBindError(c, err)
 		return
 	}
 
+	// Apply optional per-request flags from the query string
+	request.IncludeTiming = queryBool(c, "includeTiming")
+	request.Explain = h.allowExplain && queryBool(c, "explain")
+	request.Debug = queryBool(c, "debug")
+	request.Locale = requestLocale(c, request.Locale)
+	request.Measure = c.Query("measure")
+	if raw := c.Query("minConfidence"); raw != "" {
+		minConfidence, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid request: minConfidence must be a number")
+			return
+		}
+		request.MinConfidence = &minConfidence
+	}
+	if raw := c.Query("candidates"); raw != "" {
+		candidates, err := strconv.Atoi(raw)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid request: candidates must be a whole number")
+			return
+		}
+		request.Candidates = candidates
+	}
+
 	// Call nutrition service
-	result, err := h.nutritionService.SearchNutrition(c.Request.Context(), &request)
+	ctx, timings := h.withServerTiming(c.Request.Context())
+	result, err := h.nutritionService.SearchNutrition(ctx, &request)
+	result = limitResponseSize(result, h.maxResponseBytes)
+	setServerTiming(c, timings)
 
 	// Handle errors with appropriate HTTP status codes
 	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrInvalidRequest):
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
-		case errors.Is(err, domain.ErrProductNotFound):
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "No matching product found in USDA database",
-			})
-		case errors.Is(err, domain.ErrLowConfidence):
+		if errors.Is(err, domain.ErrLowConfidence) && result != nil {
 			// Return data with warning for low confidence matches
 			c.JSON(http.StatusOK, gin.H{
-				"data":    result,
-				"warning": "Low confidence match - verify the product manually",
-			})
-		case errors.Is(err, domain.ErrRateLimited):
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded, please try again later",
-			})
-		case errors.Is(err, domain.ErrUSDAAPIFailure):
-			c.JSON(http.StatusBadGateway, gin.H{
-				"error": "USDA API temporarily unavailable",
+				"data":          result,
+				"lowConfidence": true,
+				"warning":       lowConfidenceWarning,
 			})
+			return
+		}
+		status, code, message := searchErrorResponse(err)
+		respondError(c, status, code, message)
+		return
+	}
+
+	// Success - return nutrition data
+	c.JSON(http.StatusOK, result)
+}
+
+// batchSearchRequest is the request body for a batch nutrition search
+type batchSearchRequest struct {
+	Items []domain.SearchRequest `json:"items" binding:"required,dive"`
+}
+
+// batchItemResult is one item's outcome in a batch response: data, an error, or
+// data flagged lowConfidence
+type batchItemResult struct {
+	Data          *domain.NutritionData `json:"data,omitempty"`
+	LowConfidence bool                  `json:"lowConfidence,omitempty"`
+	Error         string                `json:"error,omitempty"`
+	Code          string                `json:"code,omitempty"`
+}
+
+// BatchSearchNutrition looks up several products in one request, e.g. a whole cart
+// POST /api/v1/nutrition/batch
+// Request body: { "items": [{ "productName": "...", "brand": "..." }, ...] } (1-25 items)
+// Response: { "results": [{ "data": NutritionData } | { "data", "lowConfidence": true } | { "error", "code" }] }
+// in the same order as the items
+func (h *Handler) BatchSearchNutrition(c *gin.Context) {
+	if h.nutritionService == nil {
+		respondError(c, http.StatusNotImplemented, CodeNotImplemented, "Nutrition search service not configured")
+		return
+	}
+
+	var request batchSearchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	for i := range request.Items {
+		request.Items[i].Locale = requestLocale(c, request.Items[i].Locale)
+	}
+
+	results, err := h.nutritionService.SearchNutritionBatch(c.Request.Context(), request.Items)
+	if err != nil {
+		status, code, message := searchErrorResponse(err)
+		respondError(c, status, code, message)
+		return
+	}
+
+	response := make([]batchItemResult, len(results))
+	for i, result := range results {
+		switch {
+		case result.Err == nil:
+			response[i] = batchItemResult{Data: result.Data}
+		case errors.Is(result.Err, domain.ErrLowConfidence):
+			response[i] = batchItemResult{Data: result.Data, LowConfidence: true}
 		default:
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "An unexpected error occurred",
+			_, code, message := searchErrorResponse(result.Err)
+			response[i] = batchItemResult{Error: message, Code: code}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": response})
+}
+
+// matchFoodsRequest is the request body for matching a product against given foods
+type matchFoodsRequest struct {
+	ProductName string            `json:"productName" binding:"required"`
+	Brand       string            `json:"brand,omitempty"`
+	Retailer    string            `json:"retailer,omitempty"`
+	Locale      string            `json:"locale,omitempty"`
+	Foods       []domain.USDAFood `json:"foods"`
+}
+
+// MatchFoods runs the matcher against USDA foods the caller already has, skipping
+// the USDA search and the cache. Useful for debugging match quality.
+// POST /api/v1/nutrition/match
+// Request body: { "productName": "...", "brand": "...", "foods": [{ "fdcId": 1, "description": "...", "dataType": "..." }, ...] }
+// Query params: minConfidence=70 overrides the configured confidence threshold (10-100)
+// Response: MatchResult; { "data", "lowConfidence": true, "warning" } for low-confidence matches; or error.
+func (h *Handler) MatchFoods(c *gin.Context) {
+	if h.nutritionService == nil {
+		respondError(c, http.StatusNotImplemented, CodeNotImplemented, "Nutrition search service not configured")
+		return
+	}
+
+	var request matchFoodsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	searchRequest := domain.SearchRequest{
+		ProductName: request.ProductName,
+		Brand:       request.Brand,
+		Retailer:    request.Retailer,
+		Locale:      requestLocale(c, request.Locale),
+	}
+	if raw := c.Query("minConfidence"); raw != "" {
+		minConfidence, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid request: minConfidence must be a number")
+			return
+		}
+		searchRequest.MinConfidence = &minConfidence
+	}
+
+	result, err := h.nutritionService.MatchFoods(c.Request.Context(), &searchRequest, request.Foods)
+	if err != nil {
+		if errors.Is(err, domain.ErrLowConfidence) && result != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"data":          result,
+				"lowConfidence": true,
+				"warning":       lowConfidenceWarning,
 			})
+			return
 		}
+		status, code, message := searchErrorResponse(err)
+		respondError(c, status, code, message)
 		return
 	}
 
-	// Success - return nutrition data
 	c.JSON(http.StatusOK, result)
 }
+
+// barcodeRequest is the request body for a barcode lookup. The product fields are
+// optional and only used to fall back to a name search.
+type barcodeRequest struct {
+	UPC         string `json:"upc" binding:"required"`
+	ProductName string `json:"productName,omitempty"`
+	Brand       string `json:"brand,omitempty"`
+	Size        string `json:"size,omitempty"`
+	Retailer    string `json:"retailer,omitempty"`
+	Locale      string `json:"locale,omitempty"`
+}
+
+// SearchNutritionByBarcode looks up nutrition data by UPC/GTIN barcode, skipping the
+// fuzzy matcher for an exact Branded match
+// POST /api/v1/nutrition/barcode
+// Request body: { "upc": "012345678905", "productName": "...", "brand": "..." }
+// productName (optional) is searched by name when USDA has no food with the barcode.
+// Response: NutritionData (confidence 100 for a barcode match); the search response
+// shapes for a name fallback; or error.
+func (h *Handler) SearchNutritionByBarcode(c *gin.Context) {
+	if h.nutritionService == nil {
+		respondError(c, http.StatusNotImplemented, CodeNotImplemented, "Nutrition search service not configured")
+		return
+	}
+
+	var request barcodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	fallback := &domain.SearchRequest{
+		ProductName: request.ProductName,
+		Brand:       request.Brand,
+		Size:        request.Size,
+		Retailer:    request.Retailer,
+		Locale:      requestLocale(c, request.Locale),
+	}
+	result, err := h.nutritionService.SearchNutritionByUPC(c.Request.Context(), request.UPC, fallback)
+	if err != nil {
+		if errors.Is(err, domain.ErrLowConfidence) && result != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"data":          result,
+				"lowConfidence": true,
+				"warning":       lowConfidenceWarning,
+			})
+			return
+		}
+		status, code, message := searchErrorResponse(err)
+		respondError(c, status, code, message)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// lowConfidenceWarning accompanies data returned for a low-confidence match
+const lowConfidenceWarning = "Low confidence match - verify the product manually"
+
+// ListCandidates lists the USDA foods a search finds for a product, scored and
+// paged, so a user can pick the right one
+// POST /api/v1/nutrition/candidates
+// Request body: { "productName": "...", "brand": "...", "retailer": "walmart", "locale": "en-US" }
+// Query params: page=2 (from 1, default 1); pageSize=10 (default 10, clamped to 50)
+// Response: { "candidates": [{ "fdcId", "description", "dataType", "confidence", "nutrients" }, ...],
+// "totalHits", "currentPage", "totalPages", "pageSize" } or error.
+func (h *Handler) ListCandidates(c *gin.Context) {
+	if h.nutritionService == nil {
+		respondError(c, http.StatusNotImplemented, CodeNotImplemented, "Nutrition search service not configured")
+		return
+	}
+
+	var request domain.SearchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	request.Locale = requestLocale(c, request.Locale)
+
+	page, ok := queryPositiveInt(c, "page")
+	if !ok {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid request: page must be a positive whole number")
+		return
+	}
+	pageSize, ok := queryPositiveInt(c, "pageSize")
+	if !ok {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid request: pageSize must be a positive whole number")
+		return
+	}
+
+	result, err := h.nutritionService.ListCandidates(c.Request.Context(), &request, page, pageSize)
+	if err != nil {
+		status, code, message := searchErrorResponse(err)
+		respondError(c, status, code, message)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// searchErrorResponse maps a nutrition search error to its HTTP status, error code
+// and client-facing message
+func searchErrorResponse(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidRequest):
+		return http.StatusBadRequest, CodeInvalidRequest, err.Error()
+	case errors.Is(err, domain.ErrProductNotFound):
+		return http.StatusNotFound, CodeProductNotFound, "No matching product found in USDA database"
+	case errors.Is(err, domain.ErrLowConfidence):
+		return http.StatusNotFound, CodeLowConfidence, "No sufficiently confident match found in USDA database"
+	case errors.Is(err, domain.ErrRateLimited):
+		return http.StatusTooManyRequests, CodeRateLimited, "Rate limit exceeded, please try again later"
+	case errors.Is(err, domain.ErrUSDAAPIFailure):
+		return http.StatusBadGateway, CodeUpstreamUnavailable, "USDA API temporarily unavailable"
+	default:
+		return http.StatusInternalServerError, CodeInternalError, "An unexpected error occurred"
+	}
+}
+
+// GetNutritionByID returns nutrition data for a known USDA food. Food details
+// rarely change, so responses carry an ETag for conditional requests.
+// GET /api/v1/nutrition/:fdcId
+// Headers: If-None-Match with a previous ETag gets a bodiless 304 when it still matches
+// Response: NutritionData (confidence 100), 304 Not Modified, or error
+func (h *Handler) GetNutritionByID(c *gin.Context) {
+	// POST-only routes like "/nutrition/search" should stay a 404 on GET, not an invalid fdcId
+	if h.nutritionPostRoutes[c.Param("fdcId")] {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	if h.nutritionService == nil {
+		respondError(c, http.StatusNotImplemented, CodeNotImplemented, "Nutrition search service not configured")
+		return
+	}
+
+	result, err := h.nutritionService.GetNutritionByID(c.Request.Context(), c.Param("fdcId"))
+	if err != nil {
+		status, code, message := searchErrorResponse(err)
+		respondError(c, status, code, message)
+		return
+	}
+
+	if etag, err := nutritionETag(result); err == nil {
+		c.Header("ETag", etag)
+		if etagMatches(c.GetHeader("If-None-Match"), etag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// cacheSnapshot is the request/response body for cache export and import
+type cacheSnapshot struct {
+	Entries []domain.CacheEntry `json:"entries"`
+}
+
+// ExportCache returns all unexpired cache entries so a new instance can start warm
+// GET /api/v1/cache/export
+// Response: { "entries": [{ "key": "...", "value": ..., "expiresAt": "..." }] }
+func (h *Handler) ExportCache(c *gin.Context) {
+	if h.cacheSnapshotter == nil {
+		respondError(c, http.StatusNotImplemented, CodeNotImplemented, "Cache export not supported by the configured cache")
+		return
+	}
+
+	entries, err := h.cacheSnapshotter.Export(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to export cache")
+		return
+	}
+
+	c.JSON(http.StatusOK, cacheSnapshot{Entries: entries})
+}
+
+// ImportCache loads entries produced by ExportCache, skipping expired ones
+// POST /api/v1/cache/import
+// Request body: { "entries": [...] }
+// Response: { "imported": n }
+func (h *Handler) ImportCache(c *gin.Context) {
+	if h.cacheSnapshotter == nil {
+		respondError(c, http.StatusNotImplemented, CodeNotImplemented, "Cache import not supported by the configured cache")
+		return
+	}
+
+	var snapshot cacheSnapshot
+	if err := c.ShouldBindJSON(&snapshot); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	imported, err := h.cacheSnapshotter.Import(c.Request.Context(), snapshot.Entries)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to import cache")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"imported": imported})
+}
+
+// InvalidateSearch evicts the cached result for a search, e.g. a bad match that
+// would otherwise be served until it expires
+// DELETE /api/v1/nutrition/search
+// Request body: the search's body, { "productName": "...", "brand": "..." }
+// Response: 204 whether or not the search was cached
+func (h *Handler) InvalidateSearch(c *gin.Context) {
+	if h.nutritionService == nil {
+		respondError(c, http.StatusNotImplemented, CodeNotImplemented, "Nutrition search service not configured")
+		return
+	}
+
+	var request domain.SearchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	// Keyed like the search, which read the same header
+	request.Locale = requestLocale(c, request.Locale)
+	if err := h.nutritionService.InvalidateSearch(c.Request.Context(), &request); err != nil {
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+			return
+		}
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to invalidate cached search")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CacheStats reports cache size and hit rate, for tuning TTLs
+// GET /api/v1/cache/stats
+// Response: { "size": n, "hits": n, "misses": n, "hitRatio": 0.0-1.0 }
+func (h *Handler) CacheStats(c *gin.Context) {
+	if h.cacheStats == nil {
+		respondError(c, http.StatusNotImplemented, CodeNotImplemented, "Cache stats not supported by the configured cache")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.cacheStats.Stats())
+}
+
+// RecordFeedback stores a client's report on whether a match was correct
+// POST /api/v1/nutrition/feedback
+// Request body: { "query": "...", "fdcId": "...", "correct": true, "dataType": "..." (optional) }
+// Response: { "recorded": true }
+func (h *Handler) RecordFeedback(c *gin.Context) {
+	if h.feedbackService == nil {
+		respondError(c, http.StatusNotImplemented, CodeNotImplemented, "Match feedback not configured")
+		return
+	}
+
+	var feedback domain.MatchFeedback
+	if err := c.ShouldBindJSON(&feedback); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	if err := h.feedbackService.RecordFeedback(c.Request.Context(), &feedback); err != nil {
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			respondError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+			return
+		}
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to record feedback")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recorded": true})
+}
+
+// FeedbackStats returns match accuracy overall and by USDA data type
+// GET /api/v1/debug/feedback
+// Response: { "total": n, "correct": n, "accuracy": 0.9, "byDataType": { "Branded": {...} } }
+func (h *Handler) FeedbackStats(c *gin.Context) {
+	if h.feedbackService == nil {
+		respondError(c, http.StatusNotImplemented, CodeNotImplemented, "Match feedback not configured")
+		return
+	}
+
+	stats, err := h.feedbackService.FeedbackStats(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "Failed to read feedback stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// ConfidenceDistribution returns a histogram of match confidences served over the last window
+// GET /api/v1/debug/confidence
+// Response: { "window": "1h0m0s", "total": n, "buckets": [{ "min": 0, "max": 20, "count": n }, ...] }
+func (h *Handler) ConfidenceDistribution(c *gin.Context) {
+	if h.confidenceHistogram == nil {
+		respondError(c, http.StatusNotImplemented, CodeNotImplemented, "Confidence monitoring not configured")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.confidenceHistogram.Snapshot())
+}
+
+// Dictionaries returns the term dictionaries the server tokenizes queries with,
+// including configured extras, so clients can mirror server-side preprocessing
+// GET /api/v1/dictionaries
+// Response: { "foodTerms": [...], "descriptiveTerms": [...], "stopWords": [...], "compoundFoods": [...], "storeBrands": { "walmart": [...] } }
+func (h *Handler) Dictionaries(c *gin.Context) {
+	if h.nutritionService == nil {
+		respondError(c, http.StatusNotImplemented, CodeNotImplemented, "Nutrition search service not configured")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.nutritionService.Dictionaries())
+}
+
+// preprocessRequest is the request body for previewing query cleaning
+type preprocessRequest struct {
+	ProductName string `json:"productName" binding:"required"`
+	Brand       string `json:"brand,omitempty"`
+	Retailer    string `json:"retailer,omitempty"`
+}
+
+// Preprocess shows how a product title is cleaned into a USDA query, without
+// searching. Useful when tuning scraping.
+// POST /api/v1/preprocess
+// Request body: { "productName": "...", "brand": "...", "retailer": "walmart" }
+// Response: { "query": "...", "keywords": [...], "storeBrandGeneric": false } or error.
+func (h *Handler) Preprocess(c *gin.Context) {
+	if h.nutritionService == nil {
+		respondError(c, http.StatusNotImplemented, CodeNotImplemented, "Nutrition search service not configured")
+		return
+	}
+
+	var request preprocessRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	preprocessed, err := h.nutritionService.Preprocess(&domain.SearchRequest{
+		ProductName: request.ProductName,
+		Brand:       request.Brand,
+		Retailer:    request.Retailer,
+	})
+	if err != nil {
+		status, code, message := searchErrorResponse(err)
+		respondError(c, status, code, message)
+		return
+	}
+
+	c.JSON(http.StatusOK, preprocessed)
+}
+
+// Vocabulary lists the store brands, noise words and stop words query cleaning
+// strips, so integrators can predict how a product name is rewritten
+// GET /api/v1/config/vocabulary
+// Query params: q=truth keeps only the words containing "truth"
+// Response: { "storeBrands": { "walmart": [...] }, "retailNoiseWords": { "walmart": [...] }, "noiseWords": [...],
+// "stopWords": [...], "foodTermCount": n, "descriptiveTermCount": n }
+func (h *Handler) Vocabulary(c *gin.Context) {
+	if h.nutritionService == nil {
+		respondError(c, http.StatusNotImplemented, CodeNotImplemented, "Nutrition search service not configured")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.nutritionService.Vocabulary(c.Query("q")))
+}
+
+// respondError writes the standard error envelope: { "error": "...", "code": "...", "requestId": "..." }
+func respondError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, ErrorResponse{
+		Code:      code,
+		Message:   message,
+		RequestID: c.GetString(requestIDKey),
+	})
+}
+
+// respondBindError reports a request body that failed to bind: 413 when it ran
+// past the body size limit, 400 otherwise
+func respondBindError(c *gin.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondError(c, http.StatusRequestEntityTooLarge, CodePayloadTooLarge,
+			"Request body exceeds "+strconv.FormatInt(tooLarge.Limit, 10)+" bytes")
+		return
+	}
+	respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid request: "+err.Error())
+}
+
+// requestLocale returns the locale product names in a request are written in: the
+// body's locale when set, otherwise the Accept-Language header
+func requestLocale(c *gin.Context, locale string) string {
+	if locale != "" {
+		return locale
+	}
+	return c.GetHeader("Accept-Language")
+}
+
+// queryPositiveInt parses an optional positive whole-number query parameter. It
+// returns 0 when the parameter is absent, and false when it's set to anything else.
+func queryPositiveInt(c *gin.Context, name string) (int, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return 0, true
+	}
+	value, err := strconv.Atoi(raw)
+	return value, err == nil && value > 0
+}
+
+// queryBool reports whether a boolean query parameter is set to a true value
+func queryBool(c *gin.Context, name string) bool {
+	value, err := strconv.ParseBool(c.Query(name))
+	return err == nil && value
+}
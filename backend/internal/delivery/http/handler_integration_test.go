@@ -3,16 +3,23 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/macrolens/backend/config"
 	"github.com/macrolens/backend/internal/domain"
+	"github.com/macrolens/backend/internal/infrastructure/cache"
+	"github.com/macrolens/backend/internal/infrastructure/feedback"
 	"github.com/macrolens/backend/internal/usecase"
 )
 
@@ -91,6 +98,78 @@ func TestHealthCheckEndpoint(t *testing.T) {
 		}
 	})
 
+	t.Run("reports cache and uptime", func(t *testing.T) {
+		memoryCache := cache.NewMemoryCache()
+		memoryCache.Set(context.Background(), "key", "value", time.Hour)
+		handler := NewHandler(nil)
+		handler.SetCacheType("memory")
+		handler.SetCacheStats(memoryCache)
+		router := SetupRouter(&config.Config{Server: config.ServerConfig{Environment: "test"}}, handler)
+
+		req, _ := http.NewRequest("GET", "/health", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response["cacheType"] != "memory" {
+			t.Errorf("cacheType = %v, want memory", response["cacheType"])
+		}
+		if response["cacheSize"] != float64(1) {
+			t.Errorf("cacheSize = %v, want 1", response["cacheSize"])
+		}
+		if _, err := time.ParseDuration(fmt.Sprint(response["uptime"])); err != nil {
+			t.Errorf("uptime = %v, want a duration: %v", response["uptime"], err)
+		}
+	})
+
+	t.Run("pings a Redis cache before sizing it", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		redisCache, err := cache.NewRedisCache("redis://" + server.Addr())
+		if err != nil {
+			t.Fatalf("NewRedisCache() error = %v", err)
+		}
+		defer redisCache.Close()
+		redisCache.Set(context.Background(), "key", "value", time.Hour)
+		handler := NewHandler(nil)
+		handler.SetCacheType("redis")
+		handler.SetCacheStats(redisCache)
+		router := SetupRouter(&config.Config{Server: config.ServerConfig{Environment: "test"}}, handler)
+
+		health := func() map[string]interface{} {
+			req, _ := http.NewRequest("GET", "/health", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Errorf("Status = %d, want %d", w.Code, http.StatusOK)
+			}
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			return response
+		}
+
+		response := health()
+		if response["cacheReachable"] != true {
+			t.Errorf("cacheReachable = %v, want true", response["cacheReachable"])
+		}
+		if response["cacheSize"] != float64(1) {
+			t.Errorf("cacheSize = %v, want 1 from DBSIZE", response["cacheSize"])
+		}
+
+		server.Close()
+		response = health()
+		if response["cacheReachable"] != false {
+			t.Errorf("cacheReachable = %v, want false once Redis is down", response["cacheReachable"])
+		}
+		if _, ok := response["cacheSize"]; ok {
+			t.Errorf("cacheSize = %v, want it omitted while Redis is unreachable", response["cacheSize"])
+		}
+	})
+
 	t.Run("accepts GET requests only", func(t *testing.T) {
 		router := setupTestRouter()
 
@@ -220,6 +299,34 @@ func TestCORSIntegration(t *testing.T) {
 			t.Errorf("Access-Control-Allow-Origin = %q, want %q", gotOrigin, "http://localhost:3000")
 		}
 	})
+
+	t.Run("preflight advertises only the route's methods", func(t *testing.T) {
+		cfg := &config.Config{Server: config.ServerConfig{
+			Environment:    "test",
+			AllowedOrigins: []string{"chrome-extension://*"},
+			AdminAPIKey:    "admin-key",
+		}}
+		router := SetupRouter(cfg, NewHandler(nil))
+
+		for path, want := range map[string]string{
+			"/health":                  "GET, OPTIONS",
+			"/api/v1/nutrition/12345":  "GET, OPTIONS",
+			"/api/v1/nutrition/search": "DELETE, POST, OPTIONS",
+			"/api/v1/cache/import":     "POST, OPTIONS",
+			"/api/v1/no/such/endpoint": "",
+		} {
+			req, _ := http.NewRequest("OPTIONS", path, nil)
+			req.Header.Set("Origin", "chrome-extension://abcdefghijklmnop")
+			req.Header.Set("Access-Control-Request-Method", "GET")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Access-Control-Allow-Methods"); got != want {
+				t.Errorf("%s: Access-Control-Allow-Methods = %q, want %q", path, got, want)
+			}
+		}
+	})
 }
 
 // TestRecoveryMiddleware tests panic recovery
@@ -238,10 +345,16 @@ func TestRecoveryMiddleware(t *testing.T) {
 		// This should not crash the test - recovery middleware should handle it
 		router.ServeHTTP(w, req)
 
-		// Gin's default recovery returns 500
 		if w.Code != http.StatusInternalServerError {
 			t.Errorf("Status = %d, want %d", w.Code, http.StatusInternalServerError)
 		}
+		var response ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response.Code != CodeInternalError {
+			t.Errorf("code = %q, want %s", response.Code, CodeInternalError)
+		}
 	})
 }
 
@@ -347,6 +460,9 @@ func (m *mockCacheRepository) Exists(ctx context.Context, key string) (bool, err
 type mockUSDAClient struct {
 	searchResult *domain.USDASearchResponse
 	searchError  error
+	foodResult   *domain.USDAFood
+	foodError    error
+	upcResult    *domain.USDAFood
 }
 
 func newMockUSDAClient() *mockUSDAClient {
@@ -354,6 +470,10 @@ func newMockUSDAClient() *mockUSDAClient {
 }
 
 func (m *mockUSDAClient) SearchFoods(ctx context.Context, query string) (*domain.USDASearchResponse, error) {
+	return m.SearchFoodsWithOptions(ctx, query, domain.SearchOptions{})
+}
+
+func (m *mockUSDAClient) SearchFoodsWithOptions(ctx context.Context, query string, opts domain.SearchOptions) (*domain.USDASearchResponse, error) {
 	if m.searchError != nil {
 		return nil, m.searchError
 	}
@@ -361,7 +481,14 @@ func (m *mockUSDAClient) SearchFoods(ctx context.Context, query string) (*domain
 }
 
 func (m *mockUSDAClient) GetFoodDetails(ctx context.Context, fdcID string) (*domain.USDAFood, error) {
-	return nil, nil
+	return m.foodResult, m.foodError
+}
+
+func (m *mockUSDAClient) SearchByUPC(ctx context.Context, upc string) (*domain.USDAFood, error) {
+	if m.upcResult == nil || m.upcResult.GtinUpc != upc {
+		return nil, domain.ErrProductNotFound
+	}
+	return m.upcResult, nil
 }
 
 // setupTestRouterWithService creates a test router with a real NutritionService using mocks
@@ -597,9 +724,1553 @@ func TestNutritionSearchWithService(t *testing.T) {
 			t.Error("expected data field even with low confidence")
 		}
 
+		if response["lowConfidence"] != true {
+			t.Errorf("lowConfidence = %v, want true", response["lowConfidence"])
+		}
+
 		warningStr, ok := response["warning"].(string)
 		if !ok || warningStr != "Low confidence match - verify the product manually" {
 			t.Errorf("warning = %v, want 'Low confidence match - verify the product manually'", response["warning"])
 		}
 	})
 }
+
+// TestGetNutritionByID tests direct lookup of a known food by FDC ID
+func TestGetNutritionByID(t *testing.T) {
+	testCases := []struct {
+		name       string
+		fdcID      string
+		foodResult *domain.USDAFood
+		foodError  error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "known food",
+			fdcID:      "746782",
+			foodResult: &domain.USDAFood{FdcID: 746782, Description: "Milk, whole"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unknown food",
+			fdcID:      "1",
+			foodError:  domain.ErrProductNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   CodeProductNotFound,
+		},
+		{
+			name:       "non-numeric id",
+			fdcID:      "milk",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeInvalidRequest,
+		},
+		{
+			name:       "USDA failure",
+			fdcID:      "746782",
+			foodError:  domain.ErrUSDAAPIFailure,
+			wantStatus: http.StatusBadGateway,
+			wantCode:   CodeUpstreamUnavailable,
+		},
+		{
+			name:       "rate limited",
+			fdcID:      "746782",
+			foodError:  domain.ErrRateLimited,
+			wantStatus: http.StatusTooManyRequests,
+			wantCode:   CodeRateLimited,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newMockUSDAClient()
+			client.foodResult = tc.foodResult
+			client.foodError = tc.foodError
+			router := setupTestRouterWithService(newMockCacheRepository(), client)
+
+			req, _ := http.NewRequest("GET", "/api/v1/nutrition/"+tc.fdcID, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.wantStatus {
+				t.Fatalf("Status = %d, want %d", w.Code, tc.wantStatus)
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if tc.wantCode != "" && response["code"] != tc.wantCode {
+				t.Errorf("code = %v, want %s", response["code"], tc.wantCode)
+			}
+			if tc.wantCode != "" && response["requestId"] != w.Header().Get("X-Request-ID") {
+				t.Errorf("requestId = %v, want the X-Request-ID header %q", response["requestId"], w.Header().Get("X-Request-ID"))
+			}
+			if tc.wantStatus == http.StatusOK {
+				if response["fdcId"] != tc.fdcID || response["confidence"] != 100.0 {
+					t.Errorf("response = %v, want fdcId %s at confidence 100", response, tc.fdcID)
+				}
+			}
+		})
+	}
+}
+
+// TestGetNutritionByID_ETag tests the 200-then-304 conditional GET flow
+func TestGetNutritionByID_ETag(t *testing.T) {
+	client := newMockUSDAClient()
+	client.foodResult = &domain.USDAFood{FdcID: 746782, Description: "Milk, whole"}
+	router := setupTestRouterWithService(cache.NewMemoryCache(), client)
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/v1/nutrition/746782", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("Status = %d with ETag %q, want 200 with an ETag", first.Code, etag)
+	}
+
+	t.Run("matching tag gets 304 from the cached copy", func(t *testing.T) {
+		w := get(etag)
+
+		if w.Code != http.StatusNotModified {
+			t.Fatalf("Status = %d, want %d", w.Code, http.StatusNotModified)
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("body = %q, want none", w.Body.String())
+		}
+		if w.Header().Get("ETag") != etag {
+			t.Errorf("ETag = %q, want %q", w.Header().Get("ETag"), etag)
+		}
+	})
+
+	t.Run("tag lists and wildcards match", func(t *testing.T) {
+		for _, header := range []string{`"stale", ` + etag, "*"} {
+			if w := get(header); w.Code != http.StatusNotModified {
+				t.Errorf("If-None-Match %s: Status = %d, want %d", header, w.Code, http.StatusNotModified)
+			}
+		}
+	})
+
+	t.Run("stale tag gets the body", func(t *testing.T) {
+		w := get(`W/"stale"`)
+
+		if w.Code != http.StatusOK || w.Body.Len() == 0 {
+			t.Errorf("Status = %d with %d bytes, want 200 with a body", w.Code, w.Body.Len())
+		}
+	})
+}
+
+// TestNutritionBarcode tests the barcode lookup endpoint
+func TestNutritionBarcode(t *testing.T) {
+	client := newMockUSDAClient()
+	client.upcResult = &domain.USDAFood{FdcID: 2262072, Description: "Peanut butter, creamy", DataType: "Branded", GtinUpc: "051500255162"}
+	client.searchResult = &domain.USDASearchResponse{
+		Foods: []domain.USDAFood{{FdcID: 12345, Description: "Peanut Butter Crunchy"}},
+	}
+	router := setupTestRouterWithService(newMockCacheRepository(), client)
+
+	testCases := []struct {
+		name       string
+		payload    string
+		wantStatus int
+		wantCode   string
+		wantFdcID  string
+	}{
+		{"barcode match", `{"upc":"051500255162"}`, http.StatusOK, "", "2262072"},
+		{"falls back to the product name", `{"upc":"051500255179","productName":"crunchy peanut butter"}`, http.StatusOK, "", "12345"},
+		{"unknown barcode without a product name", `{"upc":"051500255179"}`, http.StatusNotFound, CodeProductNotFound, ""},
+		{"malformed barcode", `{"upc":"not-a-barcode"}`, http.StatusBadRequest, CodeInvalidRequest, ""},
+		{"missing barcode", `{"productName":"peanut butter"}`, http.StatusBadRequest, CodeInvalidRequest, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/api/v1/nutrition/barcode", strings.NewReader(tc.payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.wantStatus {
+				t.Fatalf("Status = %d, want %d: %s", w.Code, tc.wantStatus, w.Body.String())
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if tc.wantCode != "" && response["code"] != tc.wantCode {
+				t.Errorf("code = %v, want %s", response["code"], tc.wantCode)
+			}
+			if tc.wantFdcID != "" && response["fdcId"] != tc.wantFdcID {
+				t.Errorf("fdcId = %v, want %s", response["fdcId"], tc.wantFdcID)
+			}
+		})
+	}
+
+	t.Run("GET is not an fdcId lookup", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/nutrition/barcode", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+}
+
+// TestNutritionSearchTiming tests the includeTiming query flag
+func TestNutritionSearchTiming(t *testing.T) {
+	newClient := func() *mockUSDAClient {
+		client := newMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{
+			Foods: []domain.USDAFood{
+				{FdcID: 12345, Description: "Whole Milk"},
+			},
+		}
+		return client
+	}
+
+	t.Run("includes timing fields when requested", func(t *testing.T) {
+		router := setupTestRouterWithService(newMockCacheRepository(), newClient())
+
+		payload := `{"productName":"whole milk"}`
+		req, _ := http.NewRequest("POST", "/api/v1/nutrition/search?includeTiming=true", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", w.Code, http.StatusOK)
+		}
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		if _, ok := response["usdaLatencyMs"].(float64); !ok {
+			t.Errorf("usdaLatencyMs = %v, want a number", response["usdaLatencyMs"])
+		}
+		if _, ok := response["matchLatencyMs"].(float64); !ok {
+			t.Errorf("matchLatencyMs = %v, want a number", response["matchLatencyMs"])
+		}
+	})
+
+	t.Run("omits timing fields by default", func(t *testing.T) {
+		router := setupTestRouterWithService(newMockCacheRepository(), newClient())
+
+		payload := `{"productName":"whole milk"}`
+		req, _ := http.NewRequest("POST", "/api/v1/nutrition/search", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		if _, ok := response["usdaLatencyMs"]; ok {
+			t.Error("expected usdaLatencyMs to be omitted")
+		}
+		if _, ok := response["matchLatencyMs"]; ok {
+			t.Error("expected matchLatencyMs to be omitted")
+		}
+	})
+}
+
+// TestNutritionSearchMinConfidence tests the per-request confidence threshold override
+func TestNutritionSearchMinConfidence(t *testing.T) {
+	testCases := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantWarning bool
+	}{
+		{"configured threshold when absent", "", http.StatusOK, false},
+		{"looser override still matches", "?minConfidence=50", http.StatusOK, false},
+		{"stricter override warns", "?minConfidence=90", http.StatusOK, true},
+		{"non-numeric override is rejected", "?minConfidence=high", http.StatusBadRequest, false},
+		{"out-of-range override is rejected", "?minConfidence=5", http.StatusBadRequest, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newMockUSDAClient()
+			client.searchResult = &domain.USDASearchResponse{
+				Foods: []domain.USDAFood{{FdcID: 12345, Description: "Milk, whole"}},
+			}
+			router := setupTestRouterWithService(newMockCacheRepository(), client)
+
+			payload := `{"productName":"whole milk"}`
+			req, _ := http.NewRequest("POST", "/api/v1/nutrition/search"+tc.query, strings.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.wantStatus {
+				t.Fatalf("Status = %d, want %d", w.Code, tc.wantStatus)
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if tc.wantStatus == http.StatusBadRequest && response["code"] != CodeInvalidRequest {
+				t.Errorf("code = %v, want %s", response["code"], CodeInvalidRequest)
+			}
+			if _, hasWarning := response["warning"]; hasWarning != tc.wantWarning {
+				t.Errorf("warning present = %v, want %v", hasWarning, tc.wantWarning)
+			}
+		})
+	}
+}
+
+// TestNutritionSearchCandidates tests the optional candidates list
+func TestNutritionSearchCandidates(t *testing.T) {
+	testCases := []struct {
+		name           string
+		query          string
+		wantStatus     int
+		wantCandidates int
+	}{
+		{"omitted by default", "", http.StatusOK, 0},
+		{"lists requested count", "?candidates=2", http.StatusOK, 2},
+		{"non-numeric count is rejected", "?candidates=some", http.StatusBadRequest, 0},
+		{"out-of-range count is rejected", "?candidates=50", http.StatusBadRequest, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newMockUSDAClient()
+			client.searchResult = &domain.USDASearchResponse{
+				Foods: []domain.USDAFood{
+					{FdcID: 1, Description: "Milk, whole"},
+					{FdcID: 2, Description: "Milk, skim"},
+					{FdcID: 3, Description: "Milk, chocolate"},
+				},
+			}
+			router := setupTestRouterWithService(newMockCacheRepository(), client)
+
+			payload := `{"productName":"whole milk"}`
+			req, _ := http.NewRequest("POST", "/api/v1/nutrition/search"+tc.query, strings.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.wantStatus {
+				t.Fatalf("Status = %d, want %d", w.Code, tc.wantStatus)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response domain.NutritionData
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if len(response.Candidates) != tc.wantCandidates {
+				t.Errorf("len(candidates) = %d, want %d", len(response.Candidates), tc.wantCandidates)
+			}
+		})
+	}
+}
+
+// TestNutritionSearchExplain tests that the score breakdown is only returned when enabled
+func TestNutritionSearchExplain(t *testing.T) {
+	testCases := []struct {
+		name            string
+		allowExplain    bool
+		query           string
+		wantExplanation bool
+	}{
+		{"ignored unless enabled", false, "?explain=true", false},
+		{"omitted unless requested", true, "", false},
+		{"returned when enabled and requested", true, "?explain=true", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newMockUSDAClient()
+			client.searchResult = &domain.USDASearchResponse{
+				Foods: []domain.USDAFood{
+					{FdcID: 1, Description: "Milk, whole", DataType: "Branded"},
+					{FdcID: 2, Description: "Milk, skim", DataType: "Branded"},
+				},
+			}
+			svc := usecase.NewNutritionService(cache.NewMemoryCache(), client, usecase.NutritionServiceConfig{MinConfidenceThreshold: 40})
+			handler := NewHandler(svc)
+			handler.SetAllowExplain(tc.allowExplain)
+			router := SetupRouter(&config.Config{Server: config.ServerConfig{Environment: "test"}}, handler)
+
+			search := func(query string) domain.NutritionData {
+				payload := `{"productName":"whole milk"}`
+				req, _ := http.NewRequest("POST", "/api/v1/nutrition/search"+query, strings.NewReader(payload))
+				req.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				if w.Code != http.StatusOK {
+					t.Fatalf("Status = %d, want %d", w.Code, http.StatusOK)
+				}
+				var response domain.NutritionData
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				return response
+			}
+
+			response := search(tc.query)
+			if (response.Explanation != nil) != tc.wantExplanation {
+				t.Fatalf("explanation = %+v, want present = %v", response.Explanation, tc.wantExplanation)
+			}
+			if tc.wantExplanation && response.Explanation.DataTypeBonus == 0 {
+				t.Errorf("dataTypeBonus = 0, want the Branded bonus")
+			}
+
+			// The breakdown is never cached
+			if cached := search(""); cached.Explanation != nil {
+				t.Errorf("cached explanation = %+v, want nil", cached.Explanation)
+			}
+		})
+	}
+}
+
+// TestNutritionSearchServerTiming tests the opt-in Server-Timing header on searches
+func TestNutritionSearchServerTiming(t *testing.T) {
+	client := newMockUSDAClient()
+	client.searchResult = &domain.USDASearchResponse{
+		Foods: []domain.USDAFood{{FdcID: 1, Description: "Milk, whole", DataType: "Branded"}},
+	}
+
+	search := func(router *gin.Engine) string {
+		req, _ := http.NewRequest("POST", "/api/v1/nutrition/search", strings.NewReader(`{"productName":"whole milk"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", w.Code, http.StatusOK)
+		}
+		return w.Header().Get("Server-Timing")
+	}
+	newRouter := func(enable bool) *gin.Engine {
+		svc := usecase.NewNutritionService(cache.NewMemoryCache(), client, usecase.NutritionServiceConfig{MinConfidenceThreshold: 40})
+		handler := NewHandler(svc)
+		handler.SetServerTiming(enable)
+		return SetupRouter(&config.Config{Server: config.ServerConfig{Environment: "test"}}, handler)
+	}
+
+	if header := search(newRouter(false)); header != "" {
+		t.Errorf("Server-Timing = %q, want none unless enabled", header)
+	}
+
+	router := newRouter(true)
+	phases := regexp.MustCompile(`^cache;dur=[0-9.]+, usda;dur=[0-9.]+, match;dur=[0-9.]+$`)
+	if header := search(router); !phases.MatchString(header) {
+		t.Errorf("Server-Timing = %q, want cache, usda and match durations", header)
+	}
+	// A cache hit never reaches USDA or matching
+	if header := search(router); !regexp.MustCompile(`^cache;dur=[0-9.]+$`).MatchString(header) {
+		t.Errorf("cached Server-Timing = %q, want only the cache duration", header)
+	}
+}
+
+// TestNutritionSearchDebug tests that debug=true exposes the cache key and timing
+func TestNutritionSearchDebug(t *testing.T) {
+	client := newMockUSDAClient()
+	client.searchResult = &domain.USDASearchResponse{
+		Foods: []domain.USDAFood{{FdcID: 1, Description: "Milk, whole"}},
+	}
+	svc := usecase.NewNutritionService(cache.NewMemoryCache(), client, usecase.NutritionServiceConfig{CacheNamespace: "staging"})
+	router := SetupRouter(&config.Config{Server: config.ServerConfig{Environment: "test"}}, NewHandler(svc))
+
+	req, _ := http.NewRequest("POST", "/api/v1/nutrition/search?debug=true", strings.NewReader(`{"productName":"Whole Milk","brand":"Horizon"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var response domain.NutritionData
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if !strings.HasPrefix(response.CacheKey, "staging:") || !strings.HasSuffix(response.CacheKey, ":nutrition:search:whole milk:horizon") {
+		t.Errorf("cacheKey = %q, want the namespaced key for whole milk by horizon", response.CacheKey)
+	}
+	if response.Source != "USDA" || response.USDALatencyMs == nil {
+		t.Errorf("source = %s, usdaLatencyMs = %v; want USDA with timing", response.Source, response.USDALatencyMs)
+	}
+}
+
+// TestNutritionSearchResponseLimit tests that oversized responses drop optional sections
+func TestNutritionSearchResponseLimit(t *testing.T) {
+	foods := make([]domain.USDAFood, 10)
+	for i := range foods {
+		foods[i] = domain.USDAFood{FdcID: i + 1, Description: fmt.Sprintf("Milk, whole, vitamin D added, variety %d", i+1)}
+	}
+
+	// All optional sections enabled: raw confidence, timing, and candidates
+	search := func(maxBytes int) (int, domain.NutritionData) {
+		client := newMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{Foods: foods}
+		svc := usecase.NewNutritionService(newMockCacheRepository(), client, usecase.NutritionServiceConfig{
+			MinConfidenceThreshold: 40,
+			ReportRawConfidence:    true,
+		})
+		handler := NewHandler(svc)
+		handler.SetMaxResponseBytes(maxBytes)
+		router := SetupRouter(&config.Config{Server: config.ServerConfig{Environment: "test"}}, handler)
+
+		payload := `{"productName":"whole milk"}`
+		req, _ := http.NewRequest("POST", "/api/v1/nutrition/search?includeTiming=true&candidates=10", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", w.Code, http.StatusOK)
+		}
+		var response domain.NutritionData
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return w.Body.Len(), response
+	}
+
+	fullSize, full := search(0)
+	if full.Truncated || full.MatchLatencyMs == nil || full.RawConfidence == nil || len(full.Candidates) != 10 {
+		t.Fatalf("unlimited response = %+v, want every optional section", full)
+	}
+
+	// Timing values vary slightly in length between runs; leave a margin for them
+	const timingJitter = 20
+
+	t.Run("fits without truncation at the full size", func(t *testing.T) {
+		_, got := search(fullSize + timingJitter)
+		if got.Truncated {
+			t.Error("Truncated = true, want false")
+		}
+	})
+
+	t.Run("drops diagnostics first", func(t *testing.T) {
+		size, got := search(fullSize - timingJitter)
+		if !got.Truncated {
+			t.Error("Truncated = false, want true")
+		}
+		if got.MatchLatencyMs != nil || got.USDALatencyMs != nil || got.RawConfidence != nil {
+			t.Errorf("diagnostics kept: timing %v/%v, rawConfidence %v", got.MatchLatencyMs, got.USDALatencyMs, got.RawConfidence)
+		}
+		if len(got.Candidates) != 10 {
+			t.Errorf("len(Candidates) = %d, want 10", len(got.Candidates))
+		}
+		if size > fullSize-timingJitter {
+			t.Errorf("size = %d, want at most %d", size, fullSize-timingJitter)
+		}
+	})
+
+	t.Run("drops candidates next", func(t *testing.T) {
+		_, got := search(fullSize / 3)
+		if !got.Truncated || got.Candidates != nil {
+			t.Errorf("Truncated = %v, candidates = %d; want truncated without candidates", got.Truncated, len(got.Candidates))
+		}
+		if got.FdcID == "" || got.Nutrients != full.Nutrients {
+			t.Errorf("core data = %+v, want it kept", got)
+		}
+	})
+}
+
+// TestNutritionSearchErrorCodes tests that each failure cause maps to a distinct status and code
+func TestNutritionSearchErrorCodes(t *testing.T) {
+	testCases := []struct {
+		name         string
+		searchResult *domain.USDASearchResponse
+		searchError  error
+		wantStatus   int
+		wantCode     string
+	}{
+		{
+			name:         "empty successful search is not found",
+			searchResult: &domain.USDASearchResponse{Foods: []domain.USDAFood{}},
+			wantStatus:   http.StatusNotFound,
+			wantCode:     CodeProductNotFound,
+		},
+		{
+			name:        "client not found is not found",
+			searchError: domain.ErrProductNotFound,
+			wantStatus:  http.StatusNotFound,
+			wantCode:    CodeProductNotFound,
+		},
+		{
+			name:        "USDA failure is upstream unavailable",
+			searchError: domain.ErrUSDAAPIFailure,
+			wantStatus:  http.StatusBadGateway,
+			wantCode:    CodeUpstreamUnavailable,
+		},
+		{
+			name:        "transport error is upstream unavailable",
+			searchError: errors.New("connection refused"),
+			wantStatus:  http.StatusBadGateway,
+			wantCode:    CodeUpstreamUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newMockUSDAClient()
+			client.searchResult = tc.searchResult
+			client.searchError = tc.searchError
+
+			router := setupTestRouterWithService(newMockCacheRepository(), client)
+
+			payload := `{"productName":"whole milk"}`
+			req, _ := http.NewRequest("POST", "/api/v1/nutrition/search", strings.NewReader(payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.wantStatus {
+				t.Errorf("Status = %d, want %d", w.Code, tc.wantStatus)
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if response["code"] != tc.wantCode {
+				t.Errorf("code = %v, want %s", response["code"], tc.wantCode)
+			}
+			if response["error"] == nil {
+				t.Error("expected error field in response")
+			}
+			if requestID := w.Header().Get(RequestIDHeader); requestID == "" || response["requestId"] != requestID {
+				t.Errorf("requestId = %v, want the %s header %q", response["requestId"], RequestIDHeader, requestID)
+			}
+		})
+	}
+
+	t.Run("control characters are invalid request", func(t *testing.T) {
+		client := newMockUSDAClient()
+		cfg := &config.Config{Server: config.ServerConfig{Environment: "test"}}
+		svc := usecase.NewNutritionService(newMockCacheRepository(), client, usecase.NutritionServiceConfig{RejectControlChars: true})
+		router := SetupRouter(cfg, NewHandler(svc))
+
+		req, _ := http.NewRequest("POST", "/api/v1/nutrition/search", strings.NewReader(`{"productName":"whole\u0000milk"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response["code"] != CodeInvalidRequest {
+			t.Errorf("code = %v, want %s", response["code"], CodeInvalidRequest)
+		}
+	})
+
+	t.Run("invalid body is invalid request", func(t *testing.T) {
+		router := setupTestRouterWithService(newMockCacheRepository(), newMockUSDAClient())
+
+		req, _ := http.NewRequest("POST", "/api/v1/nutrition/search", strings.NewReader(`{"brand":"x"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response["code"] != CodeInvalidRequest {
+			t.Errorf("code = %v, want %s", response["code"], CodeInvalidRequest)
+		}
+	})
+}
+
+func TestSearchErrorResponse(t *testing.T) {
+	testCases := []struct {
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{fmt.Errorf("%w: productName is required", domain.ErrInvalidRequest), http.StatusBadRequest, CodeInvalidRequest},
+		{domain.ErrProductNotFound, http.StatusNotFound, CodeProductNotFound},
+		{domain.ErrLowConfidence, http.StatusNotFound, CodeLowConfidence},
+		{domain.ErrRateLimited, http.StatusTooManyRequests, CodeRateLimited},
+		{fmt.Errorf("%w: %w after 5s", domain.ErrUSDAAPIFailure, domain.ErrTimeout), http.StatusBadGateway, CodeUpstreamUnavailable},
+		{errors.New("unexpected"), http.StatusInternalServerError, CodeInternalError},
+	}
+
+	for _, tc := range testCases {
+		status, code, message := searchErrorResponse(tc.err)
+		if status != tc.wantStatus || code != tc.wantCode {
+			t.Errorf("searchErrorResponse(%v) = %d %s, want %d %s", tc.err, status, code, tc.wantStatus, tc.wantCode)
+		}
+		if message == "" {
+			t.Errorf("searchErrorResponse(%v) message is empty", tc.err)
+		}
+	}
+}
+
+// milkOnlyUSDAClient finds a food only for queries mentioning milk. It keeps no
+// state, so it is safe for the concurrent searches a batch runs.
+type milkOnlyUSDAClient struct{}
+
+func (c milkOnlyUSDAClient) SearchFoods(ctx context.Context, query string) (*domain.USDASearchResponse, error) {
+	return c.SearchFoodsWithOptions(ctx, query, domain.SearchOptions{})
+}
+
+func (milkOnlyUSDAClient) SearchFoodsWithOptions(ctx context.Context, query string, opts domain.SearchOptions) (*domain.USDASearchResponse, error) {
+	if !strings.Contains(query, "milk") {
+		return &domain.USDASearchResponse{}, nil
+	}
+	return &domain.USDASearchResponse{Foods: []domain.USDAFood{{FdcID: 12345, Description: "Milk, whole"}}}, nil
+}
+
+func (milkOnlyUSDAClient) GetFoodDetails(ctx context.Context, fdcID string) (*domain.USDAFood, error) {
+	return nil, domain.ErrProductNotFound
+}
+
+func (milkOnlyUSDAClient) SearchByUPC(ctx context.Context, upc string) (*domain.USDAFood, error) {
+	return nil, domain.ErrProductNotFound
+}
+
+// TestBatchSearchNutrition tests the batch search endpoint
+func TestBatchSearchNutrition(t *testing.T) {
+	postBatch := func(router *gin.Engine, payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/nutrition/batch", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("returns per-item results in order", func(t *testing.T) {
+		memoryCache := cache.NewMemoryCache()
+		router := setupTestRouterWithService(memoryCache, milkOnlyUSDAClient{})
+
+		w := postBatch(router, `{"items":[{"productName":"whole milk"},{"productName":"unobtainium"},{"productName":"whole milk"}]}`)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+		var response struct {
+			Results []struct {
+				Data *domain.NutritionData `json:"data"`
+				Code string                `json:"code"`
+			} `json:"results"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(response.Results) != 3 {
+			t.Fatalf("len(results) = %d, want 3", len(response.Results))
+		}
+		for _, i := range []int{0, 2} {
+			if response.Results[i].Data == nil || response.Results[i].Data.FdcID != "12345" {
+				t.Errorf("results[%d].data = %+v, want fdcId 12345", i, response.Results[i].Data)
+			}
+		}
+		if response.Results[1].Data != nil || response.Results[1].Code != CodeProductNotFound {
+			t.Errorf("results[1] = %+v, want code %s", response.Results[1], CodeProductNotFound)
+		}
+	})
+
+	t.Run("rejects invalid batches", func(t *testing.T) {
+		router := setupTestRouterWithService(newMockCacheRepository(), milkOnlyUSDAClient{})
+		tooMany := strings.TrimSuffix(strings.Repeat(`{"productName":"milk"},`, usecase.MaxBatchItems+1), ",")
+
+		payloads := map[string]string{
+			"missing items":        `{}`,
+			"empty items":          `{"items":[]}`,
+			"missing product name": `{"items":[{"productName":"milk"},{"brand":"x"}]}`,
+			"too many items":       `{"items":[` + tooMany + `]}`,
+		}
+		for name, payload := range payloads {
+			w := postBatch(router, payload)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: Status = %d, want %d", name, w.Code, http.StatusBadRequest)
+			}
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("%s: Failed to unmarshal response: %v", name, err)
+			}
+			if response["code"] != CodeInvalidRequest {
+				t.Errorf("%s: code = %v, want %s", name, response["code"], CodeInvalidRequest)
+			}
+		}
+	})
+
+	t.Run("returns not implemented without a service", func(t *testing.T) {
+		w := postBatch(setupTestRouter(), `{"items":[{"productName":"milk"}]}`)
+		if w.Code != http.StatusNotImplemented {
+			t.Errorf("Status = %d, want %d", w.Code, http.StatusNotImplemented)
+		}
+	})
+}
+
+// setupAdminTestRouter creates a router backed by a memory cache with admin endpoints enabled
+func setupAdminTestRouter(memoryCache *cache.MemoryCache, adminKey string) *gin.Engine {
+	cfg := &config.Config{
+		Server: config.ServerConfig{
+			Environment: "test",
+			AdminAPIKey: adminKey,
+		},
+	}
+
+	handler := NewHandler(nil)
+	handler.SetCacheSnapshotter(memoryCache)
+	handler.SetCacheStats(memoryCache)
+	return SetupRouter(cfg, handler)
+}
+
+// TestCacheExportImport tests bootstrapping one instance's cache from another's export
+func TestCacheExportImport(t *testing.T) {
+	ctx := context.Background()
+	const adminKey = "test-admin-key"
+
+	t.Run("round-trips cache through export and import", func(t *testing.T) {
+		sourceCache := cache.NewMemoryCache()
+		entry := map[string]interface{}{"fdcId": "12345", "productName": "Whole Milk"}
+		if err := sourceCache.Set(ctx, "nutrition:whole milk:", entry, time.Hour); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		source := setupAdminTestRouter(sourceCache, adminKey)
+
+		req, _ := http.NewRequest("GET", "/api/v1/cache/export", nil)
+		req.Header.Set("X-API-Key", adminKey)
+		w := httptest.NewRecorder()
+		source.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("export Status = %d, want %d", w.Code, http.StatusOK)
+		}
+		exported := w.Body.String()
+
+		targetCache := cache.NewMemoryCache()
+		target := setupAdminTestRouter(targetCache, adminKey)
+
+		req, _ = http.NewRequest("POST", "/api/v1/cache/import", strings.NewReader(exported))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", adminKey)
+		w = httptest.NewRecorder()
+		target.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("import Status = %d, want %d", w.Code, http.StatusOK)
+		}
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response["imported"] != float64(1) {
+			t.Errorf("imported = %v, want 1", response["imported"])
+		}
+
+		got, err := targetCache.Get(ctx, "nutrition:whole milk:")
+		if err != nil {
+			t.Fatalf("imported entry missing: %v", err)
+		}
+		if got.(map[string]interface{})["fdcId"] != "12345" {
+			t.Errorf("imported entry = %v, want fdcId 12345", got)
+		}
+	})
+
+	t.Run("import has its own body limit", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+		entries := make([]string, 0, 1000)
+		for i := range 1000 {
+			entries = append(entries, fmt.Sprintf(`{"key":"nutrition:food %d:","value":{"fdcId":"%d","productName":"Food %d"},"expiresAt":%q}`, i, i, i, expiresAt))
+		}
+		snapshot := `{"entries":[` + strings.Join(entries, ",") + `]}`
+		if len(snapshot) <= 64*1024 {
+			t.Fatalf("snapshot is %d bytes, want more than the 64KB request limit", len(snapshot))
+		}
+
+		importSnapshot := func(maxImportBytes int) *httptest.ResponseRecorder {
+			cfg := &config.Config{Server: config.ServerConfig{
+				Environment:     "test",
+				AdminAPIKey:     adminKey,
+				MaxRequestBytes: 64 * 1024,
+				MaxImportBytes:  maxImportBytes,
+			}}
+			handler := NewHandler(nil)
+			handler.SetCacheSnapshotter(cache.NewMemoryCache())
+			req, _ := http.NewRequest("POST", "/api/v1/cache/import", strings.NewReader(snapshot))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-API-Key", adminKey)
+			w := httptest.NewRecorder()
+			SetupRouter(cfg, handler).ServeHTTP(w, req)
+			return w
+		}
+
+		if w := importSnapshot(1024 * 1024); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"imported":1000`) {
+			t.Errorf("Status = %d, body %s, want all 1000 entries imported", w.Code, w.Body.String())
+		}
+		if w := importSnapshot(32 * 1024); w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Status = %d, want %d above the import limit", w.Code, http.StatusRequestEntityTooLarge)
+		}
+	})
+
+	t.Run("rejects missing or wrong API key", func(t *testing.T) {
+		router := setupAdminTestRouter(cache.NewMemoryCache(), adminKey)
+
+		for _, key := range []string{"", "wrong-key"} {
+			req, _ := http.NewRequest("GET", "/api/v1/cache/export", nil)
+			if key != "" {
+				req.Header.Set("X-API-Key", key)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("key %q: Status = %d, want %d", key, w.Code, http.StatusUnauthorized)
+			}
+		}
+	})
+
+	t.Run("endpoints not registered without admin key", func(t *testing.T) {
+		router := setupAdminTestRouter(cache.NewMemoryCache(), "")
+
+		req, _ := http.NewRequest("GET", "/api/v1/cache/export", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+}
+
+// TestCacheStats tests the admin cache stats endpoint
+func TestCacheStats(t *testing.T) {
+	ctx := context.Background()
+	const adminKey = "test-admin-key"
+
+	memoryCache := cache.NewMemoryCache()
+	memoryCache.Set(ctx, "nutrition:whole milk:", map[string]interface{}{"fdcId": "12345"}, time.Hour)
+	memoryCache.Get(ctx, "nutrition:whole milk:")
+	memoryCache.Get(ctx, "nutrition:skim milk:")
+	router := setupAdminTestRouter(memoryCache, adminKey)
+
+	req, _ := http.NewRequest("GET", "/api/v1/cache/stats", nil)
+	req.Header.Set("X-API-Key", adminKey)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var stats domain.CacheStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	want := domain.CacheStats{Size: 1, Hits: 1, Misses: 1, HitRatio: 0.5}
+	if stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+
+	t.Run("requires the admin API key", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/cache/stats", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Status = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	})
+}
+
+// TestInvalidateSearch tests evicting a cached search through the admin endpoint
+func TestInvalidateSearch(t *testing.T) {
+	const adminKey = "test-admin-key"
+	const payload = `{"productName":"Whole Milk"}`
+
+	memoryCache := cache.NewMemoryCache()
+	client := newMockUSDAClient()
+	client.searchResult = &domain.USDASearchResponse{
+		Foods: []domain.USDAFood{{FdcID: 12345, Description: "Whole Milk"}},
+	}
+	nutritionService := usecase.NewNutritionService(memoryCache, client, usecase.NutritionServiceConfig{})
+	router := SetupRouter(&config.Config{
+		Server: config.ServerConfig{Environment: "test", AdminAPIKey: adminKey},
+	}, NewHandler(nutritionService))
+
+	send := func(method, key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/api/v1/nutrition/search", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send("POST", ""); w.Code != http.StatusOK {
+		t.Fatalf("search Status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if memoryCache.Size() != 1 {
+		t.Fatalf("cache size after search = %d, want 1", memoryCache.Size())
+	}
+
+	t.Run("requires the admin API key", func(t *testing.T) {
+		if w := send("DELETE", ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Status = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+		if memoryCache.Size() != 1 {
+			t.Errorf("cache size = %d, want the entry kept", memoryCache.Size())
+		}
+	})
+
+	t.Run("evicts the cached search", func(t *testing.T) {
+		if w := send("DELETE", adminKey); w.Code != http.StatusNoContent {
+			t.Errorf("Status = %d, want %d", w.Code, http.StatusNoContent)
+		}
+		if memoryCache.Size() != 0 {
+			t.Errorf("cache size = %d, want 0", memoryCache.Size())
+		}
+	})
+
+	t.Run("succeeds when nothing is cached", func(t *testing.T) {
+		if w := send("DELETE", adminKey); w.Code != http.StatusNoContent {
+			t.Errorf("Status = %d, want %d", w.Code, http.StatusNoContent)
+		}
+	})
+}
+
+func setupFeedbackTestRouter(enabled bool) *gin.Engine {
+	cfg := &config.Config{
+		Server:   config.ServerConfig{Environment: "test"},
+		Feedback: config.FeedbackConfig{Enabled: enabled, BufferSize: 100},
+	}
+
+	handler := NewHandler(nil)
+	if enabled {
+		handler.SetFeedbackService(usecase.NewFeedbackService(feedback.NewMemoryStore(100), nil))
+	}
+	return SetupRouter(cfg, handler)
+}
+
+// TestMatchFeedback tests recording feedback and reading the aggregate stats
+func TestMatchFeedback(t *testing.T) {
+	postFeedback := func(router *gin.Engine, payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/nutrition/feedback", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("records feedback and reports accuracy by data type", func(t *testing.T) {
+		router := setupFeedbackTestRouter(true)
+
+		for _, payload := range []string{
+			`{"query":"whole milk","fdcId":"1","correct":true,"dataType":"Branded"}`,
+			`{"query":"whole milk","fdcId":"2","correct":false,"dataType":"Branded"}`,
+			`{"query":"white bread","fdcId":"3","correct":true,"dataType":"Foundation"}`,
+		} {
+			if w := postFeedback(router, payload); w.Code != http.StatusOK {
+				t.Fatalf("POST feedback status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+			}
+		}
+
+		req, _ := http.NewRequest("GET", "/api/v1/debug/feedback", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET stats status = %d, want %d", w.Code, http.StatusOK)
+		}
+
+		var stats domain.FeedbackStats
+		if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if stats.Total != 3 || stats.Correct != 2 {
+			t.Errorf("overall = %+v, want 2/3 correct", stats.FeedbackCounts)
+		}
+		if branded := stats.ByDataType["Branded"]; branded.Accuracy != 0.5 {
+			t.Errorf("Branded accuracy = %v, want 0.5", branded.Accuracy)
+		}
+	})
+
+	t.Run("rejects feedback without a verdict", func(t *testing.T) {
+		router := setupFeedbackTestRouter(true)
+
+		w := postFeedback(router, `{"query":"whole milk","fdcId":"1"}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("GET is not found rather than an invalid fdcId", func(t *testing.T) {
+		router := setupFeedbackTestRouter(true)
+
+		req, _ := http.NewRequest("GET", "/api/v1/nutrition/feedback", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("endpoints are absent when disabled", func(t *testing.T) {
+		router := setupFeedbackTestRouter(false)
+
+		w := postFeedback(router, `{"query":"whole milk","fdcId":"1","correct":true}`)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+}
+
+// TestConfidenceDistribution tests the confidence histogram debug endpoint
+func TestConfidenceDistribution(t *testing.T) {
+	cfg := &config.Config{
+		Server:   config.ServerConfig{Environment: "test"},
+		Matching: config.MatchingConfig{ConfidenceHistogramWindow: time.Hour},
+	}
+	histogram := usecase.NewConfidenceHistogram(time.Hour)
+	histogram.Record(30)
+	histogram.Record(90)
+
+	handler := NewHandler(nil)
+	handler.SetConfidenceHistogram(histogram)
+	router := SetupRouter(cfg, handler)
+
+	req, _ := http.NewRequest("GET", "/api/v1/debug/confidence", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var snapshot usecase.HistogramSnapshot
+	if err := json.Unmarshal(w.Body.Bytes(), &snapshot); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if snapshot.Total != 2 || len(snapshot.Buckets) != 5 {
+		t.Errorf("snapshot = %s, want 2 results in 5 buckets", snapshot)
+	}
+	if snapshot.Buckets[1].Count != 1 || snapshot.Buckets[4].Count != 1 {
+		t.Errorf("snapshot = %s, want one result each in 20-40 and 80-100", snapshot)
+	}
+}
+
+// TestDictionaries tests the dictionaries endpoint, including configured extras
+func TestDictionaries(t *testing.T) {
+	setup := func(expose bool) *gin.Engine {
+		cfg := &config.Config{Server: config.ServerConfig{Environment: "test", ExposeDictionaries: expose}}
+		svc := usecase.NewNutritionService(newMockCacheRepository(), newMockUSDAClient(), usecase.NutritionServiceConfig{
+			ExtraFoodTerms:        []string{"Kefir"},
+			ExtraDescriptiveTerms: []string{"sprouted"},
+			ExtraStopWords:        []string{"multipack"},
+		})
+		return SetupRouter(cfg, NewHandler(svc))
+	}
+
+	t.Run("includes built-in and extra terms", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/dictionaries", nil)
+		w := httptest.NewRecorder()
+		setup(true).ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", w.Code, http.StatusOK)
+		}
+
+		var dicts usecase.Dictionaries
+		if err := json.Unmarshal(w.Body.Bytes(), &dicts); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		for _, check := range []struct {
+			list  []string
+			terms []string
+		}{
+			{dicts.FoodTerms, []string{"milk", "kefir"}},
+			{dicts.DescriptiveTerms, []string{"organic", "sprouted"}},
+			{dicts.StopWords, []string{"the", "multipack"}},
+			{dicts.StoreBrands["walmart"], []string{"great value"}},
+		} {
+			for _, term := range check.terms {
+				if !slices.Contains(check.list, term) {
+					t.Errorf("%q missing from %v", term, check.list)
+				}
+			}
+		}
+	})
+
+	t.Run("not exposed by default", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/dictionaries", nil)
+		w := httptest.NewRecorder()
+		setup(false).ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("501 without a nutrition service", func(t *testing.T) {
+		cfg := &config.Config{Server: config.ServerConfig{Environment: "test", ExposeDictionaries: true}}
+		req, _ := http.NewRequest("GET", "/api/v1/dictionaries", nil)
+		w := httptest.NewRecorder()
+		SetupRouter(cfg, NewHandler(nil)).ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotImplemented || !strings.Contains(w.Body.String(), CodeNotImplemented) {
+			t.Errorf("Status = %d, body %s, want %d with code %s", w.Code, w.Body.String(), http.StatusNotImplemented, CodeNotImplemented)
+		}
+	})
+}
+
+// TestVocabulary tests the preprocessing vocabulary endpoint
+func TestVocabulary(t *testing.T) {
+	svc := usecase.NewNutritionService(newMockCacheRepository(), newMockUSDAClient(), usecase.NutritionServiceConfig{})
+	router := SetupRouter(&config.Config{Server: config.ServerConfig{Environment: "test"}}, NewHandler(svc))
+
+	t.Run("lists store brands and noise words", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/config/vocabulary", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", w.Code, http.StatusOK)
+		}
+		var vocabulary usecase.Vocabulary
+		if err := json.Unmarshal(w.Body.Bytes(), &vocabulary); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if !slices.Contains(vocabulary.StoreBrands["walmart"], "great value") {
+			t.Errorf("walmart store brands = %v, want great value", vocabulary.StoreBrands["walmart"])
+		}
+		if !slices.Contains(vocabulary.RetailNoiseWords["kroger"], "coupon") {
+			t.Errorf("kroger noise words = %v, want coupon", vocabulary.RetailNoiseWords["kroger"])
+		}
+		if vocabulary.FoodTermCount == 0 {
+			t.Error("foodTermCount = 0, want the dictionary size")
+		}
+	})
+
+	t.Run("filters by q", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/config/vocabulary?q=gather", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var vocabulary usecase.Vocabulary
+		if err := json.Unmarshal(w.Body.Bytes(), &vocabulary); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if !slices.Equal(vocabulary.StoreBrands["target"], []string{"good & gather"}) || len(vocabulary.StoreBrands["walmart"]) != 0 {
+			t.Errorf("store brands = %v, want only good & gather", vocabulary.StoreBrands)
+		}
+	})
+}
+
+// TestPreprocess tests previewing query cleaning without a USDA search
+func TestPreprocess(t *testing.T) {
+	client := newMockUSDAClient()
+	client.searchError = errors.New("USDA must not be called")
+	router := setupTestRouterWithService(newMockCacheRepository(), client)
+	postPreprocess := func(payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/preprocess", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("returns the query and keywords", func(t *testing.T) {
+		w := postPreprocess(`{"productName":"Horizon Organic Whole Milk, 64 fl oz","retailer":"walmart"}`)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status = %d, want %d (body %s)", w.Code, http.StatusOK, w.Body.String())
+		}
+		var preprocessed usecase.Preprocessed
+		if err := json.Unmarshal(w.Body.Bytes(), &preprocessed); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if preprocessed.Query != "horizon organic whole milk" {
+			t.Errorf("query = %q, want %q", preprocessed.Query, "horizon organic whole milk")
+		}
+		if len(preprocessed.Keywords) == 0 || preprocessed.Keywords[0] != "milk" {
+			t.Errorf("keywords = %v, want milk first", preprocessed.Keywords)
+		}
+	})
+
+	t.Run("requires a product name", func(t *testing.T) {
+		w := postPreprocess(`{"brand":"Horizon"}`)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+// TestListCandidates tests paging through scored USDA candidates
+func TestListCandidates(t *testing.T) {
+	client := newMockUSDAClient()
+	client.searchResult = &domain.USDASearchResponse{
+		Foods: []domain.USDAFood{
+			{FdcID: 1, Description: "Whole Milk", DataType: "Branded"},
+			{FdcID: 2, Description: "Milk, whole, 3.25% milkfat", DataType: "Survey (FNDDS)"},
+			{FdcID: 3, Description: "Milk, chocolate", DataType: "Branded"},
+		},
+	}
+	router := setupTestRouterWithService(cache.NewMemoryCache(), client)
+	postCandidates := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/nutrition/candidates"+query, strings.NewReader(`{"productName":"whole milk"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := postCandidates("?page=1&pageSize=2")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status = %d, want %d (body %s)", w.Code, http.StatusOK, w.Body.String())
+	}
+	var first usecase.CandidatePage
+	if err := json.Unmarshal(w.Body.Bytes(), &first); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(first.Candidates) != 2 || first.TotalHits != 3 || first.TotalPages != 2 || first.Candidates[0].FdcID != "1" {
+		t.Errorf("page 1 = %+v, want 2 of 3 candidates over 2 pages, whole milk first", first)
+	}
+
+	// The next page comes from the cached search
+	client.searchError = errors.New("USDA must not be called again")
+	w = postCandidates("?page=2&pageSize=2")
+	var second usecase.CandidatePage
+	if err := json.Unmarshal(w.Body.Bytes(), &second); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("page 2: Status = %d, body %s", w.Code, w.Body.String())
+	}
+	if len(second.Candidates) != 1 || second.CurrentPage != 2 {
+		t.Errorf("page 2 = %+v, want the last candidate", second)
+	}
+
+	for _, query := range []string{"?page=0", "?page=abc", "?pageSize=-5"} {
+		if w := postCandidates(query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: Status = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+// TestSearchNutrition_BodySizeLimit tests that oversized request bodies get a 413
+func TestSearchNutrition_BodySizeLimit(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.ServerConfig{Environment: "test", MaxRequestBytes: 1024},
+	}
+	nutritionService := usecase.NewNutritionService(cache.NewMemoryCache(), milkOnlyUSDAClient{}, usecase.NutritionServiceConfig{})
+	router := SetupRouter(cfg, NewHandler(nutritionService))
+
+	oversized := `{"productName":"` + strings.Repeat("milk ", 300) + `"}`
+
+	tests := []struct {
+		name    string
+		payload string
+		chunked bool // no Content-Length, so the limit is hit while binding
+		want    int
+	}{
+		{name: "within limit", payload: `{"productName":"whole milk"}`, want: http.StatusOK},
+		{name: "oversized with Content-Length", payload: oversized, want: http.StatusRequestEntityTooLarge},
+		{name: "oversized without Content-Length", payload: oversized, chunked: true, want: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/nutrition/search", strings.NewReader(tt.payload))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("Status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+			if tt.want == http.StatusRequestEntityTooLarge && !strings.Contains(w.Body.String(), CodePayloadTooLarge) {
+				t.Errorf("body = %s, want code %s", w.Body.String(), CodePayloadTooLarge)
+			}
+		})
+	}
+}
+
+// TestClientAPIKey tests that the client API key guards /api/v1 but not /health,
+// and that admin endpoints keep using the admin key
+func TestClientAPIKey(t *testing.T) {
+	const clientKey, adminKey = "test-client-key", "test-admin-key"
+	cfg := &config.Config{
+		Server: config.ServerConfig{Environment: "test", APIKey: clientKey, AdminAPIKey: adminKey},
+	}
+	memoryCache := cache.NewMemoryCache()
+	handler := NewHandler(usecase.NewNutritionService(memoryCache, milkOnlyUSDAClient{}, usecase.NutritionServiceConfig{}))
+	handler.SetCacheSnapshotter(memoryCache)
+	router := SetupRouter(cfg, handler)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		want   int
+	}{
+		{name: "health stays public", method: "GET", path: "/health", want: http.StatusOK},
+		{name: "search without key", method: "POST", path: "/api/v1/nutrition/search", want: http.StatusUnauthorized},
+		{name: "search with wrong key", method: "POST", path: "/api/v1/nutrition/search", key: "wrong-key", want: http.StatusUnauthorized},
+		{name: "search with client key", method: "POST", path: "/api/v1/nutrition/search", key: clientKey, want: http.StatusOK},
+		{name: "vocabulary without key", method: "GET", path: "/api/v1/config/vocabulary", want: http.StatusUnauthorized},
+		{name: "vocabulary with client key", method: "GET", path: "/api/v1/config/vocabulary", key: clientKey, want: http.StatusOK},
+		{name: "preprocess without key", method: "POST", path: "/api/v1/preprocess", want: http.StatusUnauthorized},
+		{name: "preprocess with client key", method: "POST", path: "/api/v1/preprocess", key: clientKey, want: http.StatusOK},
+		{name: "admin endpoint with client key", method: "GET", path: "/api/v1/cache/export", key: clientKey, want: http.StatusUnauthorized},
+		{name: "admin endpoint with admin key", method: "GET", path: "/api/v1/cache/export", key: adminKey, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(`{"productName":"whole milk"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("Status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestRateLimitClientIP tests that rate limiting only believes X-Forwarded-For from
+// configured proxies
+func TestRateLimitClientIP(t *testing.T) {
+	// health sends three requests from remoteIP, each with its own X-Forwarded-For,
+	// and returns the last status
+	health := func(router *gin.Engine, remoteIP string) int {
+		var status int
+		for i := range 3 {
+			req := httptest.NewRequest("GET", "/health", nil)
+			req.RemoteAddr = remoteIP + ":12345"
+			req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i+1))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			status = w.Code
+		}
+		return status
+	}
+
+	t.Run("ignores a spoofed X-Forwarded-For by default", func(t *testing.T) {
+		cfg := &config.Config{
+			Server:    config.ServerConfig{Environment: "test"},
+			RateLimit: config.RateLimitConfig{PerIP: 2},
+		}
+		router := SetupRouter(cfg, NewHandler(nil))
+
+		if status := health(router, "198.51.100.7"); status != http.StatusTooManyRequests {
+			t.Errorf("third request status = %d, want %d", status, http.StatusTooManyRequests)
+		}
+	})
+
+	t.Run("believes X-Forwarded-For from a trusted proxy", func(t *testing.T) {
+		cfg := &config.Config{
+			Server:    config.ServerConfig{Environment: "test", TrustedProxies: []string{"10.0.0.0/8"}},
+			RateLimit: config.RateLimitConfig{PerIP: 2},
+		}
+		router := SetupRouter(cfg, NewHandler(nil))
+
+		if status := health(router, "10.0.0.1"); status != http.StatusOK {
+			t.Errorf("third request status = %d, want %d", status, http.StatusOK)
+		}
+	})
+}
+
+// TestMatchFoods tests matching a product against caller-supplied USDA foods
+func TestMatchFoods(t *testing.T) {
+	router := setupTestRouterWithService(cache.NewMemoryCache(), milkOnlyUSDAClient{})
+	postMatch := func(payload string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/nutrition/match", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("returns the match result", func(t *testing.T) {
+		w := postMatch(`{"productName":"Whole Milk","foods":[` +
+			`{"fdcId":1,"description":"Cheese, cheddar","dataType":"Survey (FNDDS)"},` +
+			`{"fdcId":2,"description":"Milk, whole","dataType":"Foundation"}]}`)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status = %d, want %d (body %s)", w.Code, http.StatusOK, w.Body.String())
+		}
+		var result domain.MatchResult
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if result.FdcID != "2" || result.MatchScore <= 0 || !slices.Contains(result.MatchedTokens, "milk") {
+			t.Errorf("result = %+v, want fdcId 2 with a score and milk matched", result)
+		}
+	})
+
+	t.Run("flags low-confidence matches", func(t *testing.T) {
+		w := postMatch(`{"productName":"orange juice","foods":[{"fdcId":1,"description":"Cheese, cheddar"}]}`)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", w.Code, http.StatusOK)
+		}
+		if !strings.Contains(w.Body.String(), `"lowConfidence":true`) {
+			t.Errorf("body = %s, want lowConfidence", w.Body.String())
+		}
+	})
+
+	t.Run("rejects missing or empty foods", func(t *testing.T) {
+		for _, payload := range []string{`{"productName":"milk"}`, `{"productName":"milk","foods":[]}`, `{"foods":[{"fdcId":1}]}`} {
+			w := postMatch(payload)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s: Status = %d, want %d", payload, w.Code, http.StatusBadRequest)
+			}
+		}
+	})
+
+	t.Run("GET on any POST-only nutrition route is not found", func(t *testing.T) {
+		var checked []string
+		for _, route := range router.Routes() {
+			name, ok := strings.CutPrefix(route.Path, "/api/v1/nutrition/")
+			if route.Method != http.MethodPost || !ok {
+				continue
+			}
+			checked = append(checked, name)
+
+			req, _ := http.NewRequest("GET", route.Path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusNotFound {
+				t.Errorf("GET %s: Status = %d, want %d", route.Path, w.Code, http.StatusNotFound)
+			}
+		}
+		if !slices.Contains(checked, "match") {
+			t.Errorf("checked routes = %v, want match among them", checked)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/macrolens/backend/internal/domain"
+)
+
+const (
+	// RequestIDHeader carries the request ID back to the client
+	RequestIDHeader = "X-Request-ID"
+
+	// requestIDKey is the gin context key holding the request ID
+	requestIDKey = "requestID"
+
+	// Request log formats
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// RequestIDMiddleware assigns each request a random UUID, returns it in the
+// X-Request-ID header and stores it on the request context for lower layers
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := newRequestID()
+		c.Set(requestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(domain.WithRequestID(c.Request.Context(), requestID))
+
+		c.Next()
+	}
+}
+
+// newRequestID returns a random (version 4) UUID
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// requestLogEntry is one request in the JSON log format
+type requestLogEntry struct {
+	Time      string  `json:"time"`
+	RequestID string  `json:"requestId,omitempty"`
+	Method    string  `json:"method"`
+	Path      string  `json:"path"`
+	Status    int     `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	ClientIP  string  `json:"clientIp"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// LoggerMiddleware logs one line per request, including its request ID, as
+// either gin-style text or a JSON object (format "json")
+func LoggerMiddleware(format string) gin.HandlerFunc {
+	return newLoggerMiddleware(format, gin.DefaultWriter)
+}
+
+// newLoggerMiddleware is LoggerMiddleware writing to out
+func newLoggerMiddleware(format string, out io.Writer) gin.HandlerFunc {
+	formatter := textLogFormatter
+	if format == LogFormatJSON {
+		formatter = jsonLogFormatter
+	}
+	return gin.LoggerWithConfig(gin.LoggerConfig{Formatter: formatter, Output: out})
+}
+
+// textLogFormatter mirrors gin's default log line with the request ID appended
+func textLogFormatter(param gin.LogFormatterParams) string {
+	return fmt.Sprintf("[GIN] %s | %3d | %13v | %15s | %-7s %q | req=%s\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		param.StatusCode,
+		param.Latency,
+		param.ClientIP,
+		param.Method,
+		param.Path,
+		requestIDFrom(param),
+		param.ErrorMessage,
+	)
+}
+
+// jsonLogFormatter renders the request as a single JSON line
+func jsonLogFormatter(param gin.LogFormatterParams) string {
+	line, err := json.Marshal(requestLogEntry{
+		Time:      param.TimeStamp.UTC().Format(time.RFC3339Nano),
+		RequestID: requestIDFrom(param),
+		Method:    param.Method,
+		Path:      param.Path,
+		Status:    param.StatusCode,
+		LatencyMs: float64(param.Latency.Microseconds()) / 1000,
+		ClientIP:  param.ClientIP,
+		Error:     param.ErrorMessage,
+	})
+	if err != nil {
+		return fmt.Sprintf("{\"error\":%q}\n", err.Error())
+	}
+	return string(line) + "\n"
+}
+
+// requestIDFrom returns the request ID set by RequestIDMiddleware, if any
+func requestIDFrom(param gin.LogFormatterParams) string {
+	requestID, _ := param.Keys[requestIDKey].(string)
+	return requestID
+}
@@ -1,24 +1,73 @@
 package http
 
 import (
+	"crypto/subtle"
+	"math"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
 )
 
-// CORSMiddleware handles CORS for Chrome extension
-func CORSMiddleware(allowedOrigins []string) gin.HandlerFunc {
+const (
+	// rateLimitWindow is the period the per-IP budget applies to
+	rateLimitWindow = time.Minute
+
+	// rateLimitIdleTTL is how long a client's limiter is kept after its last request
+	rateLimitIdleTTL = 10 * time.Minute
+)
+
+// CORSOptions tunes CORSMiddleware's preflight response
+type CORSOptions struct {
+	AllowedHeaders []string      // Request headers browsers may send; empty uses defaultCORSAllowedHeaders. X-API-Key is always allowed.
+	MaxAge         time.Duration // How long browsers may cache a preflight response; 0 uses the default 1h
+}
+
+// defaultCORSAllowedHeaders are the request headers allowed when CORSOptions sets none
+var defaultCORSAllowedHeaders = []string{"Content-Type", "Authorization", "X-Requested-With", "X-API-Key", "If-None-Match"}
+
+// defaultCORSMaxAge is how long browsers cache a preflight when CORSOptions sets no max age
+const defaultCORSMaxAge = time.Hour
+
+// CORSMiddleware handles CORS for Chrome extension. routes lists the registered
+// routes, usually the engine's Routes method; Access-Control-Allow-Methods only
+// advertises the methods registered for the requested path. Routes are read on the
+// first allowed request, so register them all before serving.
+func CORSMiddleware(allowedOrigins []string, opts CORSOptions, routes func() gin.RoutesInfo) gin.HandlerFunc {
+	var (
+		loadRoutes sync.Once
+		registered gin.RoutesInfo
+	)
+
+	allowedHeaders := strings.Join(corsAllowedHeaders(opts.AllowedHeaders), ", ")
+	maxAge := opts.MaxAge
+	if maxAge <= 0 {
+		maxAge = defaultCORSMaxAge
+	}
+	maxAgeSeconds := strconv.Itoa(int(maxAge.Seconds()))
+
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
 
 		// Check if origin is allowed
 		if isAllowedOrigin(origin, allowedOrigins) {
+			loadRoutes.Do(func() { registered = routes() })
+
 			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
 			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-			c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-			c.Writer.Header().Set("Access-Control-Max-Age", "3600")
+			if methods := routeMethods(registered, c.Request.URL.Path); len(methods) > 0 {
+				c.Writer.Header().Set("Access-Control-Allow-Methods", strings.Join(append(methods, http.MethodOptions), ", "))
+			}
+			c.Writer.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+			c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag")
+			c.Writer.Header().Set("Access-Control-Max-Age", maxAgeSeconds)
+			// Lets the extension read Server-Timing entries, not just devtools
+			c.Writer.Header().Set("Timing-Allow-Origin", origin)
 		}
 
 		// Handle preflight requests
@@ -47,12 +96,208 @@ func isAllowedOrigin(origin string, allowedOrigins []string) bool {
 	return false
 }
 
-// LoggerMiddleware logs requests (simple version for now)
-func LoggerMiddleware() gin.HandlerFunc {
-	return gin.Logger()
+// corsAllowedHeaders returns the configured headers, or the defaults when none are,
+// adding X-API-Key when missing since the auth middlewares read it
+func corsAllowedHeaders(configured []string) []string {
+	headers := make([]string, 0, len(configured)+1)
+	for _, header := range configured {
+		if header = strings.TrimSpace(header); header != "" {
+			headers = append(headers, header)
+		}
+	}
+	if len(headers) == 0 {
+		return defaultCORSAllowedHeaders
+	}
+	for _, header := range headers {
+		if strings.EqualFold(header, "X-API-Key") {
+			return headers
+		}
+	}
+	return append(headers, "X-API-Key")
+}
+
+// routeMethods returns the sorted methods registered for path. Routes matching it
+// exactly win over :param and *catch-all routes, as they do in gin's router.
+func routeMethods(routes gin.RoutesInfo, path string) []string {
+	var exact, wildcard []string
+	for _, route := range routes {
+		matched, static := matchRoute(route.Path, path)
+		switch {
+		case !matched:
+		case static:
+			exact = append(exact, route.Method)
+		default:
+			wildcard = append(wildcard, route.Method)
+		}
+	}
+
+	methods := wildcard
+	if len(exact) > 0 {
+		methods = exact
+	}
+	slices.Sort(methods)
+	return slices.Compact(methods)
+}
+
+// matchRoute reports whether path matches a gin route pattern, and whether it did
+// so without any :param or *catch-all segment
+func matchRoute(pattern, path string) (matched, static bool) {
+	patternParts := strings.Split(pattern, "/")
+	pathParts := strings.Split(path, "/")
+	static = true
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, "*") {
+			return len(pathParts) >= i, false
+		}
+		if i >= len(pathParts) {
+			return false, false
+		}
+		if strings.HasPrefix(part, ":") {
+			if pathParts[i] == "" {
+				return false, false
+			}
+			static = false
+		} else if part != pathParts[i] {
+			return false, false
+		}
+	}
+	return len(patternParts) == len(pathParts), static
+}
+
+// APIKeyAuthMiddleware rejects requests whose X-API-Key header does not match expectedKey
+func APIKeyAuthMiddleware(expectedKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader("X-API-Key")
+		if expectedKey == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(expectedKey)) != 1 {
+			respondError(c, http.StatusUnauthorized, CodeUnauthorized, "Invalid or missing API key")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AuthMiddleware requires clients to send expectedKey in the X-API-Key header,
+// answering 401 otherwise. An empty expectedKey disables the check.
+func AuthMiddleware(expectedKey string) gin.HandlerFunc {
+	if expectedKey == "" {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return APIKeyAuthMiddleware(expectedKey)
+}
+
+// RateLimitMiddleware limits each client IP to perIP requests per minute, with
+// bursts up to the full budget. Clients over budget get a 429 with Retry-After.
+// perIP <= 0 disables rate limiting.
+func RateLimitMiddleware(perIP int) gin.HandlerFunc {
+	if perIP <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	limiters := newIPRateLimiters(perIP)
+	return func(c *gin.Context) {
+		if delay := limiters.reserve(c.ClientIP(), time.Now()); delay > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			respondError(c, http.StatusTooManyRequests, CodeRateLimited, "Rate limit exceeded, please try again later")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ipLimiter is one client's token bucket and when it was last used
+type ipLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// ipRateLimiters holds a limiter per client IP, evicting idle ones so memory
+// stays bounded by the number of recently active clients
+type ipRateLimiters struct {
+	limit     rate.Limit
+	burst     int
+	limiters  map[string]*ipLimiter
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+func newIPRateLimiters(perIP int) *ipRateLimiters {
+	return &ipRateLimiters{
+		limit:    rate.Limit(float64(perIP) / rateLimitWindow.Seconds()),
+		burst:    perIP,
+		limiters: make(map[string]*ipLimiter),
+	}
+}
+
+// reserve takes a token for ip, returning 0 if the request may proceed or how
+// long the client must wait before its next token
+func (l *ipRateLimiters) reserve(ip string, now time.Time) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if now.Sub(l.lastSweep) >= rateLimitIdleTTL {
+		l.evictIdle(now)
+	}
+
+	entry, ok := l.limiters[ip]
+	if !ok {
+		entry = &ipLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[ip] = entry
+	}
+	entry.lastSeen = now
+
+	reservation := entry.limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		// Rejected requests don't consume budget
+		reservation.CancelAt(now)
+	}
+	return delay
+}
+
+// evictIdle drops limiters unused for rateLimitIdleTTL. An idle limiter has
+// refilled to its full burst, so dropping it doesn't change behavior.
+func (l *ipRateLimiters) evictIdle(now time.Time) {
+	for ip, entry := range l.limiters {
+		if now.Sub(entry.lastSeen) >= rateLimitIdleTTL {
+			delete(l.limiters, ip)
+		}
+	}
+	l.lastSweep = now
+}
+
+// BodySizeLimitMiddleware caps request bodies at maxBytes. Requests declaring a
+// larger Content-Length get a 413 straight away; other bodies are cut off at the
+// limit, which handlers report as a 413 when binding (see respondBindError).
+// maxBytes <= 0 disables the limit.
+func BodySizeLimitMiddleware(maxBytes int) gin.HandlerFunc {
+	if maxBytes <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	limit := int64(maxBytes)
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			respondError(c, http.StatusRequestEntityTooLarge, CodePayloadTooLarge,
+				"Request body exceeds "+strconv.FormatInt(limit, 10)+" bytes")
+			c.Abort()
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+
+		c.Next()
+	}
 }
 
-// RecoveryMiddleware recovers from panics
+// RecoveryMiddleware recovers from panics with a 500 error envelope
 func RecoveryMiddleware() gin.HandlerFunc {
-	return gin.Recovery()
+	return gin.CustomRecovery(func(c *gin.Context, _ any) {
+		respondError(c, http.StatusInternalServerError, CodeInternalError, "An unexpected error occurred")
+		c.Abort()
+	})
 }
@@ -1,11 +1,20 @@
 package http
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/macrolens/backend/internal/domain"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestIsAllowedOrigin(t *testing.T) {
@@ -129,7 +138,7 @@ func TestCORSMiddleware(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Setup router
 			router := gin.New()
-			router.Use(CORSMiddleware(tt.allowedOrigins))
+			router.Use(CORSMiddleware(tt.allowedOrigins, CORSOptions{}, router.Routes))
 			router.GET("/test", func(c *gin.Context) {
 				c.String(http.StatusOK, "OK")
 			})
@@ -173,7 +182,7 @@ func TestCORSMiddleware_PreflightRequest(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	router := gin.New()
-	router.Use(CORSMiddleware([]string{"chrome-extension://*"}))
+	router.Use(CORSMiddleware([]string{"chrome-extension://*"}, CORSOptions{}, router.Routes))
 	router.POST("/test", func(c *gin.Context) {
 		c.String(http.StatusOK, "OK")
 	})
@@ -196,13 +205,389 @@ func TestCORSMiddleware_PreflightRequest(t *testing.T) {
 	if w.Header().Get("Access-Control-Allow-Origin") != "chrome-extension://abcdefg12345" {
 		t.Errorf("Access-Control-Allow-Origin not set correctly")
 	}
-	if w.Header().Get("Access-Control-Allow-Methods") == "" {
-		t.Errorf("Access-Control-Allow-Methods not set")
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "POST, OPTIONS" {
+		t.Errorf("Access-Control-Allow-Methods = %q, want the route's POST, OPTIONS", got)
 	}
-	if w.Header().Get("Access-Control-Allow-Headers") == "" {
-		t.Errorf("Access-Control-Allow-Headers not set")
+	if !strings.Contains(w.Header().Get("Access-Control-Allow-Headers"), "If-None-Match") {
+		t.Errorf("Access-Control-Allow-Headers = %q, want If-None-Match allowed for conditional GETs", w.Header().Get("Access-Control-Allow-Headers"))
+	}
+	if w.Header().Get("Access-Control-Expose-Headers") != "ETag" {
+		t.Errorf("Access-Control-Expose-Headers = %q, want ETag", w.Header().Get("Access-Control-Expose-Headers"))
 	}
 	if w.Header().Get("Access-Control-Max-Age") == "" {
 		t.Errorf("Access-Control-Max-Age not set")
 	}
+	if w.Header().Get("Timing-Allow-Origin") != "chrome-extension://abcdefg12345" {
+		t.Errorf("Timing-Allow-Origin = %q, want the allowed origin", w.Header().Get("Timing-Allow-Origin"))
+	}
+}
+
+func TestCORSMiddleware_Options(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	preflight := func(opts CORSOptions) http.Header {
+		router := gin.New()
+		router.Use(CORSMiddleware([]string{"chrome-extension://*"}, opts, router.Routes))
+		router.POST("/test", func(c *gin.Context) {
+			c.String(http.StatusOK, "OK")
+		})
+
+		req := httptest.NewRequest("OPTIONS", "/test", nil)
+		req.Header.Set("Origin", "chrome-extension://abcdefg12345")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Header()
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		header := preflight(CORSOptions{})
+		if got := header.Get("Access-Control-Allow-Headers"); got != "Content-Type, Authorization, X-Requested-With, X-API-Key, If-None-Match" {
+			t.Errorf("Access-Control-Allow-Headers = %q, want the built-in list", got)
+		}
+		if got := header.Get("Access-Control-Max-Age"); got != "3600" {
+			t.Errorf("Access-Control-Max-Age = %q, want 3600", got)
+		}
+	})
+
+	t.Run("custom headers and max age", func(t *testing.T) {
+		header := preflight(CORSOptions{AllowedHeaders: []string{"Content-Type", " X-Tenant "}, MaxAge: 10 * time.Minute})
+		if got := header.Get("Access-Control-Allow-Headers"); got != "Content-Type, X-Tenant, X-API-Key" {
+			t.Errorf("Access-Control-Allow-Headers = %q, want the custom headers plus X-API-Key", got)
+		}
+		if got := header.Get("Access-Control-Max-Age"); got != "600" {
+			t.Errorf("Access-Control-Max-Age = %q, want 600", got)
+		}
+	})
+
+	t.Run("X-API-Key is not repeated", func(t *testing.T) {
+		header := preflight(CORSOptions{AllowedHeaders: []string{"x-api-key", "Content-Type"}})
+		if got := header.Get("Access-Control-Allow-Headers"); got != "x-api-key, Content-Type" {
+			t.Errorf("Access-Control-Allow-Headers = %q, want the configured headers unchanged", got)
+		}
+	})
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		expectedKey string
+		sentKey     string
+		wantStatus  int
+	}{
+		{name: "valid key", expectedKey: "client-key", sentKey: "client-key", wantStatus: http.StatusOK},
+		{name: "invalid key", expectedKey: "client-key", sentKey: "wrong-key", wantStatus: http.StatusUnauthorized},
+		{name: "absent key", expectedKey: "client-key", wantStatus: http.StatusUnauthorized},
+		{name: "disabled when no key is configured", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(AuthMiddleware(tt.expectedKey))
+			router.GET("/test", func(c *gin.Context) {
+				c.String(http.StatusOK, "OK")
+			})
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			if tt.sentKey != "" {
+				req.Header.Set("X-API-Key", tt.sentKey)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && !strings.Contains(w.Body.String(), CodeUnauthorized) {
+				t.Errorf("body = %s, want code %s", w.Body.String(), CodeUnauthorized)
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(perIP int) *gin.Engine {
+		router := gin.New()
+		router.Use(RateLimitMiddleware(perIP))
+		router.GET("/test", func(c *gin.Context) {
+			c.String(http.StatusOK, "OK")
+		})
+		return router
+	}
+	request := func(router *gin.Engine, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = ip + ":12345"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("allows requests within budget and rejects the rest", func(t *testing.T) {
+		router := newRouter(3)
+		for i := 0; i < 3; i++ {
+			if w := request(router, "10.0.0.1"); w.Code != http.StatusOK {
+				t.Fatalf("request %d status = %d, want %d", i+1, w.Code, http.StatusOK)
+			}
+		}
+
+		w := request(router, "10.0.0.1")
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+		}
+		if retryAfter := w.Header().Get("Retry-After"); retryAfter != "20" {
+			t.Errorf("Retry-After = %q, want %q", retryAfter, "20")
+		}
+		if !strings.Contains(w.Body.String(), CodeRateLimited) {
+			t.Errorf("body = %s, want code %s", w.Body.String(), CodeRateLimited)
+		}
+	})
+
+	t.Run("tracks each client IP separately", func(t *testing.T) {
+		router := newRouter(1)
+		if w := request(router, "10.0.0.1"); w.Code != http.StatusOK {
+			t.Fatalf("first client status = %d, want %d", w.Code, http.StatusOK)
+		}
+		if w := request(router, "10.0.0.2"); w.Code != http.StatusOK {
+			t.Errorf("second client status = %d, want %d", w.Code, http.StatusOK)
+		}
+		if w := request(router, "10.0.0.1"); w.Code != http.StatusTooManyRequests {
+			t.Errorf("first client repeat status = %d, want %d", w.Code, http.StatusTooManyRequests)
+		}
+	})
+
+	t.Run("zero disables rate limiting", func(t *testing.T) {
+		router := newRouter(0)
+		for i := 0; i < 5; i++ {
+			if w := request(router, "10.0.0.1"); w.Code != http.StatusOK {
+				t.Fatalf("request %d status = %d, want %d", i+1, w.Code, http.StatusOK)
+			}
+		}
+	})
+}
+
+func TestIPRateLimiters_EvictIdle(t *testing.T) {
+	limiters := newIPRateLimiters(1)
+	start := time.Now()
+
+	limiters.reserve("10.0.0.1", start)
+	limiters.reserve("10.0.0.2", start.Add(rateLimitIdleTTL/2))
+
+	// The next sweep is due one TTL after the first; only the first IP is idle by then
+	limiters.reserve("10.0.0.3", start.Add(rateLimitIdleTTL))
+
+	if _, ok := limiters.limiters["10.0.0.1"]; ok {
+		t.Error("idle limiter for 10.0.0.1 was not evicted")
+	}
+	if _, ok := limiters.limiters["10.0.0.2"]; !ok {
+		t.Error("active limiter for 10.0.0.2 was evicted")
+	}
+	if len(limiters.limiters) != 2 {
+		t.Errorf("len(limiters) = %d, want 2", len(limiters.limiters))
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(MetricsMiddleware())
+	router.GET("/items/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "OK")
+	})
+	router.GET("/metrics", MetricsHandler())
+
+	// Counters are process-wide, so compare against the values before the requests
+	matched := httpRequestsTotal.WithLabelValues("/items/:id", "GET", "200")
+	unmatched := httpRequestsTotal.WithLabelValues(unmatchedRoute, "GET", "404")
+	matchedBefore, unmatchedBefore := testutil.ToFloat64(matched), testutil.ToFloat64(unmatched)
+
+	for _, path := range []string{"/items/1", "/items/2", "/no-such-path"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	if got := testutil.ToFloat64(matched) - matchedBefore; got != 2 {
+		t.Errorf("requests for /items/:id = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(unmatched) - unmatchedBefore; got != 1 {
+		t.Errorf("requests for unmatched routes = %v, want 1", got)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", w.Code, http.StatusOK)
+	}
+	for _, want := range []string{"macrolens_http_requests_total", "macrolens_http_request_duration_seconds_bucket"} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("metrics output missing %s", want)
+		}
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var contextID string
+	router := gin.New()
+	router.Use(RequestIDMiddleware())
+	router.GET("/test", func(c *gin.Context) {
+		contextID = domain.RequestID(c.Request.Context())
+		c.String(http.StatusOK, "OK")
+	})
+
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+
+		headerID := w.Header().Get(RequestIDHeader)
+		if !uuidPattern.MatchString(headerID) {
+			t.Errorf("X-Request-ID = %q, want a v4 UUID", headerID)
+		}
+		if contextID != headerID {
+			t.Errorf("context request ID = %q, want %q", contextID, headerID)
+		}
+		if seen[headerID] {
+			t.Errorf("request ID %q repeated", headerID)
+		}
+		seen[headerID] = true
+	}
+}
+
+func TestLoggerMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(format string) (string, string) {
+		var out bytes.Buffer
+		router := gin.New()
+		router.Use(RequestIDMiddleware())
+		router.Use(newLoggerMiddleware(format, &out))
+		router.GET("/test", func(c *gin.Context) {
+			c.String(http.StatusTeapot, "OK")
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test?q=1", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		router.ServeHTTP(w, req)
+		return out.String(), w.Header().Get(RequestIDHeader)
+	}
+
+	t.Run("json writes one object per request", func(t *testing.T) {
+		line, requestID := serve(LogFormatJSON)
+
+		if strings.Count(line, "\n") != 1 {
+			t.Fatalf("log = %q, want a single line", line)
+		}
+		var entry requestLogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line is not JSON: %v", err)
+		}
+		if entry.RequestID != requestID || entry.Method != "GET" || entry.Path != "/test?q=1" ||
+			entry.Status != http.StatusTeapot || entry.ClientIP != "203.0.113.7" || entry.Time == "" {
+			t.Errorf("entry = %+v, want GET /test?q=1 418 from 203.0.113.7 with request ID %s", entry, requestID)
+		}
+	})
+
+	t.Run("text includes the request ID", func(t *testing.T) {
+		line, requestID := serve(LogFormatText)
+
+		if !strings.Contains(line, "req="+requestID) || !strings.Contains(line, "418") {
+			t.Errorf("log = %q, want status 418 and req=%s", line, requestID)
+		}
+	})
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	large := strings.Repeat("whole milk ", 200)
+	router := gin.New()
+	router.Use(CORSMiddleware([]string{"chrome-extension://*"}, CORSOptions{}, router.Routes))
+	router.Use(CompressionMiddleware())
+	router.GET("/large", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"description": large})
+	})
+	router.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+	})
+	router.GET("/encoded", func(c *gin.Context) {
+		c.Header("Content-Encoding", "identity")
+		c.String(http.StatusOK, large)
+	})
+
+	serve := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Origin", "chrome-extension://abc123")
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("compresses large responses", func(t *testing.T) {
+		w := serve("/large", "gzip, deflate")
+
+		if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+			t.Fatalf("Content-Encoding = %q, want gzip", got)
+		}
+		if got := w.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+			t.Errorf("Content-Type = %q, want application/json; charset=utf-8", got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "chrome-extension://abc123" {
+			t.Errorf("Access-Control-Allow-Origin = %q, want chrome-extension://abc123", got)
+		}
+
+		reader, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("body is not gzip: %v", err)
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("failed to decompress body: %v", err)
+		}
+		var response map[string]string
+		if err := json.Unmarshal(body, &response); err != nil || response["description"] != large {
+			t.Errorf("decompressed body = %.60q..., want the original JSON", body)
+		}
+	})
+
+	t.Run("leaves small responses uncompressed", func(t *testing.T) {
+		w := serve("/small", "gzip")
+
+		if got := w.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("Content-Encoding = %q, want none", got)
+		}
+		if got := w.Body.String(); got != `{"status":"healthy"}` {
+			t.Errorf("body = %q, want the plain JSON", got)
+		}
+	})
+
+	t.Run("requires gzip in Accept-Encoding", func(t *testing.T) {
+		for _, acceptEncoding := range []string{"", "deflate", "gzip;q=0"} {
+			w := serve("/large", acceptEncoding)
+			if got := w.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("Accept-Encoding %q: Content-Encoding = %q, want none", acceptEncoding, got)
+			}
+		}
+	})
+
+	t.Run("passes already encoded responses through", func(t *testing.T) {
+		w := serve("/encoded", "gzip")
+
+		if got := w.Header().Get("Content-Encoding"); got != "identity" {
+			t.Errorf("Content-Encoding = %q, want identity", got)
+		}
+		if w.Body.String() != large {
+			t.Error("body was modified")
+		}
+	})
 }
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"encoding/json"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// optionalSections strip optional response fields, most expendable first
+var optionalSections = []func(data *domain.NutritionData){
+	// Diagnostics: timing, cache key, raw confidence, detail-fetch status, score breakdown
+	func(data *domain.NutritionData) {
+		data.MatchLatencyMs = nil
+		data.USDALatencyMs = nil
+		data.CacheKey = ""
+		data.RawConfidence = nil
+		data.DetailsFetched = nil
+		data.Explanation = nil
+	},
+	// Alternative matches
+	func(data *domain.NutritionData) {
+		data.Candidates = nil
+	},
+}
+
+// limitResponseSize drops optional sections from a response until it serializes
+// within maxBytes, and flags it as truncated if anything was dropped. The core
+// nutrition data is never dropped, so the result can still exceed a very small
+// limit. A maxBytes of 0 disables the limit.
+func limitResponseSize(data *domain.NutritionData, maxBytes int) *domain.NutritionData {
+	if data == nil || maxBytes <= 0 || responseSize(data) <= maxBytes {
+		return data
+	}
+
+	limited := *data
+	limited.Truncated = true
+	for _, drop := range optionalSections {
+		drop(&limited)
+		if responseSize(&limited) <= maxBytes {
+			break
+		}
+	}
+	return &limited
+}
+
+// responseSize returns the serialized size of a response in bytes
+func responseSize(data *domain.NutritionData) int {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return 0
+	}
+	return len(body)
+}
@@ -1,6 +1,9 @@
 package http
 
 import (
+	"net/http"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"github.com/macrolens/backend/config"
 )
@@ -13,26 +16,107 @@ func SetupRouter(cfg *config.Config, handler *Handler) *gin.Engine {
 	}
 
 	router := gin.New()
+	// Client IPs (used for rate limiting) come from X-Forwarded-For only when a
+	// configured proxy sent it; the config is validated, so an error trusts nobody
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		router.SetTrustedProxies(nil)
+	}
 
 	// Global middleware
 	router.Use(RecoveryMiddleware())
-	router.Use(LoggerMiddleware())
-	router.Use(CORSMiddleware(cfg.Server.AllowedOrigins))
+	router.Use(RequestIDMiddleware())
+	router.Use(MetricsMiddleware())
+	router.Use(LoggerMiddleware(cfg.Server.LogFormat))
+	router.Use(CORSMiddleware(cfg.Server.AllowedOrigins, CORSOptions{
+		AllowedHeaders: cfg.Server.CORSAllowedHeaders,
+		MaxAge:         cfg.Server.CORSMaxAge,
+	}, router.Routes))
+	if cfg.Server.Compression {
+		router.Use(CompressionMiddleware())
+	}
+	router.Use(RateLimitMiddleware(cfg.RateLimit.PerIP))
 
 	// Health check endpoint
 	router.GET("/health", handler.HealthCheck)
 
-	// API v1 routes
+	// Prometheus metrics
+	router.GET("/metrics", MetricsHandler())
+
+	// API v1 routes, with request bodies capped; the cache import takes whole
+	// cache exports, so it has its own limit
 	v1 := router.Group("/api/v1")
+	bodyLimit := BodySizeLimitMiddleware(cfg.Server.MaxRequestBytes)
 	{
+		// Client endpoints, behind the client API key when one is configured
+		api := v1.Group("", bodyLimit, AuthMiddleware(cfg.Server.APIKey))
+
 		// Nutrition endpoints
-		nutrition := v1.Group("/nutrition")
+		nutrition := api.Group("/nutrition")
 		{
 			nutrition.POST("/search", handler.SearchNutrition)
-			// TODO: Add more endpoints in Phase 2
-			// nutrition.GET("/:fdcId", handler.GetNutritionByID)
+			nutrition.POST("/batch", handler.BatchSearchNutrition)
+			nutrition.POST("/barcode", handler.SearchNutritionByBarcode)
+			nutrition.POST("/match", handler.MatchFoods)
+			nutrition.POST("/candidates", handler.ListCandidates)
+			nutrition.GET("/:fdcId", handler.GetNutritionByID)
+			if cfg.Feedback.Enabled {
+				nutrition.POST("/feedback", handler.RecordFeedback)
+			}
+		}
+		handler.nutritionPostRoutes = postRouteNames(router, nutrition.BasePath()+"/")
+
+		// Preview of query cleaning, without a USDA search
+		api.POST("/preprocess", handler.Preprocess)
+
+		// Read-only view of the words query cleaning strips
+		api.GET("/config/vocabulary", handler.Vocabulary)
+
+		// Term dictionaries for client-side query preprocessing
+		if cfg.Server.ExposeDictionaries {
+			api.GET("/dictionaries", handler.Dictionaries)
+		}
+
+		// Admin cache endpoints, only exposed when an admin API key is configured
+		if cfg.Server.AdminAPIKey != "" {
+			cacheAdmin := v1.Group("/cache", APIKeyAuthMiddleware(cfg.Server.AdminAPIKey))
+			{
+				cacheAdmin.GET("/export", handler.ExportCache)
+				cacheAdmin.POST("/import", BodySizeLimitMiddleware(cfg.Server.MaxImportBytes), handler.ImportCache)
+				cacheAdmin.GET("/stats", handler.CacheStats)
+			}
+
+			// Evicting a cached search takes the search's own body
+			v1.DELETE("/nutrition/search", bodyLimit, APIKeyAuthMiddleware(cfg.Server.AdminAPIKey), handler.InvalidateSearch)
+		}
+
+		// Debug endpoints, behind the admin API key when one is configured and
+		// the client API key otherwise
+		if cfg.Feedback.Enabled || cfg.Matching.ConfidenceHistogramWindow > 0 {
+			debug := api.Group("/debug")
+			if cfg.Server.AdminAPIKey != "" {
+				debug = v1.Group("/debug", bodyLimit, APIKeyAuthMiddleware(cfg.Server.AdminAPIKey))
+			}
+			if cfg.Feedback.Enabled {
+				debug.GET("/feedback", handler.FeedbackStats)
+			}
+			if cfg.Matching.ConfidenceHistogramWindow > 0 {
+				debug.GET("/confidence", handler.ConfidenceDistribution)
+			}
 		}
 	}
 
 	return router
 }
+
+// postRouteNames returns the last path segment of each POST route registered
+// directly under prefix, e.g. "search" for "/api/v1/nutrition/search"
+func postRouteNames(router *gin.Engine, prefix string) map[string]bool {
+	names := make(map[string]bool)
+	for _, route := range router.Routes() {
+		name, ok := strings.CutPrefix(route.Path, prefix)
+		if route.Method == http.MethodPost && ok && name != "" && !strings.Contains(name, "/") {
+			names[name] = true
+		}
+	}
+	return names
+}
@@ -0,0 +1,39 @@
+package http
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// withServerTiming returns a context that records search phase durations when
+// the Server-Timing header is enabled, and nil timings otherwise
+func (h *Handler) withServerTiming(ctx context.Context) (context.Context, *domain.PhaseTimings) {
+	if !h.serverTiming {
+		return ctx, nil
+	}
+	timings := &domain.PhaseTimings{}
+	return domain.WithPhaseTimings(ctx, timings), timings
+}
+
+// setServerTiming writes the recorded phases as a Server-Timing header, e.g.
+// "cache;dur=1.2, usda;dur=210.4, match;dur=3.4", so they show up in browser devtools
+func setServerTiming(c *gin.Context, timings *domain.PhaseTimings) {
+	if timings == nil {
+		return
+	}
+	phases := timings.Phases()
+	if len(phases) == 0 {
+		return
+	}
+	entries := make([]string, len(phases))
+	for i, phase := range phases {
+		ms := float64(phase.Duration) / float64(time.Millisecond)
+		entries[i] = phase.Name + ";dur=" + strconv.FormatFloat(ms, 'f', 1, 64)
+	}
+	c.Header("Server-Timing", strings.Join(entries, ", "))
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// requestIDKey is the context key for WithRequestID
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the ID of the HTTP request it serves,
+// so lower layers can tag their logs with it
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the ID set by WithRequestID, or "" if there is none
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// Phases of a search recorded by RecordPhase
+const (
+	PhaseCache = "cache"
+	PhaseUSDA  = "usda"
+	PhaseMatch = "match"
+)
+
+// PhaseTiming is the total time a request spent in one phase
+type PhaseTiming struct {
+	Name     string
+	Duration time.Duration
+}
+
+// PhaseTimings accumulates per-phase durations for one request. It is safe for
+// concurrent use, since a timed-out search can keep recording after its caller
+// has moved on.
+type PhaseTimings struct {
+	mu     sync.Mutex
+	phases []PhaseTiming
+}
+
+// Add adds d to the named phase
+func (t *PhaseTimings) Add(name string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range t.phases {
+		if t.phases[i].Name == name {
+			t.phases[i].Duration += d
+			return
+		}
+	}
+	t.phases = append(t.phases, PhaseTiming{Name: name, Duration: d})
+}
+
+// Phases returns the recorded phases in the order they first ran
+func (t *PhaseTimings) Phases() []PhaseTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]PhaseTiming(nil), t.phases...)
+}
+
+// phaseTimingsKey is the context key for WithPhaseTimings
+type phaseTimingsKey struct{}
+
+// WithPhaseTimings returns a context whose RecordPhase calls add to timings
+func WithPhaseTimings(ctx context.Context, timings *PhaseTimings) context.Context {
+	return context.WithValue(ctx, phaseTimingsKey{}, timings)
+}
+
+// RecordPhase adds d to the named phase of the context's PhaseTimings. It does
+// nothing when the context carries none.
+func RecordPhase(ctx context.Context, name string, d time.Duration) {
+	if timings, ok := ctx.Value(phaseTimingsKey{}).(*PhaseTimings); ok {
+		timings.Add(name, d)
+	}
+}
@@ -23,4 +23,8 @@ var (
 
 	// ErrCacheUnavailable is returned when cache service is unavailable
 	ErrCacheUnavailable = errors.New("cache service unavailable")
+
+	// ErrTimeout is returned (wrapped in ErrUSDAAPIFailure) when a search runs past
+	// its request timeout
+	ErrTimeout = errors.New("request timed out")
 )
@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// MatchFeedback is a client's report on whether a returned match was correct
+type MatchFeedback struct {
+	Query      string    `json:"query" binding:"required"`
+	FdcID      string    `json:"fdcId" binding:"required"`
+	Correct    *bool     `json:"correct" binding:"required"`
+	DataType   string    `json:"dataType,omitempty"` // USDA data type of the matched food, resolved if not sent
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// FeedbackCounts aggregates feedback for one slice of matches
+type FeedbackCounts struct {
+	Total    int     `json:"total"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"` // Correct / Total, 0 when there is no feedback
+}
+
+// FeedbackStats summarizes recorded feedback overall and by USDA data type
+type FeedbackStats struct {
+	FeedbackCounts
+	ByDataType map[string]FeedbackCounts `json:"byDataType"`
+}
@@ -1,6 +1,11 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
 
 // NutritionData represents the complete nutrition information for a food product
 type NutritionData struct {
@@ -10,8 +15,47 @@ type NutritionData struct {
 	ServingSizeUnit string    `json:"servingSizeUnit"`
 	Nutrients       Nutrients `json:"nutrients"`
 	Confidence      float64   `json:"confidence"` // Match confidence score 0-100
+	RawConfidence   *float64  `json:"rawConfidence,omitempty"` // Confidence against the uncleaned product name
 	Source          string    `json:"source"`     // "USDA" or "Cache"
 	CachedAt        time.Time `json:"cachedAt,omitempty"`
+	DetailsFetched  *bool     `json:"detailsFetched,omitempty"` // Set when a full-detail fetch was attempted
+	Measure         string    `json:"measure,omitempty"`        // Household measure the nutrients are scaled to, if any
+
+	// Label serving text for Branded foods (e.g., "2 Tbsp"), when USDA reports one
+	HouseholdServing string `json:"householdServing,omitempty"`
+
+	// Calibrated probability 0-1 that the match is correct, when calibration is configured
+	CalibratedConfidence *float64 `json:"calibratedConfidence,omitempty"`
+
+	// Optional timing fields, only populated when the request asks for them.
+	// usdaLatencyMs is the upstream round-trip, 0 when the result came from cache.
+	MatchLatencyMs *float64 `json:"matchLatencyMs,omitempty"`
+	USDALatencyMs  *float64 `json:"usdaLatencyMs,omitempty"`
+
+	// Cache key the search is stored under, only populated for debug requests
+	CacheKey string `json:"cacheKey,omitempty"`
+
+	// Canonical serving weight for clients computing totals. Estimated when the
+	// conversion assumes a density (e.g., ml -> g for liquids).
+	ServingGrams          *float64 `json:"servingGrams,omitempty"`
+	ServingGramsEstimated bool     `json:"servingGramsEstimated,omitempty"`
+
+	// Top candidate matches, best first, only populated when the request asks for them
+	Candidates []MatchCandidate `json:"candidates,omitempty"`
+
+	// Score breakdown of the chosen match, only populated when the request asks for it
+	Explanation *MatchExplanation `json:"explanation,omitempty"`
+
+	// Set when optional fields were dropped to fit the configured response size limit
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// MatchCandidate is a USDA food considered for a search, so clients can offer
+// alternatives when confidence is borderline
+type MatchCandidate struct {
+	FdcID       string  `json:"fdcId"`
+	Description string  `json:"description"`
+	Confidence  float64 `json:"confidence"`
 }
 
 // Nutrients contains the key macronutrients for MVP
@@ -20,6 +64,11 @@ type Nutrients struct {
 	Protein       float64 `json:"protein"`       // grams
 	Carbohydrates float64 `json:"carbohydrates"` // grams
 	TotalFat      float64 `json:"totalFat"`      // grams
+	Fiber         float64 `json:"fiber"`         // grams
+	Sugar         float64 `json:"sugar"`         // grams
+	Sodium        float64 `json:"sodium"`        // milligrams
+	SaturatedFat  float64 `json:"saturatedFat"`  // grams
+	Cholesterol   float64 `json:"cholesterol"`   // milligrams
 }
 
 // SearchRequest represents a nutrition search request
@@ -27,6 +76,21 @@ type SearchRequest struct {
 	ProductName string `json:"productName" binding:"required"`
 	Brand       string `json:"brand,omitempty"`
 	Size        string `json:"size,omitempty"`
+	Retailer    string `json:"retailer,omitempty"` // Selects the preprocessing profile; defaults to walmart
+	Locale      string `json:"locale,omitempty"`   // Language tag like "es-ES"; comma-decimal locales read "1,5 L" as 1.5 L
+
+	ServingSize float64 `json:"servingSize,omitempty"` // Label serving in grams; scales nutrients from per-100g when set
+
+	CacheTTL string `json:"cacheTTL,omitempty"` // Duration like "6h" to cache this result for, capped server-side
+
+	// Per-request options set by the delivery layer (not part of the JSON body)
+	IncludeTiming bool     `json:"-"`
+	Measure       string   `json:"-"` // Household measure to scale nutrients to (e.g., "cup")
+	PreferGeneric bool     `json:"-"` // Favor generic USDA data types over Branded when matching
+	MinConfidence *float64 `json:"-"` // Overrides the configured confidence threshold when set
+	Candidates    int      `json:"-"` // Number of top matches to list in the response (0 = none)
+	Explain       bool     `json:"-"` // Include the match score breakdown in the response
+	Debug         bool     `json:"-"` // Include the cache key and timing in the response
 }
 
 // USDAFood represents a food item from the USDA FoodData Central API
@@ -36,6 +100,58 @@ type USDAFood struct {
 	DataType    string        `json:"dataType"`
 	FoodClass   string        `json:"foodClass,omitempty"`
 	Nutrients   []USDANutrient `json:"foodNutrients"`
+	Portions    []USDAFoodPortion `json:"foodPortions,omitempty"` // Household measures, only on food details
+
+	// Label serving, reported for Branded foods. Nutrients stay per 100 g (or
+	// 100 ml for liquids) regardless.
+	ServingSize              float64 `json:"servingSize,omitempty"`
+	ServingSizeUnit          string  `json:"servingSizeUnit,omitempty"`          // e.g. "g", "GRM", "MLT"
+	HouseholdServingFullText string  `json:"householdServingFullText,omitempty"` // e.g. "2 Tbsp"
+
+	GtinUpc string `json:"gtinUpc,omitempty"` // UPC/GTIN barcode, reported for Branded foods
+
+	// When the entry was published. Search results report publishedDate, food
+	// details report publicationDate.
+	PublishedDate   string `json:"publishedDate,omitempty"`   // e.g. "2021-10-28"
+	PublicationDate string `json:"publicationDate,omitempty"` // e.g. "10/28/2021"
+}
+
+// UnmarshalJSON accepts fdcId as a JSON number, as USDA sends it, or as a numeric
+// string, as clients passing IDs through from our own responses often do
+func (f *USDAFood) UnmarshalJSON(data []byte) error {
+	type plainFood USDAFood // Drops this method so decoding doesn't recurse
+	var raw struct {
+		plainFood
+		FdcID json.Number `json:"fdcId"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*f = USDAFood(raw.plainFood)
+	if raw.FdcID != "" {
+		id, err := strconv.Atoi(raw.FdcID.String())
+		if err != nil {
+			return fmt.Errorf("invalid fdcId %q: want a whole number", raw.FdcID)
+		}
+		f.FdcID = id
+	}
+	return nil
+}
+
+// USDAFoodPortion is a household measure defined for a food (e.g., 1 cup = 244 g)
+type USDAFoodPortion struct {
+	Amount             float64         `json:"amount"`
+	GramWeight         float64         `json:"gramWeight"`
+	Modifier           string          `json:"modifier,omitempty"`
+	PortionDescription string          `json:"portionDescription,omitempty"`
+	MeasureUnit        USDAMeasureUnit `json:"measureUnit"`
+}
+
+// USDAMeasureUnit names the unit of a food portion
+type USDAMeasureUnit struct {
+	Name         string `json:"name"`
+	Abbreviation string `json:"abbreviation,omitempty"`
 }
 
 // USDANutrient represents a single nutrient from USDA data
@@ -47,6 +163,46 @@ type USDANutrient struct {
 	Value          float64 `json:"value"`
 }
 
+// UnmarshalJSON accepts both shapes USDA reports nutrients in: flat on search
+// results ({"nutrientId", "value"}) and nested on food details ({"nutrient":
+// {"id", "name", "unitName"}, "amount"}). Either way the flat fields are filled.
+func (n *USDANutrient) UnmarshalJSON(data []byte) error {
+	type flatNutrient USDANutrient // Drops this method so decoding doesn't recurse
+	var raw struct {
+		flatNutrient
+		Nutrient *struct {
+			ID       int    `json:"id"`
+			Number   string `json:"number"`
+			Name     string `json:"name"`
+			UnitName string `json:"unitName"`
+		} `json:"nutrient"`
+		Amount *float64 `json:"amount"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*n = USDANutrient(raw.flatNutrient)
+	if nested := raw.Nutrient; nested != nil {
+		if n.NutrientID == 0 {
+			n.NutrientID = nested.ID
+		}
+		if n.NutrientName == "" {
+			n.NutrientName = nested.Name
+		}
+		if n.NutrientNumber == "" {
+			n.NutrientNumber = nested.Number
+		}
+		if n.UnitName == "" {
+			n.UnitName = nested.UnitName
+		}
+	}
+	if raw.Amount != nil && n.Value == 0 {
+		n.Value = *raw.Amount
+	}
+	return nil
+}
+
 // USDASearchResponse represents the response from USDA search API
 type USDASearchResponse struct {
 	Foods      []USDAFood `json:"foods"`
@@ -16,5 +16,34 @@ type MatchResult struct {
 	FdcID         string  `json:"fdcId"`
 	Description   string  `json:"description"`
 	MatchScore    float64 `json:"matchScore"`
+	RawScore      float64 `json:"rawScore"` // Score before calibration
 	MatchedTokens []string `json:"matchedTokens,omitempty"`
+
+	// Calibrated probability 0-1 that the match is correct, when calibration is configured
+	CalibratedConfidence *float64 `json:"calibratedConfidence,omitempty"`
+
+	// Score breakdown, only populated when the request asks for an explanation
+	Explanation *MatchExplanation `json:"explanation,omitempty"`
+}
+
+// MatchExplanation breaks a match score down into the parts that produced it
+type MatchExplanation struct {
+	BaseScore       float64        `json:"baseScore"`   // Weighted token similarity, 0-100
+	TotalWeight     float64        `json:"totalWeight"` // Sum of the product's token weights
+	MatchedTokens   []MatchedToken `json:"matchedTokens"`
+	BrandBonus      float64        `json:"brandBonus"`
+	DataTypeBonus   float64        `json:"dataTypeBonus"`
+	SubstringBonus  float64        `json:"substringBonus"`
+	PhraseBonus     float64        `json:"phraseBonus"`
+	CategoryPenalty float64        `json:"categoryPenalty"`
+	ConfirmedBonus  float64        `json:"confirmedBonus"`
+	RecencyBonus    float64        `json:"recencyBonus"`
+	Score           float64        `json:"score"` // Final score capped to 0-100, before calibration
+}
+
+// MatchedToken is a product token that matched the USDA description, with the
+// weight it contributed to the base score
+type MatchedToken struct {
+	Token  string  `json:"token"`
+	Weight float64 `json:"weight"`
 }
@@ -2,9 +2,24 @@ package domain
 
 import (
 	"context"
+	"strings"
 	"time"
 )
 
+// CacheKeyVersion identifies the matching/preprocessing algorithm that produced a
+// cached result. Bump it when scoring, query cleaning or the key layout changes so
+// stale results are not served.
+const CacheKeyVersion = "v2"
+
+// CacheKeyPrefix returns the prefix every cache key starts with:
+// "{namespace}:{version}:", or "{version}:" when namespace is empty
+func CacheKeyPrefix(namespace string) string {
+	if namespace = strings.TrimSpace(namespace); namespace != "" {
+		return namespace + ":" + CacheKeyVersion + ":"
+	}
+	return CacheKeyVersion + ":"
+}
+
 // CacheRepository defines the interface for caching operations
 type CacheRepository interface {
 	Get(ctx context.Context, key string) (interface{}, error)
@@ -13,10 +28,72 @@ type CacheRepository interface {
 	Exists(ctx context.Context, key string) (bool, error)
 }
 
+// CacheEntry is a single serialized cache entry, used to move a warm cache between instances
+type CacheEntry struct {
+	Key       string      `json:"key"`
+	Value     interface{} `json:"value"`
+	ExpiresAt time.Time   `json:"expiresAt"`
+}
+
+// CacheSnapshotter is implemented by caches that can export and import their contents
+type CacheSnapshotter interface {
+	// Export returns all unexpired entries
+	Export(ctx context.Context) ([]CacheEntry, error)
+	// Import stores entries, skipping any that have already expired, and returns how many were stored
+	Import(ctx context.Context, entries []CacheEntry) (int, error)
+}
+
+// CacheStats reports cache size and hit rate since startup
+type CacheStats struct {
+	Size     int     `json:"size"`
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	HitRatio float64 `json:"hitRatio"` // Hits / (Hits + Misses), 0 before any lookups
+}
+
+// CacheStatsProvider is implemented by caches that track hit and miss counts
+type CacheStatsProvider interface {
+	Stats() CacheStats
+}
+
+// CachePinger is implemented by caches backed by a server, so health checks can
+// confirm it is reachable with a cheap round trip instead of asking for its size
+type CachePinger interface {
+	Ping(ctx context.Context) error
+}
+
+// FeedbackStore persists match-quality feedback and aggregates it for tuning
+type FeedbackStore interface {
+	Record(ctx context.Context, feedback MatchFeedback) error
+	Stats(ctx context.Context) (*FeedbackStats, error)
+	// ConfirmedFdcIDs returns the fdcIds confirmed correct for a query more often than rejected
+	ConfirmedFdcIDs(ctx context.Context, query string) (map[string]bool, error)
+}
+
 // USDAClient defines the interface for interacting with USDA FoodData Central API
 type USDAClient interface {
+	// SearchFoods searches USDA foods with the client's default page size and data types
 	SearchFoods(ctx context.Context, query string) (*USDASearchResponse, error)
+	// SearchFoodsWithOptions searches USDA foods with a caller-chosen page size,
+	// page and data types
+	SearchFoodsWithOptions(ctx context.Context, query string, opts SearchOptions) (*USDASearchResponse, error)
 	GetFoodDetails(ctx context.Context, fdcID string) (*USDAFood, error)
+	// SearchByUPC returns the Branded food whose barcode is exactly upc, or
+	// ErrProductNotFound when USDA has none
+	SearchByUPC(ctx context.Context, upc string) (*USDAFood, error)
+}
+
+// BulkFoodDetailsClient is implemented by USDA clients that can fetch several
+// foods' details in one request. Foods USDA doesn't know are left out of the map.
+type BulkFoodDetailsClient interface {
+	GetFoodsDetails(ctx context.Context, fdcIDs []string) (map[string]*USDAFood, error)
+}
+
+// SearchOptions controls the size and scope of a USDA search
+type SearchOptions struct {
+	PageSize   int      // Results per page; 0 uses the default, otherwise clamped to 1-200
+	DataTypes  []string // Data types to search, in order (USDA weighs relevance partly by it); empty uses the client's default
+	PageNumber int      // 1-based page to fetch; 0 leaves it to USDA (first page)
 }
 
 // NutritionRepository defines the interface for nutrition data persistence
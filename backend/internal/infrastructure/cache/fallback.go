@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// FallbackCache serves from a primary cache (typically Redis) and falls back to a
+// local cache (typically memory) whenever the primary errors, so the service stays
+// fast during a primary outage. A miss on a healthy primary is still a miss.
+type FallbackCache struct {
+	primary  domain.CacheRepository
+	fallback domain.CacheRepository
+	degraded atomic.Bool // Set while the primary is failing
+}
+
+// NewFallbackCache creates a cache that uses fallback while primary is unavailable
+func NewFallbackCache(primary, fallback domain.CacheRepository) *FallbackCache {
+	return &FallbackCache{
+		primary:  primary,
+		fallback: fallback,
+	}
+}
+
+// Get retrieves a value from the primary, or from the fallback if the primary errors
+func (c *FallbackCache) Get(ctx context.Context, key string) (interface{}, error) {
+	value, err := c.primary.Get(ctx, key)
+	if !c.primaryFailed(err) {
+		return value, err
+	}
+	return c.fallback.Get(ctx, key)
+}
+
+// Set stores a value in the primary, or in the fallback if the primary errors
+func (c *FallbackCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	err := c.primary.Set(ctx, key, value, ttl)
+	if !c.primaryFailed(err) {
+		return nil
+	}
+	return c.fallback.Set(ctx, key, value, ttl)
+}
+
+// Delete removes a key from both caches so the fallback never serves a deleted entry
+func (c *FallbackCache) Delete(ctx context.Context, key string) error {
+	err := c.primary.Delete(ctx, key)
+	fallbackErr := c.fallback.Delete(ctx, key)
+	if c.primaryFailed(err) {
+		return fallbackErr
+	}
+	return nil
+}
+
+// Exists checks the primary, or the fallback if the primary errors
+func (c *FallbackCache) Exists(ctx context.Context, key string) (bool, error) {
+	exists, err := c.primary.Exists(ctx, key)
+	if !c.primaryFailed(err) {
+		return exists, err
+	}
+	return c.fallback.Exists(ctx, key)
+}
+
+// Stats returns the primary's stats, or zero stats if it doesn't track them
+func (c *FallbackCache) Stats() domain.CacheStats {
+	if provider, ok := c.primary.(domain.CacheStatsProvider); ok {
+		return provider.Stats()
+	}
+	return domain.CacheStats{}
+}
+
+// Ping pings the primary, or succeeds if it has no server to ping
+func (c *FallbackCache) Ping(ctx context.Context) error {
+	if pinger, ok := c.primary.(domain.CachePinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// Degraded reports whether the most recent primary operation failed
+func (c *FallbackCache) Degraded() bool {
+	return c.degraded.Load()
+}
+
+// primaryFailed reports whether err is a primary failure (not a plain miss) and
+// logs transitions into and out of degraded mode
+func (c *FallbackCache) primaryFailed(err error) bool {
+	failed := err != nil && !errors.Is(err, domain.ErrCacheMiss)
+	if c.degraded.Swap(failed) != failed {
+		if failed {
+			log.Printf("[CACHE] Primary cache unavailable, falling back to local cache: %v", err)
+		} else {
+			log.Printf("[CACHE] Primary cache recovered")
+		}
+	}
+	return failed
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+var errRedisDown = errors.New("dial tcp 127.0.0.1:6379: connection refused")
+
+func TestFallbackCache_HealthyPrimary(t *testing.T) {
+	ctx := context.Background()
+	primary := newMockBackend()
+	fallback := newMockBackend()
+	cache := NewFallbackCache(primary, fallback)
+
+	if err := cache.Set(ctx, "key", "value", time.Hour); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if primary.data["key"] != "value" {
+		t.Errorf("primary value = %v, want value", primary.data["key"])
+	}
+	if fallback.setCalls != 0 {
+		t.Errorf("fallback setCalls = %d, want 0", fallback.setCalls)
+	}
+
+	// A miss on a healthy primary is not a reason to consult the fallback
+	if _, err := cache.Get(ctx, "missing"); !errors.Is(err, domain.ErrCacheMiss) {
+		t.Errorf("Get() error = %v, want ErrCacheMiss", err)
+	}
+	if fallback.getCalls != 0 {
+		t.Errorf("fallback getCalls = %d, want 0", fallback.getCalls)
+	}
+	if cache.Degraded() {
+		t.Error("Degraded() = true, want false")
+	}
+}
+
+func TestFallbackCache_PrimaryErrors(t *testing.T) {
+	ctx := context.Background()
+	primary := newMockBackend()
+	primary.err = errRedisDown
+	fallback := NewMemoryCache()
+	cache := NewFallbackCache(primary, fallback)
+
+	if err := cache.Set(ctx, "key", "value", time.Hour); err != nil {
+		t.Fatalf("Set() error = %v, want nil when the fallback stores the value", err)
+	}
+
+	value, err := cache.Get(ctx, "key")
+	if err != nil {
+		t.Fatalf("Get() error = %v, want the fallback value", err)
+	}
+	if value != "value" {
+		t.Errorf("Get() = %v, want value", value)
+	}
+
+	exists, err := cache.Exists(ctx, "key")
+	if err != nil || !exists {
+		t.Errorf("Exists() = %v, %v, want true from the fallback", exists, err)
+	}
+
+	if _, err := cache.Get(ctx, "missing"); !errors.Is(err, domain.ErrCacheMiss) {
+		t.Errorf("Get() error = %v, want ErrCacheMiss from the fallback", err)
+	}
+	if !cache.Degraded() {
+		t.Error("Degraded() = false, want true during an outage")
+	}
+}
+
+func TestFallbackCache_Recovery(t *testing.T) {
+	ctx := context.Background()
+	primary := newMockBackend()
+	fallback := newMockBackend()
+	cache := NewFallbackCache(primary, fallback)
+
+	primary.err = errRedisDown
+	_ = cache.Set(ctx, "key", "outage value", time.Hour)
+
+	primary.err = nil
+	_ = cache.Set(ctx, "key", "fresh value", time.Hour)
+
+	if cache.Degraded() {
+		t.Error("Degraded() = true, want false after the primary recovers")
+	}
+	value, err := cache.Get(ctx, "key")
+	if err != nil || value != "fresh value" {
+		t.Errorf("Get() = %v, %v, want fresh value from the primary", value, err)
+	}
+
+	if err := cache.Delete(ctx, "key"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, ok := fallback.data["key"]; ok {
+		t.Error("expected Delete to also clear the fallback's outage copy")
+	}
+}
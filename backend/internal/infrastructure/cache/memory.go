@@ -1,9 +1,16 @@
 package cache
 
 import (
+	"container/list"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/macrolens/backend/internal/domain"
@@ -15,39 +22,107 @@ type cacheItem struct {
 	Expiration time.Time
 }
 
-// MemoryCache is a thread-safe in-memory cache with TTL support
+// defaultCleanupInterval is how often expired entries are swept when
+// MemoryCacheConfig.CleanupInterval is unset
+const defaultCleanupInterval = 10 * time.Minute
+
+// MemoryCacheConfig configures NewMemoryCacheWithConfig
+type MemoryCacheConfig struct {
+	CleanupInterval time.Duration // How often expired entries are removed (0 uses the default 10 minutes)
+	MaxEntries      int           // Most items held, evicting the least recently used (0 is unbounded)
+}
+
+// MemoryCache is a thread-safe in-memory cache with TTL support and optional
+// least-recently-used eviction
 type MemoryCache struct {
 	data  map[string]cacheItem
 	mutex sync.RWMutex
+
+	// LRU bookkeeping, only used when maxEntries > 0. order holds keys with the
+	// most recently used at the front; elements indexes it by key.
+	maxEntries int
+	order      *list.List
+	elements   map[string]*list.Element
+
+	// Lookup counters; atomic because Get only holds the read lock
+	hits   atomic.Int64
+	misses atomic.Int64
+
+	// Closed by Close to stop the cleanup goroutine
+	stop      chan struct{}
+	closeOnce sync.Once
 }
 
-// NewMemoryCache creates a new in-memory cache
+// NewMemoryCache creates a new unbounded in-memory cache. Like every MemoryCache
+// constructor, it starts a cleanup goroutine; call Close when done with the cache.
 func NewMemoryCache() *MemoryCache {
+	return NewMemoryCacheWithCapacity(0)
+}
+
+// NewMemoryCacheWithCapacity creates an in-memory cache holding at most maxEntries
+// items, evicting the least recently used when full. 0 means unbounded. Call Close
+// when done with the cache.
+func NewMemoryCacheWithCapacity(maxEntries int) *MemoryCache {
+	return NewMemoryCacheWithConfig(MemoryCacheConfig{MaxEntries: maxEntries})
+}
+
+// NewMemoryCacheWithConfig creates an in-memory cache that removes expired entries
+// every config.CleanupInterval. Call Close to stop the cleanup goroutine when the
+// cache is no longer needed.
+func NewMemoryCacheWithConfig(config MemoryCacheConfig) *MemoryCache {
 	cache := &MemoryCache{
 		data: make(map[string]cacheItem),
+		stop: make(chan struct{}),
+	}
+	if config.MaxEntries > 0 {
+		cache.maxEntries = config.MaxEntries
+		cache.order = list.New()
+		cache.elements = make(map[string]*list.Element)
 	}
 
-	// Start cleanup goroutine to remove expired entries every 10 minutes
-	go cache.cleanupExpired()
+	interval := config.CleanupInterval
+	if interval <= 0 {
+		interval = defaultCleanupInterval
+	}
+	go cache.cleanupExpired(interval)
 
 	return cache
 }
 
+// Close stops the cleanup goroutine. The cache stays usable, but expired entries
+// are no longer swept. Closing more than once is a no-op.
+func (c *MemoryCache) Close() error {
+	c.closeOnce.Do(func() { close(c.stop) })
+	return nil
+}
+
 // Get retrieves a value from the cache
 func (c *MemoryCache) Get(ctx context.Context, key string) (interface{}, error) {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
+	if c.bounded() {
+		// A hit reorders the LRU list, so bounded caches need the write lock
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+	} else {
+		c.mutex.RLock()
+		defer c.mutex.RUnlock()
+	}
 
 	item, exists := c.data[key]
 	if !exists {
+		c.misses.Add(1)
 		return nil, domain.ErrCacheMiss
 	}
 
 	// Check if expired
 	if time.Now().After(item.Expiration) {
+		c.misses.Add(1)
 		return nil, domain.ErrCacheMiss
 	}
 
+	c.hits.Add(1)
+	if c.bounded() {
+		c.order.MoveToFront(c.elements[key])
+	}
 	return item.Value, nil
 }
 
@@ -73,6 +148,17 @@ func (c *MemoryCache) Set(ctx context.Context, key string, value interface{}, tt
 		Expiration: time.Now().Add(ttl),
 	}
 
+	if c.bounded() {
+		if element, exists := c.elements[key]; exists {
+			c.order.MoveToFront(element)
+		} else {
+			c.elements[key] = c.order.PushFront(key)
+		}
+		for c.order.Len() > c.maxEntries {
+			c.remove(c.order.Back().Value.(string))
+		}
+	}
+
 	return nil
 }
 
@@ -81,10 +167,24 @@ func (c *MemoryCache) Delete(ctx context.Context, key string) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	delete(c.data, key)
+	c.remove(key)
 	return nil
 }
 
+// bounded reports whether the cache evicts least-recently-used entries
+func (c *MemoryCache) bounded() bool {
+	return c.maxEntries > 0
+}
+
+// remove deletes a key and its LRU bookkeeping. Caller must hold the write lock.
+func (c *MemoryCache) remove(key string) {
+	delete(c.data, key)
+	if element, exists := c.elements[key]; exists {
+		c.order.Remove(element)
+		delete(c.elements, key)
+	}
+}
+
 // Exists checks if a key exists in the cache and is not expired
 func (c *MemoryCache) Exists(ctx context.Context, key string) (bool, error) {
 	c.mutex.RLock()
@@ -103,23 +203,121 @@ func (c *MemoryCache) Exists(ctx context.Context, key string) (bool, error) {
 	return true, nil
 }
 
-// cleanupExpired removes expired entries from the cache periodically
-func (c *MemoryCache) cleanupExpired() {
-	ticker := time.NewTicker(10 * time.Minute)
+// cleanupExpired removes expired entries from the cache every interval until Close
+func (c *MemoryCache) cleanupExpired(interval time.Duration) {
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		}
+
 		c.mutex.Lock()
 		now := time.Now()
 		for key, item := range c.data {
 			if now.After(item.Expiration) {
-				delete(c.data, key)
+				c.remove(key)
 			}
 		}
 		c.mutex.Unlock()
 	}
 }
 
+// Export returns all unexpired entries in the cache
+func (c *MemoryCache) Export(ctx context.Context) ([]domain.CacheEntry, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	now := time.Now()
+	entries := make([]domain.CacheEntry, 0, len(c.data))
+	for key, item := range c.data {
+		if now.After(item.Expiration) {
+			continue
+		}
+		entries = append(entries, domain.CacheEntry{
+			Key:       key,
+			Value:     item.Value,
+			ExpiresAt: item.Expiration,
+		})
+	}
+
+	return entries, nil
+}
+
+// Import stores the given entries with their remaining TTL, skipping expired ones.
+// Existing keys are overwritten.
+func (c *MemoryCache) Import(ctx context.Context, entries []domain.CacheEntry) (int, error) {
+	imported := 0
+	for _, entry := range entries {
+		ttl := time.Until(entry.ExpiresAt)
+		if entry.Key == "" || ttl <= 0 {
+			continue
+		}
+		if err := c.Set(ctx, entry.Key, entry.Value, ttl); err != nil {
+			return imported, err
+		}
+		imported++
+	}
+
+	return imported, nil
+}
+
+// SaveToFile writes all unexpired entries to path as JSON. The file is written
+// to a temporary sibling first and renamed, so a crash never leaves it truncated.
+func (c *MemoryCache) SaveToFile(path string) error {
+	entries, err := c.Export(context.Background())
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entries: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace cache file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadFromFile restores entries saved by SaveToFile, skipping any that have
+// expired since, and returns how many were loaded. A missing file loads nothing
+// and is not an error; a corrupt file returns an error and leaves the cache unchanged.
+func (c *MemoryCache) LoadFromFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache file: %w", err)
+	}
+
+	var entries []domain.CacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return 0, fmt.Errorf("failed to decode cache file: %w", err)
+	}
+
+	return c.Import(context.Background(), entries)
+}
+
 // Size returns the current number of items in the cache (for debugging/monitoring)
 func (c *MemoryCache) Size() int {
 	c.mutex.RLock()
@@ -127,9 +325,27 @@ func (c *MemoryCache) Size() int {
 	return len(c.data)
 }
 
+// Stats returns the number of items and the Get hit/miss counts since creation
+func (c *MemoryCache) Stats() domain.CacheStats {
+	return newCacheStats(c.Size(), c.hits.Load(), c.misses.Load())
+}
+
+// newCacheStats builds CacheStats, deriving the hit ratio from the counts
+func newCacheStats(size int, hits, misses int64) domain.CacheStats {
+	stats := domain.CacheStats{Size: size, Hits: hits, Misses: misses}
+	if total := hits + misses; total > 0 {
+		stats.HitRatio = float64(hits) / float64(total)
+	}
+	return stats
+}
+
 // Clear removes all items from the cache
 func (c *MemoryCache) Clear() {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	c.data = make(map[string]cacheItem)
+	if c.bounded() {
+		c.order.Init()
+		c.elements = make(map[string]*list.Element)
+	}
 }
@@ -2,6 +2,10 @@ package cache
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
 	"testing"
 	"time"
 
@@ -207,6 +211,64 @@ func TestMemoryCache_Size(t *testing.T) {
 	}
 }
 
+func TestMemoryCache_CleanupInterval(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("short interval reclaims expired entries", func(t *testing.T) {
+		cache := NewMemoryCacheWithConfig(MemoryCacheConfig{CleanupInterval: 10 * time.Millisecond})
+		defer cache.Close()
+
+		cache.Set(ctx, "expiring", "value", 5*time.Millisecond)
+		cache.Set(ctx, "kept", "value", time.Hour)
+
+		deadline := time.Now().Add(time.Second)
+		for cache.Size() != 1 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if size := cache.Size(); size != 1 {
+			t.Errorf("Size() = %d, want 1 once the expired entry is swept", size)
+		}
+	})
+
+	t.Run("close stops the sweep", func(t *testing.T) {
+		cache := NewMemoryCacheWithConfig(MemoryCacheConfig{CleanupInterval: 10 * time.Millisecond})
+		cache.Close()
+		cache.Close() // Closing twice is harmless
+
+		cache.Set(ctx, "expiring", "value", time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		if size := cache.Size(); size != 1 {
+			t.Errorf("Size() = %d, want the expired entry left in place after Close", size)
+		}
+		if _, err := cache.Get(ctx, "expiring"); err != domain.ErrCacheMiss {
+			t.Errorf("Get() error = %v, want ErrCacheMiss for the expired entry", err)
+		}
+	})
+}
+
+func TestMemoryCache_CloseStopsGoroutine(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	caches := make([]*MemoryCache, 50)
+	for i := range caches {
+		caches[i] = NewMemoryCacheWithConfig(MemoryCacheConfig{CleanupInterval: time.Hour})
+	}
+	if running := runtime.NumGoroutine(); running < before+len(caches) {
+		t.Fatalf("NumGoroutine() = %d, want at least %d with a cleanup goroutine per cache", running, before+len(caches))
+	}
+
+	for _, cache := range caches {
+		cache.Close()
+	}
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if running := runtime.NumGoroutine(); running > before {
+		t.Errorf("NumGoroutine() = %d after Close, want %d: cleanup goroutines leaked", running, before)
+	}
+}
+
 func TestMemoryCache_Clear(t *testing.T) {
 	cache := NewMemoryCache()
 	ctx := context.Background()
@@ -271,3 +333,238 @@ func TestMemoryCache_Concurrent(t *testing.T) {
 		<-done
 	}
 }
+
+func TestMemoryCache_Stats(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("counts hits and misses", func(t *testing.T) {
+		cache := NewMemoryCache()
+		if stats := cache.Stats(); stats != (domain.CacheStats{}) {
+			t.Errorf("Stats() on empty cache = %+v, want zero", stats)
+		}
+
+		cache.Set(ctx, "key", "value", time.Minute)
+		cache.Set(ctx, "expired", "value", time.Millisecond)
+		time.Sleep(5 * time.Millisecond)
+
+		cache.Get(ctx, "key")
+		cache.Get(ctx, "key")
+		cache.Get(ctx, "key")
+		cache.Get(ctx, "missing")
+		cache.Get(ctx, "expired")
+
+		want := domain.CacheStats{Size: 2, Hits: 3, Misses: 2, HitRatio: 0.6}
+		if stats := cache.Stats(); stats != want {
+			t.Errorf("Stats() = %+v, want %+v", stats, want)
+		}
+	})
+
+	t.Run("counts concurrent lookups", func(t *testing.T) {
+		cache := NewMemoryCache()
+		cache.Set(ctx, "key", "value", time.Minute)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					cache.Get(ctx, "key")
+					cache.Get(ctx, "missing")
+				}
+			}()
+		}
+		wg.Wait()
+
+		if stats := cache.Stats(); stats.Hits != 1000 || stats.Misses != 1000 {
+			t.Errorf("Stats() = %+v, want 1000 hits and 1000 misses", stats)
+		}
+	})
+}
+
+func TestMemoryCache_LRUEviction(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("evicts the least recently set entry", func(t *testing.T) {
+		cache := NewMemoryCacheWithCapacity(2)
+		cache.Set(ctx, "a", 1, time.Minute)
+		cache.Set(ctx, "b", 2, time.Minute)
+		cache.Set(ctx, "c", 3, time.Minute)
+
+		if _, err := cache.Get(ctx, "a"); err != domain.ErrCacheMiss {
+			t.Errorf("Get(a) error = %v, want ErrCacheMiss", err)
+		}
+		for _, key := range []string{"b", "c"} {
+			if _, err := cache.Get(ctx, key); err != nil {
+				t.Errorf("Get(%s) error = %v", key, err)
+			}
+		}
+		if cache.Size() != 2 {
+			t.Errorf("Size() = %d, want 2", cache.Size())
+		}
+	})
+
+	t.Run("a read makes an entry most recently used", func(t *testing.T) {
+		cache := NewMemoryCacheWithCapacity(2)
+		cache.Set(ctx, "a", 1, time.Minute)
+		cache.Set(ctx, "b", 2, time.Minute)
+		cache.Get(ctx, "a")
+		cache.Set(ctx, "c", 3, time.Minute)
+
+		if _, err := cache.Get(ctx, "b"); err != domain.ErrCacheMiss {
+			t.Errorf("Get(b) error = %v, want ErrCacheMiss", err)
+		}
+		if _, err := cache.Get(ctx, "a"); err != nil {
+			t.Errorf("Get(a) error = %v", err)
+		}
+	})
+
+	t.Run("overwriting an entry makes it most recently used", func(t *testing.T) {
+		cache := NewMemoryCacheWithCapacity(2)
+		cache.Set(ctx, "a", 1, time.Minute)
+		cache.Set(ctx, "b", 2, time.Minute)
+		cache.Set(ctx, "a", 10, time.Minute)
+		cache.Set(ctx, "c", 3, time.Minute)
+
+		if _, err := cache.Get(ctx, "b"); err != domain.ErrCacheMiss {
+			t.Errorf("Get(b) error = %v, want ErrCacheMiss", err)
+		}
+		if value, err := cache.Get(ctx, "a"); err != nil || value != 10.0 {
+			t.Errorf("Get(a) = %v, %v; want 10, nil", value, err)
+		}
+	})
+
+	t.Run("deleted entries free their slot", func(t *testing.T) {
+		cache := NewMemoryCacheWithCapacity(2)
+		cache.Set(ctx, "a", 1, time.Minute)
+		cache.Set(ctx, "b", 2, time.Minute)
+		cache.Delete(ctx, "a")
+		cache.Set(ctx, "c", 3, time.Minute)
+
+		if _, err := cache.Get(ctx, "b"); err != nil {
+			t.Errorf("Get(b) error = %v", err)
+		}
+		if cache.Size() != 2 {
+			t.Errorf("Size() = %d, want 2", cache.Size())
+		}
+	})
+
+	t.Run("zero capacity is unbounded", func(t *testing.T) {
+		cache := NewMemoryCacheWithCapacity(0)
+		for i := 0; i < 100; i++ {
+			cache.Set(ctx, string(rune('a'+i)), i, time.Minute)
+		}
+		if cache.Size() != 100 {
+			t.Errorf("Size() = %d, want 100", cache.Size())
+		}
+	})
+}
+
+func TestMemoryCache_ExportImport(t *testing.T) {
+	ctx := context.Background()
+	source := NewMemoryCache()
+
+	value := map[string]interface{}{"fdcId": "12345", "confidence": 87.5}
+	if err := source.Set(ctx, "nutrition:milk:", value, time.Hour); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := source.Set(ctx, "expired", "gone", time.Millisecond); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	entries, err := source.Export(ctx)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Export() returned %d entries, want 1 (expired entries skipped)", len(entries))
+	}
+
+	target := NewMemoryCache()
+	imported, err := target.Import(ctx, entries)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if imported != 1 {
+		t.Errorf("Import() = %d, want 1", imported)
+	}
+
+	got, err := target.Get(ctx, "nutrition:milk:")
+	if err != nil {
+		t.Fatalf("Get() after import error = %v", err)
+	}
+	gotMap, ok := got.(map[string]interface{})
+	if !ok || gotMap["fdcId"] != "12345" || gotMap["confidence"] != 87.5 {
+		t.Errorf("Get() after import = %v, want %v", got, value)
+	}
+
+	t.Run("skips expired and keyless entries", func(t *testing.T) {
+		imported, err := target.Import(ctx, []domain.CacheEntry{
+			{Key: "old", Value: "x", ExpiresAt: time.Now().Add(-time.Minute)},
+			{Key: "", Value: "x", ExpiresAt: time.Now().Add(time.Minute)},
+		})
+		if err != nil {
+			t.Fatalf("Import() error = %v", err)
+		}
+		if imported != 0 {
+			t.Errorf("Import() = %d, want 0", imported)
+		}
+	})
+}
+
+func TestMemoryCache_SaveAndLoadFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	source := NewMemoryCache()
+	if err := source.Set(ctx, "nutrition:milk:", map[string]interface{}{"fdcId": "12345"}, time.Hour); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := source.Set(ctx, "expired", "gone", time.Millisecond); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if err := source.SaveToFile(path); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+
+	target := NewMemoryCache()
+	loaded, err := target.LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if loaded != 1 {
+		t.Errorf("LoadFromFile() = %d, want 1 (expired entries skipped)", loaded)
+	}
+	got, err := target.Get(ctx, "nutrition:milk:")
+	if err != nil {
+		t.Fatalf("Get() after load error = %v", err)
+	}
+	if gotMap, ok := got.(map[string]interface{}); !ok || gotMap["fdcId"] != "12345" {
+		t.Errorf("Get() after load = %v, want fdcId 12345", got)
+	}
+
+	t.Run("missing file loads nothing", func(t *testing.T) {
+		cache := NewMemoryCache()
+		loaded, err := cache.LoadFromFile(filepath.Join(t.TempDir(), "missing.json"))
+		if err != nil || loaded != 0 {
+			t.Errorf("LoadFromFile() = %d, %v, want 0, nil", loaded, err)
+		}
+	})
+
+	t.Run("corrupt file is an error", func(t *testing.T) {
+		corrupt := filepath.Join(t.TempDir(), "corrupt.json")
+		if err := os.WriteFile(corrupt, []byte("{not json"), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		cache := NewMemoryCache()
+		if _, err := cache.LoadFromFile(corrupt); err == nil {
+			t.Error("LoadFromFile() error = nil, want decode error")
+		}
+		if cache.Size() != 0 {
+			t.Errorf("Size() = %d, want 0", cache.Size())
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/macrolens/backend/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// redisPingTimeout bounds the connectivity check when the cache is created
+	redisPingTimeout = 5 * time.Second
+
+	// redisStatsTimeout bounds the DBSIZE lookup in Stats, which has no caller context
+	redisStatsTimeout = 500 * time.Millisecond
+)
+
+// RedisCache is a CacheRepository backed by Redis. Values are stored as JSON and
+// decoded generically on Get, the same shape MemoryCache returns.
+type RedisCache struct {
+	client *redis.Client
+
+	// Lookup counters for this instance; other instances sharing the server keep their own
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewRedisCache connects to the Redis server at url (e.g., "redis://localhost:6379/0")
+// and pings it, so a misconfigured cache fails at startup rather than on first use
+func NewRedisCache(url string) (*RedisCache, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+
+	client := redis.NewClient(options)
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisPingTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("%w: %v", domain.ErrCacheUnavailable, err)
+	}
+
+	return &RedisCache{client: client}, nil
+}
+
+// Get retrieves a value from Redis
+func (c *RedisCache) Get(ctx context.Context, key string) (interface{}, error) {
+	data, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		c.misses.Add(1)
+		return nil, domain.ErrCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrCacheUnavailable, err)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		// A corrupt entry is treated as absent so it gets recomputed and overwritten
+		c.misses.Add(1)
+		return nil, domain.ErrCacheMiss
+	}
+	c.hits.Add(1)
+	return value, nil
+}
+
+// Set stores a value in Redis as JSON with the given TTL
+func (c *RedisCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if err := c.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrCacheUnavailable, err)
+	}
+	return nil
+}
+
+// Delete removes a value from Redis
+func (c *RedisCache) Delete(ctx context.Context, key string) error {
+	if err := c.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrCacheUnavailable, err)
+	}
+	return nil
+}
+
+// Exists checks if a key exists in Redis
+func (c *RedisCache) Exists(ctx context.Context, key string) (bool, error) {
+	count, err := c.client.Exists(ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", domain.ErrCacheUnavailable, err)
+	}
+	return count > 0, nil
+}
+
+// Stats returns this instance's Get hit/miss counts and the number of keys in the
+// Redis database (0 if Redis can't be reached)
+func (c *RedisCache) Stats() domain.CacheStats {
+	ctx, cancel := context.WithTimeout(context.Background(), redisStatsTimeout)
+	defer cancel()
+
+	size, err := c.client.DBSize(ctx).Result()
+	if err != nil {
+		size = 0
+	}
+	return newCacheStats(int(size), c.hits.Load(), c.misses.Load())
+}
+
+// Ping checks that the Redis server is reachable
+func (c *RedisCache) Ping(ctx context.Context) error {
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrCacheUnavailable, err)
+	}
+	return nil
+}
+
+// Close closes the connection pool
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// newTestRedisCache starts an in-process Redis server and connects a RedisCache to it
+func newTestRedisCache(t *testing.T) (*RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+
+	cache, err := NewRedisCache("redis://" + server.Addr())
+	if err != nil {
+		t.Fatalf("NewRedisCache() error = %v", err)
+	}
+	t.Cleanup(func() { cache.Close() })
+	return cache, server
+}
+
+func TestRedisCache_SetAndGet(t *testing.T) {
+	cache, _ := newTestRedisCache(t)
+	ctx := context.Background()
+
+	data := &domain.NutritionData{FdcID: "12345", ProductName: "Milk", Confidence: 85}
+	if err := cache.Set(ctx, "key", data, time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	value, err := cache.Get(ctx, "key")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	got, ok := value.(map[string]interface{})
+	if !ok {
+		t.Fatalf("Get() = %T, want map[string]interface{}", value)
+	}
+	if got["fdcId"] != "12345" || got["productName"] != "Milk" || got["confidence"] != 85.0 {
+		t.Errorf("Get() = %v, want the stored NutritionData fields", got)
+	}
+}
+
+func TestRedisCache_Get_CacheMiss(t *testing.T) {
+	cache, _ := newTestRedisCache(t)
+
+	_, err := cache.Get(context.Background(), "missing")
+	if !errors.Is(err, domain.ErrCacheMiss) {
+		t.Errorf("Get() error = %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestRedisCache_TTL(t *testing.T) {
+	cache, server := newTestRedisCache(t)
+	ctx := context.Background()
+
+	if err := cache.Set(ctx, "key", "value", time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if ttl := server.TTL("key"); ttl != time.Minute {
+		t.Errorf("TTL = %v, want 1m", ttl)
+	}
+
+	server.FastForward(2 * time.Minute)
+	if _, err := cache.Get(ctx, "key"); !errors.Is(err, domain.ErrCacheMiss) {
+		t.Errorf("Get() after expiry error = %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestRedisCache_DeleteAndExists(t *testing.T) {
+	cache, _ := newTestRedisCache(t)
+	ctx := context.Background()
+
+	if err := cache.Set(ctx, "key", "value", time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if exists, err := cache.Exists(ctx, "key"); err != nil || !exists {
+		t.Errorf("Exists() = %v, %v; want true, nil", exists, err)
+	}
+
+	if err := cache.Delete(ctx, "key"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if exists, err := cache.Exists(ctx, "key"); err != nil || exists {
+		t.Errorf("Exists() after Delete = %v, %v; want false, nil", exists, err)
+	}
+}
+
+func TestRedisCache_Stats(t *testing.T) {
+	cache, _ := newTestRedisCache(t)
+	ctx := context.Background()
+
+	if err := cache.Set(ctx, "key", "value", time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	cache.Get(ctx, "key")
+	cache.Get(ctx, "missing")
+
+	want := domain.CacheStats{Size: 1, Hits: 1, Misses: 1, HitRatio: 0.5}
+	if stats := cache.Stats(); stats != want {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+}
+
+func TestRedisCache_Unavailable(t *testing.T) {
+	t.Run("constructor fails when the server is unreachable", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		addr := server.Addr()
+		server.Close()
+
+		_, err := NewRedisCache("redis://" + addr)
+		if !errors.Is(err, domain.ErrCacheUnavailable) {
+			t.Errorf("NewRedisCache() error = %v, want ErrCacheUnavailable", err)
+		}
+	})
+
+	t.Run("constructor rejects an invalid URL", func(t *testing.T) {
+		if _, err := NewRedisCache("not-a-url"); err == nil {
+			t.Error("NewRedisCache() error = nil, want error")
+		}
+	})
+
+	t.Run("operations report ErrCacheUnavailable after the server goes away", func(t *testing.T) {
+		cache, server := newTestRedisCache(t)
+		ctx := context.Background()
+		server.Close()
+
+		if _, err := cache.Get(ctx, "key"); !errors.Is(err, domain.ErrCacheUnavailable) {
+			t.Errorf("Get() error = %v, want ErrCacheUnavailable", err)
+		}
+		if err := cache.Set(ctx, "key", "value", time.Minute); !errors.Is(err, domain.ErrCacheUnavailable) {
+			t.Errorf("Set() error = %v, want ErrCacheUnavailable", err)
+		}
+		if _, err := cache.Exists(ctx, "key"); !errors.Is(err, domain.ErrCacheUnavailable) {
+			t.Errorf("Exists() error = %v, want ErrCacheUnavailable", err)
+		}
+	})
+}
+
+func TestRedisCache_Ping(t *testing.T) {
+	cache, server := newTestRedisCache(t)
+	ctx := context.Background()
+
+	if err := cache.Ping(ctx); err != nil {
+		t.Errorf("Ping() error = %v, want nil", err)
+	}
+
+	server.Close()
+	if err := cache.Ping(ctx); !errors.Is(err, domain.ErrCacheUnavailable) {
+		t.Errorf("Ping() error = %v, want ErrCacheUnavailable", err)
+	}
+}
@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// defaultL1TTL bounds how long a promoted entry lives in L1.
+// Kept short so L1 never serves data long after L2 has expired it.
+const defaultL1TTL = 5 * time.Minute
+
+// TieredCacheConfig holds configuration for a two-tier cache
+type TieredCacheConfig struct {
+	L1Size int           // Maximum number of entries kept in L1; 0 disables L1 and reads and writes go to L2 only
+	L1TTL  time.Duration // Maximum lifetime of an entry in L1
+}
+
+// TieredCache is a two-tier cache with a small, fast L1 in front of a larger L2
+// (typically Redis). Reads check L1 first and promote L2 hits into L1.
+// Writes go through to both tiers.
+type TieredCache struct {
+	l1     domain.CacheRepository
+	l2     domain.CacheRepository
+	l1Size int
+	l1TTL  time.Duration
+
+	// l1Order holds the L1 keys, most recently used first, so the least recently
+	// used entry can be evicted once L1Size is reached
+	l1Order *list.List
+	l1Index map[string]*list.Element
+	mutex   sync.Mutex
+}
+
+// NewTieredCache creates a new two-tier cache from the given L1 and L2 backends.
+// An L1Size of 0 or less leaves L1 unused.
+func NewTieredCache(l1, l2 domain.CacheRepository, config TieredCacheConfig) *TieredCache {
+	l1Size := max(config.L1Size, 0)
+
+	l1TTL := config.L1TTL
+	if l1TTL <= 0 {
+		l1TTL = defaultL1TTL
+	}
+
+	return &TieredCache{
+		l1:      l1,
+		l2:      l2,
+		l1Size:  l1Size,
+		l1TTL:   l1TTL,
+		l1Order: list.New(),
+		l1Index: make(map[string]*list.Element),
+	}
+}
+
+// Get retrieves a value from L1, falling back to L2 and promoting on an L2 hit
+func (c *TieredCache) Get(ctx context.Context, key string) (interface{}, error) {
+	if c.l1Size > 0 {
+		value, err := c.l1.Get(ctx, key)
+		c.mutex.Lock()
+		if err == nil {
+			c.touchL1Key(key)
+		} else {
+			// A tracked key missing from L1 has expired there
+			c.forgetL1Key(key)
+		}
+		c.mutex.Unlock()
+		if err == nil {
+			return value, nil
+		}
+	}
+
+	value, err := c.l2.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Promote to L1; a failed promotion only costs a future L2 round-trip
+	_ = c.setL1(ctx, key, value, c.l1TTL)
+
+	return value, nil
+}
+
+// Set writes a value through to both L1 and L2
+func (c *TieredCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if err := c.l2.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+
+	l1TTL := ttl
+	if l1TTL > c.l1TTL {
+		l1TTL = c.l1TTL
+	}
+	return c.setL1(ctx, key, value, l1TTL)
+}
+
+// Delete removes a value from both tiers
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	c.mutex.Lock()
+	c.forgetL1Key(key)
+	c.mutex.Unlock()
+
+	l1Err := c.l1.Delete(ctx, key)
+	l2Err := c.l2.Delete(ctx, key)
+	return errors.Join(l1Err, l2Err)
+}
+
+// Exists checks whether a key exists in either tier
+func (c *TieredCache) Exists(ctx context.Context, key string) (bool, error) {
+	if exists, err := c.l1.Exists(ctx, key); err == nil && exists {
+		return true, nil
+	}
+	return c.l2.Exists(ctx, key)
+}
+
+// Stats returns L2's stats with L1 hits added. Every L1 miss is looked up in L2,
+// so L1 hits plus L2 hits and misses covers every lookup.
+func (c *TieredCache) Stats() domain.CacheStats {
+	l2, ok := c.l2.(domain.CacheStatsProvider)
+	if !ok {
+		return domain.CacheStats{}
+	}
+	stats := l2.Stats()
+	if l1, ok := c.l1.(domain.CacheStatsProvider); ok {
+		stats = newCacheStats(stats.Size, stats.Hits+l1.Stats().Hits, stats.Misses)
+	}
+	return stats
+}
+
+// Ping pings L2, or succeeds if it has no server to ping
+func (c *TieredCache) Ping(ctx context.Context) error {
+	if pinger, ok := c.l2.(domain.CachePinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// setL1 stores a value in L1, evicting the least recently used L1 entry when full
+func (c *TieredCache) setL1(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if c.l1Size <= 0 {
+		return nil
+	}
+
+	c.mutex.Lock()
+	var evicted []string
+	if _, tracked := c.l1Index[key]; tracked {
+		c.touchL1Key(key)
+	} else {
+		for c.l1Order.Len() >= c.l1Size {
+			oldest := c.l1Order.Remove(c.l1Order.Back()).(string)
+			delete(c.l1Index, oldest)
+			evicted = append(evicted, oldest)
+		}
+		c.l1Index[key] = c.l1Order.PushFront(key)
+	}
+	c.mutex.Unlock()
+
+	for _, oldest := range evicted {
+		_ = c.l1.Delete(ctx, oldest)
+	}
+
+	return c.l1.Set(ctx, key, value, ttl)
+}
+
+// touchL1Key marks a tracked key as most recently used. Caller must hold the mutex.
+func (c *TieredCache) touchL1Key(key string) {
+	if element, ok := c.l1Index[key]; ok {
+		c.l1Order.MoveToFront(element)
+	}
+}
+
+// forgetL1Key removes a key from the L1 bookkeeping. Caller must hold the mutex.
+func (c *TieredCache) forgetL1Key(key string) {
+	if element, ok := c.l1Index[key]; ok {
+		c.l1Order.Remove(element)
+		delete(c.l1Index, key)
+	}
+}
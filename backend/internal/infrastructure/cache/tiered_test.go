@@ -0,0 +1,267 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// mockBackend is a simple map-backed CacheRepository that counts calls
+type mockBackend struct {
+	data     map[string]interface{}
+	getCalls int
+	setCalls int
+	ttls     map[string]time.Duration
+	err      error // Returned by every operation when set, simulating an outage
+}
+
+func newMockBackend() *mockBackend {
+	return &mockBackend{
+		data: make(map[string]interface{}),
+		ttls: make(map[string]time.Duration),
+	}
+}
+
+func (m *mockBackend) Get(ctx context.Context, key string) (interface{}, error) {
+	m.getCalls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	if value, ok := m.data[key]; ok {
+		return value, nil
+	}
+	return nil, domain.ErrCacheMiss
+}
+
+func (m *mockBackend) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	m.setCalls++
+	if m.err != nil {
+		return m.err
+	}
+	m.data[key] = value
+	m.ttls[key] = ttl
+	return nil
+}
+
+func (m *mockBackend) Delete(ctx context.Context, key string) error {
+	if m.err != nil {
+		return m.err
+	}
+	delete(m.data, key)
+	return nil
+}
+
+func (m *mockBackend) Exists(ctx context.Context, key string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	_, ok := m.data[key]
+	return ok, nil
+}
+
+func TestTieredCache_WriteThrough(t *testing.T) {
+	ctx := context.Background()
+	l1 := newMockBackend()
+	l2 := newMockBackend()
+	cache := NewTieredCache(l1, l2, TieredCacheConfig{L1Size: 10, L1TTL: time.Minute})
+
+	if err := cache.Set(ctx, "key", "value", time.Hour); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if l1.data["key"] != "value" {
+		t.Errorf("L1 value = %v, want value", l1.data["key"])
+	}
+	if l2.data["key"] != "value" {
+		t.Errorf("L2 value = %v, want value", l2.data["key"])
+	}
+	if l2.ttls["key"] != time.Hour {
+		t.Errorf("L2 TTL = %v, want 1h", l2.ttls["key"])
+	}
+	if l1.ttls["key"] != time.Minute {
+		t.Errorf("L1 TTL = %v, want capped at 1m", l1.ttls["key"])
+	}
+}
+
+func TestTieredCache_ReadsL1First(t *testing.T) {
+	ctx := context.Background()
+	l1 := newMockBackend()
+	l2 := newMockBackend()
+	cache := NewTieredCache(l1, l2, TieredCacheConfig{L1Size: 10})
+
+	l1.data["key"] = "from-l1"
+	l2.data["key"] = "from-l2"
+
+	got, err := cache.Get(ctx, "key")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got != "from-l1" {
+		t.Errorf("Get() = %v, want from-l1", got)
+	}
+	if l2.getCalls != 0 {
+		t.Errorf("L2 Get calls = %d, want 0 on L1 hit", l2.getCalls)
+	}
+}
+
+func TestTieredCache_PromotesOnL2Hit(t *testing.T) {
+	ctx := context.Background()
+	l1 := newMockBackend()
+	l2 := newMockBackend()
+	cache := NewTieredCache(l1, l2, TieredCacheConfig{L1Size: 10})
+
+	l2.data["key"] = "from-l2"
+
+	got, err := cache.Get(ctx, "key")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got != "from-l2" {
+		t.Errorf("Get() = %v, want from-l2", got)
+	}
+	if l1.data["key"] != "from-l2" {
+		t.Errorf("L1 value = %v, want promoted from-l2", l1.data["key"])
+	}
+
+	// Second read is served from L1 without touching L2
+	if _, err := cache.Get(ctx, "key"); err != nil {
+		t.Fatalf("second Get() error = %v", err)
+	}
+	if l2.getCalls != 1 {
+		t.Errorf("L2 Get calls = %d, want 1", l2.getCalls)
+	}
+}
+
+func TestTieredCache_MissInBothTiers(t *testing.T) {
+	ctx := context.Background()
+	cache := NewTieredCache(newMockBackend(), newMockBackend(), TieredCacheConfig{})
+
+	_, err := cache.Get(ctx, "missing")
+	if err != domain.ErrCacheMiss {
+		t.Errorf("Get() error = %v, want %v", err, domain.ErrCacheMiss)
+	}
+}
+
+func TestTieredCache_EvictsOldestL1Entry(t *testing.T) {
+	ctx := context.Background()
+	l1 := newMockBackend()
+	l2 := newMockBackend()
+	cache := NewTieredCache(l1, l2, TieredCacheConfig{L1Size: 2})
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := cache.Set(ctx, key, key, time.Hour); err != nil {
+			t.Fatalf("Set(%s) error = %v", key, err)
+		}
+	}
+
+	if _, ok := l1.data["a"]; ok {
+		t.Error("expected oldest key 'a' to be evicted from L1")
+	}
+	if len(l1.data) != 2 {
+		t.Errorf("L1 size = %d, want 2", len(l1.data))
+	}
+	if len(l2.data) != 3 {
+		t.Errorf("L2 size = %d, want 3 (L2 is not bounded by L1Size)", len(l2.data))
+	}
+}
+
+func TestTieredCache_EvictsLeastRecentlyUsedL1Entry(t *testing.T) {
+	ctx := context.Background()
+	l1 := newMockBackend()
+	l2 := newMockBackend()
+	cache := NewTieredCache(l1, l2, TieredCacheConfig{L1Size: 2})
+
+	cache.Set(ctx, "a", "a", time.Hour)
+	cache.Set(ctx, "b", "b", time.Hour)
+	if _, err := cache.Get(ctx, "a"); err != nil {
+		t.Fatalf("Get(a) error = %v", err)
+	}
+	cache.Set(ctx, "c", "c", time.Hour)
+
+	if _, ok := l1.data["b"]; ok {
+		t.Error("expected least recently used key 'b' to be evicted from L1")
+	}
+	if _, ok := l1.data["a"]; !ok {
+		t.Error("expected recently read key 'a' to stay in L1")
+	}
+}
+
+func TestTieredCache_ZeroL1SizeDisablesL1(t *testing.T) {
+	ctx := context.Background()
+	l1 := newMockBackend()
+	l2 := newMockBackend()
+	cache := NewTieredCache(l1, l2, TieredCacheConfig{L1Size: 0})
+
+	if err := cache.Set(ctx, "key", "value", time.Hour); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, err := cache.Get(ctx, "key"); err != nil || got != "value" {
+		t.Fatalf("Get() = %v, %v, want value from L2", got, err)
+	}
+
+	if len(l1.data) != 0 || l1.getCalls != 0 {
+		t.Errorf("L1 entries = %d, Get calls = %d, want L1 unused", len(l1.data), l1.getCalls)
+	}
+}
+
+func TestTieredCache_DropsExpiredL1Keys(t *testing.T) {
+	ctx := context.Background()
+	l1 := newMockBackend()
+	l2 := newMockBackend()
+	cache := NewTieredCache(l1, l2, TieredCacheConfig{L1Size: 2})
+
+	cache.Set(ctx, "a", "a", time.Hour)
+	cache.Set(ctx, "b", "b", time.Hour)
+
+	// "a" expires from both tiers
+	delete(l1.data, "a")
+	delete(l2.data, "a")
+	if _, err := cache.Get(ctx, "a"); err != domain.ErrCacheMiss {
+		t.Fatalf("Get(a) error = %v, want %v", err, domain.ErrCacheMiss)
+	}
+
+	if _, tracked := cache.l1Index["a"]; tracked || cache.l1Order.Len() != 1 {
+		t.Errorf("L1 index holds %d keys (a tracked: %v), want only b", cache.l1Order.Len(), tracked)
+	}
+}
+
+func TestTieredCache_DeleteRemovesFromBothTiers(t *testing.T) {
+	ctx := context.Background()
+	l1 := newMockBackend()
+	l2 := newMockBackend()
+	cache := NewTieredCache(l1, l2, TieredCacheConfig{L1Size: 10})
+
+	if err := cache.Set(ctx, "key", "value", time.Hour); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := cache.Delete(ctx, "key"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	exists, err := cache.Exists(ctx, "key")
+	if err != nil {
+		t.Fatalf("Exists() error = %v", err)
+	}
+	if exists {
+		t.Error("Exists() = true, want false after delete")
+	}
+}
+
+func TestTieredCache_Stats(t *testing.T) {
+	ctx := context.Background()
+	l1 := NewMemoryCache()
+	l2 := NewMemoryCache()
+	cache := NewTieredCache(l1, l2, TieredCacheConfig{L1Size: 10, L1TTL: time.Minute})
+
+	l2.Set(ctx, "l2-only", "value", time.Minute)
+	cache.Get(ctx, "l2-only") // L1 miss, L2 hit, promoted
+	cache.Get(ctx, "l2-only") // L1 hit
+	cache.Get(ctx, "missing") // Miss in both tiers
+
+	want := domain.CacheStats{Size: 1, Hits: 2, Misses: 1, HitRatio: 2.0 / 3.0}
+	if stats := cache.Stats(); stats != want {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+}
@@ -0,0 +1,112 @@
+package feedback
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// DefaultCapacity is the number of feedback entries kept when no capacity is given
+const DefaultCapacity = 1000
+
+// MemoryStore is a thread-safe ring buffer of recent feedback. Once full, the
+// oldest entries are overwritten, so stats reflect the most recent matches.
+type MemoryStore struct {
+	entries []domain.MatchFeedback
+	next    int // Index the next entry is written to
+	full    bool
+	mutex   sync.RWMutex
+}
+
+// NewMemoryStore creates a store holding up to capacity entries
+func NewMemoryStore(capacity int) *MemoryStore {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &MemoryStore{
+		entries: make([]domain.MatchFeedback, capacity),
+	}
+}
+
+// Record adds feedback, evicting the oldest entry when the buffer is full
+func (s *MemoryStore) Record(ctx context.Context, feedback domain.MatchFeedback) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.entries[s.next] = feedback
+	s.next = (s.next + 1) % len(s.entries)
+	if s.next == 0 {
+		s.full = true
+	}
+	return nil
+}
+
+// Stats aggregates the buffered feedback overall and by data type
+func (s *MemoryStore) Stats(ctx context.Context) (*domain.FeedbackStats, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	count := s.next
+	if s.full {
+		count = len(s.entries)
+	}
+
+	stats := &domain.FeedbackStats{ByDataType: make(map[string]domain.FeedbackCounts)}
+	for _, entry := range s.entries[:count] {
+		byType := stats.ByDataType[entry.DataType]
+		addFeedback(&stats.FeedbackCounts, entry)
+		addFeedback(&byType, entry)
+		stats.ByDataType[entry.DataType] = byType
+	}
+
+	return stats, nil
+}
+
+// ConfirmedFdcIDs returns fdcIds whose correct reports for the query outnumber
+// incorrect ones. Queries are compared case- and whitespace-insensitively.
+func (s *MemoryStore) ConfirmedFdcIDs(ctx context.Context, query string) (map[string]bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	query = normalizeQuery(query)
+	count := s.next
+	if s.full {
+		count = len(s.entries)
+	}
+
+	votes := make(map[string]int)
+	for _, entry := range s.entries[:count] {
+		if entry.Correct == nil || normalizeQuery(entry.Query) != query {
+			continue
+		}
+		if *entry.Correct {
+			votes[entry.FdcID]++
+		} else {
+			votes[entry.FdcID]--
+		}
+	}
+
+	confirmed := make(map[string]bool)
+	for fdcID, vote := range votes {
+		if vote > 0 {
+			confirmed[fdcID] = true
+		}
+	}
+	return confirmed, nil
+}
+
+// normalizeQuery lowercases a query and collapses whitespace
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// addFeedback counts one entry and refreshes the accuracy
+func addFeedback(counts *domain.FeedbackCounts, entry domain.MatchFeedback) {
+	counts.Total++
+	if entry.Correct != nil && *entry.Correct {
+		counts.Correct++
+	}
+	counts.Accuracy = float64(counts.Correct) / float64(counts.Total)
+}
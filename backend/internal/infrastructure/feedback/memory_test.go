@@ -0,0 +1,99 @@
+package feedback
+
+import (
+	"context"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+func newFeedback(dataType string, correct bool) domain.MatchFeedback {
+	return domain.MatchFeedback{Query: "whole milk", FdcID: "1", DataType: dataType, Correct: &correct}
+}
+
+func TestMemoryStore_Stats(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("empty store", func(t *testing.T) {
+		stats, err := NewMemoryStore(10).Stats(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if stats.Total != 0 || stats.Accuracy != 0 || len(stats.ByDataType) != 0 {
+			t.Errorf("stats = %+v, want empty", stats)
+		}
+	})
+
+	t.Run("aggregates by data type", func(t *testing.T) {
+		store := NewMemoryStore(10)
+		for _, fb := range []domain.MatchFeedback{
+			newFeedback("Branded", true),
+			newFeedback("Branded", true),
+			newFeedback("Branded", false),
+			newFeedback("Foundation", true),
+		} {
+			if err := store.Record(ctx, fb); err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+		}
+
+		stats, err := store.Stats(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if stats.Total != 4 || stats.Correct != 3 || stats.Accuracy != 0.75 {
+			t.Errorf("overall = %+v, want 3/4 correct", stats.FeedbackCounts)
+		}
+		branded := stats.ByDataType["Branded"]
+		if branded.Total != 3 || branded.Correct != 2 {
+			t.Errorf("Branded = %+v, want 2/3 correct", branded)
+		}
+		if foundation := stats.ByDataType["Foundation"]; foundation.Accuracy != 1 {
+			t.Errorf("Foundation = %+v, want accuracy 1", foundation)
+		}
+	})
+
+	t.Run("evicts the oldest entries when full", func(t *testing.T) {
+		store := NewMemoryStore(2)
+		_ = store.Record(ctx, newFeedback("Branded", false))
+		_ = store.Record(ctx, newFeedback("Foundation", true))
+		_ = store.Record(ctx, newFeedback("Foundation", true))
+
+		stats, _ := store.Stats(ctx)
+		if stats.Total != 2 || stats.Correct != 2 {
+			t.Errorf("overall = %+v, want only the 2 most recent entries", stats.FeedbackCounts)
+		}
+		if _, ok := stats.ByDataType["Branded"]; ok {
+			t.Error("expected the evicted Branded entry to be gone")
+		}
+	})
+}
+
+func TestMemoryStore_ConfirmedFdcIDs(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(10)
+	record := func(query, fdcID string, correct bool) {
+		_ = store.Record(ctx, domain.MatchFeedback{Query: query, FdcID: fdcID, Correct: &correct})
+	}
+
+	record("whole milk", "1", true)
+	record("Whole  Milk", "1", true)
+	record("whole milk", "1", false)
+	record("whole milk", "2", true)
+	record("whole milk", "2", false)
+	record("white bread", "3", true)
+
+	confirmed, err := store.ConfirmedFdcIDs(ctx, "whole milk")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !confirmed["1"] {
+		t.Error("expected fdcId 1 to be confirmed (2 correct, 1 incorrect)")
+	}
+	if confirmed["2"] {
+		t.Error("expected fdcId 2 not to be confirmed (tied votes)")
+	}
+	if confirmed["3"] {
+		t.Error("expected fdcId 3, confirmed for another query, to be excluded")
+	}
+}
@@ -0,0 +1,36 @@
+// Package logging builds the process-wide logger from the configured level and format
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// ParseLevel parses "debug", "info", "warn" or "error". An empty name returns
+// the default, info.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q: want debug, info, warn or error", name)
+}
+
+// New returns a logger writing records at or above level to w, as JSON objects
+// when format is "json" and as key=value text otherwise. Installed with
+// slog.SetDefault, it also receives the standard log package's output at info level.
+func New(w io.Writer, level slog.Level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    slog.Level
+		wantErr bool
+	}{
+		{name: "debug", want: slog.LevelDebug},
+		{name: "INFO", want: slog.LevelInfo},
+		{name: "", want: slog.LevelInfo},
+		{name: "warn", want: slog.LevelWarn},
+		{name: "warning", want: slog.LevelWarn},
+		{name: " error ", want: slog.LevelError},
+		{name: "verbose", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLevel(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLevel(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseLevel(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Run("drops records below the level", func(t *testing.T) {
+		var out bytes.Buffer
+		logger := New(&out, slog.LevelWarn, "text")
+
+		logger.Info("cache warmed")
+		logger.Warn("cache file corrupt")
+
+		if strings.Contains(out.String(), "cache warmed") {
+			t.Errorf("info record logged at warn level: %q", out.String())
+		}
+		if !strings.Contains(out.String(), "cache file corrupt") {
+			t.Errorf("warn record missing: %q", out.String())
+		}
+	})
+
+	t.Run("json writes one object per record", func(t *testing.T) {
+		var out bytes.Buffer
+		logger := New(&out, slog.LevelDebug, "json")
+
+		logger.Debug("matching", "query", "whole milk")
+
+		var record map[string]interface{}
+		if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+			t.Fatalf("output is not JSON: %v (%q)", err, out.String())
+		}
+		if record["level"] != "DEBUG" || record["msg"] != "matching" || record["query"] != "whole milk" {
+			t.Errorf("record = %v, want DEBUG matching with query", record)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package usda
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// MaxBulkFoodIDs is the most FDC IDs USDA's /v1/foods endpoint accepts per request
+const MaxBulkFoodIDs = 20
+
+// bulkFoodsRequest is the body of a POST /v1/foods request
+type bulkFoodsRequest struct {
+	FdcIDs []int `json:"fdcIds"`
+}
+
+// GetFoodsDetails retrieves the details of up to MaxBulkFoodIDs foods in one USDA
+// request, keyed by FDC ID. Cached foods are served from the details cache and
+// only the rest are requested. IDs USDA doesn't know are left out of the result;
+// ErrProductNotFound is returned only when none of them are found.
+func (c *Client) GetFoodsDetails(ctx context.Context, fdcIDs []string) (map[string]*domain.USDAFood, error) {
+	ids, err := parseFdcIDs(fdcIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	foods := make(map[string]*domain.USDAFood, len(ids))
+	var missing []int
+	for _, id := range ids {
+		fdcID := strconv.Itoa(id)
+		if food, ok := c.cachedFoodDetails(ctx, fdcID); ok {
+			foods[fdcID] = food
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) > 0 {
+		if err := c.allowRequest(ctx); err != nil {
+			observeOutcome(operationBulkDetails, err)
+			return nil, err
+		}
+
+		fetched, err := c.getFoodsDetails(ctx, missing)
+		c.recordOutcome(ctx, err, false)
+		observeOutcome(operationBulkDetails, err)
+		if err != nil {
+			return nil, err
+		}
+		for i := range fetched {
+			food := &fetched[i]
+			fdcID := strconv.Itoa(food.FdcID)
+			foods[fdcID] = food
+			c.storeFoodDetails(ctx, fdcID, food)
+		}
+	}
+
+	if len(foods) == 0 {
+		return nil, domain.ErrProductNotFound
+	}
+	return foods, nil
+}
+
+// getFoodsDetails performs a single bulk food details request
+func (c *Client) getFoodsDetails(ctx context.Context, ids []int) ([]domain.USDAFood, error) {
+	// One rate limiter token per call, however many ids it carries
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limiter error: %w", err)
+	}
+
+	body, err := json.Marshal(bulkFoodsRequest{FdcIDs: ids})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/foods", c.baseURL)
+	resp, err := c.doRequestWithBody(ctx, http.MethodPost, endpoint, url.Values{}, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, readErr := readLimitedBody(resp.Body, maxErrorBodySize)
+		if readErr != nil {
+			c.debugLog(ctx, "Error reading error response body", "error", readErr)
+		}
+		return nil, fmt.Errorf("%w: status %d, body: %s", domain.ErrUSDAAPIFailure, resp.StatusCode, string(body))
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	var foods []domain.USDAFood
+	if err := json.Unmarshal(raw, &foods); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.debugLog(ctx, "Fetched foods", "found", len(foods), "requested", len(ids))
+	return foods, nil
+}
+
+// parseFdcIDs converts FDC IDs to the integers USDA expects, dropping duplicates.
+// An empty list, a non-numeric id or more than MaxBulkFoodIDs ids is invalid.
+func parseFdcIDs(fdcIDs []string) ([]int, error) {
+	seen := make(map[int]bool, len(fdcIDs))
+	ids := make([]int, 0, len(fdcIDs))
+	for _, fdcID := range fdcIDs {
+		id, err := strconv.Atoi(fdcID)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("%w: invalid fdcId %q", domain.ErrInvalidRequest, fdcID)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 || len(ids) > MaxBulkFoodIDs {
+		return nil, fmt.Errorf("%w: between 1 and %d fdcIds are required", domain.ErrInvalidRequest, MaxBulkFoodIDs)
+	}
+	return ids, nil
+}
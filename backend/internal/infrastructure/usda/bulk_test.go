@@ -0,0 +1,136 @@
+package usda
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+	"github.com/macrolens/backend/internal/infrastructure/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The nutrition service finds bulk lookups through this interface
+var (
+	_ domain.BulkFoodDetailsClient = (*Client)(nil)
+	_ domain.BulkFoodDetailsClient = (*MockUSDAClient)(nil)
+)
+
+func TestGetFoodsDetails(t *testing.T) {
+	// newServer knows foods 1 and 2 and records the ids each request asked for
+	newServer := func(requested *[][]int) *httptest.Server {
+		known := map[int]domain.USDAFood{
+			1: {FdcID: 1, Description: "Milk, whole"},
+			2: {FdcID: 2, Description: "Bread, wheat"},
+		}
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "/v1/foods", r.URL.Path)
+			assert.Equal(t, "test-api-key", r.URL.Query().Get("api_key"))
+
+			var body bulkFoodsRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			*requested = append(*requested, body.FdcIDs)
+
+			foods := []domain.USDAFood{}
+			for _, id := range body.FdcIDs {
+				if food, ok := known[id]; ok {
+					foods = append(foods, food)
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(foods)
+		}))
+	}
+
+	t.Run("one request for every id", func(t *testing.T) {
+		var requested [][]int
+		server := newServer(&requested)
+		defer server.Close()
+
+		foods, err := NewClient("test-api-key", server.URL).GetFoodsDetails(context.Background(), []string{"1", "2", "1"})
+
+		require.NoError(t, err)
+		assert.Equal(t, [][]int{{1, 2}}, requested)
+		assert.Equal(t, "Milk, whole", foods["1"].Description)
+		assert.Equal(t, "Bread, wheat", foods["2"].Description)
+	})
+
+	t.Run("unknown ids are left out", func(t *testing.T) {
+		var requested [][]int
+		server := newServer(&requested)
+		defer server.Close()
+
+		foods, err := NewClient("test-api-key", server.URL).GetFoodsDetails(context.Background(), []string{"1", "999"})
+
+		require.NoError(t, err)
+		assert.Len(t, foods, 1)
+		assert.Contains(t, foods, "1")
+	})
+
+	t.Run("not found when no id is known", func(t *testing.T) {
+		var requested [][]int
+		server := newServer(&requested)
+		defer server.Close()
+
+		_, err := NewClient("test-api-key", server.URL).GetFoodsDetails(context.Background(), []string{"998", "999"})
+
+		assert.ErrorIs(t, err, domain.ErrProductNotFound)
+	})
+
+	t.Run("cached foods are not requested", func(t *testing.T) {
+		var requested [][]int
+		server := newServer(&requested)
+		defer server.Close()
+		client := NewClientWithOptions("test-api-key", server.URL, ClientOptions{DetailsCache: cache.NewMemoryCache()})
+
+		client.storeFoodDetails(context.Background(), "1", &domain.USDAFood{FdcID: 1, Description: "Milk, whole"})
+
+		foods, err := client.GetFoodsDetails(context.Background(), []string{"1", "2"})
+
+		require.NoError(t, err)
+		assert.Len(t, foods, 2)
+		assert.Equal(t, [][]int{{2}}, requested)
+		cached, ok := client.cachedFoodDetails(context.Background(), "2")
+		assert.True(t, ok, "fetched foods should be cached")
+		assert.Equal(t, "Bread, wheat", cached.Description)
+	})
+
+	t.Run("rejects invalid id lists", func(t *testing.T) {
+		client := NewClient("test-api-key", "http://127.0.0.1:0")
+		tooMany := make([]string, MaxBulkFoodIDs+1)
+		for i := range tooMany {
+			tooMany[i] = strconv.Itoa(i + 1)
+		}
+
+		for _, ids := range [][]string{nil, {"abc"}, {"-1"}, tooMany} {
+			_, err := client.GetFoodsDetails(context.Background(), ids)
+			assert.ErrorIs(t, err, domain.ErrInvalidRequest, "ids %v", ids)
+		}
+	})
+
+	t.Run("upstream failure", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		_, err := NewClient("test-api-key", server.URL).GetFoodsDetails(context.Background(), []string{"1"})
+
+		assert.ErrorIs(t, err, domain.ErrUSDAAPIFailure)
+	})
+}
+
+func TestMockUSDAClient_GetFoodsDetails(t *testing.T) {
+	client, err := NewMockUSDAClient()
+	require.NoError(t, err)
+
+	foods, err := client.GetFoodsDetails(context.Background(), []string{"2262072", "1"})
+	require.NoError(t, err)
+	assert.Len(t, foods, 1)
+	assert.Equal(t, 2262072, foods["2262072"].FdcID)
+}
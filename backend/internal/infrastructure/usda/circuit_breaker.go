@@ -0,0 +1,148 @@
+package usda
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned (wrapped in domain.ErrUSDAAPIFailure) when the breaker
+// is rejecting calls after repeated upstream failures
+var ErrCircuitOpen = errors.New("USDA circuit breaker open")
+
+const (
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerOpenDuration     = 30 * time.Second
+)
+
+// Circuit breaker states
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// CircuitBreakerConfig holds configuration for the USDA circuit breaker
+type CircuitBreakerConfig struct {
+	FailureThreshold int           // Consecutive failures that open the breaker
+	OpenDuration     time.Duration // How long the breaker stays open before probing
+
+	// CountRetryAttempts counts every failed retry attempt against the breaker.
+	// By default a logical request counts once no matter how many retries it made,
+	// so one flaky request cannot trip the breaker on its own.
+	CountRetryAttempts bool
+}
+
+// CircuitBreaker fast-fails USDA calls after consecutive failures, then lets a
+// single probe through once OpenDuration has passed
+type CircuitBreaker struct {
+	failureThreshold   int
+	openDuration       time.Duration
+	countRetryAttempts bool
+
+	mutex         sync.Mutex
+	state         string
+	failures      int
+	openedAt      time.Time
+	probeInFlight bool
+	now           func() time.Time
+}
+
+// NewCircuitBreaker creates a closed circuit breaker
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	threshold := config.FailureThreshold
+	if threshold <= 0 {
+		threshold = defaultBreakerFailureThreshold
+	}
+
+	openDuration := config.OpenDuration
+	if openDuration <= 0 {
+		openDuration = defaultBreakerOpenDuration
+	}
+
+	observeBreakerState(breakerClosed)
+	return &CircuitBreaker{
+		failureThreshold:   threshold,
+		openDuration:       openDuration,
+		countRetryAttempts: config.CountRetryAttempts,
+		state:              breakerClosed,
+		now:                time.Now,
+	}
+}
+
+// setState moves the breaker to state and reports it to metrics. Callers hold the mutex.
+func (b *CircuitBreaker) setState(state string) {
+	b.state = state
+	observeBreakerState(state)
+}
+
+// Allow reports whether a call may proceed, moving an expired open breaker to half-open
+func (b *CircuitBreaker) Allow() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.openDuration {
+			return ErrCircuitOpen
+		}
+		b.setState(breakerHalfOpen)
+		b.probeInFlight = true
+		return nil
+	case breakerHalfOpen:
+		if b.probeInFlight {
+			return ErrCircuitOpen
+		}
+		b.probeInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// RecordSuccess closes the breaker and resets the failure count
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.setState(breakerClosed)
+	b.failures = 0
+	b.probeInFlight = false
+}
+
+// ReleaseProbe ends a half-open probe whose outcome says nothing about USDA's
+// health (e.g. the caller gave up), so the next call may probe instead
+func (b *CircuitBreaker) ReleaseProbe() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.probeInFlight = false
+}
+
+// RecordFailure counts a failure, opening the breaker at the threshold.
+// A failed half-open probe reopens it immediately.
+func (b *CircuitBreaker) RecordFailure() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.failures++
+	b.probeInFlight = false
+	if b.state == breakerHalfOpen || b.failures >= b.failureThreshold {
+		b.setState(breakerOpen)
+		b.openedAt = b.now()
+	}
+}
+
+// Failures returns the current consecutive failure count
+func (b *CircuitBreaker) Failures() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.failures
+}
+
+// State returns "closed", "open", or "half-open"
+func (b *CircuitBreaker) State() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.state
+}
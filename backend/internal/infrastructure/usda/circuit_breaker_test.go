@@ -0,0 +1,197 @@
+package usda
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/macrolens/backend/internal/domain"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCircuitBreaker_Defaults(t *testing.T) {
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{})
+
+	assert.Equal(t, defaultBreakerFailureThreshold, breaker.failureThreshold)
+	assert.Equal(t, defaultBreakerOpenDuration, breaker.openDuration)
+	assert.False(t, breaker.countRetryAttempts)
+	assert.Equal(t, breakerClosed, breaker.State())
+}
+
+func TestCircuitBreaker_OpensAtThresholdAndProbes(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, OpenDuration: time.Minute})
+	breaker.now = func() time.Time { return now }
+
+	breaker.RecordFailure()
+	require.NoError(t, breaker.Allow())
+	breaker.RecordFailure()
+	assert.Equal(t, breakerOpen, breaker.State())
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+
+	// After the cooldown a single probe is let through
+	now = now.Add(time.Minute)
+	require.NoError(t, breaker.Allow())
+	assert.Equal(t, breakerHalfOpen, breaker.State())
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen, "only one probe at a time")
+
+	// A failed probe reopens immediately
+	breaker.RecordFailure()
+	assert.Equal(t, breakerOpen, breaker.State())
+
+	// A successful probe closes the breaker
+	now = now.Add(time.Minute)
+	require.NoError(t, breaker.Allow())
+	breaker.RecordSuccess()
+	assert.Equal(t, breakerClosed, breaker.State())
+	assert.Equal(t, 0, breaker.Failures())
+}
+
+func TestCircuitBreaker_StateMetric(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Minute})
+	breaker.now = func() time.Time { return now }
+	assert.Equal(t, 0.0, testutil.ToFloat64(usdaBreakerState))
+
+	breaker.RecordFailure()
+	assert.Equal(t, 2.0, testutil.ToFloat64(usdaBreakerState), "open")
+
+	now = now.Add(time.Minute)
+	require.NoError(t, breaker.Allow())
+	assert.Equal(t, 1.0, testutil.ToFloat64(usdaBreakerState), "half-open")
+
+	breaker.RecordSuccess()
+	assert.Equal(t, 0.0, testutil.ToFloat64(usdaBreakerState), "closed")
+}
+
+func TestSearchFoods_RetriesCountAsOneBreakerFailure(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-api-key", server.URL)
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 3})
+	client.SetCircuitBreaker(breaker)
+
+	_, err := client.SearchFoods(context.Background(), "flaky")
+
+	require.ErrorIs(t, err, domain.ErrUSDAAPIFailure)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	assert.Equal(t, 1, breaker.Failures(), "one logical request is one breaker failure")
+	assert.Equal(t, breakerClosed, breaker.State())
+}
+
+func TestSearchFoods_CountRetryAttempts(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-api-key", server.URL)
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 3, CountRetryAttempts: true})
+	client.SetCircuitBreaker(breaker)
+
+	_, err := client.SearchFoods(context.Background(), "flaky")
+
+	require.ErrorIs(t, err, domain.ErrUSDAAPIFailure)
+	assert.Equal(t, 3, breaker.Failures())
+	assert.Equal(t, breakerOpen, breaker.State())
+}
+
+func TestSearchFoods_HalfOpenProbeDecodeErrorReopens(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !healthy.Load() {
+			w.Write([]byte(`{"foods": [`))
+			return
+		}
+		w.Write([]byte(`{"foods": [{"fdcId": 1, "description": "Milk, whole"}]}`))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Minute})
+	breaker.now = func() time.Time { return now }
+	breaker.RecordFailure()
+	client := NewClient("test-api-key", server.URL)
+	client.SetCircuitBreaker(breaker)
+	ctx := context.Background()
+
+	// The probe fails to decode, which must reopen the breaker rather than leave it stuck half-open
+	now = now.Add(time.Minute)
+	_, err := client.SearchFoods(ctx, "whole milk")
+	require.ErrorContains(t, err, "failed to decode response")
+	assert.Equal(t, breakerOpen, breaker.State())
+
+	// Once USDA recovers, the next probe closes it
+	healthy.Store(true)
+	now = now.Add(time.Minute)
+	_, err = client.SearchFoods(ctx, "whole milk")
+	require.NoError(t, err)
+	assert.Equal(t, breakerClosed, breaker.State())
+}
+
+func TestCircuitBreaker_ReleaseProbe(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Minute})
+	breaker.now = func() time.Time { return now }
+	breaker.RecordFailure()
+
+	now = now.Add(time.Minute)
+	require.NoError(t, breaker.Allow())
+	breaker.ReleaseProbe()
+	assert.Equal(t, breakerHalfOpen, breaker.State())
+	assert.NoError(t, breaker.Allow(), "a released probe lets the next call probe")
+}
+
+func TestGetFoodDetails_OpenBreakerFailsFast(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-api-key", server.URL)
+	client.SetCircuitBreaker(NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, OpenDuration: time.Hour}))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.GetFoodDetails(ctx, "123")
+		require.ErrorIs(t, err, domain.ErrUSDAAPIFailure)
+	}
+
+	_, err := client.GetFoodDetails(ctx, "123")
+	assert.ErrorIs(t, err, domain.ErrUSDAAPIFailure)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests), "open breaker should not reach the server")
+}
+
+func TestGetFoodDetails_NotFoundDoesNotTripBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-api-key", server.URL)
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1})
+	client.SetCircuitBreaker(breaker)
+
+	_, err := client.GetFoodDetails(context.Background(), "999")
+
+	assert.ErrorIs(t, err, domain.ErrProductNotFound)
+	assert.Equal(t, breakerClosed, breaker.State())
+}
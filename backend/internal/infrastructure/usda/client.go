@@ -1,12 +1,18 @@
 package usda
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/macrolens/backend/internal/domain"
@@ -17,85 +23,276 @@ const (
 	// maxErrorBodySize limits how much of an error response body we read
 	// to prevent memory issues from large error responses
 	maxErrorBodySize = 4096
+
+	// defaultDataTypes are the data types searched when neither the caller nor
+	// ClientOptions.DataTypes chooses
+	defaultDataTypes = "Survey (FNDDS),Foundation,SR Legacy,Branded"
+
+	// defaultPageSize is the number of search results requested by SearchFoods
+	defaultPageSize = 10
+
+	// maxPageSize is the largest page USDA FoodData Central will return
+	maxPageSize = 200
+
+	// defaultTimeout bounds a single USDA HTTP request
+	defaultTimeout = 30 * time.Second
+
+	// defaultMaxRetries is how many times a failed search is retried (3 attempts in all)
+	defaultMaxRetries = 2
+
+	// minAttemptTime is the least time a retry needs before the caller's deadline
+	// to be worth making; retries that can't fit it after backing off are skipped
+	minAttemptTime = 100 * time.Millisecond
+
+	// defaultDetailsCacheTTL is how long cached food details are kept; FDC
+	// records rarely change once published
+	defaultDetailsCacheTTL = 24 * time.Hour
 )
 
 // Client handles communication with the USDA FoodData Central API
 type Client struct {
 	httpClient  *http.Client
-	apiKey      string
+	keys        *keyPool
 	baseURL     string
 	rateLimiter *rate.Limiter
-	debug       bool
+	maxRetries  int             // Retries after a failed search attempt; 0 makes a single attempt
+	breaker     *CircuitBreaker // nil disables the circuit breaker
+	randFloat   func() float64  // Jitter source in [0, 1); injectable for tests
+
+	detailsCache    domain.CacheRepository // nil disables food details caching
+	detailsCacheTTL time.Duration
+	cacheKeyPrefix  string // "{namespace}:{version}:" or "{version}:", as in the nutrition cache
+
+	dataTypes string // dataType param used when a search doesn't choose one
+}
+
+// ClientOptions tunes the USDA client
+type ClientOptions struct {
+	Timeout    time.Duration // Per-request HTTP timeout; 0 uses the default 30s
+	MaxRetries int           // Retries after a failed search on 5xx, 429 or transport errors; 0 disables retries. Food details aren't retried.
+
+	// DetailsCache, when set, caches GetFoodDetails results by FDC ID for
+	// DetailsCacheTTL (0 uses the default 24h), so repeat lookups skip USDA
+	DetailsCache    domain.CacheRepository
+	DetailsCacheTTL time.Duration
+
+	// CacheNamespace prefixes details cache keys, matching the nutrition cache's namespace
+	CacheNamespace string
+
+	// DataTypes are the FDC data types searched, in priority order, when a search
+	// sets none through its SearchOptions; empty uses the built-in
+	// Survey (FNDDS), Foundation, SR Legacy, Branded order
+	DataTypes []string
 }
 
-// NewClient creates a new USDA API client
+// NewClient creates a new USDA API client with the default timeout and retries.
+// apiKey may be a comma-separated list of keys to rotate between.
 func NewClient(apiKey, baseURL string) *Client {
-	// USDA allows 1000 requests per hour
+	return NewClientWithOptions(apiKey, baseURL, ClientOptions{MaxRetries: defaultMaxRetries})
+}
+
+// NewClientWithOptions creates a new USDA API client with a custom timeout and retry count
+func NewClientWithOptions(apiKey, baseURL string, opts ClientOptions) *Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.DetailsCacheTTL <= 0 {
+		opts.DetailsCacheTTL = defaultDetailsCacheTTL
+	}
+
+	dataTypes := defaultDataTypes
+	if len(opts.DataTypes) > 0 {
+		dataTypes = strings.Join(opts.DataTypes, ",")
+	}
+
+	keys := newKeyPool(apiKey)
+
+	// USDA allows 1000 requests per hour per key
 	// rate.Limit is requests per second, so 1000/3600 ≈ 0.278 requests/sec
-	limiter := rate.NewLimiter(rate.Limit(0.278), 10) // burst of 10 requests
+	limiter := rate.NewLimiter(rate.Limit(0.278*float64(keys.size())), 10*keys.size()) // burst of 10 requests per key
 
 	return &Client{
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: opts.Timeout,
 		},
-		apiKey:      apiKey,
+		keys:        keys,
 		baseURL:     baseURL,
 		rateLimiter: limiter,
-		debug:       false, // Set to true only for local development
+		maxRetries:  opts.MaxRetries,
+		randFloat:   rand.Float64,
+
+		detailsCache:    opts.DetailsCache,
+		detailsCacheTTL: opts.DetailsCacheTTL,
+		cacheKeyPrefix:  domain.CacheKeyPrefix(opts.CacheNamespace),
+
+		dataTypes: dataTypes,
 	}
 }
 
-// SetDebug enables or disables debug logging
-func (c *Client) SetDebug(enabled bool) {
-	c.debug = enabled
+// KeyCount returns the number of API keys requests rotate between
+func (c *Client) KeyCount() int {
+	return c.keys.size()
 }
 
-// doRequest executes an HTTP GET request with proper headers and error handling
-func (c *Client) doRequest(ctx context.Context, reqURL string) (*http.Response, error) {
-	// Create request
-	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// SetCircuitBreaker enables a circuit breaker around USDA calls (nil disables it)
+func (c *Client) SetCircuitBreaker(breaker *CircuitBreaker) {
+	c.breaker = breaker
+}
+
+// allowRequest checks the circuit breaker before any rate limiter token is spent
+func (c *Client) allowRequest(ctx context.Context) error {
+	if c.breaker == nil {
+		return nil
+	}
+	if err := c.breaker.Allow(); err != nil {
+		c.debugLog(ctx, "Circuit breaker open, failing fast")
+		return fmt.Errorf("%w: %w", domain.ErrUSDAAPIFailure, err)
+	}
+	return nil
+}
+
+// recordAttemptFailure counts a failed retry attempt when the breaker is
+// configured to count attempts rather than logical requests
+func (c *Client) recordAttemptFailure() {
+	if c.breaker != nil && c.breaker.countRetryAttempts {
+		c.breaker.RecordFailure()
 	}
-	req.Header.Set("User-Agent", "MacroLens/1.0")
+}
 
-	// Execute request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %v", domain.ErrUSDAAPIFailure, err)
+// recordOutcome reports the final result of a logical request to the breaker.
+// Not-found is a healthy response and a caller that gave up (ctx done) says nothing
+// about USDA; every other error, including unreadable or undecodable responses,
+// counts against it, so a half-open probe always resolves. retriesExhausted is true
+// when err ended a run of failed attempts that were each already recordable.
+func (c *Client) recordOutcome(ctx context.Context, err error, retriesExhausted bool) {
+	if c.breaker == nil {
+		return
+	}
+	switch {
+	case err == nil || errors.Is(err, domain.ErrProductNotFound):
+		c.breaker.RecordSuccess()
+	case ctx.Err() != nil:
+		c.breaker.ReleaseProbe()
+	case retriesExhausted && c.breaker.countRetryAttempts:
+		// Retry attempts were already counted individually
+	default:
+		c.breaker.RecordFailure()
 	}
+}
+
+// doRequest executes an HTTP GET request with proper headers and error handling,
+// signed with the next API key in rotation. When USDA rate limits (429) or rejects
+// (403) a key and other keys are configured, the key is quarantined and the request
+// is repeated with the next one.
+func (c *Client) doRequest(ctx context.Context, endpoint string, params url.Values) (*http.Response, error) {
+	return c.doRequestWithBody(ctx, http.MethodGet, endpoint, params, nil)
+}
+
+// doRequestWithBody is doRequest for any method, sending body as JSON when it's
+// non-nil. The body is resent in full if the request moves to another key.
+func (c *Client) doRequestWithBody(ctx context.Context, method, endpoint string, params url.Values, body []byte) (*http.Response, error) {
+	for {
+		key, index, err := c.keys.acquire()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", domain.ErrUSDAAPIFailure, err)
+		}
+		params.Set("api_key", key)
+
+		// Create request
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s?%s", endpoint, params.Encode()), reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("User-Agent", "MacroLens/1.0")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
 
-	return resp, nil
+		// Execute request
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrUSDAAPIFailure, err)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusForbidden {
+			if c.keys.quarantineKey(index) {
+				resp.Body.Close()
+				usdaKeyQuarantinesTotal.Inc()
+				c.debugLog(ctx, "API key quarantined, rotating", "key", index+1, "keys", c.keys.size(), "status", resp.StatusCode)
+				continue
+			}
+		}
+
+		return resp, nil
+	}
 }
 
-// SearchFoods searches for foods in the USDA database
+// SearchFoods searches for foods in the USDA database using the default page size
+// and data types
 func (c *Client) SearchFoods(ctx context.Context, query string) (*domain.USDASearchResponse, error) {
-	c.debugLog("SearchFoods called with query: %q", query)
+	return c.SearchFoodsWithOptions(ctx, query, domain.SearchOptions{})
+}
+
+// SearchFoodsWithOptions searches for foods with a caller-chosen page size, page
+// and data types, e.g. to fetch a wider candidate pool or only Foundation foods
+func (c *Client) SearchFoodsWithOptions(ctx context.Context, query string, opts domain.SearchOptions) (*domain.USDASearchResponse, error) {
+	if err := c.allowRequest(ctx); err != nil {
+		observeOutcome(operationSearch, err)
+		return nil, err
+	}
+
+	result, retriesExhausted, err := c.searchFoods(ctx, query, opts)
+	c.recordOutcome(ctx, err, retriesExhausted)
+	observeOutcome(operationSearch, err)
+	return result, err
+}
+
+// searchFoods performs the search with retries. retriesExhausted reports whether
+// the error is the last of a run of retryable attempt failures.
+func (c *Client) searchFoods(ctx context.Context, query string, opts domain.SearchOptions) (*domain.USDASearchResponse, bool, error) {
+	c.debugLog(ctx, "SearchFoods called", "query", query)
 
 	// Build request URL
 	endpoint := fmt.Sprintf("%s/v1/foods/search", c.baseURL)
 	params := url.Values{}
 	params.Add("query", query)
-	params.Add("api_key", c.apiKey)
-	params.Add("dataType", "Survey (FNDDS),Foundation,Branded") // Focus on relevant data types
-	params.Add("pageSize", "10")                                // Get top 10 results
-
-	reqURL := fmt.Sprintf("%s?%s", endpoint, params.Encode())
+	params.Add("dataType", searchDataTypes(opts, c.dataTypes))
+	params.Add("pageSize", strconv.Itoa(searchPageSize(opts)))
+	if opts.PageNumber > 0 {
+		params.Add("pageNumber", strconv.Itoa(opts.PageNumber))
+	}
 
-	// Retry up to 3 times for transient failures
+	// Retry transient failures up to maxRetries times
 	var lastErr error
-	for attempt := 1; attempt <= 3; attempt++ {
+	for attempt := 1; attempt <= c.maxRetries+1; attempt++ {
 		// Wait for rate limiter
 		if err := c.rateLimiter.Wait(ctx); err != nil {
-			return nil, fmt.Errorf("rate limiter error: %w", err)
+			return nil, false, fmt.Errorf("rate limiter error: %w", err)
 		}
 
 		// Execute request
-		resp, err := c.doRequest(ctx, reqURL)
+		resp, err := c.doRequest(ctx, endpoint, params)
+		if errors.Is(err, ErrAPIKeysExhausted) {
+			// Retrying can't help until a quarantine expires
+			return nil, false, err
+		}
 		if err != nil {
-			c.debugLog("Request error (attempt %d): %v", attempt, err)
+			c.debugLog(ctx, "Request error", "attempt", attempt, "error", err)
 			lastErr = err
-			time.Sleep(exponentialBackoff(attempt))
+			retry, err := c.retryableFailure(ctx, attempt)
+			if err != nil {
+				return nil, false, err
+			}
+			if !retry {
+				break
+			}
 			continue
 		}
 
@@ -106,64 +303,132 @@ func (c *Client) SearchFoods(ctx context.Context, query string) (*domain.USDASea
 			resp.Body.Close()
 
 			if readErr != nil {
-				c.debugLog("Error reading response body (attempt %d): %v", attempt, readErr)
+				c.debugLog(ctx, "Error reading response body", "attempt", attempt, "error", readErr)
 			}
 
-			c.debugLog("API error (attempt %d) - Status: %d, Body: %s", attempt, resp.StatusCode, string(body))
+			c.debugLog(ctx, "API error", "attempt", attempt, "status", resp.StatusCode, "body", string(body))
 
 			if resp.StatusCode == http.StatusNotFound {
-				return nil, domain.ErrProductNotFound
+				return nil, false, domain.ErrProductNotFound
 			}
 
 			// Retry only on server errors (5xx) and rate limiting (429)
 			if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
 				lastErr = fmt.Errorf("%w: status %d", domain.ErrUSDAAPIFailure, resp.StatusCode)
-				time.Sleep(exponentialBackoff(attempt))
+				retry, err := c.retryableFailure(ctx, attempt)
+				if err != nil {
+					return nil, false, err
+				}
+				if !retry {
+					break
+				}
 				continue
 			}
 
 			// For other 4xx errors, don't retry as it's likely a client error
-			return nil, fmt.Errorf("%w: status %d", domain.ErrUSDAAPIFailure, resp.StatusCode)
+			return nil, false, fmt.Errorf("%w: status %d", domain.ErrUSDAAPIFailure, resp.StatusCode)
 		}
 
 		// Read successful response body
 		body, err := io.ReadAll(resp.Body)
 		resp.Body.Close()
 		if err != nil {
-			c.debugLog("Error reading response body: %v", err)
-			return nil, fmt.Errorf("failed to read response body: %w", err)
+			c.debugLog(ctx, "Error reading response body", "error", err)
+			return nil, false, fmt.Errorf("failed to read response body: %w", err)
 		}
 
 		// Parse response
 		var searchResp domain.USDASearchResponse
 		if err := json.Unmarshal(body, &searchResp); err != nil {
-			c.debugLog("JSON decode error: %v", err)
-			return nil, fmt.Errorf("failed to decode response: %w", err)
+			c.debugLog(ctx, "JSON decode error", "error", err)
+			return nil, false, fmt.Errorf("failed to decode response: %w", err)
 		}
 
 		if len(searchResp.Foods) == 0 {
-			c.debugLog("No foods found for query: %q", query)
-			return nil, domain.ErrProductNotFound
+			c.debugLog(ctx, "No foods found", "query", query)
+			return nil, false, domain.ErrProductNotFound
 		}
 
-		c.debugLog("Found %d foods for query: %q", len(searchResp.Foods), query)
-		return &searchResp, nil
+		c.debugLog(ctx, "Found foods", "count", len(searchResp.Foods), "query", query)
+		return &searchResp, false, nil
 	}
 
-	c.debugLog("All retries failed for query: %q", query)
-	return nil, lastErr
+	c.debugLog(ctx, "All retries failed", "query", query)
+	return nil, true, lastErr
 }
 
-// debugLog logs a message only when debug mode is enabled
-func (c *Client) debugLog(format string, args ...interface{}) {
-	if c.debug {
-		fmt.Printf("[USDA] "+format+"\n", args...)
+// retryableFailure records a failed search attempt and, if another attempt
+// follows, counts the retry and backs off before it. It reports false when no
+// attempt follows: retries are used up, or the backoff plus minAttemptTime
+// would run past ctx's deadline, so sleeping would only delay the failure.
+// It returns ctx's error when ctx ends during the backoff.
+func (c *Client) retryableFailure(ctx context.Context, attempt int) (bool, error) {
+	c.recordAttemptFailure()
+	if attempt > c.maxRetries {
+		return false, nil
 	}
+
+	backoff := c.exponentialBackoff(attempt)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff+minAttemptTime {
+		c.debugLog(ctx, "Skipping retry: backoff won't fit before the deadline", "backoff", backoff)
+		return false, nil
+	}
+
+	usdaRetriesTotal.WithLabelValues(operationSearch).Inc()
+	select {
+	case <-time.After(backoff):
+		return true, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// searchPageSize returns the requested page size, clamped to what USDA accepts
+func searchPageSize(opts domain.SearchOptions) int {
+	switch {
+	case opts.PageSize == 0:
+		return defaultPageSize
+	case opts.PageSize < 1:
+		return 1
+	case opts.PageSize > maxPageSize:
+		return maxPageSize
+	}
+	return opts.PageSize
+}
+
+// searchDataTypes returns the dataType param: the requested data types, or the
+// client's fallback when the search chose none
+func searchDataTypes(opts domain.SearchOptions, fallback string) string {
+	if len(opts.DataTypes) > 0 {
+		return strings.Join(opts.DataTypes, ",")
+	}
+	return fallback
+}
+
+// debugLog logs msg with its key-value args at debug level through slog, tagged
+// with the USDA component and the request ID from ctx when there is one
+func (c *Client) debugLog(ctx context.Context, msg string, args ...any) {
+	logger := slog.Default()
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	args = append([]any{"component", "usda"}, args...)
+	if requestID := domain.RequestID(ctx); requestID != "" {
+		args = append(args, "requestId", requestID)
+	}
+	logger.DebugContext(ctx, msg, args...)
 }
 
-// exponentialBackoff returns the sleep duration for a given retry attempt
+// exponentialBackoff returns the sleep duration for a given retry attempt.
+// Uses full jitter: a random duration up to maxBackoff(attempt), so concurrent
+// clients don't retry a recovering API in lockstep.
+func (c *Client) exponentialBackoff(attempt int) time.Duration {
+	return time.Duration(c.randFloat() * float64(maxBackoff(attempt)))
+}
+
+// maxBackoff returns the upper bound on the backoff for a given retry attempt
 // Uses true exponential backoff: 500ms, 1000ms, 2000ms
-func exponentialBackoff(attempt int) time.Duration {
+func maxBackoff(attempt int) time.Duration {
 	return time.Duration(500*(1<<(attempt-1))) * time.Millisecond
 }
 
@@ -176,6 +441,27 @@ func readLimitedBody(r io.ReadCloser, maxBytes int64) ([]byte, error) {
 
 // GetFoodDetails retrieves detailed nutrition information for a specific food by FDC ID
 func (c *Client) GetFoodDetails(ctx context.Context, fdcID string) (*domain.USDAFood, error) {
+	// Cached details are served even while the circuit breaker is open
+	if food, ok := c.cachedFoodDetails(ctx, fdcID); ok {
+		return food, nil
+	}
+
+	if err := c.allowRequest(ctx); err != nil {
+		observeOutcome(operationDetails, err)
+		return nil, err
+	}
+
+	food, err := c.getFoodDetails(ctx, fdcID)
+	c.recordOutcome(ctx, err, false)
+	observeOutcome(operationDetails, err)
+	if err == nil {
+		c.storeFoodDetails(ctx, fdcID, food)
+	}
+	return food, err
+}
+
+// getFoodDetails performs a single food details request
+func (c *Client) getFoodDetails(ctx context.Context, fdcID string) (*domain.USDAFood, error) {
 	// Wait for rate limiter
 	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, fmt.Errorf("rate limiter error: %w", err)
@@ -183,13 +469,9 @@ func (c *Client) GetFoodDetails(ctx context.Context, fdcID string) (*domain.USDA
 
 	// Build request URL
 	endpoint := fmt.Sprintf("%s/v1/food/%s", c.baseURL, fdcID)
-	params := url.Values{}
-	params.Add("api_key", c.apiKey)
-
-	reqURL := fmt.Sprintf("%s?%s", endpoint, params.Encode())
 
 	// Execute request
-	resp, err := c.doRequest(ctx, reqURL)
+	resp, err := c.doRequest(ctx, endpoint, url.Values{})
 	if err != nil {
 		return nil, err
 	}
@@ -202,7 +484,7 @@ func (c *Client) GetFoodDetails(ctx context.Context, fdcID string) (*domain.USDA
 	if resp.StatusCode != http.StatusOK {
 		body, readErr := readLimitedBody(resp.Body, maxErrorBodySize)
 		if readErr != nil {
-			c.debugLog("Error reading error response body: %v", readErr)
+			c.debugLog(ctx, "Error reading error response body", "error", readErr)
 		}
 		return nil, fmt.Errorf("%w: status %d, body: %s", domain.ErrUSDAAPIFailure, resp.StatusCode, string(body))
 	}
@@ -1,14 +1,19 @@
 package usda
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/macrolens/backend/internal/domain"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -17,29 +22,52 @@ func TestNewClient(t *testing.T) {
 	client := NewClient("test-api-key", "https://api.example.com")
 
 	assert.NotNil(t, client)
-	assert.Equal(t, "test-api-key", client.apiKey)
+	assert.Equal(t, []string{"test-api-key"}, client.keys.keys)
 	assert.Equal(t, "https://api.example.com", client.baseURL)
 	assert.NotNil(t, client.httpClient)
 	assert.NotNil(t, client.rateLimiter)
-	assert.False(t, client.debug)
 }
 
-func TestSetDebug(t *testing.T) {
-	client := NewClient("test-api-key", "https://api.example.com")
+func TestNewClientWithOptions(t *testing.T) {
+	t.Run("zero options use the default timeout and no retries", func(t *testing.T) {
+		client := NewClientWithOptions("test-api-key", "https://api.example.com", ClientOptions{})
+
+		assert.Equal(t, 30*time.Second, client.httpClient.Timeout)
+		assert.Equal(t, 0, client.maxRetries)
+	})
+
+	t.Run("NewClient retries twice", func(t *testing.T) {
+		assert.Equal(t, 2, NewClient("test-api-key", "https://api.example.com").maxRetries)
+	})
+
+	t.Run("applies timeout and retries", func(t *testing.T) {
+		client := NewClientWithOptions("test-api-key", "https://api.example.com", ClientOptions{
+			Timeout:    5 * time.Second,
+			MaxRetries: 5,
+		})
+
+		assert.Equal(t, 5*time.Second, client.httpClient.Timeout)
+		assert.Equal(t, 5, client.maxRetries)
+	})
+
+	t.Run("times out slow responses", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer server.Close()
 
-	assert.False(t, client.debug)
+		client := NewClientWithOptions("test-api-key", server.URL, ClientOptions{Timeout: 20 * time.Millisecond})
 
-	client.SetDebug(true)
-	assert.True(t, client.debug)
+		_, err := client.GetFoodDetails(context.Background(), "123")
 
-	client.SetDebug(false)
-	assert.False(t, client.debug)
+		assert.ErrorIs(t, err, domain.ErrUSDAAPIFailure)
+	})
 }
 
 func TestExponentialBackoff(t *testing.T) {
 	tests := []struct {
-		attempt  int
-		expected time.Duration
+		attempt int
+		max     time.Duration
 	}{
 		{1, 500 * time.Millisecond},
 		{2, 1000 * time.Millisecond},
@@ -48,8 +76,20 @@ func TestExponentialBackoff(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run("", func(t *testing.T) {
-			result := exponentialBackoff(tt.attempt)
-			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.max, maxBackoff(tt.attempt))
+
+			client := NewClient("test-api-key", "https://api.example.com")
+			for i := 0; i < 100; i++ {
+				result := client.exponentialBackoff(tt.attempt)
+				assert.GreaterOrEqual(t, result, time.Duration(0))
+				assert.LessOrEqual(t, result, tt.max)
+			}
+
+			// Injected randomness pins the jitter to known points in the range
+			client.randFloat = func() float64 { return 0 }
+			assert.Equal(t, time.Duration(0), client.exponentialBackoff(tt.attempt))
+			client.randFloat = func() float64 { return 0.5 }
+			assert.Equal(t, tt.max/2, client.exponentialBackoff(tt.attempt))
 		})
 	}
 }
@@ -88,6 +128,75 @@ func TestSearchFoods_Success(t *testing.T) {
 	assert.Equal(t, "Test Food", result.Foods[0].Description)
 }
 
+func TestSearchFoods_DataTypeOrder(t *testing.T) {
+	testCases := []struct {
+		name       string
+		configured []string
+		dataTypes  []string
+		want       string
+	}{
+		{"default order", nil, nil, "Survey (FNDDS),Foundation,SR Legacy,Branded"},
+		{"order from options", nil, []string{"Branded", "Survey (FNDDS)", "Foundation"}, "Branded,Survey (FNDDS),Foundation"},
+		{"configured data types", []string{"SR Legacy", "Foundation"}, nil, "SR Legacy,Foundation"},
+		{"options override configured", []string{"SR Legacy", "Foundation"}, []string{"Branded"}, "Branded"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.URL.Query().Get("dataType")
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(domain.USDASearchResponse{Foods: []domain.USDAFood{{FdcID: 1}}})
+			}))
+			defer server.Close()
+
+			client := NewClientWithOptions("test-api-key", server.URL, ClientOptions{DataTypes: tc.configured})
+			_, err := client.SearchFoodsWithOptions(context.Background(), "whole milk", domain.SearchOptions{DataTypes: tc.dataTypes})
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestSearchFoodsWithOptions(t *testing.T) {
+	testCases := []struct {
+		name           string
+		opts           domain.SearchOptions
+		wantPageSize   string
+		wantDataType   string
+		wantPageNumber string
+	}{
+		{"defaults", domain.SearchOptions{}, "10", "Survey (FNDDS),Foundation,SR Legacy,Branded", ""},
+		{"foundation only", domain.SearchOptions{PageSize: 50, DataTypes: []string{"Foundation"}}, "50", "Foundation", ""},
+		{"page number", domain.SearchOptions{PageSize: 25, PageNumber: 3}, "25", "Survey (FNDDS),Foundation,SR Legacy,Branded", "3"},
+		{"page size clamped to max", domain.SearchOptions{PageSize: 500}, "200", "Survey (FNDDS),Foundation,SR Legacy,Branded", ""},
+		{"negative page size clamped to min", domain.SearchOptions{PageSize: -5}, "1", "Survey (FNDDS),Foundation,SR Legacy,Branded", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var query url.Values
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query = r.URL.Query()
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(domain.USDASearchResponse{Foods: []domain.USDAFood{{FdcID: 1}}})
+			}))
+			defer server.Close()
+
+			client := NewClient("test-api-key", server.URL)
+
+			_, err := client.SearchFoodsWithOptions(context.Background(), "whole milk", tc.opts)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantPageSize, query.Get("pageSize"))
+			assert.Equal(t, tc.wantDataType, query.Get("dataType"))
+			assert.Equal(t, tc.wantPageNumber, query.Get("pageNumber"))
+		})
+	}
+}
+
 func TestSearchFoods_NotFound(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
@@ -154,23 +263,93 @@ func TestSearchFoods_ServerError_Retries(t *testing.T) {
 	assert.Equal(t, 3, attempts)
 }
 
+func TestClientMetrics(t *testing.T) {
+	// Counters are process-wide, so compare against the values before each call
+	counts := func(operation string) [3]float64 {
+		return [3]float64{
+			testutil.ToFloat64(usdaRequestsTotal.WithLabelValues(operation)),
+			testutil.ToFloat64(usdaRetriesTotal.WithLabelValues(operation)),
+			testutil.ToFloat64(usdaFailuresTotal.WithLabelValues(operation)),
+		}
+	}
+
+	t.Run("search retries then succeeds", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts < 2 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(domain.USDASearchResponse{Foods: []domain.USDAFood{{FdcID: 1}}})
+		}))
+		defer server.Close()
+
+		client := NewClient("test-api-key", server.URL)
+		client.randFloat = func() float64 { return 0 }
+		before := counts(operationSearch)
+
+		_, err := client.SearchFoods(context.Background(), "milk")
+
+		require.NoError(t, err)
+		after := counts(operationSearch)
+		assert.Equal(t, [3]float64{1, 1, 0}, [3]float64{after[0] - before[0], after[1] - before[1], after[2] - before[2]})
+	})
+
+	t.Run("details failure counts as a failure", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		client := NewClient("test-api-key", server.URL)
+		before := counts(operationDetails)
+
+		_, err := client.GetFoodDetails(context.Background(), "123")
+
+		require.ErrorIs(t, err, domain.ErrUSDAAPIFailure)
+		after := counts(operationDetails)
+		assert.Equal(t, [3]float64{1, 0, 1}, [3]float64{after[0] - before[0], after[1] - before[1], after[2] - before[2]})
+	})
+
+	t.Run("not found is not a failure", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		client := NewClient("test-api-key", server.URL)
+		before := counts(operationDetails)
+
+		_, err := client.GetFoodDetails(context.Background(), "123")
+
+		require.ErrorIs(t, err, domain.ErrProductNotFound)
+		after := counts(operationDetails)
+		assert.Equal(t, before[2], after[2])
+	})
+}
+
 func TestSearchFoods_ClientError_NoRetry(t *testing.T) {
-	attempts := 0
+	for _, maxRetries := range []int{0, 2, 5} {
+		t.Run(fmt.Sprintf("max retries %d", maxRetries), func(t *testing.T) {
+			attempts := 0
 
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		attempts++
-		w.WriteHeader(http.StatusBadRequest)
-	}))
-	defer server.Close()
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.WriteHeader(http.StatusBadRequest)
+			}))
+			defer server.Close()
 
-	client := NewClient("test-api-key", server.URL)
-	ctx := context.Background()
+			client := NewClientWithOptions("test-api-key", server.URL, ClientOptions{MaxRetries: maxRetries})
+			ctx := context.Background()
 
-	result, err := client.SearchFoods(ctx, "bad-request")
+			result, err := client.SearchFoods(ctx, "bad-request")
 
-	assert.Nil(t, result)
-	assert.Error(t, err)
-	assert.Equal(t, 1, attempts) // Should not retry 4xx errors
+			assert.Nil(t, result)
+			assert.Error(t, err)
+			assert.Equal(t, 1, attempts) // Should not retry 4xx errors
+		})
+	}
 }
 
 func TestSearchFoods_TooManyRequests_Retries(t *testing.T) {
@@ -237,6 +416,70 @@ func TestSearchFoods_ContextCancelled(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestSearchFoods_RetryBudget(t *testing.T) {
+	newServer := func(attempts *int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*attempts++
+			time.Sleep(50 * time.Millisecond)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+	}
+
+	t.Run("skips a retry that can't fit before the deadline", func(t *testing.T) {
+		attempts := 0
+		server := newServer(&attempts)
+		defer server.Close()
+
+		client := NewClient("test-api-key", server.URL)
+		client.randFloat = func() float64 { return 1 } // 500ms backoff
+		ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		result, err := client.SearchFoods(ctx, "deadline-test")
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrUSDAAPIFailure)
+		assert.Equal(t, 1, attempts)
+		assert.Less(t, time.Since(start), 300*time.Millisecond)
+	})
+
+	t.Run("stops backing off when the context is cancelled", func(t *testing.T) {
+		attempts := 0
+		server := newServer(&attempts)
+		defer server.Close()
+
+		client := NewClient("test-api-key", server.URL)
+		client.randFloat = func() float64 { return 1 } // 500ms backoff
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		start := time.Now()
+		result, err := client.SearchFoods(ctx, "cancel-test")
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, attempts)
+		assert.Less(t, time.Since(start), 400*time.Millisecond)
+	})
+
+	t.Run("retries while the budget allows", func(t *testing.T) {
+		attempts := 0
+		server := newServer(&attempts)
+		defer server.Close()
+
+		client := NewClient("test-api-key", server.URL)
+		client.randFloat = func() float64 { return 0 }
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_, err := client.SearchFoods(ctx, "deadline-test")
+
+		assert.ErrorIs(t, err, domain.ErrUSDAAPIFailure)
+		assert.Equal(t, 3, attempts)
+	})
+}
+
 func TestGetFoodDetails_Success(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, "/v1/food/123456", r.URL.Path)
@@ -319,13 +562,32 @@ func TestGetFoodDetails_InvalidJSON(t *testing.T) {
 func TestDebugLog(t *testing.T) {
 	client := NewClient("test-api-key", "https://api.example.com")
 
-	// Should not panic when debug is false
-	client.debug = false
-	client.debugLog("test message %s", "arg")
+	// logAt installs a default logger at level for the test and returns its output
+	logAt := func(t *testing.T, level slog.Level) *bytes.Buffer {
+		var buf bytes.Buffer
+		previous := slog.Default()
+		slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: level})))
+		t.Cleanup(func() { slog.SetDefault(previous) })
+		return &buf
+	}
 
-	// Should not panic when debug is true
-	client.debug = true
-	client.debugLog("test message %s", "arg")
+	t.Run("silent above debug level", func(t *testing.T) {
+		buf := logAt(t, slog.LevelInfo)
+
+		client.debugLog(context.Background(), "test message", "query", "milk")
+
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("tags messages with the component and request ID", func(t *testing.T) {
+		buf := logAt(t, slog.LevelDebug)
+
+		client.debugLog(domain.WithRequestID(context.Background(), "abc-123"), "test message", "query", "milk")
+
+		output := buf.String()
+		assert.Contains(t, output, "level=DEBUG")
+		assert.Contains(t, output, `msg="test message" component=usda query=milk requestId=abc-123`)
+	})
 }
 
 func TestReadLimitedBody(t *testing.T) {
@@ -364,22 +626,38 @@ func TestReadLimitedBody(t *testing.T) {
 }
 
 func TestSearchFoods_AllRetriesFail(t *testing.T) {
-	attempts := 0
+	testCases := []struct {
+		maxRetries   int
+		wantAttempts int
+	}{
+		{maxRetries: 0, wantAttempts: 1},
+		{maxRetries: 1, wantAttempts: 2},
+		{maxRetries: 2, wantAttempts: 3},
+		{maxRetries: 4, wantAttempts: 5},
+	}
 
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		attempts++
-		w.WriteHeader(http.StatusInternalServerError)
-	}))
-	defer server.Close()
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("max retries %d", tc.maxRetries), func(t *testing.T) {
+			attempts := 0
 
-	client := NewClient("test-api-key", server.URL)
-	ctx := context.Background()
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer server.Close()
 
-	result, err := client.SearchFoods(ctx, "all-fail")
+			client := NewClientWithOptions("test-api-key", server.URL, ClientOptions{MaxRetries: tc.maxRetries})
+			client.randFloat = func() float64 { return 0 }
+			retriesBefore := testutil.ToFloat64(usdaRetriesTotal.WithLabelValues(operationSearch))
 
-	assert.Nil(t, result)
-	assert.Error(t, err)
-	assert.Equal(t, 3, attempts) // Should try 3 times
+			result, err := client.SearchFoods(context.Background(), "all-fail")
+
+			assert.Nil(t, result)
+			assert.ErrorIs(t, err, domain.ErrUSDAAPIFailure)
+			assert.Equal(t, tc.wantAttempts, attempts)
+			assert.Equal(t, float64(tc.maxRetries), testutil.ToFloat64(usdaRetriesTotal.WithLabelValues(operationSearch))-retriesBefore)
+		})
+	}
 }
 
 func TestSearchFoods_RequestCreationError(t *testing.T) {
@@ -0,0 +1,53 @@
+package usda
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// detailsCacheKey returns the cache key for a food's details. It shares the
+// namespaced, versioned prefix of the nutrition cache keys, so tenants sharing a
+// cache stay apart and a version bump retires old entries.
+func (c *Client) detailsCacheKey(fdcID string) string {
+	return c.cacheKeyPrefix + "usda:food:" + fdcID
+}
+
+// cachedFoodDetails returns the cached details for fdcID, if caching is enabled and
+// the food is cached. Cache errors count as misses.
+func (c *Client) cachedFoodDetails(ctx context.Context, fdcID string) (*domain.USDAFood, bool) {
+	if c.detailsCache == nil {
+		return nil, false
+	}
+
+	value, err := c.detailsCache.Get(ctx, c.detailsCacheKey(fdcID))
+	if err != nil {
+		return nil, false
+	}
+	if food, ok := value.(*domain.USDAFood); ok {
+		return food, true
+	}
+
+	// Caches that serialize values hand them back as maps
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	var food domain.USDAFood
+	if err := json.Unmarshal(raw, &food); err != nil || food.FdcID == 0 {
+		return nil, false
+	}
+	return &food, true
+}
+
+// storeFoodDetails caches the details fetched for fdcID. Failures are ignored; the
+// next lookup just goes to USDA again.
+func (c *Client) storeFoodDetails(ctx context.Context, fdcID string, food *domain.USDAFood) {
+	if c.detailsCache == nil || food == nil {
+		return
+	}
+	if err := c.detailsCache.Set(ctx, c.detailsCacheKey(fdcID), food, c.detailsCacheTTL); err != nil {
+		c.debugLog(ctx, "Failed to cache food details", "fdcId", fdcID, "error", err)
+	}
+}
@@ -0,0 +1,88 @@
+package usda
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+	"github.com/macrolens/backend/internal/infrastructure/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFoodDetails_Cache(t *testing.T) {
+	newServer := func(requests *int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*requests++
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(domain.USDAFood{
+				FdcID:       123456,
+				Description: "Detailed Food",
+				Nutrients: []domain.USDANutrient{
+					{NutrientID: 1003, NutrientName: "Protein", Value: 10.5, UnitName: "G"},
+				},
+			})
+		}))
+	}
+
+	t.Run("repeat lookup makes no request", func(t *testing.T) {
+		var requests int
+		server := newServer(&requests)
+		defer server.Close()
+		client := NewClientWithOptions("test-api-key", server.URL, ClientOptions{DetailsCache: cache.NewMemoryCache()})
+
+		first, err := client.GetFoodDetails(context.Background(), "123456")
+		require.NoError(t, err)
+		require.Equal(t, 1, requests)
+
+		second, err := client.GetFoodDetails(context.Background(), "123456")
+		require.NoError(t, err)
+		assert.Equal(t, 1, requests, "second lookup should be served from the cache")
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("different ids are cached separately", func(t *testing.T) {
+		var requests int
+		server := newServer(&requests)
+		defer server.Close()
+		client := NewClientWithOptions("test-api-key", server.URL, ClientOptions{DetailsCache: cache.NewMemoryCache()})
+
+		for _, id := range []string{"1", "2", "1"} {
+			_, err := client.GetFoodDetails(context.Background(), id)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, requests)
+	})
+
+	t.Run("keys share the nutrition cache's namespaced, versioned prefix", func(t *testing.T) {
+		var requests int
+		server := newServer(&requests)
+		defer server.Close()
+		detailsCache := cache.NewMemoryCache()
+		client := NewClientWithOptions("test-api-key", server.URL, ClientOptions{DetailsCache: detailsCache, CacheNamespace: "tenant-a"})
+
+		_, err := client.GetFoodDetails(context.Background(), "123456")
+		require.NoError(t, err)
+
+		exists, err := detailsCache.Exists(context.Background(), domain.CacheKeyPrefix("tenant-a")+"usda:food:123456")
+		require.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, "tenant-a:"+domain.CacheKeyVersion+":", domain.CacheKeyPrefix("tenant-a"))
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		var requests int
+		server := newServer(&requests)
+		defer server.Close()
+		client := NewClient("test-api-key", server.URL)
+
+		for range 2 {
+			_, err := client.GetFoodDetails(context.Background(), "123456")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, requests)
+	})
+}
@@ -0,0 +1,84 @@
+package usda
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrAPIKeysExhausted is returned (wrapped in domain.ErrUSDAAPIFailure) when every
+// configured API key is quarantined after USDA rejected or rate limited it
+var ErrAPIKeysExhausted = errors.New("all USDA API keys are rate limited or rejected")
+
+// defaultKeyQuarantine is how long a key USDA answered with 429 or 403 sits out
+const defaultKeyQuarantine = 5 * time.Minute
+
+// keyPool rotates requests across USDA API keys round-robin, so throughput scales
+// with the number of keys. With several keys, one USDA rejects is set aside for a
+// while and the next takes over; a single key is never quarantined.
+type keyPool struct {
+	mutex       sync.Mutex
+	keys        []string
+	next        int         // Index of the key to try first on the next request
+	quarantined []time.Time // Per key, when it may be used again
+	quarantine  time.Duration
+	now         func() time.Time // Clock; injectable for tests
+}
+
+// newKeyPool creates a pool from a comma-separated list of API keys. Blank entries
+// are dropped; an empty list keeps a single empty key so requests still go out.
+func newKeyPool(apiKeys string) *keyPool {
+	var keys []string
+	for _, key := range strings.Split(apiKeys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		keys = []string{""}
+	}
+
+	return &keyPool{
+		keys:        keys,
+		quarantined: make([]time.Time, len(keys)),
+		quarantine:  defaultKeyQuarantine,
+		now:         time.Now,
+	}
+}
+
+// size returns the number of configured keys
+func (p *keyPool) size() int {
+	return len(p.keys)
+}
+
+// acquire returns the next key in rotation that isn't quarantined, with its
+// index for quarantining it later
+func (p *keyPool) acquire() (string, int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := p.now()
+	for i := range p.keys {
+		index := (p.next + i) % len(p.keys)
+		if now.Before(p.quarantined[index]) {
+			continue
+		}
+		p.next = (index + 1) % len(p.keys)
+		return p.keys[index], index, nil
+	}
+	return "", 0, ErrAPIKeysExhausted
+}
+
+// quarantineKey sets the key at index aside after USDA rate limited or rejected it.
+// Reports false, leaving the key in use, when it is the only key.
+func (p *keyPool) quarantineKey(index int) bool {
+	if len(p.keys) == 1 {
+		return false
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.quarantined[index] = p.now().Add(p.quarantine)
+	return true
+}
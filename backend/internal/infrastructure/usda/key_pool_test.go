@@ -0,0 +1,145 @@
+package usda
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/macrolens/backend/internal/domain"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKeyPool(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"single key", "key-a", []string{"key-a"}},
+		{"comma-separated keys", "key-a, key-b,,key-c ", []string{"key-a", "key-b", "key-c"}},
+		{"empty keeps one blank key", "", []string{""}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, newKeyPool(tc.input).keys)
+		})
+	}
+}
+
+func TestKeyPool(t *testing.T) {
+	acquireAll := func(pool *keyPool, n int) []string {
+		var got []string
+		for range n {
+			key, _, err := pool.acquire()
+			require.NoError(t, err)
+			got = append(got, key)
+		}
+		return got
+	}
+
+	t.Run("rotates round-robin", func(t *testing.T) {
+		pool := newKeyPool("a,b,c")
+		assert.Equal(t, []string{"a", "b", "c", "a"}, acquireAll(pool, 4))
+	})
+
+	t.Run("skips quarantined keys until the quarantine expires", func(t *testing.T) {
+		now := time.Now()
+		pool := newKeyPool("a,b,c")
+		pool.now = func() time.Time { return now }
+
+		assert.True(t, pool.quarantineKey(1))
+		assert.Equal(t, []string{"a", "c", "a"}, acquireAll(pool, 3))
+
+		now = now.Add(defaultKeyQuarantine)
+		assert.Equal(t, []string{"b", "c", "a"}, acquireAll(pool, 3))
+	})
+
+	t.Run("reports exhaustion when every key is quarantined", func(t *testing.T) {
+		pool := newKeyPool("a,b")
+		pool.quarantineKey(0)
+		pool.quarantineKey(1)
+
+		_, _, err := pool.acquire()
+		assert.ErrorIs(t, err, ErrAPIKeysExhausted)
+	})
+
+	t.Run("never quarantines a single key", func(t *testing.T) {
+		pool := newKeyPool("a")
+		assert.False(t, pool.quarantineKey(0))
+		assert.Equal(t, []string{"a"}, acquireAll(pool, 1))
+	})
+}
+
+func TestClient_KeyRotation(t *testing.T) {
+	// newServer answers 200 for allowed keys and status for every other key,
+	// recording the key each request was signed with
+	newServer := func(status int, allowed ...string) (*httptest.Server, *[]string) {
+		var used []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.URL.Query().Get("api_key")
+			used = append(used, key)
+			for _, k := range allowed {
+				if k == key {
+					w.Header().Set("Content-Type", "application/json")
+					json.NewEncoder(w).Encode(domain.USDASearchResponse{Foods: []domain.USDAFood{{FdcID: 1}}})
+					return
+				}
+			}
+			w.WriteHeader(status)
+		}))
+		return server, &used
+	}
+
+	t.Run("spreads searches across keys", func(t *testing.T) {
+		server, used := newServer(http.StatusForbidden, "key-a", "key-b")
+		defer server.Close()
+		client := NewClientWithOptions("key-a,key-b", server.URL, ClientOptions{})
+
+		for range 3 {
+			_, err := client.SearchFoods(context.Background(), "milk")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []string{"key-a", "key-b", "key-a"}, *used)
+	})
+
+	t.Run("moves past a rate limited key", func(t *testing.T) {
+		server, used := newServer(http.StatusTooManyRequests, "key-b")
+		defer server.Close()
+		client := NewClientWithOptions("key-a,key-b", server.URL, ClientOptions{})
+		before := testutil.ToFloat64(usdaKeyQuarantinesTotal)
+
+		for range 2 {
+			_, err := client.SearchFoods(context.Background(), "milk")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []string{"key-a", "key-b", "key-b"}, *used, "quarantined key-a should sit out the second search")
+		assert.Equal(t, 1.0, testutil.ToFloat64(usdaKeyQuarantinesTotal)-before)
+	})
+
+	t.Run("moves past a rejected key for food details", func(t *testing.T) {
+		server, used := newServer(http.StatusForbidden, "key-b")
+		defer server.Close()
+		client := NewClientWithOptions("key-a,key-b", server.URL, ClientOptions{})
+
+		_, err := client.GetFoodDetails(context.Background(), "1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"key-a", "key-b"}, *used)
+	})
+
+	t.Run("fails fast once every key is exhausted", func(t *testing.T) {
+		server, used := newServer(http.StatusTooManyRequests)
+		defer server.Close()
+		client := NewClientWithOptions("key-a,key-b", server.URL, ClientOptions{MaxRetries: 2})
+
+		_, err := client.SearchFoods(context.Background(), "milk")
+		assert.ErrorIs(t, err, domain.ErrUSDAAPIFailure)
+		assert.ErrorIs(t, err, ErrAPIKeysExhausted)
+		assert.Equal(t, []string{"key-a", "key-b"}, *used, "retries should not resend with quarantined keys")
+	})
+}
@@ -2,6 +2,8 @@ package usda
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/macrolens/backend/internal/domain"
 )
@@ -14,11 +16,23 @@ const (
 	NutrientIDTotalFat     = 1004 // Total Fat (g)
 )
 
-// MapToNutritionData converts USDA food data to our domain NutritionData model
+// USDA Nutrient IDs for other nutrition label values
+const (
+	NutrientIDFiber        = 1079 // Dietary fiber (g)
+	NutrientIDTotalSugars  = 2000 // Total sugars (g)
+	NutrientIDSugarsNLEA   = 1063 // Sugars, total NLEA (g); used when 2000 is absent
+	NutrientIDSodium       = 1093 // Sodium (mg)
+	NutrientIDSaturatedFat = 1258 // Saturated fat (g)
+	NutrientIDCholesterol  = 1253 // Cholesterol (mg)
+)
+
+// MapToNutritionData converts USDA food data to our domain NutritionData model.
+// Branded foods with a label serving are reported per serving; everything else
+// is per 100g, the basis USDA reports nutrients on.
 func MapToNutritionData(usdaFood *domain.USDAFood, confidence float64) *domain.NutritionData {
 	nutrients := extractNutrients(usdaFood.Nutrients)
 
-	return &domain.NutritionData{
+	data := &domain.NutritionData{
 		FdcID:           fmt.Sprintf("%d", usdaFood.FdcID),
 		ProductName:     usdaFood.Description,
 		ServingSize:     "100", // USDA typically uses 100g as standard serving
@@ -27,11 +41,42 @@ func MapToNutritionData(usdaFood *domain.USDAFood, confidence float64) *domain.N
 		Confidence:      confidence,
 		Source:          "USDA",
 	}
+
+	size, unit, ok := labelServing(usdaFood)
+	if !ok {
+		return data
+	}
+	grams, _, _ := ServingSizeInGrams(size, unit)
+	labeled := scaleNutrients(data, grams/100)
+	labeled.ServingSize = strconv.FormatFloat(size, 'f', -1, 64)
+	labeled.ServingSizeUnit = unit
+	labeled.HouseholdServing = strings.TrimSpace(usdaFood.HouseholdServingFullText)
+	return labeled
+}
+
+// servingUnitCodes maps USDA Branded servingSizeUnit codes to the units we report
+var servingUnitCodes = map[string]string{
+	"grm": "g",
+	"mlt": "ml",
+}
+
+// labelServing returns a food's label serving with its unit normalized (e.g., "GRM"
+// to "g"). ok is false when there is none or its unit has no gram equivalent.
+func labelServing(food *domain.USDAFood) (size float64, unit string, ok bool) {
+	unit = strings.ToLower(strings.TrimSpace(food.ServingSizeUnit))
+	if code, found := servingUnitCodes[unit]; found {
+		unit = code
+	}
+	if _, _, convertible := ServingSizeInGrams(food.ServingSize, unit); !convertible {
+		return 0, "", false
+	}
+	return food.ServingSize, unit, true
 }
 
-// extractNutrients extracts the key macronutrients from USDA nutrient list
+// extractNutrients extracts the key macronutrients and label values from USDA nutrient list
 func extractNutrients(usdaNutrients []domain.USDANutrient) domain.Nutrients {
 	nutrients := domain.Nutrients{}
+	hasTotalSugars := false
 
 	for _, nutrient := range usdaNutrients {
 		switch nutrient.NutrientID {
@@ -43,6 +88,21 @@ func extractNutrients(usdaNutrients []domain.USDANutrient) domain.Nutrients {
 			nutrients.Carbohydrates = nutrient.Value
 		case NutrientIDTotalFat:
 			nutrients.TotalFat = nutrient.Value
+		case NutrientIDFiber:
+			nutrients.Fiber = nutrient.Value
+		case NutrientIDTotalSugars:
+			nutrients.Sugar = nutrient.Value
+			hasTotalSugars = true
+		case NutrientIDSugarsNLEA:
+			if !hasTotalSugars {
+				nutrients.Sugar = nutrient.Value
+			}
+		case NutrientIDSodium:
+			nutrients.Sodium = nutrient.Value
+		case NutrientIDSaturatedFat:
+			nutrients.SaturatedFat = nutrient.Value
+		case NutrientIDCholesterol:
+			nutrients.Cholesterol = nutrient.Value
 		}
 	}
 
@@ -58,3 +118,200 @@ func FindNutrientValue(nutrients []domain.USDANutrient, nutrientID int) float64
 	}
 	return 0.0
 }
+
+// measureAliases maps common abbreviations to the unit names USDA uses
+var measureAliases = map[string]string{
+	"tbsp": "tablespoon",
+	"tsp":  "teaspoon",
+	"c":    "cup",
+	"pc":   "piece",
+}
+
+// FindPortion returns the portion matching a household measure (e.g., "cup", "tbsp"),
+// or nil if the food does not define it. Matching is case-insensitive and ignores plurals.
+func FindPortion(portions []domain.USDAFoodPortion, measure string) *domain.USDAFoodPortion {
+	want := normalizeMeasure(measure)
+	if want == "" {
+		return nil
+	}
+
+	for i := range portions {
+		portion := &portions[i]
+		if portion.GramWeight <= 0 {
+			continue
+		}
+		for _, name := range portionNames(portion) {
+			if normalizeMeasure(name) == want {
+				return portion
+			}
+		}
+	}
+	return nil
+}
+
+// ScaleToPortion returns a copy of nutrition data scaled to one unit of the portion
+func ScaleToPortion(data *domain.NutritionData, portion *domain.USDAFoodPortion, measure string) *domain.NutritionData {
+	amount := portion.Amount
+	if amount <= 0 {
+		amount = 1
+	}
+
+	// The portion's gram weight is measured by USDA, so this is never an estimate
+	scaled := scaleToGrams(data, portion.GramWeight/amount)
+	scaled.ServingSize = "1"
+	scaled.ServingSizeUnit = measure
+	scaled.Measure = measure
+	return scaled
+}
+
+// ScaleToServing returns a copy of nutrition data scaled to a serving of the given
+// weight in grams, e.g. the serving size printed on a product label
+func ScaleToServing(data *domain.NutritionData, grams float64) *domain.NutritionData {
+	scaled := scaleToGrams(data, grams)
+	scaled.ServingSize = strconv.FormatFloat(grams, 'f', -1, 64)
+	scaled.ServingSizeUnit = "g"
+	return scaled
+}
+
+// scaleToGrams copies nutrition data with every nutrient scaled from its current
+// serving to the given weight, recording that weight as exact serving grams
+func scaleToGrams(data *domain.NutritionData, grams float64) *domain.NutritionData {
+	scaled := scaleNutrients(data, grams/servingBasisGrams(data))
+	scaled.HouseholdServing = ""
+	scaled.ServingGrams = &grams
+	scaled.ServingGramsEstimated = false
+	return scaled
+}
+
+// servingBasisGrams returns the weight the data's nutrients are given for: its
+// serving size in grams, or 100 when that can't be converted
+func servingBasisGrams(data *domain.NutritionData) float64 {
+	size, err := strconv.ParseFloat(strings.TrimSpace(data.ServingSize), 64)
+	if err != nil {
+		return 100
+	}
+	if grams, _, ok := ServingSizeInGrams(size, data.ServingSizeUnit); ok {
+		return grams
+	}
+	return 100
+}
+
+// scaleNutrients returns a copy of data with every nutrient multiplied by factor
+func scaleNutrients(data *domain.NutritionData, factor float64) *domain.NutritionData {
+	scaled := *data
+	scaled.Nutrients = domain.Nutrients{
+		Calories:      data.Nutrients.Calories * factor,
+		Protein:       data.Nutrients.Protein * factor,
+		Carbohydrates: data.Nutrients.Carbohydrates * factor,
+		TotalFat:      data.Nutrients.TotalFat * factor,
+		Fiber:         data.Nutrients.Fiber * factor,
+		Sugar:         data.Nutrients.Sugar * factor,
+		Sodium:        data.Nutrients.Sodium * factor,
+		SaturatedFat:  data.Nutrients.SaturatedFat * factor,
+		Cholesterol:   data.Nutrients.Cholesterol * factor,
+	}
+	return &scaled
+}
+
+// gramsPerUnit converts mass units to grams. These conversions are exact.
+var gramsPerUnit = map[string]float64{
+	"g":     1,
+	"gram":  1,
+	"grm":   1, // USDA Branded servingSizeUnit code
+	"mg":    0.001,
+	"kg":    1000,
+	"oz":    28.35,
+	"ounce": 28.35,
+	"lb":    453.59,
+	"lbs":   453.59,
+	"pound": 453.59,
+}
+
+// millilitersPerUnit converts volume units to milliliters. Grams are estimated from
+// milliliters assuming the density of water, which is close for most beverages.
+var millilitersPerUnit = map[string]float64{
+	"ml":         1,
+	"mlt":        1, // USDA Branded servingSizeUnit code
+	"milliliter": 1,
+	"l":          1000,
+	"liter":      1000,
+	"fl oz":      29.57,
+	"cup":        240,
+	"tablespoon": 15,
+	"teaspoon":   5,
+}
+
+// ServingSizeInGrams converts a serving size to grams. estimated is true when the
+// conversion assumes a density (volume units); ok is false for units with no gram
+// equivalent, such as pieces.
+func ServingSizeInGrams(size float64, unit string) (grams float64, estimated bool, ok bool) {
+	if size <= 0 {
+		return 0, false, false
+	}
+
+	u := strings.ToLower(strings.TrimSpace(unit))
+	u = strings.Join(strings.Fields(strings.ReplaceAll(u, ".", "")), " ")
+	if u == "fluid ounce" || u == "fluid ounces" {
+		u = "fl oz"
+	}
+	u = normalizeMeasure(u)
+
+	if factor, found := gramsPerUnit[u]; found {
+		return size * factor, false, true
+	}
+	if factor, found := millilitersPerUnit[u]; found {
+		return size * factor, true, true
+	}
+	return 0, false, false
+}
+
+// AttachServingGrams sets data.ServingGrams from the serving size and unit, unless
+// it is already known (e.g., from a USDA portion). Unconvertible units are left unset.
+func AttachServingGrams(data *domain.NutritionData) {
+	if data == nil || data.ServingGrams != nil {
+		return
+	}
+
+	size, err := strconv.ParseFloat(strings.TrimSpace(data.ServingSize), 64)
+	if err != nil {
+		return
+	}
+
+	grams, estimated, ok := ServingSizeInGrams(size, data.ServingSizeUnit)
+	if !ok {
+		return
+	}
+	data.ServingGrams = &grams
+	data.ServingGramsEstimated = estimated
+}
+
+// portionNames lists the names a portion can be referred to by. Modifiers and
+// descriptions like "cup, chopped" or "1 cup" contribute their unit word.
+func portionNames(portion *domain.USDAFoodPortion) []string {
+	names := []string{portion.MeasureUnit.Name, portion.MeasureUnit.Abbreviation}
+	for _, text := range []string{portion.Modifier, portion.PortionDescription} {
+		for _, word := range strings.FieldsFunc(text, func(r rune) bool { return r == ' ' || r == ',' || r == '(' }) {
+			if _, err := strconv.ParseFloat(word, 64); err == nil {
+				continue // Skip amounts like "1" or "0.5"
+			}
+			names = append(names, word)
+			break
+		}
+	}
+	return names
+}
+
+// normalizeMeasure lowercases a unit name, resolves aliases, and strips a plural "s"
+func normalizeMeasure(measure string) string {
+	m := strings.ToLower(strings.TrimSpace(measure))
+	if m == "" || m == "undetermined" {
+		return ""
+	}
+	if alias, ok := measureAliases[m]; ok {
+		return alias
+	}
+	if strings.HasSuffix(m, "s") && len(m) > 3 {
+		m = strings.TrimSuffix(m, "s")
+	}
+	return m
+}
@@ -1,6 +1,9 @@
 package usda
 
 import (
+	"encoding/json"
+	"math"
+	"os"
 	"testing"
 
 	"github.com/macrolens/backend/internal/domain"
@@ -133,6 +136,177 @@ func TestMapToNutritionData(t *testing.T) {
 	}
 }
 
+func TestMapToNutritionData_LabelServing(t *testing.T) {
+	// Branded nutrients are per 100g even when USDA reports a label serving
+	chips := func(size float64, unit string) *domain.USDAFood {
+		return &domain.USDAFood{
+			FdcID:                    2187834,
+			Description:              "Potato chips, original",
+			DataType:                 "Branded",
+			ServingSize:              size,
+			ServingSizeUnit:          unit,
+			HouseholdServingFullText: "1 oz",
+			Nutrients: []domain.USDANutrient{
+				{NutrientID: NutrientIDEnergy, Value: 536},
+				{NutrientID: NutrientIDProtein, Value: 7.14},
+				{NutrientID: NutrientIDTotalFat, Value: 35.7},
+			},
+		}
+	}
+
+	t.Run("reports nutrients per label serving", func(t *testing.T) {
+		got := MapToNutritionData(chips(28, "g"), 90)
+
+		if got.ServingSize != "28" || got.ServingSizeUnit != "g" || got.HouseholdServing != "1 oz" {
+			t.Errorf("serving = %s %s (%q), want 28 g (\"1 oz\")", got.ServingSize, got.ServingSizeUnit, got.HouseholdServing)
+		}
+		if math.Abs(got.Nutrients.Calories-150.08) > 1e-9 || math.Abs(got.Nutrients.TotalFat-9.996) > 1e-9 {
+			t.Errorf("Nutrients = %+v, want 150.08 kcal and 9.996 g fat (per-100g x 0.28)", got.Nutrients)
+		}
+	})
+
+	t.Run("normalizes USDA unit codes", func(t *testing.T) {
+		got := MapToNutritionData(chips(240, "MLT"), 90)
+
+		if got.ServingSize != "240" || got.ServingSizeUnit != "ml" {
+			t.Errorf("serving = %s %s, want 240 ml", got.ServingSize, got.ServingSizeUnit)
+		}
+		if math.Abs(got.Nutrients.Calories-536*2.4) > 1e-9 {
+			t.Errorf("Calories = %v, want %v", got.Nutrients.Calories, 536*2.4)
+		}
+	})
+
+	t.Run("falls back to 100g without a usable serving", func(t *testing.T) {
+		for _, food := range []*domain.USDAFood{chips(0, ""), chips(1, "piece")} {
+			got := MapToNutritionData(food, 90)
+			if got.ServingSize != "100" || got.ServingSizeUnit != "g" || got.Nutrients.Calories != 536 || got.HouseholdServing != "" {
+				t.Errorf("serving %v %q: got %s %s at %v kcal, want per-100g", food.ServingSize, food.ServingSizeUnit, got.ServingSize, got.ServingSizeUnit, got.Nutrients.Calories)
+			}
+		}
+	})
+
+	t.Run("rescales from the label serving", func(t *testing.T) {
+		labeled := MapToNutritionData(chips(28, "GRM"), 90)
+
+		doubled := ScaleToServing(labeled, 56)
+		if math.Abs(doubled.Nutrients.Calories-300.16) > 1e-9 || doubled.HouseholdServing != "" {
+			t.Errorf("56g serving = %v kcal (%q), want 300.16 kcal", doubled.Nutrients.Calories, doubled.HouseholdServing)
+		}
+
+		portion := ScaleToPortion(labeled, &domain.USDAFoodPortion{Amount: 1, GramWeight: 100}, "bag")
+		if math.Abs(portion.Nutrients.Calories-536) > 1e-9 {
+			t.Errorf("100g portion = %v kcal, want 536", portion.Nutrients.Calories)
+		}
+	})
+}
+
+func TestMapToNutritionData_ResponseFormats(t *testing.T) {
+	// The search endpoint reports nutrients flat, the details endpoint nested
+	readFood := map[string]func(t *testing.T) *domain.USDAFood{
+		"search result": func(t *testing.T) *domain.USDAFood {
+			var response domain.USDASearchResponse
+			readFixture(t, "fixtures/search_response.json", &response)
+			return &response.Foods[0]
+		},
+		"food details": func(t *testing.T) *domain.USDAFood {
+			var food domain.USDAFood
+			readFixture(t, "fixtures/food_details_response.json", &food)
+			return &food
+		},
+	}
+	want := domain.Nutrients{Calories: 61, Protein: 3.27, Carbohydrates: 4.63, TotalFat: 3.2}
+
+	for name, read := range readFood {
+		t.Run(name, func(t *testing.T) {
+			food := read(t)
+			if got := MapToNutritionData(food, 100).Nutrients; got != want {
+				t.Errorf("Nutrients = %+v, want %+v", got, want)
+			}
+			if protein := food.Nutrients[1]; protein.NutrientID != NutrientIDProtein || protein.NutrientName != "Protein" ||
+				protein.NutrientNumber != "203" || protein.UnitName == "" {
+				t.Errorf("protein nutrient = %+v, want id, name, number and unit filled", protein)
+			}
+		})
+	}
+}
+
+func TestMapToNutritionData_FdcIDFormats(t *testing.T) {
+	for name, payload := range map[string]string{
+		"number":         `{"fdcId": 2262072, "description": "Peanut butter"}`,
+		"numeric string": `{"fdcId": "2262072", "description": "Peanut butter"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var food domain.USDAFood
+			if err := json.Unmarshal([]byte(payload), &food); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if food.FdcID != 2262072 || food.Description != "Peanut butter" {
+				t.Errorf("food = %+v, want fdcId 2262072 and the description", food)
+			}
+			if got := MapToNutritionData(&food, 100).FdcID; got != "2262072" {
+				t.Errorf("FdcID = %q, want %q", got, "2262072")
+			}
+		})
+	}
+
+	t.Run("rejects non-numeric ids", func(t *testing.T) {
+		for _, payload := range []string{`{"fdcId": "milk"}`, `{"fdcId": 12.5}`, `{"fdcId": true}`} {
+			var food domain.USDAFood
+			if err := json.Unmarshal([]byte(payload), &food); err == nil {
+				t.Errorf("%s: error = nil, want an invalid fdcId", payload)
+			}
+		}
+	})
+}
+
+// readFixture decodes a JSON fixture file into v
+func readFixture(t *testing.T, path string, v interface{}) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("decoding %s: %v", path, err)
+	}
+}
+
+func TestExtractNutrients_LabelValues(t *testing.T) {
+	t.Run("maps fiber, sugar, sodium, saturated fat, and cholesterol", func(t *testing.T) {
+		got := extractNutrients([]domain.USDANutrient{
+			{NutrientID: NutrientIDEnergy, Value: 250},
+			{NutrientID: NutrientIDFiber, Value: 2.5},
+			{NutrientID: NutrientIDTotalSugars, Value: 12},
+			{NutrientID: NutrientIDSodium, Value: 480},
+			{NutrientID: NutrientIDSaturatedFat, Value: 3.5},
+			{NutrientID: NutrientIDCholesterol, Value: 30},
+		})
+
+		want := domain.Nutrients{Calories: 250, Fiber: 2.5, Sugar: 12, Sodium: 480, SaturatedFat: 3.5, Cholesterol: 30}
+		if got != want {
+			t.Errorf("extractNutrients() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("prefers total sugars over NLEA sugars in either order", func(t *testing.T) {
+		for _, nutrients := range [][]domain.USDANutrient{
+			{{NutrientID: NutrientIDSugarsNLEA, Value: 9}, {NutrientID: NutrientIDTotalSugars, Value: 12}},
+			{{NutrientID: NutrientIDTotalSugars, Value: 12}, {NutrientID: NutrientIDSugarsNLEA, Value: 9}},
+		} {
+			if got := extractNutrients(nutrients).Sugar; got != 12 {
+				t.Errorf("Sugar = %v, want 12", got)
+			}
+		}
+	})
+
+	t.Run("falls back to NLEA sugars", func(t *testing.T) {
+		got := extractNutrients([]domain.USDANutrient{{NutrientID: NutrientIDSugarsNLEA, Value: 9}})
+		if got.Sugar != 9 {
+			t.Errorf("Sugar = %v, want 9", got.Sugar)
+		}
+	})
+}
+
 func TestFindNutrientValue(t *testing.T) {
 	nutrients := []domain.USDANutrient{
 		{NutrientID: NutrientIDEnergy, Value: 100.0},
@@ -175,3 +349,172 @@ func TestFindNutrientValue(t *testing.T) {
 		})
 	}
 }
+
+func TestFindPortion(t *testing.T) {
+	portions := []domain.USDAFoodPortion{
+		{Amount: 1, GramWeight: 244, Modifier: "cup", MeasureUnit: domain.USDAMeasureUnit{Name: "undetermined"}},
+		{Amount: 1, GramWeight: 15.2, MeasureUnit: domain.USDAMeasureUnit{Name: "tablespoon", Abbreviation: "tbsp"}},
+		{Amount: 1, GramWeight: 0, Modifier: "slice"}, // No gram weight, unusable
+	}
+
+	tests := []struct {
+		name       string
+		measure    string
+		wantWeight float64
+		wantNil    bool
+	}{
+		{name: "matches modifier", measure: "cup", wantWeight: 244},
+		{name: "case-insensitive plural", measure: "Cups", wantWeight: 244},
+		{name: "matches unit name", measure: "tablespoon", wantWeight: 15.2},
+		{name: "matches alias", measure: "tbsp", wantWeight: 15.2},
+		{name: "skips portion without gram weight", measure: "slice", wantNil: true},
+		{name: "undefined measure", measure: "quart", wantNil: true},
+		{name: "empty measure", measure: "", wantNil: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindPortion(portions, tt.measure)
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("FindPortion(%q) = %+v, want nil", tt.measure, got)
+				}
+				return
+			}
+			if got == nil || got.GramWeight != tt.wantWeight {
+				t.Errorf("FindPortion(%q) = %+v, want gramWeight %v", tt.measure, got, tt.wantWeight)
+			}
+		})
+	}
+}
+
+func TestScaleToPortion(t *testing.T) {
+	data := &domain.NutritionData{
+		FdcID:           "12345",
+		ServingSize:     "100",
+		ServingSizeUnit: "g",
+		Nutrients:       domain.Nutrients{Calories: 60, Protein: 3, Carbohydrates: 5, TotalFat: 2},
+	}
+
+	// 2 cups = 500 g, so one cup is 250 g (2.5x per-100g values)
+	portion := &domain.USDAFoodPortion{Amount: 2, GramWeight: 500, Modifier: "cup"}
+	got := ScaleToPortion(data, portion, "cup")
+
+	want := domain.Nutrients{Calories: 150, Protein: 7.5, Carbohydrates: 12.5, TotalFat: 5}
+	if got.Nutrients != want {
+		t.Errorf("Nutrients = %+v, want %+v", got.Nutrients, want)
+	}
+	if got.ServingSize != "1" || got.ServingSizeUnit != "cup" || got.Measure != "cup" {
+		t.Errorf("serving = %s %s (measure %q), want 1 cup", got.ServingSize, got.ServingSizeUnit, got.Measure)
+	}
+	if got.ServingGrams == nil || *got.ServingGrams != 250 || got.ServingGramsEstimated {
+		t.Errorf("ServingGrams = %v (estimated %v), want exact 250", got.ServingGrams, got.ServingGramsEstimated)
+	}
+	if data.Nutrients.Calories != 60 {
+		t.Error("ScaleToPortion should not modify the input data")
+	}
+}
+
+func TestScaleToServing(t *testing.T) {
+	data := &domain.NutritionData{
+		FdcID:           "12345",
+		ServingSize:     "100",
+		ServingSizeUnit: "g",
+		Nutrients: domain.Nutrients{
+			Calories: 60, Protein: 3.2, Carbohydrates: 4.8, TotalFat: 3.25,
+			Fiber: 0.5, Sugar: 5, Sodium: 43, SaturatedFat: 1.9, Cholesterol: 10,
+		},
+	}
+
+	// A 240g label serving is 2.4x the per-100g values
+	got := ScaleToServing(data, 240)
+
+	want := domain.Nutrients{
+		Calories: 144, Protein: 7.68, Carbohydrates: 11.52, TotalFat: 7.8,
+		Fiber: 1.2, Sugar: 12, Sodium: 103.2, SaturatedFat: 4.56, Cholesterol: 24,
+	}
+	for _, field := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"Calories", got.Nutrients.Calories, want.Calories},
+		{"Protein", got.Nutrients.Protein, want.Protein},
+		{"Carbohydrates", got.Nutrients.Carbohydrates, want.Carbohydrates},
+		{"TotalFat", got.Nutrients.TotalFat, want.TotalFat},
+		{"Fiber", got.Nutrients.Fiber, want.Fiber},
+		{"Sugar", got.Nutrients.Sugar, want.Sugar},
+		{"Sodium", got.Nutrients.Sodium, want.Sodium},
+		{"SaturatedFat", got.Nutrients.SaturatedFat, want.SaturatedFat},
+		{"Cholesterol", got.Nutrients.Cholesterol, want.Cholesterol},
+	} {
+		if math.Abs(field.got-field.want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", field.name, field.got, field.want)
+		}
+	}
+	if got.ServingSize != "240" || got.ServingSizeUnit != "g" {
+		t.Errorf("serving = %s %s, want 240 g", got.ServingSize, got.ServingSizeUnit)
+	}
+	if got.ServingGrams == nil || *got.ServingGrams != 240 || got.ServingGramsEstimated {
+		t.Errorf("ServingGrams = %v (estimated %v), want exact 240", got.ServingGrams, got.ServingGramsEstimated)
+	}
+	if data.Nutrients.Calories != 60 || data.ServingSize != "100" {
+		t.Error("ScaleToServing should not modify the input data")
+	}
+
+	if fractional := ScaleToServing(data, 28.5); fractional.ServingSize != "28.5" {
+		t.Errorf("ServingSize = %q, want 28.5", fractional.ServingSize)
+	}
+}
+
+func TestAttachServingGrams(t *testing.T) {
+	tests := []struct {
+		name          string
+		size          string
+		unit          string
+		wantGrams     float64
+		wantEstimated bool
+		wantSet       bool
+	}{
+		{name: "grams", size: "100", unit: "g", wantGrams: 100, wantSet: true},
+		{name: "USDA gram code", size: "30", unit: "GRM", wantGrams: 30, wantSet: true},
+		{name: "milliliters estimated", size: "240", unit: "ml", wantGrams: 240, wantEstimated: true, wantSet: true},
+		{name: "USDA milliliter code", size: "355", unit: "MLT", wantGrams: 355, wantEstimated: true, wantSet: true},
+		{name: "ounces", size: "2", unit: "oz", wantGrams: 56.7, wantSet: true},
+		{name: "fluid ounces estimated", size: "8", unit: "fl oz", wantGrams: 236.56, wantEstimated: true, wantSet: true},
+		{name: "cup estimated", size: "1", unit: "cup", wantGrams: 240, wantEstimated: true, wantSet: true},
+		{name: "pieces unconvertible", size: "1", unit: "piece", wantSet: false},
+		{name: "non-numeric size", size: "about 1", unit: "g", wantSet: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := &domain.NutritionData{ServingSize: tt.size, ServingSizeUnit: tt.unit}
+			AttachServingGrams(data)
+
+			if !tt.wantSet {
+				if data.ServingGrams != nil {
+					t.Errorf("ServingGrams = %v, want unset", *data.ServingGrams)
+				}
+				return
+			}
+			if data.ServingGrams == nil {
+				t.Fatal("ServingGrams not set")
+			}
+			if diff := *data.ServingGrams - tt.wantGrams; diff > 0.01 || diff < -0.01 {
+				t.Errorf("ServingGrams = %v, want %v", *data.ServingGrams, tt.wantGrams)
+			}
+			if data.ServingGramsEstimated != tt.wantEstimated {
+				t.Errorf("ServingGramsEstimated = %v, want %v", data.ServingGramsEstimated, tt.wantEstimated)
+			}
+		})
+	}
+
+	t.Run("keeps portion grams", func(t *testing.T) {
+		grams := 250.0
+		data := &domain.NutritionData{ServingSize: "1", ServingSizeUnit: "cup", ServingGrams: &grams}
+		AttachServingGrams(data)
+		if *data.ServingGrams != 250 || data.ServingGramsEstimated {
+			t.Errorf("ServingGrams = %v (estimated %v), want exact 250", *data.ServingGrams, data.ServingGramsEstimated)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package usda
+
+import (
+	"errors"
+
+	"github.com/macrolens/backend/internal/domain"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Operation labels for USDA metrics
+const (
+	operationSearch      = "search"
+	operationDetails     = "details"
+	operationBulkDetails = "bulk_details"
+)
+
+var (
+	usdaRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "macrolens",
+		Subsystem: "usda",
+		Name:      "requests_total",
+		Help:      "USDA API calls by operation, counting each logical request once.",
+	}, []string{"operation"})
+
+	usdaRetriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "macrolens",
+		Subsystem: "usda",
+		Name:      "retries_total",
+		Help:      "USDA API attempts that failed and were retried, by operation.",
+	}, []string{"operation"})
+
+	usdaFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "macrolens",
+		Subsystem: "usda",
+		Name:      "failures_total",
+		Help:      "USDA API calls that failed with an upstream error (ErrUSDAAPIFailure), by operation.",
+	}, []string{"operation"})
+
+	usdaKeyQuarantinesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "macrolens",
+		Subsystem: "usda",
+		Name:      "key_quarantines_total",
+		Help:      "USDA API keys set aside after a 429 or 403 while other keys were available.",
+	})
+
+	usdaBreakerState = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "macrolens",
+		Subsystem: "usda",
+		Name:      "circuit_breaker_state",
+		Help:      "USDA circuit breaker state: 0 closed, 1 half-open, 2 open.",
+	})
+)
+
+// breakerStateValues maps circuit breaker states to their gauge values
+var breakerStateValues = map[string]float64{
+	breakerClosed:   0,
+	breakerHalfOpen: 1,
+	breakerOpen:     2,
+}
+
+// observeBreakerState records the circuit breaker's current state
+func observeBreakerState(state string) {
+	usdaBreakerState.Set(breakerStateValues[state])
+}
+
+// observeOutcome counts a finished USDA call. Not-found is a healthy response
+// and isn't counted as a failure.
+func observeOutcome(operation string, err error) {
+	usdaRequestsTotal.WithLabelValues(operation).Inc()
+	if errors.Is(err, domain.ErrUSDAAPIFailure) {
+		usdaFailuresTotal.WithLabelValues(operation).Inc()
+	}
+}
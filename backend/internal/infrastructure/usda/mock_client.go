@@ -0,0 +1,170 @@
+package usda
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+//go:embed fixtures/mock_foods.json
+var mockFoodsJSON []byte
+
+// mockFixture is a canned search result for one query
+type mockFixture struct {
+	Query string            `json:"query"`
+	Foods []domain.USDAFood `json:"foods"`
+}
+
+// MockUSDAClient serves canned USDA foods from an embedded fixtures file, so the
+// backend can run offline without a USDA API key (e.g., for extension development).
+// Responses are deterministic: the same query always returns the same foods.
+type MockUSDAClient struct {
+	fixtures []mockFixture
+	foods    map[int]domain.USDAFood
+}
+
+// NewMockUSDAClient creates a mock client from the embedded fixtures
+func NewMockUSDAClient() (*MockUSDAClient, error) {
+	var fixtures []mockFixture
+	if err := json.Unmarshal(mockFoodsJSON, &fixtures); err != nil {
+		return nil, fmt.Errorf("failed to parse mock USDA fixtures: %w", err)
+	}
+
+	client := &MockUSDAClient{
+		fixtures: fixtures,
+		foods:    make(map[int]domain.USDAFood),
+	}
+	for i := range fixtures {
+		fixtures[i].Query = normalizeMockQuery(fixtures[i].Query)
+		for _, food := range fixtures[i].Foods {
+			client.foods[food.FdcID] = food
+		}
+	}
+
+	return client, nil
+}
+
+// SearchFoods returns the fixture for the query. Queries with no exact fixture
+// (e.g., "Horizon whole milk") get every fixture whose words all appear in the query.
+func (c *MockUSDAClient) SearchFoods(ctx context.Context, query string) (*domain.USDASearchResponse, error) {
+	return c.SearchFoodsWithOptions(ctx, query, domain.SearchOptions{})
+}
+
+// SearchFoodsWithOptions returns the fixture for the query like SearchFoods; the
+// fixtures are small enough that paging and data types are ignored
+func (c *MockUSDAClient) SearchFoodsWithOptions(ctx context.Context, query string, opts domain.SearchOptions) (*domain.USDASearchResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	query = normalizeMockQuery(query)
+	words := make(map[string]bool)
+	for _, word := range strings.Fields(query) {
+		words[word] = true
+	}
+
+	var foods []domain.USDAFood
+	for _, fixture := range c.fixtures {
+		if fixture.Query == query {
+			foods = fixture.Foods
+			break
+		}
+		if containsAllWords(words, fixture.Query) {
+			foods = append(foods, fixture.Foods...)
+		}
+	}
+
+	if len(foods) == 0 {
+		return nil, domain.ErrProductNotFound
+	}
+
+	return &domain.USDASearchResponse{
+		Foods:       foods,
+		TotalHits:   len(foods),
+		CurrentPage: 1,
+		TotalPages:  1,
+	}, nil
+}
+
+// GetFoodDetails returns a fixture food by fdcId
+func (c *MockUSDAClient) GetFoodDetails(ctx context.Context, fdcID string) (*domain.USDAFood, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.Atoi(fdcID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fdcId %q: %w", fdcID, err)
+	}
+
+	food, ok := c.foods[id]
+	if !ok {
+		return nil, domain.ErrProductNotFound
+	}
+	return &food, nil
+}
+
+// GetFoodsDetails returns the fixture foods with the given fdcIds, leaving out
+// unknown ones
+func (c *MockUSDAClient) GetFoodsDetails(ctx context.Context, fdcIDs []string) (map[string]*domain.USDAFood, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ids, err := parseFdcIDs(fdcIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	foods := make(map[string]*domain.USDAFood, len(ids))
+	for _, id := range ids {
+		if food, ok := c.foods[id]; ok {
+			foods[strconv.Itoa(id)] = &food
+		}
+	}
+	if len(foods) == 0 {
+		return nil, domain.ErrProductNotFound
+	}
+	return foods, nil
+}
+
+// SearchByUPC returns the fixture food with the given barcode
+func (c *MockUSDAClient) SearchByUPC(ctx context.Context, upc string) (*domain.USDAFood, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, fixture := range c.fixtures {
+		if food, err := findUPC(fixture.Foods, upc); err == nil {
+			copied := *food
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrProductNotFound
+}
+
+// containsAllWords reports whether every word of phrase is in words
+func containsAllWords(words map[string]bool, phrase string) bool {
+	for _, word := range strings.Fields(phrase) {
+		if !words[word] {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeMockQuery lowercases a query, drops punctuation, and collapses whitespace
+func normalizeMockQuery(query string) string {
+	query = strings.Map(func(r rune) rune {
+		if r == ',' || r == '.' || r == '-' {
+			return ' '
+		}
+		return r
+	}, strings.ToLower(query))
+	return strings.Join(strings.Fields(query), " ")
+}
@@ -0,0 +1,78 @@
+package usda
+
+import (
+	"context"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockUSDAClient_SearchFoods(t *testing.T) {
+	client, err := NewMockUSDAClient()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("known query returns its fixture", func(t *testing.T) {
+		result, err := client.SearchFoods(ctx, "whole milk")
+
+		require.NoError(t, err)
+		require.Len(t, result.Foods, 2)
+		assert.Equal(t, 746782, result.Foods[0].FdcID)
+		assert.Equal(t, "Foundation", result.Foods[0].DataType)
+		assert.Equal(t, 61.0, MapToNutritionData(&result.Foods[0], 100).Nutrients.Calories)
+	})
+
+	t.Run("matching ignores case, punctuation and extra words", func(t *testing.T) {
+		result, err := client.SearchFoods(ctx, "Horizon Whole,  Milk")
+
+		require.NoError(t, err)
+		assert.Equal(t, 746782, result.Foods[0].FdcID)
+	})
+
+	t.Run("responses are deterministic", func(t *testing.T) {
+		first, err := client.SearchFoods(ctx, "cheddar cheese")
+		require.NoError(t, err)
+		second, err := client.SearchFoods(ctx, "cheddar cheese")
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("branded fixture reports its label serving", func(t *testing.T) {
+		result, err := client.SearchFoods(ctx, "potato chips")
+
+		require.NoError(t, err)
+		require.Len(t, result.Foods, 1)
+		data := MapToNutritionData(&result.Foods[0], 100)
+		assert.Equal(t, "28", data.ServingSize)
+		assert.Equal(t, "g", data.ServingSizeUnit)
+		assert.Equal(t, "1 oz", data.HouseholdServing)
+		assert.InDelta(t, 150.08, data.Nutrients.Calories, 1e-9)
+	})
+
+	t.Run("unknown query is not found", func(t *testing.T) {
+		result, err := client.SearchFoods(ctx, "dragon fruit")
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrProductNotFound)
+	})
+}
+
+func TestMockUSDAClient_GetFoodDetails(t *testing.T) {
+	client, err := NewMockUSDAClient()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	food, err := client.GetFoodDetails(ctx, "746782")
+	require.NoError(t, err)
+	assert.Equal(t, "Milk, whole, 3.25% milkfat, with added vitamin D", food.Description)
+	assert.NotEmpty(t, food.Portions)
+
+	_, err = client.GetFoodDetails(ctx, "1")
+	assert.ErrorIs(t, err, domain.ErrProductNotFound)
+
+	_, err = client.GetFoodDetails(ctx, "abc")
+	assert.Error(t, err)
+}
@@ -0,0 +1,34 @@
+package usda
+
+import (
+	"context"
+	"strings"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// SearchByUPC finds the Branded food with the given UPC/GTIN barcode. USDA's
+// gtinUpc search can return near misses, so only an exact barcode match counts.
+func (c *Client) SearchByUPC(ctx context.Context, upc string) (*domain.USDAFood, error) {
+	result, err := c.SearchFoodsWithOptions(ctx, "gtinUpc:"+upc, domain.SearchOptions{DataTypes: []string{"Branded"}})
+	if err != nil {
+		return nil, err
+	}
+	return findUPC(result.Foods, upc)
+}
+
+// findUPC returns the food whose barcode is upc, or ErrProductNotFound
+func findUPC(foods []domain.USDAFood, upc string) (*domain.USDAFood, error) {
+	for i := range foods {
+		if foods[i].GtinUpc != "" && sameUPC(foods[i].GtinUpc, upc) {
+			return &foods[i], nil
+		}
+	}
+	return nil, domain.ErrProductNotFound
+}
+
+// sameUPC compares barcodes ignoring leading zeros, so a 12-digit UPC-A matches
+// the same code stored as a 13-digit EAN or 14-digit GTIN
+func sameUPC(a, b string) bool {
+	return strings.TrimLeft(a, "0") == strings.TrimLeft(b, "0")
+}
@@ -0,0 +1,63 @@
+package usda
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchByUPC(t *testing.T) {
+	newServer := func(foods []domain.USDAFood) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/foods/search", r.URL.Path)
+			assert.Equal(t, "gtinUpc:051500255162", r.URL.Query().Get("query"))
+			assert.Equal(t, "Branded", r.URL.Query().Get("dataType"))
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(domain.USDASearchResponse{Foods: foods})
+		}))
+	}
+
+	t.Run("returns the exact barcode match", func(t *testing.T) {
+		server := newServer([]domain.USDAFood{
+			{FdcID: 1, Description: "Peanut butter, crunchy", GtinUpc: "051500255179"},
+			{FdcID: 2, Description: "Peanut butter, creamy", GtinUpc: "00051500255162"},
+		})
+		defer server.Close()
+
+		food, err := NewClient("test-api-key", server.URL).SearchByUPC(context.Background(), "051500255162")
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, food.FdcID)
+	})
+
+	t.Run("near misses are not found", func(t *testing.T) {
+		server := newServer([]domain.USDAFood{
+			{FdcID: 1, Description: "Peanut butter, crunchy", GtinUpc: "051500255179"},
+			{FdcID: 3, Description: "Peanut butter, no barcode"},
+		})
+		defer server.Close()
+
+		_, err := NewClient("test-api-key", server.URL).SearchByUPC(context.Background(), "051500255162")
+
+		assert.ErrorIs(t, err, domain.ErrProductNotFound)
+	})
+}
+
+func TestMockUSDAClient_SearchByUPC(t *testing.T) {
+	client, err := NewMockUSDAClient()
+	require.NoError(t, err)
+
+	food, err := client.SearchByUPC(context.Background(), "051500255162")
+	require.NoError(t, err)
+	assert.Equal(t, 2262072, food.FdcID)
+
+	_, err = client.SearchByUPC(context.Background(), "000000000000")
+	assert.ErrorIs(t, err, domain.ErrProductNotFound)
+}
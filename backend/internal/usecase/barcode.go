@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/macrolens/backend/internal/domain"
+	"github.com/macrolens/backend/internal/infrastructure/usda"
+)
+
+// Barcode lengths accepted, from UPC-E (8 digits) through GTIN-14
+const (
+	minUPCDigits = 8
+	maxUPCDigits = 14
+)
+
+// SearchNutritionByUPC looks up nutrition data by barcode. A barcode identifies a
+// Branded food exactly, so the matcher is skipped and the result has confidence
+// 100. When USDA has no food with the barcode, fallback (if it names a product)
+// is searched by name instead.
+// Flow: check cache -> search USDA by UPC -> cache -> return, or fall back to SearchNutrition
+func (s *NutritionService) SearchNutritionByUPC(
+	ctx context.Context,
+	upc string,
+	fallback *domain.SearchRequest,
+) (*domain.NutritionData, error) {
+	upc, err := normalizeUPC(upc)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := s.upcCacheKey(upc)
+	if cached, err := s.getFromCache(ctx, cacheKey); err == nil && cached != nil {
+		cached.Source = "Cache"
+		if s.includeServingGrams {
+			usda.AttachServingGrams(cached)
+		}
+		return cached, nil
+	}
+
+	food, err := s.usdaClient.SearchByUPC(ctx, upc)
+	if errors.Is(err, domain.ErrProductNotFound) || (err == nil && food == nil) {
+		if fallback == nil || strings.TrimSpace(fallback.ProductName) == "" {
+			return nil, domain.ErrProductNotFound
+		}
+		return s.SearchNutrition(ctx, fallback)
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrRateLimited) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", domain.ErrUSDAAPIFailure, err)
+	}
+
+	nutritionData := usda.MapToNutritionData(food, 100)
+	s.hydrateDetails(ctx, nutritionData)
+	if err := s.setInCache(ctx, cacheKey, nutritionData, s.cacheTTL); err != nil {
+		// Caching is best-effort
+	}
+
+	if s.includeServingGrams {
+		usda.AttachServingGrams(nutritionData)
+	}
+	return nutritionData, nil
+}
+
+// upcCacheKey is the cache key for a barcode lookup
+func (s *NutritionService) upcCacheKey(upc string) string {
+	return s.cacheKeyPrefix + "nutrition:upc:" + upc
+}
+
+// normalizeUPC strips the spaces and dashes barcodes are often printed with and
+// checks that what's left is a plausible UPC/EAN/GTIN
+func normalizeUPC(upc string) (string, error) {
+	upc = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, upc)
+
+	if len(upc) < minUPCDigits || len(upc) > maxUPCDigits {
+		return "", fmt.Errorf("%w: upc must be %d-%d digits", domain.ErrInvalidRequest, minUPCDigits, maxUPCDigits)
+	}
+	for _, r := range upc {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("%w: upc must be %d-%d digits", domain.ErrInvalidRequest, minUPCDigits, maxUPCDigits)
+		}
+	}
+	return upc, nil
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+func TestSearchNutritionByUPC(t *testing.T) {
+	ctx := context.Background()
+	peanutButter := &domain.USDAFood{FdcID: 2262072, Description: "Peanut butter, creamy", DataType: "Branded", GtinUpc: "051500255162"}
+
+	t.Run("barcode match skips the matcher", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		client := NewMockUSDAClient()
+		client.upcResult = peanutButter
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{})
+
+		result, err := svc.SearchNutritionByUPC(ctx, "0515-0025-5162", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "2262072" || result.Confidence != 100 {
+			t.Errorf("result = %s at %v, want 2262072 at confidence 100", result.FdcID, result.Confidence)
+		}
+		if client.searchCalls != 0 {
+			t.Errorf("searchCalls = %d, want no name search", client.searchCalls)
+		}
+		if _, ok := cache.data["v2:nutrition:upc:051500255162"]; !ok {
+			t.Errorf("cached keys = %v, want the upc key", cache.data)
+		}
+
+		cached, err := svc.SearchNutritionByUPC(ctx, "051500255162", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cached.Source != "Cache" || client.upcCalls != 1 {
+			t.Errorf("source = %s after %d USDA lookups, want a cache hit after 1", cached.Source, client.upcCalls)
+		}
+	})
+
+	t.Run("never shares a key with a search", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.upcResult = peanutButter
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		if _, err := svc.SearchNutritionByUPC(ctx, "051500255162", nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		request := &domain.SearchRequest{ProductName: "upc", Brand: "051500255162"}
+		if key := svc.generateCacheKey(request); key == svc.upcCacheKey("051500255162") {
+			t.Fatalf("search key %q collides with the upc key", key)
+		}
+		if result, err := svc.SearchNutrition(ctx, request); !errors.Is(err, domain.ErrProductNotFound) {
+			t.Errorf("SearchNutrition() = %+v, %v, want ErrProductNotFound rather than the cached food", result, err)
+		}
+	})
+
+	t.Run("falls back to a name search", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{
+			Foods: []domain.USDAFood{{FdcID: 456, Description: "Peanut Butter Creamy"}},
+		}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		result, err := svc.SearchNutritionByUPC(ctx, "051500255162", &domain.SearchRequest{ProductName: "creamy peanut butter"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "456" || client.upcCalls != 1 || client.searchCalls != 1 {
+			t.Errorf("result = %s after %d upc and %d name searches, want 456 after one of each",
+				result.FdcID, client.upcCalls, client.searchCalls)
+		}
+	})
+
+	t.Run("not found without a product name", func(t *testing.T) {
+		svc := NewNutritionService(NewMockCacheRepository(), NewMockUSDAClient(), NutritionServiceConfig{})
+
+		if _, err := svc.SearchNutritionByUPC(ctx, "051500255162", &domain.SearchRequest{}); !errors.Is(err, domain.ErrProductNotFound) {
+			t.Errorf("error = %v, want ErrProductNotFound", err)
+		}
+	})
+
+	t.Run("USDA failure is not hidden by the fallback", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.upcError = errors.New("connection reset")
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		_, err := svc.SearchNutritionByUPC(ctx, "051500255162", &domain.SearchRequest{ProductName: "peanut butter"})
+		if !errors.Is(err, domain.ErrUSDAAPIFailure) {
+			t.Errorf("error = %v, want ErrUSDAAPIFailure", err)
+		}
+	})
+
+	t.Run("rejects malformed barcodes", func(t *testing.T) {
+		svc := NewNutritionService(NewMockCacheRepository(), NewMockUSDAClient(), NutritionServiceConfig{})
+
+		for _, upc := range []string{"", "12345", "0515OO255162", "123456789012345"} {
+			if _, err := svc.SearchNutritionByUPC(ctx, upc, nil); !errors.Is(err, domain.ErrInvalidRequest) {
+				t.Errorf("upc %q: error = %v, want ErrInvalidRequest", upc, err)
+			}
+		}
+	})
+}
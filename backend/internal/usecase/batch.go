@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+const (
+	// MaxBatchItems bounds how many products a single batch search can look up
+	MaxBatchItems = 25
+
+	// batchConcurrency is how many batch items are searched at once
+	batchConcurrency = 5
+)
+
+// BatchResult is the outcome of one item in a batch search. Data may be set
+// alongside Err for low-confidence matches, as with SearchNutrition.
+type BatchResult struct {
+	Data *domain.NutritionData
+	Err  error
+}
+
+// SearchNutritionBatch looks up several products at once, returning one result per
+// request in input order. Identical requests are searched once and share a result.
+// Items not started before ctx is done fail with the context's error.
+func (s *NutritionService) SearchNutritionBatch(ctx context.Context, requests []domain.SearchRequest) ([]BatchResult, error) {
+	if len(requests) == 0 || len(requests) > MaxBatchItems {
+		return nil, fmt.Errorf("%w: batch must contain between 1 and %d items", domain.ErrInvalidRequest, MaxBatchItems)
+	}
+
+	// Deduplicate so the cache and USDA see each distinct product once
+	var unique []domain.SearchRequest
+	uniqueIndex := make([]int, len(requests))
+	seen := make(map[domain.SearchRequest]int)
+	for i, request := range requests {
+		index, ok := seen[request]
+		if !ok {
+			index = len(unique)
+			seen[request] = index
+			unique = append(unique, request)
+		}
+		uniqueIndex[i] = index
+	}
+
+	uniqueResults := make([]BatchResult, len(unique))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(batchConcurrency, len(unique)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				if err := ctx.Err(); err != nil {
+					uniqueResults[index] = BatchResult{Err: err}
+					continue
+				}
+				request := unique[index]
+				data, err := s.SearchNutrition(ctx, &request)
+				uniqueResults[index] = BatchResult{Data: data, Err: err}
+			}
+		}()
+	}
+	for index := range unique {
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	results := make([]BatchResult, len(requests))
+	for i, index := range uniqueIndex {
+		results[i] = uniqueResults[index]
+	}
+	return results, nil
+}
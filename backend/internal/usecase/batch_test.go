@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// syncCacheRepository guards MockCacheRepository for concurrent batch workers
+type syncCacheRepository struct {
+	mu    sync.Mutex
+	inner *MockCacheRepository
+}
+
+func (m *syncCacheRepository) Get(ctx context.Context, key string) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inner.Get(ctx, key)
+}
+
+func (m *syncCacheRepository) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inner.Set(ctx, key, value, ttl)
+}
+
+func (m *syncCacheRepository) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inner.Delete(ctx, key)
+}
+
+func (m *syncCacheRepository) Exists(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inner.Exists(ctx, key)
+}
+
+// batchUSDAClient returns a single food named after each query and counts calls per query
+type batchUSDAClient struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (m *batchUSDAClient) SearchFoods(ctx context.Context, query string) (*domain.USDASearchResponse, error) {
+	return m.SearchFoodsWithOptions(ctx, query, domain.SearchOptions{})
+}
+
+func (m *batchUSDAClient) SearchFoodsWithOptions(ctx context.Context, query string, opts domain.SearchOptions) (*domain.USDASearchResponse, error) {
+	m.mu.Lock()
+	m.calls[query]++
+	m.mu.Unlock()
+	if strings.Contains(query, "unobtainium") {
+		return &domain.USDASearchResponse{}, nil
+	}
+	return &domain.USDASearchResponse{Foods: []domain.USDAFood{{FdcID: len(query), Description: query}}}, nil
+}
+
+func (m *batchUSDAClient) GetFoodDetails(ctx context.Context, fdcID string) (*domain.USDAFood, error) {
+	return nil, domain.ErrProductNotFound
+}
+
+func (m *batchUSDAClient) SearchByUPC(ctx context.Context, upc string) (*domain.USDAFood, error) {
+	return nil, domain.ErrProductNotFound
+}
+
+func newBatchTestService() (*NutritionService, *batchUSDAClient) {
+	client := &batchUSDAClient{calls: make(map[string]int)}
+	cache := &syncCacheRepository{inner: NewMockCacheRepository()}
+	return NewNutritionService(cache, client, NutritionServiceConfig{}), client
+}
+
+func TestSearchNutritionBatch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("returns results in input order", func(t *testing.T) {
+		svc, _ := newBatchTestService()
+		names := []string{"banana", "whole milk", "cheddar cheese", "brown rice", "apple", "chicken breast", "oats"}
+		requests := make([]domain.SearchRequest, len(names))
+		for i, name := range names {
+			requests[i] = domain.SearchRequest{ProductName: name}
+		}
+
+		results, err := svc.SearchNutritionBatch(ctx, requests)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(results) != len(names) {
+			t.Fatalf("len(results) = %d, want %d", len(results), len(names))
+		}
+		for i, result := range results {
+			if result.Err != nil {
+				t.Errorf("results[%d].Err = %v, want nil", i, result.Err)
+				continue
+			}
+			if !strings.EqualFold(result.Data.ProductName, names[i]) {
+				t.Errorf("results[%d].ProductName = %q, want %q", i, result.Data.ProductName, names[i])
+			}
+		}
+	})
+
+	t.Run("searches duplicate items once", func(t *testing.T) {
+		svc, client := newBatchTestService()
+		requests := []domain.SearchRequest{
+			{ProductName: "banana"},
+			{ProductName: "apple"},
+			{ProductName: "banana"},
+		}
+
+		results, err := svc.SearchNutritionBatch(ctx, requests)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if results[0].Data == nil || results[0].Data != results[2].Data {
+			t.Errorf("duplicate items should share a result, got %+v and %+v", results[0], results[2])
+		}
+		total := 0
+		for _, n := range client.calls {
+			total += n
+		}
+		if total != 2 {
+			t.Errorf("USDA searches = %d, want 2", total)
+		}
+	})
+
+	t.Run("reports per-item errors", func(t *testing.T) {
+		svc, _ := newBatchTestService()
+		requests := []domain.SearchRequest{
+			{ProductName: "banana"},
+			{ProductName: "unobtainium"},
+		}
+
+		results, err := svc.SearchNutritionBatch(ctx, requests)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if results[0].Err != nil {
+			t.Errorf("results[0].Err = %v, want nil", results[0].Err)
+		}
+		if !errors.Is(results[1].Err, domain.ErrProductNotFound) {
+			t.Errorf("results[1].Err = %v, want ErrProductNotFound", results[1].Err)
+		}
+	})
+
+	t.Run("rejects empty and oversized batches", func(t *testing.T) {
+		svc, _ := newBatchTestService()
+		for _, n := range []int{0, MaxBatchItems + 1} {
+			_, err := svc.SearchNutritionBatch(ctx, make([]domain.SearchRequest, n))
+			if !errors.Is(err, domain.ErrInvalidRequest) {
+				t.Errorf("%d items: err = %v, want ErrInvalidRequest", n, err)
+			}
+		}
+	})
+
+	t.Run("fails remaining items once the context is done", func(t *testing.T) {
+		svc, client := newBatchTestService()
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		results, err := svc.SearchNutritionBatch(cancelled, []domain.SearchRequest{{ProductName: "banana"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !errors.Is(results[0].Err, context.Canceled) {
+			t.Errorf("results[0].Err = %v, want context.Canceled", results[0].Err)
+		}
+		if len(client.calls) != 0 {
+			t.Errorf("USDA searches = %v, want none", client.calls)
+		}
+	})
+}
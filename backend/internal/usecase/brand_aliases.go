@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// shortBrandLength is the length below which a brand name earns a diminished
+// bonus. Short names like "gv" turn up inside unrelated words, so a match on one
+// earns brandBonus * len/shortBrandLength instead of the full bonus.
+const shortBrandLength = 4
+
+// defaultBrandAliases maps canonical brand names to the short forms and spellings
+// retailers and USDA descriptions use for them
+var defaultBrandAliases = map[string][]string{
+	"coca-cola":          {"coke", "coca cola"},
+	"pepsi":              {"pepsi-cola"},
+	"dr pepper":          {"dr. pepper"},
+	"great value":        {"gv"},
+	"kirkland signature": {"kirkland"},
+	"kellogg's":          {"kelloggs", "kellogg"},
+	"hershey's":          {"hersheys", "hershey"},
+	"reese's":            {"reeses"},
+	"m&m's":              {"m&ms", "m&m"},
+}
+
+// brandAliasTable maps each lowercased brand name to every name in its alias
+// group, canonical name first
+type brandAliasTable map[string][]string
+
+// newBrandAliasTable builds the alias groups. A name listed under several
+// canonical brands belongs to the first group it appears in, in sorted order.
+func newBrandAliasTable(aliases map[string][]string) brandAliasTable {
+	table := make(brandAliasTable)
+	for _, canonical := range slices.Sorted(maps.Keys(aliases)) {
+		group := []string{strings.ToLower(strings.TrimSpace(canonical))}
+		for _, alias := range aliases[canonical] {
+			if alias = strings.ToLower(strings.TrimSpace(alias)); alias != "" && alias != group[0] {
+				group = append(group, alias)
+			}
+		}
+		for _, name := range group {
+			if _, taken := table[name]; !taken {
+				table[name] = group
+			}
+		}
+	}
+	return table
+}
+
+// names returns the names to look for in a description for brand: its alias
+// group, or just the brand when it has none
+func (t brandAliasTable) names(brand string) []string {
+	brand = strings.ToLower(strings.TrimSpace(brand))
+	if group, ok := t[brand]; ok {
+		return group
+	}
+	return []string{brand}
+}
+
+// brandBonus returns the bonus for the longest of brand's names found in the
+// lowercased description, and that name; 0 and "" when none is found
+func (s *MatchingService) brandBonus(brand, usdaLower string) (float64, string) {
+	matched := ""
+	for _, name := range s.brandAliases.names(brand) {
+		if name != "" && len(name) > len(matched) && strings.Contains(usdaLower, name) {
+			matched = name
+		}
+	}
+	if matched == "" {
+		return 0, ""
+	}
+	if len(matched) < shortBrandLength {
+		return s.brandMatchBonus * float64(len(matched)) / shortBrandLength, matched
+	}
+	return s.brandMatchBonus, matched
+}
+
+// ParseBrandAliases parses brand aliases like "coca-cola:coke|coca cola,great value:gv"
+// (comma-separated brand:aliases entries, aliases separated by "|").
+// An empty spec returns nil.
+func ParseBrandAliases(spec string) (map[string][]string, error) {
+	return parseTermTable(spec, "brand alias", "brand:alias|alias")
+}
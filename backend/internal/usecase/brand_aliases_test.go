@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBrandBonus_Aliases(t *testing.T) {
+	const product = "Soda"
+	svc := NewMatchingService(MatchConfig{})
+	plain, _ := svc.scoreCandidate(product, "", "COCA-COLA Soda", "", false, nil)
+
+	tests := []struct {
+		brand    string
+		usdaDesc string
+	}{
+		{"Coke", "COCA-COLA Soda"},
+		{"Coca-Cola", "COKE Soda"},
+		{"coca cola", "COCA-COLA Soda"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.brand+"/"+tt.usdaDesc, func(t *testing.T) {
+			branded, _ := svc.scoreCandidate(product, tt.brand, tt.usdaDesc, "", false, nil)
+			if branded-plain != brandMatchBonus {
+				t.Errorf("score %.1f - unbranded %.1f = %.1f, want the %.0f point brand bonus", branded, plain, branded-plain, brandMatchBonus)
+			}
+		})
+	}
+
+	t.Run("unrelated brand", func(t *testing.T) {
+		if branded, _ := svc.scoreCandidate(product, "Pepsi", "COCA-COLA Soda", "", false, nil); branded != plain {
+			t.Errorf("score = %.1f, want the unbranded %.1f", branded, plain)
+		}
+	})
+}
+
+func TestBrandBonus(t *testing.T) {
+	svc := NewMatchingService(MatchConfig{BrandBonus: 20, BrandAliases: map[string][]string{"great value": {"gv"}}})
+
+	tests := []struct {
+		name      string
+		brand     string
+		usdaLower string
+		want      float64
+	}{
+		{"configured bonus", "Kraft", "kraft macaroni & cheese", 20},
+		{"short alias finds canonical name", "GV", "great value whole milk", 20},
+		{"short brand earns a diminished bonus", "GV", "gv whole milk", 10},
+		{"short unaliased brand", "A1", "a1 steak sauce", 10},
+		{"no match", "Kraft", "heinz ketchup", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := svc.brandBonus(tt.brand, tt.usdaLower); got != tt.want {
+				t.Errorf("brandBonus(%q, %q) = %v, want %v", tt.brand, tt.usdaLower, got, tt.want)
+			}
+		})
+	}
+
+	// The configured table replaces the built-in one
+	if got, _ := svc.brandBonus("Coke", "coca-cola soda"); got != 0 {
+		t.Errorf("brandBonus with configured aliases = %v, want 0 for the built-in coke alias", got)
+	}
+}
+
+func TestNewBrandAliasTable(t *testing.T) {
+	table := newBrandAliasTable(map[string][]string{"Coca-Cola": {" Coke ", "coca-cola", ""}})
+
+	for _, name := range []string{"coca-cola", "coke"} {
+		if !slices.Equal(table[name], []string{"coca-cola", "coke"}) {
+			t.Errorf("table[%s] = %v, want [coca-cola coke]", name, table[name])
+		}
+	}
+	if !slices.Equal(table.names("Pepsi"), []string{"pepsi"}) {
+		t.Errorf("names(Pepsi) = %v, want just the brand", table.names("Pepsi"))
+	}
+}
+
+func TestParseBrandAliases(t *testing.T) {
+	got, err := ParseBrandAliases(" Coca-Cola:coke|coca cola , great value:GV")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(got["coca-cola"], []string{"coke", "coca cola"}) || !slices.Equal(got["great value"], []string{"gv"}) {
+		t.Errorf("ParseBrandAliases = %v, want coca-cola and great value aliases", got)
+	}
+
+	if got, err := ParseBrandAliases(""); err != nil || got != nil {
+		t.Errorf("ParseBrandAliases(\"\") = %v, %v, want nil, nil", got, err)
+	}
+	for _, spec := range []string{"coke", ":coke", "coca-cola:"} {
+		if _, err := ParseBrandAliases(spec); err == nil {
+			t.Errorf("ParseBrandAliases(%q) error = nil, want error", spec)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/macrolens/backend/internal/domain"
+	"github.com/macrolens/backend/internal/infrastructure/cache"
+)
+
+func TestSearchNutrition_RequestCacheTTL(t *testing.T) {
+	ctx := context.Background()
+
+	newService := func(config NutritionServiceConfig) (*NutritionService, *cache.MemoryCache) {
+		memoryCache := cache.NewMemoryCache()
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{
+			Foods: []domain.USDAFood{{FdcID: 456, Description: "Whole Milk"}},
+		}
+		return NewNutritionService(memoryCache, client, config), memoryCache
+	}
+
+	t.Run("shorter TTL expires earlier", func(t *testing.T) {
+		svc, memoryCache := newService(NutritionServiceConfig{CacheTTL: time.Hour})
+		short := &domain.SearchRequest{ProductName: "whole milk", Brand: "Horizon", CacheTTL: "50ms"}
+		standard := &domain.SearchRequest{ProductName: "whole milk"}
+
+		for _, request := range []*domain.SearchRequest{short, standard} {
+			if _, err := svc.SearchNutrition(ctx, request); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		time.Sleep(100 * time.Millisecond)
+
+		if _, err := memoryCache.Get(ctx, svc.generateCacheKey(short)); err == nil {
+			t.Error("entry cached with a 50ms TTL is still present")
+		}
+		if _, err := memoryCache.Get(ctx, svc.generateCacheKey(standard)); err != nil {
+			t.Errorf("entry cached with the default TTL is gone: %v", err)
+		}
+	})
+
+	t.Run("clamped to the configured maximum", func(t *testing.T) {
+		svc, _ := newService(NutritionServiceConfig{CacheTTL: time.Hour, MaxRequestCacheTTL: 2 * time.Hour})
+
+		ttl, err := svc.requestCacheTTL(&domain.SearchRequest{CacheTTL: "720h"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ttl != 2*time.Hour {
+			t.Errorf("ttl = %s, want 2h", ttl)
+		}
+	})
+
+	t.Run("cannot outlive the default without a maximum", func(t *testing.T) {
+		svc, _ := newService(NutritionServiceConfig{CacheTTL: time.Hour})
+
+		ttl, err := svc.requestCacheTTL(&domain.SearchRequest{CacheTTL: "24h"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ttl != time.Hour {
+			t.Errorf("ttl = %s, want 1h", ttl)
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		svc, memoryCache := newService(NutritionServiceConfig{})
+
+		for _, value := range []string{"soon", "0s", "-5m"} {
+			_, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", CacheTTL: value})
+			if !errors.Is(err, domain.ErrInvalidRequest) {
+				t.Errorf("cacheTTL %q: error = %v, want ErrInvalidRequest", value, err)
+			}
+		}
+		if memoryCache.Size() != 0 {
+			t.Errorf("cache size = %d, want nothing cached", memoryCache.Size())
+		}
+	})
+}
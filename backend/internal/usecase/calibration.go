@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CalibrationPoint maps a raw match score to a calibrated confidence
+type CalibrationPoint struct {
+	Raw        float64
+	Calibrated float64
+}
+
+// calibrator applies a piecewise-linear calibration curve to raw scores
+type calibrator struct {
+	points []CalibrationPoint // Sorted by Raw; empty means identity
+}
+
+// newCalibrator builds a calibrator from points in any order.
+// Fewer than two points leaves scores unchanged.
+func newCalibrator(points []CalibrationPoint) calibrator {
+	if len(points) < 2 {
+		return calibrator{}
+	}
+
+	sorted := append([]CalibrationPoint(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Raw < sorted[j].Raw })
+	return calibrator{points: sorted}
+}
+
+// apply maps a raw score onto the curve, interpolating between neighbouring points
+// and clamping to the first/last calibrated value outside the curve's range
+func (c calibrator) apply(raw float64) float64 {
+	if len(c.points) == 0 {
+		return raw
+	}
+
+	first, last := c.points[0], c.points[len(c.points)-1]
+	if raw <= first.Raw {
+		return first.Calibrated
+	}
+	if raw >= last.Raw {
+		return last.Calibrated
+	}
+
+	for i := 1; i < len(c.points); i++ {
+		lo, hi := c.points[i-1], c.points[i]
+		if raw > hi.Raw {
+			continue
+		}
+		if hi.Raw == lo.Raw {
+			return hi.Calibrated
+		}
+		fraction := (raw - lo.Raw) / (hi.Raw - lo.Raw)
+		return lo.Calibrated + fraction*(hi.Calibrated-lo.Calibrated)
+	}
+
+	return last.Calibrated
+}
+
+// ProbabilityCalibration maps a 0-100 confidence to a 0-1 probability that the
+// match is correct with a logistic curve centred on Midpoint. Steepness sets how
+// quickly the probability rises around the midpoint; 0 disables calibration.
+type ProbabilityCalibration struct {
+	Midpoint  float64
+	Steepness float64
+}
+
+// enabled reports whether a probability should be reported at all
+func (p ProbabilityCalibration) enabled() bool {
+	return p.Steepness > 0
+}
+
+// apply returns the probability for confidence, rounded to four decimals
+func (p ProbabilityCalibration) apply(confidence float64) float64 {
+	probability := 1 / (1 + math.Exp(-p.Steepness*(confidence-p.Midpoint)))
+	return math.Round(probability*10000) / 10000
+}
+
+// ParseCalibration parses a calibration curve like "0:0,50:30,80:90,100:100"
+// (comma-separated raw:calibrated pairs, each 0-100). An empty spec returns nil.
+func ParseCalibration(spec string) ([]CalibrationPoint, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	var points []CalibrationPoint
+	for _, pair := range strings.Split(spec, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid calibration point %q: want raw:calibrated", pair)
+		}
+
+		raw, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calibration raw score %q: %w", parts[0], err)
+		}
+		calibrated, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calibrated score %q: %w", parts[1], err)
+		}
+		if raw < 0 || raw > 100 || calibrated < 0 || calibrated > 100 {
+			return nil, fmt.Errorf("calibration point %q out of range: scores must be 0-100", pair)
+		}
+
+		points = append(points, CalibrationPoint{Raw: raw, Calibrated: calibrated})
+	}
+
+	if len(points) < 2 {
+		return nil, fmt.Errorf("calibration needs at least 2 points, got %d", len(points))
+	}
+
+	return points, nil
+}
@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+func TestCalibrator_Apply(t *testing.T) {
+	// Deliberately unsorted to check the constructor orders points
+	c := newCalibrator([]CalibrationPoint{
+		{Raw: 80, Calibrated: 90},
+		{Raw: 20, Calibrated: 0},
+		{Raw: 50, Calibrated: 30},
+		{Raw: 100, Calibrated: 100},
+	})
+
+	testCases := []struct {
+		raw  float64
+		want float64
+	}{
+		{raw: 0, want: 0},     // Below the curve clamps to the first point
+		{raw: 20, want: 0},    // Exact point
+		{raw: 35, want: 15},   // Halfway between 20:0 and 50:30
+		{raw: 50, want: 30},   // Exact point
+		{raw: 65, want: 60},   // Halfway between 50:30 and 80:90
+		{raw: 90, want: 95},   // Halfway between 80:90 and 100:100
+		{raw: 100, want: 100}, // Last point
+	}
+
+	for _, tc := range testCases {
+		if got := c.apply(tc.raw); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("apply(%v) = %v, want %v", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestCalibrator_IdentityWithoutCurve(t *testing.T) {
+	for _, points := range [][]CalibrationPoint{nil, {{Raw: 50, Calibrated: 10}}} {
+		c := newCalibrator(points)
+		if got := c.apply(42); got != 42 {
+			t.Errorf("apply(42) with %v = %v, want 42", points, got)
+		}
+	}
+}
+
+func TestParseCalibration(t *testing.T) {
+	t.Run("parses points", func(t *testing.T) {
+		got, err := ParseCalibration(" 0:0, 50:30 ,100:100")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []CalibrationPoint{{0, 0}, {50, 30}, {100, 100}}
+		if len(got) != len(want) {
+			t.Fatalf("ParseCalibration() = %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("point[%d] = %v, want %v", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("empty spec disables calibration", func(t *testing.T) {
+		got, err := ParseCalibration("")
+		if err != nil || got != nil {
+			t.Errorf("ParseCalibration(\"\") = %v, %v, want nil, nil", got, err)
+		}
+	})
+
+	for _, spec := range []string{"50", "0:0,abc:10", "0:0,50:x", "0:0,150:100", "50:50"} {
+		t.Run("rejects "+spec, func(t *testing.T) {
+			if _, err := ParseCalibration(spec); err == nil {
+				t.Errorf("ParseCalibration(%q) error = nil, want error", spec)
+			}
+		})
+	}
+}
+
+func TestFindBestMatch_Calibration(t *testing.T) {
+	ctx := context.Background()
+	request := &domain.SearchRequest{ProductName: "whole milk"}
+	foods := []domain.USDAFood{{FdcID: 1, Description: "Whole Milk", DataType: "Foundation"}}
+
+	baseline, err := NewMatchingService(MatchConfig{MinConfidenceThreshold: 40}).FindBestMatch(ctx, request, foods)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if baseline.RawScore != baseline.MatchScore {
+		t.Errorf("uncalibrated RawScore = %v, want MatchScore %v", baseline.RawScore, baseline.MatchScore)
+	}
+
+	t.Run("reports calibrated score and keeps raw score", func(t *testing.T) {
+		svc := NewMatchingService(MatchConfig{
+			MinConfidenceThreshold: 40,
+			Calibration:            []CalibrationPoint{{Raw: 0, Calibrated: 0}, {Raw: 100, Calibrated: 50}},
+		})
+
+		result, err := svc.FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.RawScore != baseline.MatchScore {
+			t.Errorf("RawScore = %v, want %v", result.RawScore, baseline.MatchScore)
+		}
+		if want := baseline.MatchScore / 2; math.Abs(result.MatchScore-want) > 1e-9 {
+			t.Errorf("MatchScore = %v, want %v", result.MatchScore, want)
+		}
+	})
+
+	t.Run("threshold applies to calibrated score", func(t *testing.T) {
+		svc := NewMatchingService(MatchConfig{
+			MinConfidenceThreshold: 40,
+			Calibration:            []CalibrationPoint{{Raw: 0, Calibrated: 0}, {Raw: 100, Calibrated: 30}},
+		})
+
+		result, err := svc.FindBestMatch(ctx, request, foods)
+		if !errors.Is(err, domain.ErrLowConfidence) {
+			t.Errorf("error = %v, want ErrLowConfidence", err)
+		}
+		if result == nil || result.RawScore < 40 {
+			t.Errorf("result = %+v, want raw score above threshold", result)
+		}
+	})
+}
+
+func TestProbabilityCalibration(t *testing.T) {
+	p := ProbabilityCalibration{Midpoint: 60, Steepness: 0.1}
+
+	if got := p.apply(60); got != 0.5 {
+		t.Errorf("apply(midpoint) = %v, want 0.5", got)
+	}
+	previous := -1.0
+	for _, confidence := range []float64{0, 30, 60, 90, 100} {
+		got := p.apply(confidence)
+		if got < 0 || got > 1 || got <= previous {
+			t.Errorf("apply(%v) = %v, want a rising probability in [0,1]", confidence, got)
+		}
+		previous = got
+	}
+
+	t.Run("search reports it beside the raw confidence", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{
+			Foods: []domain.USDAFood{{FdcID: 456, Description: "Whole Milk"}},
+		}
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{ProbabilityCalibration: p})
+
+		for _, source := range []string{"USDA", "Cache"} {
+			result, err := svc.SearchNutrition(context.Background(), &domain.SearchRequest{ProductName: "Whole Milk"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Source != source {
+				t.Fatalf("Source = %s, want %s", result.Source, source)
+			}
+			if result.CalibratedConfidence == nil || *result.CalibratedConfidence != p.apply(result.Confidence) {
+				t.Errorf("%s: CalibratedConfidence = %v, want %v", source, result.CalibratedConfidence, p.apply(result.Confidence))
+			}
+		}
+		for key, value := range cache.data {
+			if data, ok := value.(*domain.NutritionData); ok && data.CalibratedConfidence != nil {
+				t.Errorf("cached %s carries calibratedConfidence, want it derived per response", key)
+			}
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{
+			Foods: []domain.USDAFood{{FdcID: 456, Description: "Whole Milk"}},
+		}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		result, err := svc.SearchNutrition(context.Background(), &domain.SearchRequest{ProductName: "Whole Milk"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.CalibratedConfidence != nil {
+			t.Errorf("CalibratedConfidence = %v, want nil", *result.CalibratedConfidence)
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/macrolens/backend/internal/domain"
+	"github.com/macrolens/backend/internal/infrastructure/usda"
+)
+
+// Candidate page sizes: the default when none is given, and the most one page holds
+const (
+	DefaultCandidatePageSize = 10
+	MaxCandidatePageSize     = 50
+)
+
+// candidatePoolSize is how many USDA search results a candidate listing scores and
+// pages through: USDA's largest page, rather than the 10 a search asks for
+const candidatePoolSize = 200
+
+// candidateSearchCacheTTL is how long a candidate listing's USDA search is reused,
+// long enough for a user to page through it
+const candidateSearchCacheTTL = time.Hour
+
+// ScoredCandidate is a USDA food scored against a product, with its nutrients as
+// USDA reports them (per label serving or per 100g)
+type ScoredCandidate struct {
+	FdcID       string           `json:"fdcId"`
+	Description string           `json:"description"`
+	DataType    string           `json:"dataType,omitempty"`
+	Confidence  float64          `json:"confidence"`
+	Nutrients   domain.Nutrients `json:"nutrients"`
+}
+
+// CandidatePage is one page of scored candidates, best first. The paging fields
+// count the scored pool: USDA's top candidatePoolSize results for the search.
+type CandidatePage struct {
+	Candidates  []ScoredCandidate `json:"candidates"`
+	TotalHits   int               `json:"totalHits"`
+	CurrentPage int               `json:"currentPage"`
+	TotalPages  int               `json:"totalPages"`
+	PageSize    int               `json:"pageSize"`
+}
+
+// ListCandidates scores every USDA search result for a product and returns one
+// page of them, for letting a user pick the right food. page counts from 1 and
+// defaults to 1; pageSize defaults to DefaultCandidatePageSize and is clamped to
+// MaxCandidatePageSize. A page past the last one is empty. The USDA search is
+// cached for an hour so paging doesn't repeat it.
+func (s *NutritionService) ListCandidates(
+	ctx context.Context,
+	request *domain.SearchRequest,
+	page, pageSize int,
+) (*CandidatePage, error) {
+	if request == nil {
+		return nil, domain.ErrInvalidRequest
+	}
+	if page < 0 || pageSize < 0 {
+		return nil, fmt.Errorf("%w: page and pageSize must be positive", domain.ErrInvalidRequest)
+	}
+	page = max(page, 1)
+	if pageSize == 0 {
+		pageSize = DefaultCandidatePageSize
+	}
+	pageSize = min(pageSize, MaxCandidatePageSize)
+
+	request, err := sanitizeRequest(request, s.rejectControlChars)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(request.ProductName) == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	query, preferGeneric := s.searchQuery(request)
+	searchResult, err := s.cachedSearchFoods(ctx, request, query, preferGeneric)
+	if err != nil {
+		return nil, err
+	}
+
+	matchRequest := s.matchRequest(request, query, preferGeneric)
+	matches, err := s.matchingService.FindTopMatches(ctx, &matchRequest, searchResult.Foods, len(searchResult.Foods))
+	if err != nil && !errors.Is(err, domain.ErrLowConfidence) {
+		return nil, err
+	}
+
+	result := &CandidatePage{
+		Candidates:  []ScoredCandidate{},
+		TotalHits:   len(matches),
+		CurrentPage: page,
+		TotalPages:  (len(matches) + pageSize - 1) / pageSize,
+		PageSize:    pageSize,
+	}
+	start := (page - 1) * pageSize
+	if start >= len(matches) {
+		return result, nil
+	}
+	pageMatches := matches[start:min(start+pageSize, len(matches))]
+
+	foods := make(map[string]*domain.USDAFood, len(searchResult.Foods))
+	for i := range searchResult.Foods {
+		foods[strconv.Itoa(searchResult.Foods[i].FdcID)] = &searchResult.Foods[i]
+	}
+	details := s.candidateDetails(ctx, pageMatches)
+	for _, match := range pageMatches {
+		candidate := ScoredCandidate{
+			FdcID:       match.FdcID,
+			Description: match.Description,
+			Confidence:  match.MatchScore,
+		}
+		if food := foods[match.FdcID]; food != nil {
+			candidate.DataType = food.DataType
+			candidate.Nutrients = usda.MapToNutritionData(food, match.MatchScore).Nutrients
+		}
+		if detailed := details[match.FdcID]; detailed != nil {
+			candidate.Nutrients = mergeNutrients(usda.MapToNutritionData(detailed, match.MatchScore).Nutrients, candidate.Nutrients)
+		}
+		result.Candidates = append(result.Candidates, candidate)
+	}
+	return result, nil
+}
+
+// cachedSearchFoods searches USDA like a search does, reusing a cached result for
+// the same query and data types
+func (s *NutritionService) cachedSearchFoods(
+	ctx context.Context,
+	request *domain.SearchRequest,
+	query string,
+	preferGeneric bool,
+) (*domain.USDASearchResponse, error) {
+	cacheKey := s.cacheKeyPrefix + "search:" + normalizeForCacheKey(query)
+	if s.brandAwareDataTypes {
+		cacheKey += ":" + strings.Join(s.searchDataTypesFor(request.Brand, preferGeneric), ",")
+	}
+	if cached, ok := s.cachedSearchResult(ctx, cacheKey); ok {
+		return cached, nil
+	}
+
+	searchResult, err := s.searchFoods(ctx, request, query, preferGeneric, candidatePoolSize)
+	if err != nil {
+		if errors.Is(err, domain.ErrProductNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", domain.ErrUSDAAPIFailure, err)
+	}
+	if searchResult == nil || len(searchResult.Foods) == 0 {
+		return nil, domain.ErrProductNotFound
+	}
+
+	if err := s.cache.Set(ctx, cacheKey, searchResult, candidateSearchCacheTTL); err != nil {
+		// Caching is best-effort; the next page just searches again
+	}
+	return searchResult, nil
+}
+
+// cachedSearchResult returns the search result cached under key, if any
+func (s *NutritionService) cachedSearchResult(ctx context.Context, key string) (*domain.USDASearchResponse, bool) {
+	value, err := s.cache.Get(ctx, key)
+	if err != nil {
+		return nil, false
+	}
+	if result, ok := value.(*domain.USDASearchResponse); ok {
+		return result, len(result.Foods) > 0
+	}
+
+	// Caches that serialize values hand them back as maps
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	var result domain.USDASearchResponse
+	if err := json.Unmarshal(raw, &result); err != nil || len(result.Foods) == 0 {
+		return nil, false
+	}
+	return &result, true
+}
+
+// candidateDetails fetches full details for a page of candidates in as few
+// requests as USDA's bulk limit allows, when food details are enabled and the
+// client supports bulk lookups. Failed lookups leave the search result's nutrients.
+func (s *NutritionService) candidateDetails(ctx context.Context, matches []*domain.MatchResult) map[string]*domain.USDAFood {
+	bulk, ok := s.usdaClient.(domain.BulkFoodDetailsClient)
+	if !s.fetchFoodDetails || !ok || len(matches) == 0 {
+		return nil
+	}
+
+	fdcIDs := make([]string, len(matches))
+	for i, match := range matches {
+		fdcIDs[i] = match.FdcID
+	}
+	details := make(map[string]*domain.USDAFood, len(fdcIDs))
+	for chunk := range slices.Chunk(fdcIDs, usda.MaxBulkFoodIDs) {
+		fetched, err := bulk.GetFoodsDetails(ctx, chunk)
+		if err != nil {
+			continue
+		}
+		maps.Copy(details, fetched)
+	}
+	return details
+}
@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// bulkUSDAClient adds bulk food details to MockUSDAClient
+type bulkUSDAClient struct {
+	*MockUSDAClient
+	details   map[string]*domain.USDAFood
+	bulkCalls [][]string
+}
+
+func (c *bulkUSDAClient) GetFoodsDetails(ctx context.Context, fdcIDs []string) (map[string]*domain.USDAFood, error) {
+	c.bulkCalls = append(c.bulkCalls, fdcIDs)
+	found := make(map[string]*domain.USDAFood)
+	for _, fdcID := range fdcIDs {
+		if food, ok := c.details[fdcID]; ok {
+			found[fdcID] = food
+		}
+	}
+	return found, nil
+}
+
+// pagedUSDAClient serves one page of a larger result set like USDA does, 10 foods
+// unless the search asks for more
+type pagedUSDAClient struct {
+	*MockUSDAClient
+	pool []domain.USDAFood
+}
+
+func (c *pagedUSDAClient) SearchFoodsWithOptions(ctx context.Context, query string, opts domain.SearchOptions) (*domain.USDASearchResponse, error) {
+	pageSize := opts.PageSize
+	if pageSize == 0 {
+		pageSize = 10
+	}
+	foods := c.pool[:min(pageSize, len(c.pool))]
+	return &domain.USDASearchResponse{Foods: foods, TotalHits: len(c.pool), CurrentPage: 1, TotalPages: (len(c.pool) + pageSize - 1) / pageSize}, nil
+}
+
+func TestListCandidates(t *testing.T) {
+	ctx := context.Background()
+	request := &domain.SearchRequest{ProductName: "whole milk"}
+	newClient := func() *MockUSDAClient {
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{
+			Foods: []domain.USDAFood{
+				{FdcID: 1, Description: "Whole Milk", DataType: "Branded", Nutrients: []domain.USDANutrient{
+					{NutrientID: 1008, Value: 150}, // Calories
+				}},
+				{FdcID: 2, Description: "Cereal with whole milk", DataType: "Branded"},
+				{FdcID: 3, Description: "Milk, whole, 3.25% milkfat", DataType: "Survey (FNDDS)"},
+				{FdcID: 4, Description: "Milk, chocolate", DataType: "Branded"},
+				{FdcID: 5, Description: "Whole wheat bread", DataType: "Branded"},
+			},
+		}
+		return client
+	}
+
+	t.Run("pages through scored candidates with one search", func(t *testing.T) {
+		client := newClient()
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		var seen []string
+		var confidences []float64
+		for page := 1; page <= 3; page++ {
+			result, err := svc.ListCandidates(ctx, request, page, 2)
+			if err != nil {
+				t.Fatalf("page %d: unexpected error: %v", page, err)
+			}
+			if result.TotalHits != 5 || result.TotalPages != 3 || result.CurrentPage != page {
+				t.Errorf("page %d: totals = %d hits, %d pages, page %d; want 5, 3, %d",
+					page, result.TotalHits, result.TotalPages, result.CurrentPage, page)
+			}
+			for _, candidate := range result.Candidates {
+				seen = append(seen, candidate.FdcID)
+				confidences = append(confidences, candidate.Confidence)
+			}
+		}
+
+		if client.searchCalls != 1 {
+			t.Errorf("searchCalls = %d, want 1 across pages", client.searchCalls)
+		}
+		if len(seen) != 5 || seen[0] != "1" || seen[4] != "5" {
+			t.Errorf("candidates = %v, want all 5 from whole milk to whole wheat bread", seen)
+		}
+		if !slices.IsSortedFunc(confidences, func(a, b float64) int { return cmp.Compare(b, a) }) {
+			t.Errorf("confidences = %v, want best first", confidences)
+		}
+
+		past, err := svc.ListCandidates(ctx, request, 4, 2)
+		if err != nil || len(past.Candidates) != 0 {
+			t.Errorf("page past the end = %+v, %v, want empty", past, err)
+		}
+	})
+
+	t.Run("candidates carry nutrients", func(t *testing.T) {
+		svc := NewNutritionService(NewMockCacheRepository(), newClient(), NutritionServiceConfig{})
+
+		result, err := svc.ListCandidates(ctx, request, 1, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := result.Candidates[0]; got.Nutrients.Calories != 150 || got.DataType != "Branded" {
+			t.Errorf("candidate = %+v, want 150 calories from the search result", got)
+		}
+	})
+
+	t.Run("food details fill in nutrients in one bulk call", func(t *testing.T) {
+		client := &bulkUSDAClient{
+			MockUSDAClient: newClient(),
+			details: map[string]*domain.USDAFood{
+				"1": {FdcID: 1, Description: "Whole Milk", Nutrients: []domain.USDANutrient{
+					{NutrientID: 1008, Value: 149}, // Calories
+					{NutrientID: 1003, Value: 8},   // Protein
+				}},
+			},
+		}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{FetchFoodDetails: true})
+
+		result, err := svc.ListCandidates(ctx, request, 1, 3)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(client.bulkCalls) != 1 || len(client.bulkCalls[0]) != 3 {
+			t.Errorf("bulk calls = %v, want one for the page's 3 candidates", client.bulkCalls)
+		}
+		if got := result.Candidates[0].Nutrients; got.Calories != 149 || got.Protein != 8 {
+			t.Errorf("nutrients = %+v, want the detailed 149 calories and 8g protein", got)
+		}
+	})
+
+	t.Run("page size defaults and clamps", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{}
+		for i := 1; i <= 60; i++ {
+			client.searchResult.Foods = append(client.searchResult.Foods,
+				domain.USDAFood{FdcID: i, Description: "Whole milk " + strconv.Itoa(i), DataType: "Branded"})
+		}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		for _, tc := range []struct{ pageSize, want int }{{0, DefaultCandidatePageSize}, {500, MaxCandidatePageSize}} {
+			result, err := svc.ListCandidates(ctx, request, 0, tc.pageSize)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.PageSize != tc.want || len(result.Candidates) != tc.want || result.CurrentPage != 1 {
+				t.Errorf("pageSize %d: got size %d with %d candidates on page %d, want %d on page 1",
+					tc.pageSize, result.PageSize, len(result.Candidates), result.CurrentPage, tc.want)
+			}
+		}
+	})
+
+	t.Run("pages through more than USDA's default page", func(t *testing.T) {
+		client := &pagedUSDAClient{MockUSDAClient: NewMockUSDAClient()}
+		for i := 1; i <= 60; i++ {
+			client.pool = append(client.pool, domain.USDAFood{FdcID: i, Description: "Whole milk " + strconv.Itoa(i), DataType: "Branded"})
+		}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		result, err := svc.ListCandidates(ctx, request, 2, 50)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.TotalHits != 60 || result.TotalPages != 2 || len(result.Candidates) != 10 {
+			t.Errorf("got %d hits over %d pages with %d candidates on page 2, want 60, 2 and 10",
+				result.TotalHits, result.TotalPages, len(result.Candidates))
+		}
+	})
+
+	t.Run("rejects invalid requests", func(t *testing.T) {
+		svc := NewNutritionService(NewMockCacheRepository(), newClient(), NutritionServiceConfig{})
+
+		for _, tc := range []struct {
+			request        *domain.SearchRequest
+			page, pageSize int
+		}{
+			{request, -1, 10},
+			{request, 1, -1},
+			{&domain.SearchRequest{ProductName: " "}, 1, 10},
+		} {
+			if _, err := svc.ListCandidates(ctx, tc.request, tc.page, tc.pageSize); !errors.Is(err, domain.ErrInvalidRequest) {
+				t.Errorf("ListCandidates(%q, %d, %d) error = %v, want ErrInvalidRequest", tc.request.ProductName, tc.page, tc.pageSize, err)
+			}
+		}
+	})
+
+	t.Run("no results", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		if _, err := svc.ListCandidates(ctx, request, 1, 10); !errors.Is(err, domain.ErrProductNotFound) {
+			t.Errorf("error = %v, want ErrProductNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// gatedUSDAClient counts searches and holds each one until release is closed or
+// its context ends, so concurrent requests pile up behind the first
+type gatedUSDAClient struct {
+	release chan struct{}
+	calls   atomic.Int32
+	err     error
+}
+
+func (m *gatedUSDAClient) SearchFoods(ctx context.Context, query string) (*domain.USDASearchResponse, error) {
+	return m.SearchFoodsWithOptions(ctx, query, domain.SearchOptions{})
+}
+
+func (m *gatedUSDAClient) SearchFoodsWithOptions(ctx context.Context, query string, opts domain.SearchOptions) (*domain.USDASearchResponse, error) {
+	m.calls.Add(1)
+	select {
+	case <-m.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &domain.USDASearchResponse{Foods: []domain.USDAFood{{FdcID: 456, Description: "Whole Milk"}}}, nil
+}
+
+func (m *gatedUSDAClient) GetFoodDetails(ctx context.Context, fdcID string) (*domain.USDAFood, error) {
+	return nil, domain.ErrProductNotFound
+}
+
+func (m *gatedUSDAClient) SearchByUPC(ctx context.Context, upc string) (*domain.USDAFood, error) {
+	return nil, domain.ErrProductNotFound
+}
+
+// countingCacheRepository counts writes to a concurrency-safe mock cache
+type countingCacheRepository struct {
+	syncCacheRepository
+	sets atomic.Int32
+}
+
+func (m *countingCacheRepository) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	m.sets.Add(1)
+	return m.syncCacheRepository.Set(ctx, key, value, ttl)
+}
+
+func TestSearchNutrition_CoalescesConcurrentRequests(t *testing.T) {
+	const requests = 10
+
+	// search runs identical searches concurrently and releases USDA once they've
+	// had time to queue up behind the first
+	search := func(svc *NutritionService, client *gatedUSDAClient) ([]*domain.NutritionData, []error) {
+		results := make([]*domain.NutritionData, requests)
+		errs := make([]error, requests)
+		var wg sync.WaitGroup
+		for i := range requests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], errs[i] = svc.SearchNutrition(context.Background(), &domain.SearchRequest{ProductName: "Whole Milk"})
+			}()
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(client.release)
+		wg.Wait()
+		return results, errs
+	}
+
+	t.Run("one USDA call and one cache write", func(t *testing.T) {
+		client := &gatedUSDAClient{release: make(chan struct{})}
+		cache := &countingCacheRepository{syncCacheRepository: syncCacheRepository{inner: NewMockCacheRepository()}}
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{})
+
+		results, errs := search(svc, client)
+
+		for i := range requests {
+			if errs[i] != nil {
+				t.Fatalf("request %d: unexpected error: %v", i, errs[i])
+			}
+			if results[i].FdcID != "456" {
+				t.Errorf("request %d: FdcID = %s, want 456", i, results[i].FdcID)
+			}
+		}
+		if got := client.calls.Load(); got != 1 {
+			t.Errorf("USDA calls = %d, want 1", got)
+		}
+		if got := cache.sets.Load(); got != 1 {
+			t.Errorf("cache writes = %d, want 1", got)
+		}
+		if results[0] == results[1] {
+			t.Error("callers share one NutritionData, want a copy each")
+		}
+	})
+
+	t.Run("different retailers do not share a call", func(t *testing.T) {
+		client := &gatedUSDAClient{release: make(chan struct{})}
+		svc := NewNutritionService(&syncCacheRepository{inner: NewMockCacheRepository()}, client, NutritionServiceConfig{})
+
+		var wg sync.WaitGroup
+		for _, retailer := range []string{"target", "walmart"} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := svc.SearchNutrition(context.Background(), &domain.SearchRequest{ProductName: "Whole Milk", Retailer: retailer}); err != nil {
+					t.Errorf("retailer %s: unexpected error: %v", retailer, err)
+				}
+			}()
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(client.release)
+		wg.Wait()
+
+		if got := client.calls.Load(); got != 2 {
+			t.Errorf("USDA calls = %d, want 2", got)
+		}
+	})
+
+	t.Run("a waiter outlives the first caller cancelling", func(t *testing.T) {
+		client := &gatedUSDAClient{release: make(chan struct{})}
+		svc := NewNutritionService(&syncCacheRepository{inner: NewMockCacheRepository()}, client, NutritionServiceConfig{})
+
+		leaderCtx, cancelLeader := context.WithCancel(context.Background())
+		leaderErr := make(chan error, 1)
+		go func() {
+			_, err := svc.SearchNutrition(leaderCtx, &domain.SearchRequest{ProductName: "Whole Milk"})
+			leaderErr <- err
+		}()
+		time.Sleep(20 * time.Millisecond)
+
+		timings := &domain.PhaseTimings{}
+		waiterCtx := domain.WithPhaseTimings(context.Background(), timings)
+		type searchResult struct {
+			data *domain.NutritionData
+			err  error
+		}
+		waiter := make(chan searchResult, 1)
+		go func() {
+			data, err := svc.SearchNutrition(waiterCtx, &domain.SearchRequest{ProductName: "Whole Milk"})
+			waiter <- searchResult{data, err}
+		}()
+		time.Sleep(20 * time.Millisecond)
+
+		cancelLeader()
+		if err := <-leaderErr; !errors.Is(err, domain.ErrUSDAAPIFailure) {
+			t.Errorf("leader error = %v, want ErrUSDAAPIFailure", err)
+		}
+		close(client.release)
+
+		result := <-waiter
+		if result.err != nil {
+			t.Fatalf("waiter: unexpected error: %v", result.err)
+		}
+		if result.data.FdcID != "456" {
+			t.Errorf("waiter FdcID = %s, want 456", result.data.FdcID)
+		}
+		if got := client.calls.Load(); got != 1 {
+			t.Errorf("USDA calls = %d, want 1", got)
+		}
+		var phases []string
+		for _, phase := range timings.Phases() {
+			phases = append(phases, phase.Name)
+		}
+		if want := []string{domain.PhaseCache, domain.PhaseUSDA, domain.PhaseMatch}; !slices.Equal(phases, want) {
+			t.Errorf("waiter phases = %v, want %v", phases, want)
+		}
+	})
+
+	t.Run("every waiter gets the error", func(t *testing.T) {
+		client := &gatedUSDAClient{release: make(chan struct{}), err: errors.New("connection reset")}
+		svc := NewNutritionService(&syncCacheRepository{inner: NewMockCacheRepository()}, client, NutritionServiceConfig{})
+
+		_, errs := search(svc, client)
+
+		for i, err := range errs {
+			if !errors.Is(err, domain.ErrUSDAAPIFailure) {
+				t.Errorf("request %d: error = %v, want ErrUSDAAPIFailure", i, err)
+			}
+		}
+		if got := client.calls.Load(); got != 1 {
+			t.Errorf("USDA calls = %d, want 1", got)
+		}
+	})
+}
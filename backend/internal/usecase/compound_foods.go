@@ -0,0 +1,57 @@
+package usecase
+
+import "strings"
+
+// defaultCompoundFoods are food names made of words that mean something else on
+// their own. Split up, "hot dog" shares "hot" with "hot sauce" and "ice cream"
+// shares "ice" with ice cubes.
+var defaultCompoundFoods = []string{
+	"ice cream", "hot dog", "corn dog", "peanut butter", "almond butter",
+	"cream cheese", "cottage cheese", "sour cream", "whipped cream",
+	"french fries", "hash browns", "potato chips", "hot sauce", "soy sauce",
+	"frozen yogurt", "olive oil",
+}
+
+// defaultCompoundPhrases is defaultCompoundFoods tokenized for matching
+var defaultCompoundPhrases = compoundPhrases(defaultCompoundFoods)
+
+// compoundPhrases tokenizes compound food names like product names, singularized
+// so plural forms ("ice creams") match too. Single-word entries are dropped.
+func compoundPhrases(names []string) [][]string {
+	var phrases [][]string
+	for _, name := range names {
+		if words := singularizeAll(tokenize(name)); len(words) > 1 {
+			phrases = append(phrases, words)
+		}
+	}
+	return phrases
+}
+
+// compoundAt returns the first phrase found at tokens[i], or nil. Two-word phrases
+// also match reversed, since USDA names foods noun first ("Cheese, cream").
+func compoundAt(phrases [][]string, tokens []string, i int) []string {
+	for _, phrase := range phrases {
+		if i+len(phrase) > len(tokens) {
+			continue
+		}
+		if len(phrase) == 2 && singularize(tokens[i]) == phrase[1] && singularize(tokens[i+1]) == phrase[0] {
+			return phrase
+		}
+		matched := true
+		for j, word := range phrase {
+			if singularize(tokens[i+j]) != word {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return phrase
+		}
+	}
+	return nil
+}
+
+// compoundToken is the single weighted token standing in for a compound food
+func compoundToken(phrase []string) TokenWeight {
+	return TokenWeight{Token: strings.Join(phrase, "-"), Weight: weightCompound}
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+func TestTokenizeWithWeights_CompoundFoods(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  []TokenWeight
+	}{
+		{"Vanilla Ice Cream", []TokenWeight{{"vanilla", weightDescriptive}, {"ice-cream", weightCompound}}},
+		{"Beef Hot Dogs", []TokenWeight{{"beef", weightFood}, {"hot-dog", weightCompound}}},
+		{"Creamy Peanut Butter", []TokenWeight{{"creamy", weightDefault}, {"peanut-butter", weightCompound}}},
+		{"Ice creams, chocolate", []TokenWeight{{"ice-cream", weightCompound}, {"chocolate", weightFood}}},
+		{"Cheese, cream", []TokenWeight{{"cream-cheese", weightCompound}}},
+		{"Sauce, hot chile", []TokenWeight{{"hot-sauce", weightCompound}, {"chile", weightDefault}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			for name, tokenize := range map[string]func(string) []TokenWeight{
+				"default":            tokenizeWithWeights,
+				"configured service": NewMatchingService(MatchConfig{NormalizePlurals: true}).tokenizeWithWeights,
+			} {
+				if got := tokenize(tc.input); !slices.Equal(got, tc.want) {
+					t.Errorf("%s: tokenizeWithWeights(%q) = %v, want %v", name, tc.input, got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenizeWithWeights_CustomCompoundFoods(t *testing.T) {
+	svc := NewMatchingService(MatchConfig{CompoundFoods: []string{"Kettle Corn"}})
+
+	got := svc.tokenizeWithWeights("kettle corn ice cream")
+	want := []TokenWeight{
+		{Token: "kettle-corn", Weight: weightCompound},
+		{Token: "ice", Weight: weightDefault},
+		{Token: "cream", Weight: weightFood},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("tokenizeWithWeights() = %v, want %v (configured list replaces the built-in one)", got, want)
+	}
+}
+
+func TestFindBestMatch_CompoundFoods(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMatchingService(MatchConfig{MinConfidenceThreshold: 40})
+
+	t.Run("hot dog does not match hot sauce", func(t *testing.T) {
+		_, matched := svc.calculateMatchScore("hot dog", "", "Sauce, hot", "")
+		if len(matched) != 0 {
+			t.Errorf("MatchedTokens = %v, want none", matched)
+		}
+
+		foods := []domain.USDAFood{
+			{FdcID: 1, Description: "Hot sauce, cayenne pepper", DataType: "Branded"},
+			{FdcID: 2, Description: "Hot dog, beef", DataType: "Branded"},
+		}
+		result, err := svc.FindBestMatch(ctx, &domain.SearchRequest{ProductName: "hot dog"}, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "2" {
+			t.Errorf("FdcID = %v (%s), want 2 (hot dog)", result.FdcID, result.Description)
+		}
+	})
+
+	t.Run("matches USDA noun-first names", func(t *testing.T) {
+		foods := []domain.USDAFood{
+			{FdcID: 1, Description: "Cheese, cheddar", DataType: "Survey (FNDDS)"},
+			{FdcID: 2, Description: "Cheese, cream", DataType: "Survey (FNDDS)"},
+		}
+		result, err := svc.FindBestMatch(ctx, &domain.SearchRequest{ProductName: "Philadelphia Cream Cheese"}, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "2" || !slices.Contains(result.MatchedTokens, "cream-cheese") {
+			t.Errorf("result = %v %v, want 2 matched on cream-cheese", result.FdcID, result.MatchedTokens)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// histogramBucketWidth is the confidence range covered by each bucket (0-20, 20-40, ...)
+	histogramBucketWidth = 20
+	histogramBuckets     = 100 / histogramBucketWidth
+
+	// histogramSlots is how many sub-intervals the window is split into. The window
+	// rolls forward one slot at a time, dropping the oldest slot's counts.
+	histogramSlots = 12
+)
+
+// HistogramBucket is the number of served matches with confidence in [Min, Max)
+type HistogramBucket struct {
+	Min   int `json:"min"`
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+// HistogramSnapshot is the confidence distribution over the last window
+type HistogramSnapshot struct {
+	Window  string            `json:"window"`
+	Total   int               `json:"total"`
+	Buckets []HistogramBucket `json:"buckets"`
+}
+
+// String formats the snapshot for logging, e.g. "0-20:1 20-40:0 ... (total 12, window 1h0m0s)"
+func (h HistogramSnapshot) String() string {
+	parts := make([]string, 0, len(h.Buckets))
+	for _, bucket := range h.Buckets {
+		parts = append(parts, fmt.Sprintf("%d-%d:%d", bucket.Min, bucket.Max, bucket.Count))
+	}
+	return fmt.Sprintf("%s (total %d, window %s)", strings.Join(parts, " "), h.Total, h.Window)
+}
+
+// histogramSlot holds bucket counts for one sub-interval of the window
+type histogramSlot struct {
+	start  time.Time
+	counts [histogramBuckets]int
+}
+
+// ConfidenceHistogram is a rolling histogram of served match confidences, used to
+// spot drift in match quality without per-request logging
+type ConfidenceHistogram struct {
+	window   time.Duration
+	slotSize time.Duration
+	slots    [histogramSlots]histogramSlot
+	mutex    sync.Mutex
+
+	now func() time.Time // Injectable clock for tests
+}
+
+// NewConfidenceHistogram creates a histogram covering the given window
+func NewConfidenceHistogram(window time.Duration) *ConfidenceHistogram {
+	if window < histogramSlots {
+		window = time.Hour
+	}
+	return &ConfidenceHistogram{
+		window:   window,
+		slotSize: window / histogramSlots,
+		now:      time.Now,
+	}
+}
+
+// Record adds one served confidence (0-100; out-of-range values are clamped)
+func (h *ConfidenceHistogram) Record(confidence float64) {
+	bucket := int(confidence) / histogramBucketWidth
+	if bucket < 0 {
+		bucket = 0
+	}
+	if bucket >= histogramBuckets {
+		bucket = histogramBuckets - 1 // 100 belongs to the top bucket
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	start := h.now().Truncate(h.slotSize)
+	slot := &h.slots[(start.UnixNano()/int64(h.slotSize))%histogramSlots]
+	if !slot.start.Equal(start) {
+		// The slot last held counts from a full window ago; reuse it
+		*slot = histogramSlot{start: start}
+	}
+	slot.counts[bucket]++
+}
+
+// Snapshot returns the distribution over the last window
+func (h *ConfidenceHistogram) Snapshot() HistogramSnapshot {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	var counts [histogramBuckets]int
+	cutoff := h.now().Truncate(h.slotSize).Add(-h.window)
+	for _, slot := range h.slots {
+		if !slot.start.After(cutoff) {
+			continue
+		}
+		for i, count := range slot.counts {
+			counts[i] += count
+		}
+	}
+
+	snapshot := HistogramSnapshot{
+		Window:  h.window.String(),
+		Buckets: make([]HistogramBucket, histogramBuckets),
+	}
+	for i, count := range counts {
+		snapshot.Buckets[i] = HistogramBucket{
+			Min:   i * histogramBucketWidth,
+			Max:   (i + 1) * histogramBucketWidth,
+			Count: count,
+		}
+		snapshot.Total += count
+	}
+	return snapshot
+}
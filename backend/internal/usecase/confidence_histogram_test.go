@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfidenceHistogram(t *testing.T) {
+	t.Run("counts scores into 20-point buckets", func(t *testing.T) {
+		h := NewConfidenceHistogram(time.Hour)
+		for _, score := range []float64{0, 19.9, 20, 45, 59, 65, 85, 99, 100, -5, 120} {
+			h.Record(score)
+		}
+
+		snapshot := h.Snapshot()
+		want := []int{3, 1, 2, 1, 4}
+		for i, bucket := range snapshot.Buckets {
+			if bucket.Count != want[i] {
+				t.Errorf("bucket %d-%d = %d, want %d", bucket.Min, bucket.Max, bucket.Count, want[i])
+			}
+		}
+		if snapshot.Total != 11 {
+			t.Errorf("Total = %d, want 11", snapshot.Total)
+		}
+		if snapshot.Buckets[4].Min != 80 || snapshot.Buckets[4].Max != 100 {
+			t.Errorf("top bucket = %d-%d, want 80-100", snapshot.Buckets[4].Min, snapshot.Buckets[4].Max)
+		}
+	})
+
+	t.Run("drops scores older than the window", func(t *testing.T) {
+		now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		h := NewConfidenceHistogram(time.Hour)
+		h.now = func() time.Time { return now }
+
+		h.Record(10)
+		now = now.Add(30 * time.Minute)
+		h.Record(90)
+
+		if got := h.Snapshot().Total; got != 2 {
+			t.Errorf("Total within window = %d, want 2", got)
+		}
+
+		now = now.Add(45 * time.Minute)
+		snapshot := h.Snapshot()
+		if snapshot.Total != 1 || snapshot.Buckets[4].Count != 1 {
+			t.Errorf("snapshot = %s, want only the recent 90", snapshot)
+		}
+
+		now = now.Add(2 * time.Hour)
+		h.Record(50)
+		if snapshot := h.Snapshot(); snapshot.Total != 1 || snapshot.Buckets[2].Count != 1 {
+			t.Errorf("snapshot = %s, want only the new 50", snapshot)
+		}
+	})
+}
+
+func TestConfidenceHistogram_String(t *testing.T) {
+	h := NewConfidenceHistogram(time.Hour)
+	h.Record(85)
+
+	want := "0-20:0 20-40:0 40-60:0 60-80:0 80-100:1 (total 1, window 1h0m0s)"
+	if got := h.Snapshot().String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"sort"
+	"strings"
+)
+
+// Dictionaries are the term lists the server tokenizes and weights queries with,
+// so clients can mirror server-side preprocessing
+type Dictionaries struct {
+	FoodTerms        []string            `json:"foodTerms"`
+	DescriptiveTerms []string            `json:"descriptiveTerms"`
+	StopWords        []string            `json:"stopWords"`
+	CompoundFoods    []string            `json:"compoundFoods"` // Kept as one token, e.g. "ice cream"
+	StoreBrands      map[string][]string `json:"storeBrands"`   // Keyed by retailer
+}
+
+// Dictionaries returns the active term lists: the built-in dictionaries merged
+// with any configured extras, each sorted
+func (s *MatchingService) Dictionaries() Dictionaries {
+	return Dictionaries{
+		FoodTerms:        mergeTerms(foodTerms, s.extraFoodTerms),
+		DescriptiveTerms: mergeTerms(descriptiveTerms, s.extraDescriptiveTerms),
+		StopWords:        mergeTerms(extendedStopWords, s.extraStopWords),
+		CompoundFoods:    s.compoundFoods(),
+		StoreBrands:      storeBrandTable(retailerProfiles),
+	}
+}
+
+// storeBrandTable copies each profile's store brands, keyed by retailer
+func storeBrandTable(profiles map[string]*RetailerProfile) map[string][]string {
+	storeBrands := make(map[string][]string, len(profiles))
+	for name, profile := range profiles {
+		storeBrands[name] = append([]string(nil), profile.StoreBrands...)
+	}
+	return storeBrands
+}
+
+// termSet builds a lookup set from configured terms, lowercased and trimmed
+func termSet(terms []string) map[string]bool {
+	if len(terms) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		if term = strings.ToLower(strings.TrimSpace(term)); term != "" {
+			set[term] = true
+		}
+	}
+	return set
+}
+
+// mergeTerms returns the union of the given sets as a sorted list
+func mergeTerms(sets ...map[string]bool) []string {
+	seen := make(map[string]bool)
+	terms := []string{}
+	for _, set := range sets {
+		for term := range set {
+			if !seen[term] {
+				seen[term] = true
+				terms = append(terms, term)
+			}
+		}
+	}
+	sort.Strings(terms)
+	return terms
+}
+
+// compoundFoods returns the active compound food names, singularized as they are
+// matched, as a sorted list
+func (s *MatchingService) compoundFoods() []string {
+	phrases := s.compoundPhrases
+	if phrases == nil {
+		phrases = defaultCompoundPhrases
+	}
+	names := make([]string, 0, len(phrases))
+	for _, phrase := range phrases {
+		names = append(names, strings.Join(phrase, " "))
+	}
+	sort.Strings(names)
+	return names
+}
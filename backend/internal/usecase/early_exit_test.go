@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// largeFoodList returns n weak candidates with a near-perfect "Whole Milk" match at index at
+func largeFoodList(n, at int) []domain.USDAFood {
+	foods := make([]domain.USDAFood, n)
+	for i := range foods {
+		foods[i] = domain.USDAFood{FdcID: i + 1, Description: fmt.Sprintf("Chocolate chip cookies %d", i), DataType: "Branded"}
+	}
+	foods[at] = domain.USDAFood{FdcID: at + 1, Description: "Whole Milk", DataType: "Branded"}
+	return foods
+}
+
+func TestFindBestMatch_EarlyExit(t *testing.T) {
+	ctx := context.Background()
+	request := &domain.SearchRequest{ProductName: "whole milk"}
+
+	t.Run("returns the first candidate reaching the score", func(t *testing.T) {
+		foods := []domain.USDAFood{
+			{FdcID: 1, Description: "Milk chocolate candy bar", DataType: "Branded"},
+			{FdcID: 2, Description: "Whole Milk", DataType: "Survey (FNDDS)"},
+			{FdcID: 3, Description: "Whole Milk", DataType: "Branded"},
+		}
+
+		all, err := NewMatchingService(MatchConfig{}).FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if all.FdcID != "3" {
+			t.Fatalf("FdcID without early exit = %s, want 3", all.FdcID)
+		}
+
+		first, err := NewMatchingService(MatchConfig{EarlyExitScore: 80}).FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if first.FdcID != "2" {
+			t.Errorf("FdcID with early exit = %s, want the first qualifying 2", first.FdcID)
+		}
+	})
+
+	t.Run("top matches still score every food", func(t *testing.T) {
+		svc := NewMatchingService(MatchConfig{EarlyExitScore: 90})
+
+		matches, err := svc.FindTopMatches(ctx, request, largeFoodList(20, 0), 5)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(matches) != 5 {
+			t.Errorf("len(matches) = %d, want 5", len(matches))
+		}
+	})
+
+	t.Run("max candidates ignores foods past the cap", func(t *testing.T) {
+		foods := largeFoodList(20, 10)
+
+		if _, err := NewMatchingService(MatchConfig{MaxCandidates: 10}).FindBestMatch(ctx, request, foods); err == nil {
+			t.Error("error = nil, want the capped list to miss the match")
+		}
+		result, err := NewMatchingService(MatchConfig{MaxCandidates: 11}).FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "11" {
+			t.Errorf("FdcID = %s, want 11", result.FdcID)
+		}
+	})
+}
+
+func BenchmarkFindBestMatch_EarlyExit(b *testing.B) {
+	ctx := context.Background()
+	request := &domain.SearchRequest{ProductName: "whole milk"}
+	foods := largeFoodList(1000, 5)
+
+	for _, bc := range []struct {
+		name   string
+		config MatchConfig
+	}{
+		{"full scan", MatchConfig{}},
+		{"early exit", MatchConfig{EarlyExitScore: 90}},
+	} {
+		svc := NewMatchingService(bc.config)
+		b.Run(bc.name, func(b *testing.B) {
+			for range b.N {
+				if _, err := svc.FindBestMatch(ctx, request, foods); err != nil {
+					b.Fatalf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
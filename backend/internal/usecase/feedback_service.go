@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// unknownDataType labels feedback whose matched food could not be looked up
+const unknownDataType = "Unknown"
+
+// FeedbackService records whether matches were correct so scoring can be tuned
+type FeedbackService struct {
+	store             domain.FeedbackStore
+	usdaClient        domain.USDAClient
+	queryPreprocessor *QueryPreprocessor
+}
+
+// NewFeedbackService creates a feedback service. usdaClient resolves the data type
+// of the matched food when the client doesn't send it; it may be nil.
+func NewFeedbackService(store domain.FeedbackStore, usdaClient domain.USDAClient) *FeedbackService {
+	return &FeedbackService{
+		store:             store,
+		usdaClient:        usdaClient,
+		queryPreprocessor: NewQueryPreprocessor(false),
+	}
+}
+
+// RecordFeedback validates and stores a feedback report
+func (s *FeedbackService) RecordFeedback(ctx context.Context, feedback *domain.MatchFeedback) error {
+	if feedback == nil || feedback.Correct == nil {
+		return domain.ErrInvalidRequest
+	}
+
+	entry := *feedback
+	query, err := sanitizeText("query", entry.Query, true)
+	if err != nil {
+		return err
+	}
+	// Store the cleaned name, the same form the matcher looks confirmed matches up by
+	if cleaned := s.queryPreprocessor.CleanProductName(query); cleaned != "" {
+		query = cleaned
+	}
+	entry.Query = query
+	entry.FdcID = strings.TrimSpace(entry.FdcID)
+	if entry.Query == "" || entry.FdcID == "" {
+		return fmt.Errorf("%w: query and fdcId are required", domain.ErrInvalidRequest)
+	}
+	if id, err := strconv.Atoi(entry.FdcID); err != nil || id <= 0 {
+		return fmt.Errorf("%w: fdcId must be a positive number", domain.ErrInvalidRequest)
+	}
+
+	entry.DataType = strings.TrimSpace(entry.DataType)
+	if entry.DataType == "" {
+		entry.DataType = s.lookupDataType(ctx, entry.FdcID)
+	}
+	entry.RecordedAt = time.Now()
+
+	return s.store.Record(ctx, entry)
+}
+
+// FeedbackStats returns aggregate accuracy overall and by USDA data type
+func (s *FeedbackService) FeedbackStats(ctx context.Context) (*domain.FeedbackStats, error) {
+	return s.store.Stats(ctx)
+}
+
+// lookupDataType fetches the matched food to learn its data type
+func (s *FeedbackService) lookupDataType(ctx context.Context, fdcID string) string {
+	if s.usdaClient == nil {
+		return unknownDataType
+	}
+	food, err := s.usdaClient.GetFoodDetails(ctx, fdcID)
+	if err != nil || food == nil || food.DataType == "" {
+		return unknownDataType
+	}
+	return food.DataType
+}
@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// mockFeedbackStore records feedback in a slice
+type mockFeedbackStore struct {
+	entries []domain.MatchFeedback
+}
+
+func (m *mockFeedbackStore) Record(ctx context.Context, feedback domain.MatchFeedback) error {
+	m.entries = append(m.entries, feedback)
+	return nil
+}
+
+func (m *mockFeedbackStore) ConfirmedFdcIDs(ctx context.Context, query string) (map[string]bool, error) {
+	confirmed := make(map[string]bool)
+	for _, entry := range m.entries {
+		if entry.Query == query && *entry.Correct {
+			confirmed[entry.FdcID] = true
+		}
+	}
+	return confirmed, nil
+}
+
+func (m *mockFeedbackStore) Stats(ctx context.Context) (*domain.FeedbackStats, error) {
+	return &domain.FeedbackStats{FeedbackCounts: domain.FeedbackCounts{Total: len(m.entries)}}, nil
+}
+
+func TestRecordFeedback(t *testing.T) {
+	ctx := context.Background()
+	correct := true
+
+	t.Run("resolves the data type of the matched food", func(t *testing.T) {
+		store := &mockFeedbackStore{}
+		client := NewMockUSDAClient()
+		client.foodResult = &domain.USDAFood{FdcID: 1, DataType: "Foundation"}
+		svc := NewFeedbackService(store, client)
+
+		err := svc.RecordFeedback(ctx, &domain.MatchFeedback{Query: " Whole  Milk, 128 fl oz ", FdcID: "1", Correct: &correct})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(store.entries) != 1 {
+			t.Fatalf("entries = %d, want 1", len(store.entries))
+		}
+		entry := store.entries[0]
+		if entry.DataType != "Foundation" || entry.Query != "whole milk" || entry.RecordedAt.IsZero() {
+			t.Errorf("entry = %+v, want Foundation, normalized query and a timestamp", entry)
+		}
+	})
+
+	t.Run("keeps a client-supplied data type without a lookup", func(t *testing.T) {
+		store := &mockFeedbackStore{}
+		client := NewMockUSDAClient()
+		svc := NewFeedbackService(store, client)
+
+		if err := svc.RecordFeedback(ctx, &domain.MatchFeedback{Query: "whole milk", FdcID: "1", Correct: &correct, DataType: "Branded"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.foodCalls != 0 {
+			t.Errorf("foodCalls = %d, want 0", client.foodCalls)
+		}
+		if store.entries[0].DataType != "Branded" {
+			t.Errorf("DataType = %q, want Branded", store.entries[0].DataType)
+		}
+	})
+
+	t.Run("labels failed lookups as unknown", func(t *testing.T) {
+		store := &mockFeedbackStore{}
+		client := NewMockUSDAClient()
+		client.foodError = domain.ErrUSDAAPIFailure
+		svc := NewFeedbackService(store, client)
+
+		if err := svc.RecordFeedback(ctx, &domain.MatchFeedback{Query: "whole milk", FdcID: "1", Correct: &correct}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if store.entries[0].DataType != unknownDataType {
+			t.Errorf("DataType = %q, want %q", store.entries[0].DataType, unknownDataType)
+		}
+	})
+
+	t.Run("rejects incomplete feedback", func(t *testing.T) {
+		svc := NewFeedbackService(&mockFeedbackStore{}, nil)
+
+		for _, fb := range []*domain.MatchFeedback{
+			nil,
+			{Query: "whole milk", FdcID: "1"},
+			{Query: " ", FdcID: "1", Correct: &correct},
+			{Query: "whole milk", FdcID: "", Correct: &correct},
+		} {
+			if err := svc.RecordFeedback(ctx, fb); !errors.Is(err, domain.ErrInvalidRequest) {
+				t.Errorf("RecordFeedback(%+v) error = %v, want ErrInvalidRequest", fb, err)
+			}
+		}
+	})
+
+	t.Run("rejects non-numeric fdcIds before any lookup", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		svc := NewFeedbackService(&mockFeedbackStore{}, client)
+
+		for _, fdcID := range []string{"abc", "0", "-5", "123?api_key=x", "../v1/foods"} {
+			err := svc.RecordFeedback(ctx, &domain.MatchFeedback{Query: "whole milk", FdcID: fdcID, Correct: &correct})
+			if !errors.Is(err, domain.ErrInvalidRequest) {
+				t.Errorf("RecordFeedback(fdcId %q) error = %v, want ErrInvalidRequest", fdcID, err)
+			}
+		}
+		if client.foodCalls != 0 {
+			t.Errorf("foodCalls = %d, want 0", client.foodCalls)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package usecase
+
+// foodTermCategory maps each food term, and its singular form, to its category
+var foodTermCategory = foodCategoryIndex(foodTermsByCategory)
+
+// foodTermSet flattens categorized food terms into a lookup set
+func foodTermSet(byCategory map[string][]string) map[string]bool {
+	set := make(map[string]bool)
+	for _, terms := range byCategory {
+		for _, term := range terms {
+			set[term] = true
+		}
+	}
+	return set
+}
+
+// foodCategoryIndex maps each term to its category. Singular forms are indexed
+// too, so singularized tokens ("cookie") classify like the listed plural.
+func foodCategoryIndex(byCategory map[string][]string) map[string]string {
+	index := make(map[string]string)
+	for category, terms := range byCategory {
+		for _, term := range terms {
+			index[term] = category
+			index[singularize(term)] = category
+		}
+	}
+	return index
+}
+
+// dominantCategory returns the food category most of the tokens belong to, or ""
+// when none is classified. Ties go to the head noun: the last classified token in
+// a retail product name, or the first in a USDA description ("Milk, chocolate").
+func dominantCategory(tokens []TokenWeight, headFirst bool) string {
+	counts := make(map[string]int)
+	var order []string // Classified tokens' categories, head noun last
+	for _, token := range tokens {
+		if category, ok := foodTermCategory[token.Token]; ok {
+			counts[category]++
+			order = append(order, category)
+		}
+	}
+	if headFirst {
+		for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+			order[i], order[j] = order[j], order[i]
+		}
+	}
+
+	dominant, best := "", 0
+	for _, category := range order {
+		if counts[category] >= best {
+			dominant, best = category, counts[category]
+		}
+	}
+	return dominant
+}
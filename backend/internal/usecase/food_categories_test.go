@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+func TestDominantCategory(t *testing.T) {
+	testCases := []struct {
+		name      string
+		input     string
+		headFirst bool
+		want      string
+	}{
+		{"single food term", "whole milk", false, "dairy"},
+		{"majority wins", "cheddar cheese crackers", false, "dairy"},
+		{"tie goes to last term in product names", "chocolate milk", false, "dairy"},
+		{"tie goes to first term in USDA descriptions", "Milk, chocolate", true, "dairy"},
+		{"singular of a listed plural", "oatmeal cookie", false, "snack"},
+		{"no food terms", "organic vanilla", false, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokens := NewMatchingService(MatchConfig{NormalizePlurals: true}).tokenizeWithWeights(tc.input)
+			if got := dominantCategory(tokens, tc.headFirst); got != tc.want {
+				t.Errorf("dominantCategory(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFindBestMatch_CategoryMismatchPenalty(t *testing.T) {
+	ctx := context.Background()
+	request := &domain.SearchRequest{ProductName: "chocolate milk"}
+	foods := []domain.USDAFood{
+		{FdcID: 1, Description: "Cake, chocolate", DataType: "Survey (FNDDS)"},
+		{FdcID: 2, Description: "Milk, whole", DataType: "Survey (FNDDS)"},
+	}
+
+	t.Run("whole milk outscores chocolate cake", func(t *testing.T) {
+		svc := NewMatchingService(MatchConfig{MinConfidenceThreshold: 30, CategoryMismatchPenalty: 15})
+
+		cakeScore, _ := svc.calculateMatchScore(request.ProductName, "", foods[0].Description, foods[0].DataType)
+		milkScore, _ := svc.calculateMatchScore(request.ProductName, "", foods[1].Description, foods[1].DataType)
+		if milkScore-cakeScore != 15 {
+			t.Errorf("milk score %.1f - cake score %.1f = %.1f, want the 15 point penalty", milkScore, cakeScore, milkScore-cakeScore)
+		}
+
+		result, err := svc.FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "2" {
+			t.Errorf("FdcID = %v (%s), want 2 (whole milk)", result.FdcID, result.Description)
+		}
+	})
+
+	t.Run("shared flavor word ties when disabled", func(t *testing.T) {
+		svc := NewMatchingService(MatchConfig{MinConfidenceThreshold: 30})
+
+		cakeScore, _ := svc.calculateMatchScore(request.ProductName, "", foods[0].Description, foods[0].DataType)
+		milkScore, _ := svc.calculateMatchScore(request.ProductName, "", foods[1].Description, foods[1].DataType)
+		if cakeScore != milkScore {
+			t.Errorf("cake score = %.1f, milk score = %.1f, want a tie", cakeScore, milkScore)
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// isSuspiciousControl reports control characters that have no place in a scraped
+// product title: NUL can truncate strings downstream and ESC starts terminal
+// escape sequences that corrupt logs
+func isSuspiciousControl(r rune) bool {
+	return r == '\x00' || r == '\x1b'
+}
+
+// sanitizeText replaces control characters with spaces and normalizes whitespace.
+// When reject is set, suspicious control characters fail with ErrInvalidRequest
+// instead of being stripped.
+func sanitizeText(field, s string, reject bool) (string, error) {
+	if s == "" {
+		return s, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if isSuspiciousControl(r) && reject {
+			return "", fmt.Errorf("%w: %s contains control characters", domain.ErrInvalidRequest, field)
+		}
+		if unicode.IsControl(r) || r == unicode.ReplacementChar {
+			b.WriteRune(' ')
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return strings.Join(strings.Fields(b.String()), " "), nil
+}
+
+// sanitizeRequest returns a copy of the request with ProductName and Brand cleaned
+// of control characters and extra whitespace, and comma decimals in ProductName
+// rewritten for the request's locale
+func sanitizeRequest(request *domain.SearchRequest, rejectControlChars bool) (*domain.SearchRequest, error) {
+	sanitized := *request
+
+	var err error
+	if sanitized.ProductName, err = sanitizeText("productName", request.ProductName, rejectControlChars); err != nil {
+		return nil, err
+	}
+	if sanitized.Brand, err = sanitizeText("brand", request.Brand, rejectControlChars); err != nil {
+		return nil, err
+	}
+	sanitized.ProductName = normalizeDecimalCommas(sanitized.ProductName, sanitized.Locale)
+
+	return &sanitized, nil
+}
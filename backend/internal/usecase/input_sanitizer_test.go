@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+func TestSanitizeText(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		reject  bool
+		want    string
+		wantErr bool
+	}{
+		{name: "leaves clean text alone", input: "Whole Milk", want: "Whole Milk"},
+		{name: "turns tabs and newlines into spaces", input: "Whole\tMilk\r\n1 Gallon", want: "Whole Milk 1 Gallon"},
+		{name: "strips bell and other C0 controls", input: "Whole\x07 Milk\x08", want: "Whole Milk"},
+		{name: "strips C1 controls", input: "Whole\u0085Milk", want: "Whole Milk"},
+		{name: "strips null byte when not rejecting", input: "Whole\x00Milk", want: "Whole Milk"},
+		{name: "rejects null byte", input: "Whole\x00Milk", reject: true, wantErr: true},
+		{name: "rejects ANSI escape", input: "Milk\x1b[31m", reject: true, wantErr: true},
+		{name: "still strips benign controls when rejecting", input: "Whole\tMilk", reject: true, want: "Whole Milk"},
+		{name: "empty input", input: "", want: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := sanitizeText("productName", tc.input, tc.reject)
+			if tc.wantErr {
+				if !errors.Is(err, domain.ErrInvalidRequest) {
+					t.Errorf("sanitizeText(%q) error = %v, want ErrInvalidRequest", tc.input, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("sanitizeText(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeRequest(t *testing.T) {
+	request := &domain.SearchRequest{ProductName: "Whole\nMilk", Brand: "Great\tValue", Size: "1 gal"}
+
+	got, err := sanitizeRequest(request, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ProductName != "Whole Milk" || got.Brand != "Great Value" || got.Size != "1 gal" {
+		t.Errorf("sanitizeRequest() = %+v", got)
+	}
+	if request.ProductName != "Whole\nMilk" {
+		t.Error("sanitizeRequest should not modify the original request")
+	}
+
+	if _, err := sanitizeRequest(&domain.SearchRequest{ProductName: "Milk", Brand: "\x00"}, true); !errors.Is(err, domain.ErrInvalidRequest) {
+		t.Errorf("brand with NUL: error = %v, want ErrInvalidRequest", err)
+	}
+}
@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// InvalidateSearch evicts the cached result for a search, so the next identical
+// search goes back to USDA. The request is keyed exactly as SearchNutrition keys
+// it, and the query's cached match decision is evicted too. Evicting a search that
+// isn't cached is not an error.
+func (s *NutritionService) InvalidateSearch(ctx context.Context, request *domain.SearchRequest) error {
+	if request == nil {
+		return domain.ErrInvalidRequest
+	}
+
+	request, err := sanitizeRequest(request, s.rejectControlChars)
+	if err != nil {
+		return err
+	}
+	if request.ProductName == "" {
+		return domain.ErrInvalidRequest
+	}
+
+	err = s.cache.Delete(ctx, s.generateCacheKey(request))
+	if s.enableMatchCache {
+		query, _ := s.searchQuery(request)
+		err = errors.Join(err, s.cache.Delete(ctx, s.matchCacheKey(query)))
+	}
+	return err
+}
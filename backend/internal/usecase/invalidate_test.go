@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+func TestInvalidateSearch(t *testing.T) {
+	ctx := context.Background()
+
+	newService := func() (*NutritionService, *MockCacheRepository, *MockUSDAClient) {
+		cache := NewMockCacheRepository()
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{
+			Foods: []domain.USDAFood{{FdcID: 456, Description: "Whole Milk"}},
+		}
+		client.foodResult = &domain.USDAFood{FdcID: 456, Description: "Whole Milk"}
+		return NewNutritionService(cache, client, NutritionServiceConfig{EnableMatchCache: true}), cache, client
+	}
+
+	t.Run("next search goes back to USDA", func(t *testing.T) {
+		svc, cache, client := newService()
+		request := &domain.SearchRequest{ProductName: "Whole Milk"}
+
+		if _, err := svc.SearchNutrition(ctx, request); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// The search result and the match decision are both cached
+		if len(cache.data) != 2 {
+			t.Fatalf("cached entries = %d, want 2", len(cache.data))
+		}
+
+		// Keyed like the search, so case and spacing differences don't matter
+		if err := svc.InvalidateSearch(ctx, &domain.SearchRequest{ProductName: "  whole MILK "}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(cache.data) != 0 {
+			t.Errorf("cached entries = %v, want none", cache.data)
+		}
+
+		if _, err := svc.SearchNutrition(ctx, request); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.searchCalls != 2 {
+			t.Errorf("searchCalls = %d, want 2", client.searchCalls)
+		}
+	})
+
+	t.Run("succeeds when nothing is cached", func(t *testing.T) {
+		svc, _, _ := newService()
+		if err := svc.InvalidateSearch(ctx, &domain.SearchRequest{ProductName: "oat milk"}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("rejects an empty product name", func(t *testing.T) {
+		svc, _, _ := newService()
+		if err := svc.InvalidateSearch(ctx, &domain.SearchRequest{}); !errors.Is(err, domain.ErrInvalidRequest) {
+			t.Errorf("error = %v, want ErrInvalidRequest", err)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MatchAlgorithm selects how token similarity is turned into a base score
+type MatchAlgorithm int
+
+const (
+	// AlgorithmTokenWeighted scores weighted token overlap with fuzzy token matching
+	AlgorithmTokenWeighted MatchAlgorithm = iota
+	// AlgorithmJaroWinkler blends token overlap with Jaro-Winkler similarity of the
+	// whole cleaned strings, which rewards shared prefixes in short product names
+	AlgorithmJaroWinkler
+	// AlgorithmTokenF1 scores the F1 of product-side recall and description-side
+	// precision, so a long multi-ingredient description that merely contains the
+	// product's words loses to a concise one
+	AlgorithmTokenF1
+)
+
+const (
+	// jaroWinklerBlend is the share of the base score taken from Jaro-Winkler
+	jaroWinklerBlend = 0.5
+
+	// jaroWinklerPrefixScale and jaroWinklerMaxPrefix are the standard Winkler
+	// boost for strings sharing a prefix of up to 4 characters
+	jaroWinklerPrefixScale = 0.1
+	jaroWinklerMaxPrefix   = 4
+)
+
+// ParseMatchAlgorithm parses "token_weighted", "jaro_winkler" or "token_f1". An
+// empty name returns the default, AlgorithmTokenWeighted.
+func ParseMatchAlgorithm(name string) (MatchAlgorithm, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "token_weighted":
+		return AlgorithmTokenWeighted, nil
+	case "jaro_winkler":
+		return AlgorithmJaroWinkler, nil
+	case "token_f1":
+		return AlgorithmTokenF1, nil
+	}
+	return AlgorithmTokenWeighted, fmt.Errorf("unknown match algorithm %q: want token_weighted, jaro_winkler or token_f1", name)
+}
+
+// String returns the algorithm's config name
+func (a MatchAlgorithm) String() string {
+	switch a {
+	case AlgorithmJaroWinkler:
+		return "jaro_winkler"
+	case AlgorithmTokenF1:
+		return "token_f1"
+	}
+	return "token_weighted"
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of s1 and s2, from 0 (nothing in
+// common) to 1 (identical)
+func jaroWinkler(s1, s2 string) float64 {
+	r1, r2 := []rune(s1), []rune(s2)
+	similarity := jaro(r1, r2)
+
+	prefix := 0
+	for prefix < min(len(r1), len(r2), jaroWinklerMaxPrefix) && r1[prefix] == r2[prefix] {
+		prefix++
+	}
+
+	return similarity + float64(prefix)*jaroWinklerPrefixScale*(1-similarity)
+}
+
+// jaro returns the Jaro similarity: the share of characters that match within a
+// sliding window, discounted by how many matched characters are out of order
+func jaro(r1, r2 []rune) float64 {
+	if len(r1) == 0 && len(r2) == 0 {
+		return 1
+	}
+	if len(r1) == 0 || len(r2) == 0 {
+		return 0
+	}
+
+	window := max(len(r1), len(r2))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	matched1 := make([]bool, len(r1))
+	matched2 := make([]bool, len(r2))
+	matches := 0
+	for i, c := range r1 {
+		for j := max(0, i-window); j < min(len(r2), i+window+1); j++ {
+			if !matched2[j] && r2[j] == c {
+				matched1[i], matched2[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	// Count matched characters that appear in a different order
+	transpositions := 0
+	j := 0
+	for i, c := range r1 {
+		if !matched1[i] {
+			continue
+		}
+		for !matched2[j] {
+			j++
+		}
+		if c != r2[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	return (m/float64(len(r1)) + m/float64(len(r2)) + (m-float64(transpositions)/2)/m) / 3
+}
@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		s1, s2 string
+		want   float64
+	}{
+		{"martha", "marhta", 0.961},
+		{"dixon", "dicksonx", 0.813},
+		{"dwayne", "duane", 0.840},
+		{"whole milk", "whole milk", 1},
+		{"", "", 1},
+		{"milk", "", 0},
+		{"abc", "xyz", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s1+"/"+tt.s2, func(t *testing.T) {
+			if got := jaroWinkler(tt.s1, tt.s2); math.Abs(got-tt.want) > 0.001 {
+				t.Errorf("jaroWinkler(%q, %q) = %.3f, want %.3f", tt.s1, tt.s2, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMatchAlgorithm(t *testing.T) {
+	for name, want := range map[string]MatchAlgorithm{
+		"":               AlgorithmTokenWeighted,
+		"token_weighted": AlgorithmTokenWeighted,
+		"Jaro_Winkler":   AlgorithmJaroWinkler,
+		"token_f1":       AlgorithmTokenF1,
+	} {
+		got, err := ParseMatchAlgorithm(name)
+		if err != nil || got != want {
+			t.Errorf("ParseMatchAlgorithm(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+
+	if _, err := ParseMatchAlgorithm("levenshtein"); err == nil {
+		t.Error("ParseMatchAlgorithm(\"levenshtein\") error = nil, want error")
+	}
+}
+
+func TestRealisticWalmartProducts_Algorithms(t *testing.T) {
+	ctx := context.Background()
+	newService := func(algorithm MatchAlgorithm) *MatchingService {
+		return NewMatchingService(MatchConfig{
+			MinConfidenceThreshold: 40,
+			EnableFuzzyMatching:    true,
+			FuzzyEditDistance:      1,
+			Algorithm:              algorithm,
+		})
+	}
+	tokenWeighted := newService(AlgorithmTokenWeighted)
+	blended := newService(AlgorithmJaroWinkler)
+	f1 := newService(AlgorithmTokenF1)
+
+	for _, tc := range walmartProductFixtures {
+		t.Run(tc.name, func(t *testing.T) {
+			request := &domain.SearchRequest{ProductName: tc.productName, Brand: tc.brand}
+
+			baseline, err := tokenWeighted.FindBestMatch(ctx, request, tc.usdaFoods)
+			if err != nil {
+				t.Fatalf("token weighted: unexpected error: %v", err)
+			}
+			result, err := blended.FindBestMatch(ctx, request, tc.usdaFoods)
+			if err != nil {
+				t.Fatalf("jaro-winkler: unexpected error: %v", err)
+			}
+
+			if result.FdcID != baseline.FdcID || result.FdcID != tc.wantFdcID {
+				t.Errorf("FdcID = %v (token weighted %v), want %v", result.FdcID, baseline.FdcID, tc.wantFdcID)
+			}
+			if result.MatchScore < tc.minConfidence {
+				t.Errorf("MatchScore = %.1f, want >= %v", result.MatchScore, tc.minConfidence)
+			}
+
+			f1Result, err := f1.FindBestMatch(ctx, request, tc.usdaFoods)
+			if err != nil {
+				t.Fatalf("token f1: unexpected error: %v", err)
+			}
+			if f1Result.FdcID != tc.wantFdcID {
+				t.Errorf("token f1 FdcID = %v, want %v", f1Result.FdcID, tc.wantFdcID)
+			}
+		})
+	}
+}
+
+func TestFindBestMatch_JaroWinklerRewardsSharedPrefix(t *testing.T) {
+	ctx := context.Background()
+	// "choc" shares a prefix with "chocolate" but is too far off for fuzzy token matching
+	request := &domain.SearchRequest{ProductName: "choc chip cookies"}
+	foods := []domain.USDAFood{{FdcID: 1, Description: "Chocolate chip cookies", DataType: "Foundation"}}
+
+	baseline, err := NewMatchingService(MatchConfig{}).FindBestMatch(ctx, request, foods)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	blended, err := NewMatchingService(MatchConfig{Algorithm: AlgorithmJaroWinkler}).FindBestMatch(ctx, request, foods)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if blended.MatchScore <= baseline.MatchScore {
+		t.Errorf("jaro-winkler score = %.1f, want above token weighted %.1f", blended.MatchScore, baseline.MatchScore)
+	}
+}
@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"regexp"
+	"strings"
+)
+
+// decimalCommaLanguages are the languages whose locales write decimals with a
+// comma ("1,5 L"). Matched on the primary language subtag, so "es-ES" and "es"
+// both count.
+var decimalCommaLanguages = map[string]bool{
+	"de": true, "es": true, "fr": true, "it": true, "nl": true, "pt": true,
+	"pl": true, "ru": true, "sv": true, "da": true, "nb": true, "no": true,
+	"fi": true, "cs": true, "tr": true, "id": true,
+}
+
+// decimalCommaPattern matches a comma between digits, like the one in "1,5"
+var decimalCommaPattern = regexp.MustCompile(`(\d),(\d)`)
+
+// usesDecimalComma reports whether a locale writes decimals with a comma. locale is
+// a language tag ("es-ES") or an Accept-Language header, whose first, most
+// preferred language decides.
+func usesDecimalComma(locale string) bool {
+	tag, _, _ := strings.Cut(locale, ",")
+	tag, _, _ = strings.Cut(tag, ";")
+	language, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+	language, _, _ = strings.Cut(language, "_")
+	return decimalCommaLanguages[strings.ToLower(language)]
+}
+
+// normalizeDecimalCommas rewrites comma decimals as dots ("1,5 L" -> "1.5 L") for
+// comma-decimal locales, so the size patterns strip the whole quantity
+func normalizeDecimalCommas(s, locale string) string {
+	if !usesDecimalComma(locale) {
+		return s
+	}
+	return decimalCommaPattern.ReplaceAllString(s, "$1.$2")
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+func TestUsesDecimalComma(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   bool
+	}{
+		{"es-ES", true},
+		{"fr", true},
+		{"de_DE", true},
+		{"pt-BR,pt;q=0.9,en;q=0.8", true},
+		{"en-US", false},
+		{"en-US,es;q=0.5", false}, // The most preferred language decides
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got := usesDecimalComma(tt.locale); got != tt.want {
+				t.Errorf("usesDecimalComma(%q) = %v, want %v", tt.locale, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeDecimalCommas(t *testing.T) {
+	if got := normalizeDecimalCommas("Leche entera, 1,5 L", "es-ES"); got != "Leche entera, 1.5 L" {
+		t.Errorf("es-ES: got %q, want the decimal comma rewritten and the list comma kept", got)
+	}
+	if got := normalizeDecimalCommas("Rice, 1,000 g", "en-US"); got != "Rice, 1,000 g" {
+		t.Errorf("en-US: got %q, want it unchanged", got)
+	}
+}
+
+func TestSearchNutrition_Locale(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name        string
+		productName string
+		locale      string
+		want        string
+	}{
+		{"Spanish liters", "Leche entera, 1,5 L", "es-ES", "leche entera"},
+		{"French kilograms", "Farine de blé 1,5 kg", "fr-FR,fr;q=0.9", "farine de blé"},
+		{"German grams", "Joghurt 0,5 kg", "de", "joghurt"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewMockUSDAClient()
+			client.searchError = domain.ErrProductNotFound
+			svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+			_, _ = svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: tc.productName, Locale: tc.locale})
+			if client.lastQuery != tc.want {
+				t.Errorf("USDA query = %q, want %q", client.lastQuery, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// MaxMatchFoods bounds how many caller-supplied foods a single match can score
+const MaxMatchFoods = 200
+
+// MatchFoods scores caller-supplied USDA foods against a product the way a search
+// would, without calling USDA or touching the cache. A best match below the
+// confidence threshold is returned along with ErrLowConfidence.
+func (s *NutritionService) MatchFoods(
+	ctx context.Context,
+	request *domain.SearchRequest,
+	foods []domain.USDAFood,
+) (*domain.MatchResult, error) {
+	if request == nil {
+		return nil, domain.ErrInvalidRequest
+	}
+	if len(foods) == 0 || len(foods) > MaxMatchFoods {
+		return nil, fmt.Errorf("%w: foods must contain between 1 and %d items", domain.ErrInvalidRequest, MaxMatchFoods)
+	}
+
+	request, err := sanitizeRequest(request, s.rejectControlChars)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMinConfidence(request.MinConfidence); err != nil {
+		return nil, err
+	}
+
+	// Score against the cleaned product name, as searches do
+	matchRequest := *request
+	if cleaned := s.queryPreprocessor.CleanProductNameForRetailer(request.ProductName, request.Retailer); cleaned != "" {
+		matchRequest.ProductName = cleaned
+	}
+
+	result, err := s.matchingService.FindBestMatch(ctx, &matchRequest, foods)
+	if result != nil && s.probability.enabled() {
+		probability := s.probability.apply(result.MatchScore)
+		result.CalibratedConfidence = &probability
+	}
+	return result, err
+}
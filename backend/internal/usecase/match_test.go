@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+func TestMatchFoods(t *testing.T) {
+	ctx := context.Background()
+	foods := []domain.USDAFood{
+		{FdcID: 1, Description: "Cheese, cheddar", DataType: "Survey (FNDDS)"},
+		{FdcID: 2, Description: "Milk, whole, 3.25% milkfat", DataType: "Foundation"},
+	}
+
+	t.Run("returns the best match without searching USDA", func(t *testing.T) {
+		svc, client := newBatchTestService()
+
+		result, err := svc.MatchFoods(ctx, &domain.SearchRequest{ProductName: "Great Value Whole Milk, 1 gallon"}, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "2" {
+			t.Errorf("FdcID = %v (%s), want 2", result.FdcID, result.Description)
+		}
+		if len(result.MatchedTokens) == 0 {
+			t.Error("MatchedTokens is empty, want the shared tokens")
+		}
+		if len(client.calls) != 0 {
+			t.Errorf("USDA searches = %v, want none", client.calls)
+		}
+	})
+
+	t.Run("returns low-confidence matches with the error", func(t *testing.T) {
+		svc, _ := newBatchTestService()
+
+		result, err := svc.MatchFoods(ctx, &domain.SearchRequest{ProductName: "orange juice"}, foods)
+		if !errors.Is(err, domain.ErrLowConfidence) {
+			t.Fatalf("err = %v, want ErrLowConfidence", err)
+		}
+		if result == nil {
+			t.Error("result = nil, want the best low-confidence match")
+		}
+	})
+
+	t.Run("rejects empty and oversized food lists", func(t *testing.T) {
+		svc, _ := newBatchTestService()
+		for _, n := range []int{0, MaxMatchFoods + 1} {
+			_, err := svc.MatchFoods(ctx, &domain.SearchRequest{ProductName: "milk"}, make([]domain.USDAFood, n))
+			if !errors.Is(err, domain.ErrInvalidRequest) {
+				t.Errorf("%d foods: err = %v, want ErrInvalidRequest", n, err)
+			}
+		}
+	})
+}
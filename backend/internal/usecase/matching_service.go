@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/macrolens/backend/internal/domain"
@@ -18,50 +21,79 @@ const (
 	weightFood        = 3.0 // Core food terms (milk, chicken, bread)
 	weightDescriptive = 2.0 // Descriptive terms (whole, skim, organic)
 	weightDefault     = 1.0 // Everything else
+	weightQualifier   = 3.0 // Multi-word qualifiers kept as one token (gluten free, sugar free)
+	weightCompound    = 3.0 // Compound food names kept as one token (ice cream, hot dog)
 	fuzzyWeightFactor = 0.8 // Fuzzy matches get 80% of normal weight
 )
 
 // Scoring bonuses
 const (
-	brandMatchBonus    = 25.0 // Brand appears in USDA description
+	brandMatchBonus    = 25.0 // Brand appears in USDA description (default; see MatchConfig.BrandBonus)
 	substringMatchBonus = 10.0 // Product name is substring of USDA description
-	dataTypeBrandedBonus = 10.0 // USDA Branded data type
-	dataTypeSurveyBonus  = 5.0  // USDA Survey (FNDDS) data type
-	dataTypeFoundationBonus = 3.0 // USDA Foundation data type
+	dataTypeBrandedBonus = 10.0 // USDA Branded data type (default; see MatchConfig.DataTypeBonuses)
+	dataTypeSurveyBonus  = 5.0  // USDA Survey (FNDDS) data type (default)
+	dataTypeSRLegacyBonus = 4.0 // USDA SR Legacy data type (default)
+	dataTypeFoundationBonus = 3.0 // USDA Foundation data type (default)
 	baseScoreMultiplier = 70.0 // Base score max before bonuses
 )
 
-// foodTerms contains high-importance food keywords (weight 3.0)
-var foodTerms = map[string]bool{
-	// Proteins
-	"chicken": true, "beef": true, "pork": true, "fish": true, "salmon": true,
-	"turkey": true, "lamb": true, "shrimp": true, "tuna": true, "bacon": true,
-	"sausage": true, "steak": true, "ham": true, "crab": true, "lobster": true,
-	// Dairy
-	"milk": true, "cheese": true, "yogurt": true, "butter": true, "cream": true,
-	"eggs": true, "egg": true, "cheddar": true, "mozzarella": true, "parmesan": true,
-	// Grains
-	"bread": true, "rice": true, "pasta": true, "cereal": true, "oats": true,
-	"wheat": true, "flour": true, "noodles": true, "tortilla": true, "bagel": true,
-	// Produce
-	"apple": true, "banana": true, "orange": true, "lettuce": true, "tomato": true,
-	"potato": true, "onion": true, "carrot": true, "broccoli": true, "spinach": true,
-	"strawberry": true, "blueberry": true, "grape": true, "lemon": true, "lime": true,
-	"avocado": true, "cucumber": true, "pepper": true, "corn": true, "beans": true,
-	// Beverages
-	"juice": true, "soda": true, "cola": true, "coffee": true, "tea": true,
-	"water": true, "lemonade": true, "smoothie": true, "shake": true,
-	// Snacks & Sweets
-	"chips": true, "crackers": true, "cookies": true, "candy": true, "chocolate": true,
-	"cake": true, "ice": true, "pie": true, "brownie": true, "popcorn": true,
-	// Condiments & Sauces
-	"ketchup": true, "mustard": true, "mayo": true, "mayonnaise": true, "sauce": true,
-	"salsa": true, "dressing": true, "syrup": true, "honey": true, "jam": true,
-	// Prepared Foods
-	"pizza": true, "burger": true, "sandwich": true, "soup": true, "salad": true,
-	"burrito": true, "taco": true, "wrap": true, "hot": true, "dog": true,
+// minConfidenceOverrideFloor is the lowest per-request confidence threshold accepted
+const minConfidenceOverrideFloor = 10.0
+
+// defaultSubstringMinLength is the shortest product name, in characters, that earns
+// the substring bonus when MatchConfig.SubstringMinLength is unset
+const defaultSubstringMinLength = 6
+
+// Fuzzy matching skips tokens shorter than the minimum length to avoid false positives.
+// Below the floor, a single edit turns too many unrelated words into each other.
+const (
+	defaultFuzzyMinTokenLength = 4
+	fuzzyMinTokenLengthFloor   = 3
+)
+
+// foodTermsByCategory groups high-importance food keywords (weight 3.0) by coarse
+// food category, used to penalize matches across categories
+var foodTermsByCategory = map[string][]string{
+	"protein": {
+		"chicken", "beef", "pork", "fish", "salmon",
+		"turkey", "lamb", "shrimp", "tuna", "bacon",
+		"sausage", "steak", "ham", "crab", "lobster",
+	},
+	"dairy": {
+		"milk", "cheese", "yogurt", "butter", "cream",
+		"eggs", "egg", "cheddar", "mozzarella", "parmesan",
+	},
+	"grain": {
+		"bread", "rice", "pasta", "cereal", "oats",
+		"wheat", "flour", "noodles", "tortilla", "bagel",
+	},
+	"produce": {
+		"apple", "banana", "orange", "lettuce", "tomato",
+		"potato", "onion", "carrot", "broccoli", "spinach",
+		"strawberry", "blueberry", "grape", "lemon", "lime",
+		"avocado", "cucumber", "pepper", "corn", "beans",
+	},
+	"beverage": {
+		"juice", "soda", "cola", "coffee", "tea",
+		"water", "lemonade", "smoothie", "shake",
+	},
+	"snack": {
+		"chips", "crackers", "cookies", "candy", "chocolate",
+		"cake", "pie", "brownie", "popcorn",
+	},
+	"condiment": {
+		"ketchup", "mustard", "mayo", "mayonnaise", "sauce",
+		"salsa", "dressing", "syrup", "honey", "jam",
+	},
+	"prepared": {
+		"pizza", "burger", "sandwich", "soup", "salad",
+		"burrito", "taco", "wrap",
+	},
 }
 
+// foodTerms contains high-importance food keywords (weight 3.0)
+var foodTerms = foodTermSet(foodTermsByCategory)
+
 // descriptiveTerms contains medium-importance descriptive keywords (weight 2.0)
 var descriptiveTerms = map[string]bool{
 	// Preparation/processing
@@ -105,20 +137,154 @@ var extendedStopWords = map[string]bool{
 	"bonus": true, "new": true, "improved": true, "product": true,
 }
 
+// defaultQualifierPhrases are multi-word qualifiers that change what a product is.
+// Split into single words they dilute into generic descriptive tokens
+// ("gluten free bread" would share "free" with "fat free bread").
+var defaultQualifierPhrases = []string{
+	"gluten free", "fat free", "sugar free", "dairy free", "lactose free",
+	"caffeine free", "nut free", "egg free", "soy free", "grain free",
+	"reduced fat", "low fat", "low sodium", "no salt added", "no sugar added",
+}
+
 // MatchConfig holds configuration for the matching service
 type MatchConfig struct {
 	MinConfidenceThreshold float64
 	EnableFuzzyMatching    bool
 	FuzzyEditDistance      int
 	EnableDebugLogging     bool
+
+	// ThinPoolSize is the candidate count below which the pool is considered thin.
+	// When fewer candidates are returned, the best score is reduced by ThinPoolDiscount
+	// (a fraction, e.g. 0.15 = 15%) to reflect the lack of alternatives. 0 disables.
+	ThinPoolSize     int
+	ThinPoolDiscount float64
+
+	// EnableQualifierPhrases keeps multi-word qualifiers as single weighted tokens.
+	// QualifierPhrases overrides the built-in list when non-empty.
+	EnableQualifierPhrases bool
+	QualifierPhrases       []string
+
+	// CompoundFoods overrides the built-in compound food names (defaultCompoundFoods)
+	// kept as single tokens when non-empty
+	CompoundFoods []string
+
+	// Calibration maps raw scores to calibrated confidence (piecewise-linear).
+	// The confidence threshold applies to the calibrated score. Empty disables.
+	Calibration []CalibrationPoint
+
+	// ConfirmedMatchBonus is added to a candidate whose fdcId was previously confirmed
+	// correct for the same cleaned query (requires SetFeedbackStore). 0 disables.
+	ConfirmedMatchBonus float64
+
+	// CategoryMismatchPenalty is subtracted from a candidate whose dominant food
+	// category (dairy, snack, ...) differs from the product's. 0 disables.
+	CategoryMismatchPenalty float64
+
+	// Extra terms added to the built-in dictionaries (single words, case-insensitive).
+	// A word in several lists resolves as: stop words first (the word is dropped),
+	// then food terms, then descriptive terms. So ExtraFoodTerms can promote a
+	// built-in descriptive term, but ExtraDescriptiveTerms can't demote a built-in
+	// food term, and a built-in stop word stays dropped.
+	ExtraFoodTerms        []string
+	ExtraDescriptiveTerms []string
+	ExtraStopWords        []string
+
+	// Synonyms maps product terms to alternatives USDA may use instead ("soda" ->
+	// "soft drink"). It overrides the built-in table when non-empty. Synonym matches
+	// count at SynonymWeight (a fraction; 0 uses the fuzzy-match weight).
+	Synonyms      map[string][]string
+	SynonymWeight float64
+
+	// NormalizePlurals singularizes tokens ("strawberries" -> "strawberry") so plural
+	// and singular forms match
+	NormalizePlurals bool
+
+	// Algorithm selects the base scoring algorithm (default AlgorithmTokenWeighted)
+	Algorithm MatchAlgorithm
+
+	// EnablePhoneticBrandMatch awards a reduced brand bonus when the brand isn't in
+	// the USDA description but sounds like words in it ("Craft" for "Kraft")
+	EnablePhoneticBrandMatch bool
+
+	// FuzzyMinTokenLength is the shortest token fuzzy matching considers (0 uses the
+	// default of 4). Values below 3 are raised to 3.
+	FuzzyMinTokenLength int
+
+	// RecencyBonus is the most a candidate gains for being recently published: the
+	// newest dated candidate gets all of it, the oldest none. Keep it small so it
+	// only decides near-ties between duplicate entries. 0 disables.
+	RecencyBonus float64
+
+	// DataTypeBonuses overrides the score bonus for the USDA data types it names
+	// ("Branded", "Survey (FNDDS)", "SR Legacy", "Foundation"); the rest keep their
+	// built-in bonus (10, 5, 4 and 3). Raise Foundation above Branded to prefer lab-measured
+	// data over manufacturer-reported labels.
+	DataTypeBonuses map[string]float64
+
+	// EarlyExitScore stops FindBestMatch at the first candidate whose score, before
+	// calibration and the thin pool discount, reaches it. Candidates are scored in
+	// the order given, so the result is the first qualifying food rather than
+	// necessarily the best one; a later food with an equal or higher score is never
+	// seen. FindTopMatches for more than one match always scores every food. 0 disables.
+	EarlyExitScore float64
+
+	// MaxCandidates caps how many foods a match scores; foods past the cap are
+	// ignored, so order them by relevance. 0 scores them all.
+	MaxCandidates int
+
+	// SubstringMinLength is the shortest product name, in characters, that earns
+	// the substring bonus for appearing whole in a description (0 uses the default 6)
+	SubstringMinLength int
+
+	// PhraseBonus is the most a candidate gains for sharing a run of two or more
+	// consecutive tokens with the product, like "chicken breast" in "Grilled chicken
+	// breast, boneless". It's scaled by the run's share of the product's tokens, so
+	// long noisy names that never appear whole still earn part of it. 0 disables.
+	PhraseBonus float64
+
+	// BrandBonus is added when the brand, or one of its aliases, appears in the
+	// description (0 uses the default 25). Names shorter than 4 characters earn a
+	// proportionally smaller share, since they turn up inside unrelated words.
+	BrandBonus float64
+
+	// BrandAliases maps canonical brands to other names for them ("coca-cola" ->
+	// "coke"), so either form finds the other in a description. It overrides the
+	// built-in table when non-empty.
+	BrandAliases map[string][]string
 }
 
 // MatchingService handles fuzzy matching of product names to USDA foods
 type MatchingService struct {
-	minConfidenceThreshold float64
-	enableFuzzyMatching    bool
-	fuzzyEditDistance      int
-	enableDebugLogging     bool
+	minConfidenceThreshold  float64
+	enableFuzzyMatching     bool
+	fuzzyEditDistance       int
+	enableDebugLogging      bool
+	thinPoolSize            int
+	thinPoolDiscount        float64
+	qualifierPhrases        [][]string // Tokenized phrases, nil when disabled
+	compoundPhrases         [][]string // Tokenized phrases, nil uses defaultCompoundPhrases
+	calibrator              calibrator
+	confirmedMatchBonus     float64
+	categoryMismatchPenalty float64
+	feedbackStore           domain.FeedbackStore
+	extraFoodTerms          map[string]bool
+	extraDescriptiveTerms   map[string]bool
+	extraStopWords          map[string]bool
+	synonyms                synonymTable
+	synonymWeight           float64
+	normalizePlurals        bool
+	algorithm               MatchAlgorithm
+
+	enablePhoneticBrandMatch bool
+	fuzzyMinTokenLength      int
+	recencyBonus             float64
+	dataTypeBonuses          map[string]float64
+	earlyExitScore           float64 // 0 disables
+	maxCandidates            int     // 0 is unlimited
+	substringMinLength       int
+	phraseBonus              float64 // 0 disables
+	brandMatchBonus          float64
+	brandAliases             brandAliasTable
 }
 
 // NewMatchingService creates a new matching service with the given configuration
@@ -133,14 +299,104 @@ func NewMatchingService(config MatchConfig) *MatchingService {
 		fuzzyDist = 1 // Default edit distance of 1
 	}
 
+	fuzzyMinLength := config.FuzzyMinTokenLength
+	if fuzzyMinLength <= 0 {
+		fuzzyMinLength = defaultFuzzyMinTokenLength
+	}
+	fuzzyMinLength = max(fuzzyMinLength, fuzzyMinTokenLengthFloor)
+
+	thinPoolDiscount := config.ThinPoolDiscount
+	if thinPoolDiscount < 0 {
+		thinPoolDiscount = 0
+	}
+	if thinPoolDiscount > 1 {
+		thinPoolDiscount = 1
+	}
+
+	var qualifierPhrases [][]string
+	if config.EnableQualifierPhrases {
+		phrases := config.QualifierPhrases
+		if len(phrases) == 0 {
+			phrases = defaultQualifierPhrases
+		}
+		for _, phrase := range phrases {
+			// Tokenize like product names so stop words and punctuation line up
+			words := tokenize(phrase)
+			if config.NormalizePlurals {
+				words = singularizeAll(words)
+			}
+			if len(words) > 1 {
+				qualifierPhrases = append(qualifierPhrases, words)
+			}
+		}
+	}
+
+	synonyms := config.Synonyms
+	if len(synonyms) == 0 {
+		synonyms = defaultSynonyms
+	}
+
+	synonymWeight := config.SynonymWeight
+	if synonymWeight <= 0 {
+		synonymWeight = defaultSynonymWeight
+	}
+	if synonymWeight > 1 {
+		synonymWeight = 1
+	}
+
+	substringMinLength := config.SubstringMinLength
+	if substringMinLength <= 0 {
+		substringMinLength = defaultSubstringMinLength
+	}
+
+	brandBonus := config.BrandBonus
+	if brandBonus <= 0 {
+		brandBonus = brandMatchBonus
+	}
+
+	brandAliases := config.BrandAliases
+	if len(brandAliases) == 0 {
+		brandAliases = defaultBrandAliases
+	}
+
 	return &MatchingService{
-		minConfidenceThreshold: threshold,
-		enableFuzzyMatching:    config.EnableFuzzyMatching,
-		fuzzyEditDistance:      fuzzyDist,
-		enableDebugLogging:     config.EnableDebugLogging,
+		minConfidenceThreshold:  threshold,
+		enableFuzzyMatching:     config.EnableFuzzyMatching,
+		fuzzyEditDistance:       fuzzyDist,
+		enableDebugLogging:      config.EnableDebugLogging,
+		thinPoolSize:            config.ThinPoolSize,
+		thinPoolDiscount:        thinPoolDiscount,
+		qualifierPhrases:        qualifierPhrases,
+		compoundPhrases:         compoundPhrases(config.CompoundFoods),
+		calibrator:              newCalibrator(config.Calibration),
+		confirmedMatchBonus:     math.Max(config.ConfirmedMatchBonus, 0),
+		categoryMismatchPenalty: math.Max(config.CategoryMismatchPenalty, 0),
+		extraFoodTerms:          termSet(config.ExtraFoodTerms),
+		extraDescriptiveTerms:   termSet(config.ExtraDescriptiveTerms),
+		extraStopWords:          termSet(config.ExtraStopWords),
+		synonyms:                newSynonymTable(synonyms, config.NormalizePlurals),
+		synonymWeight:           synonymWeight,
+		normalizePlurals:        config.NormalizePlurals,
+		algorithm:               config.Algorithm,
+
+		enablePhoneticBrandMatch: config.EnablePhoneticBrandMatch,
+		fuzzyMinTokenLength:      fuzzyMinLength,
+		recencyBonus:             math.Max(config.RecencyBonus, 0),
+		dataTypeBonuses:          dataTypeBonuses(config.DataTypeBonuses),
+		earlyExitScore:           math.Max(config.EarlyExitScore, 0),
+		maxCandidates:            max(config.MaxCandidates, 0),
+		substringMinLength:       substringMinLength,
+		phraseBonus:              math.Max(config.PhraseBonus, 0),
+		brandMatchBonus:          brandBonus,
+		brandAliases:             newBrandAliasTable(brandAliases),
 	}
 }
 
+// SetFeedbackStore enables the confirmed-match bonus using feedback from store
+func (s *MatchingService) SetFeedbackStore(store domain.FeedbackStore) {
+	s.feedbackStore = store
+}
+
 // FindBestMatch finds the best matching USDA food for a search request.
 // Returns the best match with confidence score, or error if no match meets threshold.
 func (s *MatchingService) FindBestMatch(
@@ -148,7 +404,33 @@ func (s *MatchingService) FindBestMatch(
 	request *domain.SearchRequest,
 	usdaFoods []domain.USDAFood,
 ) (*domain.MatchResult, error) {
-	if request == nil || request.ProductName == "" {
+	matches, err := s.FindTopMatches(ctx, request, usdaFoods, 1)
+	if len(matches) == 0 {
+		return nil, err
+	}
+	return matches[0], err
+}
+
+// rankedMatch is a scored candidate with the fields used to break score ties
+type rankedMatch struct {
+	result        *domain.MatchResult
+	fdcID         int
+	dataTypeBonus float64
+	recencyBonus  float64
+}
+
+// FindTopMatches scores the USDA foods (the first MaxCandidates of them, when set)
+// and returns up to n matches, best first.
+// Ties are broken by the higher data type bonus, then the more recent publication
+// (when RecencyBonus is set), then the lower fdcId, so the order is deterministic. If the best match is below the threshold, the matches are
+// returned with ErrLowConfidence.
+func (s *MatchingService) FindTopMatches(
+	ctx context.Context,
+	request *domain.SearchRequest,
+	usdaFoods []domain.USDAFood,
+	n int,
+) ([]*domain.MatchResult, error) {
+	if request == nil || request.ProductName == "" || n <= 0 {
 		return nil, domain.ErrInvalidRequest
 	}
 
@@ -160,47 +442,167 @@ func (s *MatchingService) FindBestMatch(
 		log.Printf("[MATCH] Searching for: %q (brand: %q)", request.ProductName, request.Brand)
 	}
 
-	var bestMatch *domain.MatchResult
-	highestScore := -1.0 // Initialize to -1 so any score (including 0) is considered
+	// The thin-pool check counts what USDA returned, not what is left to score
+	poolSize := len(usdaFoods)
+	if s.maxCandidates > 0 && len(usdaFoods) > s.maxCandidates {
+		if s.enableDebugLogging {
+			log.Printf("[MATCH]   Scoring the first %d of %d candidates", s.maxCandidates, len(usdaFoods))
+		}
+		usdaFoods = usdaFoods[:s.maxCandidates]
+	}
+	earlyExit := n == 1 && s.earlyExitScore > 0
+
+	confirmed := s.confirmedMatches(ctx, request.ProductName)
+	recency := recencyBonuses(usdaFoods, s.recencyBonus)
 
-	for _, food := range usdaFoods {
+	ranked := make([]rankedMatch, 0, len(usdaFoods))
+	for i, food := range usdaFoods {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		default:
 		}
 
-		score, matchedTokens := s.calculateMatchScore(request.ProductName, request.Brand, food.Description, food.DataType)
+		var explanation *domain.MatchExplanation
+		if request.Explain {
+			explanation = &domain.MatchExplanation{}
+		}
+
+		score, matchedTokens := s.scoreCandidate(request.ProductName, request.Brand, food.Description, food.DataType, request.PreferGeneric, explanation)
+		if confirmed[fmt.Sprintf("%d", food.FdcID)] {
+			score = math.Min(score+s.confirmedMatchBonus, 100)
+			if explanation != nil {
+				explanation.ConfirmedBonus = s.confirmedMatchBonus
+				explanation.Score = score
+			}
+			if s.enableDebugLogging {
+				log.Printf("[MATCH]   Confirmed match bonus: +%.0f for fdcId %d", s.confirmedMatchBonus, food.FdcID)
+			}
+		}
+		if recency[i] > 0 {
+			score = math.Min(score+recency[i], 100)
+			if explanation != nil {
+				explanation.RecencyBonus = recency[i]
+				explanation.Score = score
+			}
+			if s.enableDebugLogging {
+				log.Printf("[MATCH]   Recency bonus: +%.2f for fdcId %d", recency[i], food.FdcID)
+			}
+		}
 
 		if s.enableDebugLogging {
 			log.Printf("[MATCH] USDA: %q | DataType: %s | Score: %.1f | Matched: %v",
 				food.Description, food.DataType, score, matchedTokens)
 		}
 
-		if score > highestScore {
-			highestScore = score
-			bestMatch = &domain.MatchResult{
+		ranked = append(ranked, rankedMatch{
+			result: &domain.MatchResult{
 				FdcID:         fmt.Sprintf("%d", food.FdcID),
 				Description:   food.Description,
 				MatchScore:    score,
 				MatchedTokens: matchedTokens,
+				Explanation:   explanation,
+			},
+			fdcID:         food.FdcID,
+			dataTypeBonus: s.dataTypeBonus(food.DataType, request.PreferGeneric),
+			recencyBonus:  recency[i],
+		})
+
+		if earlyExit && score >= s.earlyExitScore {
+			if s.enableDebugLogging {
+				log.Printf("[MATCH]   Early exit: score %.1f reached %.1f after %d of %d candidates",
+					score, s.earlyExitScore, i+1, len(usdaFoods))
 			}
+			break
 		}
 	}
 
-	if bestMatch == nil {
-		return nil, domain.ErrProductNotFound
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.result.MatchScore != b.result.MatchScore {
+			return a.result.MatchScore > b.result.MatchScore
+		}
+		if a.dataTypeBonus != b.dataTypeBonus {
+			return a.dataTypeBonus > b.dataTypeBonus
+		}
+		if a.recencyBonus != b.recencyBonus {
+			return a.recencyBonus > b.recencyBonus
+		}
+		return a.fdcID < b.fdcID
+	})
+	if len(ranked) > n {
+		ranked = ranked[:n]
 	}
 
+	// Discount confidence when there were too few candidates to compare against
+	thinPool := poolSize < s.thinPoolSize && s.thinPoolDiscount > 0
+	if thinPool && s.enableDebugLogging {
+		log.Printf("[MATCH]   Thin pool discount: -%.0f%% (%d candidates < %d)",
+			s.thinPoolDiscount*100, poolSize, s.thinPoolSize)
+	}
+
+	matches := make([]*domain.MatchResult, len(ranked))
+	for i, match := range ranked {
+		if thinPool {
+			match.result.MatchScore *= 1 - s.thinPoolDiscount
+		}
+		// Calibrate so the reported confidence tracks real match probability
+		match.result.RawScore = match.result.MatchScore
+		match.result.MatchScore = s.calibrator.apply(match.result.RawScore)
+		matches[i] = match.result
+	}
+
+	bestMatch := matches[0]
 	if s.enableDebugLogging {
-		log.Printf("[MATCH] Best match: %q (confidence: %.1f%%)", bestMatch.Description, bestMatch.MatchScore)
+		log.Printf("[MATCH] Best match: %q (confidence: %.1f%%, raw: %.1f)",
+			bestMatch.Description, bestMatch.MatchScore, bestMatch.RawScore)
+	}
+
+	if bestMatch.MatchScore < s.thresholdFor(request) {
+		return matches, domain.ErrLowConfidence
+	}
+
+	return matches, nil
+}
+
+// confirmedMatches looks up fdcIds previously confirmed correct for the query.
+// Lookup failures are ignored; the bonus is an optimization, not a requirement.
+func (s *MatchingService) confirmedMatches(ctx context.Context, query string) map[string]bool {
+	if s.feedbackStore == nil || s.confirmedMatchBonus == 0 {
+		return nil
+	}
+	confirmed, err := s.feedbackStore.ConfirmedFdcIDs(ctx, query)
+	if err != nil {
+		return nil
+	}
+	return confirmed
+}
+
+// thresholdFor returns the request's confidence threshold override, or the configured default
+func (s *MatchingService) thresholdFor(request *domain.SearchRequest) float64 {
+	if request != nil && request.MinConfidence != nil {
+		return *request.MinConfidence
 	}
+	return s.minConfidenceThreshold
+}
 
-	if bestMatch.MatchScore < s.minConfidenceThreshold {
-		return bestMatch, domain.ErrLowConfidence
+// validateMinConfidence checks a per-request threshold override. Very low thresholds
+// would accept almost any candidate, so overrides are bounded to [10, 100].
+func validateMinConfidence(minConfidence *float64) error {
+	if minConfidence == nil {
+		return nil
+	}
+	if math.IsNaN(*minConfidence) || *minConfidence < minConfidenceOverrideFloor || *minConfidence > 100 {
+		return fmt.Errorf("%w: minConfidence must be between %.0f and 100", domain.ErrInvalidRequest, minConfidenceOverrideFloor)
 	}
+	return nil
+}
 
-	return bestMatch, nil
+// ScoreFood computes the calibrated match score of a single USDA food against a product
+// name and brand without applying the confidence threshold
+func (s *MatchingService) ScoreFood(productName, brand string, food domain.USDAFood) float64 {
+	score, _ := s.calculateMatchScore(productName, brand, food.Description, food.DataType)
+	return s.calibrator.apply(score)
 }
 
 // TokenWeight holds a token with its importance weight
@@ -213,29 +615,59 @@ type TokenWeight struct {
 // Uses token-based matching with importance weighting, brand boosting, and data type prioritization.
 // Returns the score (0-100) and the list of matched tokens.
 func (s *MatchingService) calculateMatchScore(productName, brand, usdaDescription, dataType string) (float64, []string) {
-	productTokens := tokenizeWithWeights(productName)
-	usdaTokens := tokenizeWithWeights(usdaDescription)
+	return s.scoreCandidate(productName, brand, usdaDescription, dataType, false, nil)
+}
+
+// scoreCandidate is calculateMatchScore with control over data type preference.
+// preferGeneric favors Foundation/Survey data over Branded, for store-brand generics.
+// When explanation is non-nil it is filled with the score breakdown.
+func (s *MatchingService) scoreCandidate(productName, brand, usdaDescription, dataType string, preferGeneric bool, explanation *domain.MatchExplanation) (float64, []string) {
+	productTokens := s.tokenizeWithWeights(productName)
+	usdaTokens := s.tokenizeWithWeights(usdaDescription)
 
 	if len(productTokens) == 0 || len(usdaTokens) == 0 {
 		return 0, nil
 	}
 
 	// Calculate weighted similarity
-	baseScore, matchedTokens := s.calculateWeightedSimilarity(productTokens, usdaTokens)
+	baseScore, matchedTokens := s.calculateWeightedSimilarity(productTokens, usdaTokens, explanation)
+	if s.algorithm == AlgorithmJaroWinkler {
+		similarity := jaroWinkler(joinTokens(productTokens), joinTokens(usdaTokens))
+		baseScore = (1-jaroWinklerBlend)*baseScore + jaroWinklerBlend*similarity*baseScoreMultiplier
+	}
+	if explanation != nil {
+		explanation.BaseScore = baseScore
+	}
 
 	// Apply bonuses
-	score := s.applyBonuses(baseScore, brand, usdaDescription, productName, dataType)
+	score := s.applyBonuses(baseScore, productTokens, usdaTokens, brand, usdaDescription, productName, dataType, preferGeneric, explanation)
 
 	// Cap score at 100
 	if score > 100 {
 		score = 100
 	}
+	if score < 0 {
+		score = 0
+	}
+	if explanation != nil {
+		explanation.Score = score
+	}
 
 	return score, matchedTokens
 }
 
-// calculateWeightedSimilarity computes similarity based on token weights
-func (s *MatchingService) calculateWeightedSimilarity(productTokens, usdaTokens []TokenWeight) (float64, []string) {
+// joinTokens rebuilds a cleaned string from weighted tokens
+func joinTokens(tokens []TokenWeight) string {
+	words := make([]string, len(tokens))
+	for i, t := range tokens {
+		words[i] = t.Token
+	}
+	return strings.Join(words, " ")
+}
+
+// calculateWeightedSimilarity computes similarity based on token weights. When
+// explanation is non-nil, the matched tokens and their credited weights are recorded.
+func (s *MatchingService) calculateWeightedSimilarity(productTokens, usdaTokens []TokenWeight, explanation *domain.MatchExplanation) (float64, []string) {
 	// Build lookup map for USDA tokens
 	usdaSet := make(map[string]TokenWeight)
 	for _, t := range usdaTokens {
@@ -245,104 +677,360 @@ func (s *MatchingService) calculateWeightedSimilarity(productTokens, usdaTokens
 	var matchedWeight float64
 	var totalProductWeight float64
 	var matchedTokens []string
-	exactMatches := make(map[string]bool)
+	matched := make(map[string]bool)
+	usdaMatched := make(map[string]bool) // USDA tokens some product token matched, for precision
+	credit := func(token string, weight float64) {
+		matchedWeight += weight
+		matchedTokens = append(matchedTokens, token)
+		if explanation != nil {
+			explanation.MatchedTokens = append(explanation.MatchedTokens, domain.MatchedToken{Token: token, Weight: weight})
+		}
+	}
 
 	// First pass: exact token matches
 	for _, pt := range productTokens {
 		totalProductWeight += pt.Weight
 		if ut, found := usdaSet[pt.Token]; found {
 			// Use max weight of the two for matched tokens
-			matchedWeight += max(pt.Weight, ut.Weight)
-			matchedTokens = append(matchedTokens, pt.Token)
-			exactMatches[pt.Token] = true
+			credit(pt.Token, max(pt.Weight, ut.Weight))
+			matched[pt.Token] = true
+			usdaMatched[ut.Token] = true
 		}
 	}
 
 	// Second pass: fuzzy matching for unmatched tokens (if enabled)
 	if s.enableFuzzyMatching {
 		for _, pt := range productTokens {
-			if exactMatches[pt.Token] {
+			if matched[pt.Token] {
 				continue // Already matched exactly
 			}
 			for _, ut := range usdaTokens {
-				if fuzzyTokenMatch(pt.Token, ut.Token, s.fuzzyEditDistance) {
+				if fuzzyTokenMatch(pt.Token, ut.Token, s.fuzzyEditDistance, s.fuzzyMinTokenLength) {
 					// Fuzzy match gets reduced weight
-					matchedWeight += max(pt.Weight, ut.Weight) * fuzzyWeightFactor
-					matchedTokens = append(matchedTokens, pt.Token+"~"+ut.Token)
+					credit(pt.Token+"~"+ut.Token, max(pt.Weight, ut.Weight)*fuzzyWeightFactor)
+					matched[pt.Token] = true
+					usdaMatched[ut.Token] = true
 					break
 				}
 			}
 		}
 	}
 
+	// Third pass: synonyms for tokens still unmatched ("soda" vs "soft drink")
+	for _, pt := range productTokens {
+		if matched[pt.Token] {
+			continue
+		}
+		if weight, synonym, found := s.matchSynonym(pt, usdaSet); found {
+			// Synonym match gets reduced weight
+			credit(pt.Token+"="+synonym, weight*s.synonymWeight)
+			for _, word := range strings.Fields(synonym) {
+				usdaMatched[word] = true
+			}
+		}
+	}
+
+	if explanation != nil {
+		explanation.TotalWeight = totalProductWeight
+	}
+
 	// Score based on how much of the product's important terms were matched
 	if totalProductWeight == 0 {
 		return 0, nil
 	}
 
-	score := (matchedWeight / totalProductWeight) * baseScoreMultiplier
+	recall := matchedWeight / totalProductWeight
+	if s.algorithm == AlgorithmTokenF1 {
+		return tokenF1(recall, usdaTokens, usdaMatched) * baseScoreMultiplier, matchedTokens
+	}
+
+	score := recall * baseScoreMultiplier
 	return score, matchedTokens
 }
 
-// applyBonuses adds scoring bonuses for brand match, data type, and substring match
-func (s *MatchingService) applyBonuses(baseScore float64, brand, usdaDesc, productName, dataType string) float64 {
+// tokenF1 combines recall (how much of the product matched) with precision (how
+// much of the USDA description's weight was matched) as their harmonic mean
+func tokenF1(recall float64, usdaTokens []TokenWeight, usdaMatched map[string]bool) float64 {
+	var matchedWeight, totalWeight float64
+	for _, ut := range usdaTokens {
+		totalWeight += ut.Weight
+		if usdaMatched[ut.Token] {
+			matchedWeight += ut.Weight
+		}
+	}
+	recall = min(recall, 1)
+	if totalWeight == 0 || recall == 0 || matchedWeight == 0 {
+		return 0
+	}
+
+	precision := matchedWeight / totalWeight
+	return 2 * precision * recall / (precision + recall)
+}
+
+// matchSynonym finds the first synonym of pt whose words all appear in the USDA
+// tokens, returning the weight to credit and the synonym as text
+func (s *MatchingService) matchSynonym(pt TokenWeight, usdaSet map[string]TokenWeight) (float64, string, bool) {
+	for _, phrase := range s.synonyms[pt.Token] {
+		weight := pt.Weight
+		found := true
+		for _, word := range phrase {
+			ut, ok := usdaSet[word]
+			if !ok {
+				found = false
+				break
+			}
+			weight = max(weight, ut.Weight)
+		}
+		if found {
+			return weight, strings.Join(phrase, " "), true
+		}
+	}
+	return 0, "", false
+}
+
+// applyBonuses adds scoring bonuses for brand match, data type, and substring match,
+// and the penalty for a food category mismatch. When explanation is non-nil, each
+// adjustment applied is recorded on it.
+func (s *MatchingService) applyBonuses(baseScore float64, productTokens, usdaTokens []TokenWeight, brand, usdaDesc, productName, dataType string, preferGeneric bool, explanation *domain.MatchExplanation) float64 {
 	score := baseScore
 
+	// Category mismatch penalty ("chocolate milk" vs "Cake, chocolate")
+	if s.categoryMismatchPenalty > 0 {
+		productCategory := dominantCategory(productTokens, false)
+		usdaCategory := dominantCategory(usdaTokens, true)
+		if productCategory != "" && usdaCategory != "" && productCategory != usdaCategory {
+			score -= s.categoryMismatchPenalty
+			if explanation != nil {
+				explanation.CategoryPenalty = s.categoryMismatchPenalty
+			}
+			if s.enableDebugLogging {
+				log.Printf("[MATCH]   Category penalty: -%.0f (%s vs %s)", s.categoryMismatchPenalty, productCategory, usdaCategory)
+			}
+		}
+	}
+
 	usdaLower := strings.ToLower(usdaDesc)
 
 	// Brand matching bonus
 	if brand != "" {
-		brandLower := strings.ToLower(brand)
-		if strings.Contains(usdaLower, brandLower) {
-			score += brandMatchBonus
+		if bonus, name := s.brandBonus(brand, usdaLower); bonus > 0 {
+			score += bonus
+			if explanation != nil {
+				explanation.BrandBonus = bonus
+			}
+			if s.enableDebugLogging {
+				log.Printf("[MATCH]   Brand bonus: +%.1f (brand %q found in description as %q)", bonus, brand, name)
+			}
+		} else if s.enablePhoneticBrandMatch && phoneticBrandMatch(brand, usdaDesc) {
+			score += phoneticBrandBonus
+			if explanation != nil {
+				explanation.BrandBonus = phoneticBrandBonus
+			}
 			if s.enableDebugLogging {
-				log.Printf("[MATCH]   Brand bonus: +%.0f (brand %q found in description)", brandMatchBonus, brand)
+				log.Printf("[MATCH]   Phonetic brand bonus: +%.0f (brand %q sounds like the description)", phoneticBrandBonus, brand)
 			}
 		}
 	}
 
 	// USDA Data Type bonus
-	var dataTypeBonus float64
-	switch dataType {
-	case "Branded":
-		dataTypeBonus = dataTypeBrandedBonus
-	case "Survey (FNDDS)":
-		dataTypeBonus = dataTypeSurveyBonus
-	case "Foundation":
-		dataTypeBonus = dataTypeFoundationBonus
-	}
-	if dataTypeBonus > 0 {
-		score += dataTypeBonus
+	typeBonus := s.dataTypeBonus(dataType, preferGeneric)
+	if typeBonus > 0 {
+		score += typeBonus
+		if explanation != nil {
+			explanation.DataTypeBonus = typeBonus
+		}
 		if s.enableDebugLogging {
-			log.Printf("[MATCH]   DataType bonus: +%.0f (%s)", dataTypeBonus, dataType)
+			log.Printf("[MATCH]   DataType bonus: +%.0f (%s)", typeBonus, dataType)
 		}
 	}
 
-	// Substring match bonus (only for significant matches > 5 chars)
+	// Substring match bonus (only for significant matches of substringMinLength chars)
 	productLower := strings.ToLower(productName)
-	if len(productLower) > 5 && strings.Contains(usdaLower, productLower) {
+	if len(productLower) >= s.substringMinLength && strings.Contains(usdaLower, productLower) {
 		score += substringMatchBonus
+		if explanation != nil {
+			explanation.SubstringBonus = substringMatchBonus
+		}
 		if s.enableDebugLogging {
 			log.Printf("[MATCH]   Substring bonus: +%.0f (product name found in description)", substringMatchBonus)
 		}
 	}
 
+	// Partial phrase bonus, for names too noisy to appear whole
+	if s.phraseBonus > 0 {
+		if run := longestCommonRun(productTokens, usdaTokens); run >= 2 {
+			bonus := s.phraseBonus * float64(run) / float64(len(productTokens))
+			score += bonus
+			if explanation != nil {
+				explanation.PhraseBonus = bonus
+			}
+			if s.enableDebugLogging {
+				log.Printf("[MATCH]   Phrase bonus: +%.1f (%d of %d tokens in a row)", bonus, run, len(productTokens))
+			}
+		}
+	}
+
 	return score
 }
 
-// tokenizeWithWeights splits a string into weighted tokens
+// longestCommonRun returns the length of the longest sequence of consecutive
+// tokens that appears in both a and b
+func longestCommonRun(a, b []TokenWeight) int {
+	longest := 0
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		curr := make([]int, len(b)+1)
+		for j := range b {
+			if a[i].Token == b[j].Token {
+				curr[j+1] = prev[j] + 1
+				longest = max(longest, curr[j+1])
+			}
+		}
+		prev = curr
+	}
+	return longest
+}
+
+// dataTypeBonus returns the score bonus for a USDA data type. Generic products match
+// USDA's generic foods better than another label's branded entry, so preferGeneric
+// gives Foundation the top bonus and Branded none.
+func (s *MatchingService) dataTypeBonus(dataType string, preferGeneric bool) float64 {
+	if preferGeneric {
+		switch dataType {
+		case "Foundation":
+			top := 0.0
+			for _, bonus := range s.dataTypeBonuses {
+				top = max(top, bonus)
+			}
+			return top
+		case "Branded":
+			return 0
+		}
+	}
+	return s.dataTypeBonuses[dataType]
+}
+
+// dataTypeBonuses returns the built-in data type bonuses with overrides applied
+func dataTypeBonuses(overrides map[string]float64) map[string]float64 {
+	bonuses := map[string]float64{
+		"Branded":        dataTypeBrandedBonus,
+		"Survey (FNDDS)": dataTypeSurveyBonus,
+		"SR Legacy":      dataTypeSRLegacyBonus,
+		"Foundation":     dataTypeFoundationBonus,
+	}
+	for dataType, bonus := range overrides {
+		bonuses[dataType] = math.Max(bonus, 0)
+	}
+	return bonuses
+}
+
+// tokenizeWithWeights splits a string into weighted tokens, merging known compound
+// foods into single tokens (e.g., "ice", "cream" becomes "ice-cream")
 func tokenizeWithWeights(s string) []TokenWeight {
 	tokens := tokenize(s)
 	weighted := make([]TokenWeight, 0, len(tokens))
 
-	for _, token := range tokens {
-		weight := getTokenWeight(token)
-		weighted = append(weighted, TokenWeight{Token: token, Weight: weight})
+	for i := 0; i < len(tokens); {
+		if phrase := compoundAt(defaultCompoundPhrases, tokens, i); phrase != nil {
+			weighted = append(weighted, compoundToken(phrase))
+			i += len(phrase)
+			continue
+		}
+		weight := getTokenWeight(tokens[i])
+		weighted = append(weighted, TokenWeight{Token: tokens[i], Weight: weight})
+		i++
+	}
+
+	return weighted
+}
+
+// tokenizeWithWeights is tokenizeWithWeights with configured compound foods and
+// qualifier phrases merged into single tokens (e.g., "gluten", "free" becomes
+// "gluten-free") and configured extra terms applied
+func (s *MatchingService) tokenizeWithWeights(text string) []TokenWeight {
+	if len(s.qualifierPhrases) == 0 && s.compoundPhrases == nil && s.extraFoodTerms == nil && s.extraDescriptiveTerms == nil && s.extraStopWords == nil && !s.normalizePlurals {
+		return tokenizeWithWeights(text)
+	}
+
+	compounds := s.compoundPhrases
+	if compounds == nil {
+		compounds = defaultCompoundPhrases
+	}
+
+	original := s.tokenize(text)
+	tokens := original
+	if s.normalizePlurals {
+		tokens = singularizeAll(slices.Clone(original))
+	}
+	weighted := make([]TokenWeight, 0, len(tokens))
+
+	for i := 0; i < len(tokens); {
+		if phrase := compoundAt(compounds, tokens, i); phrase != nil {
+			weighted = append(weighted, compoundToken(phrase))
+			i += len(phrase)
+			continue
+		}
+		if phrase := s.qualifierPhraseAt(tokens, i); phrase != nil {
+			weighted = append(weighted, TokenWeight{Token: strings.Join(phrase, "-"), Weight: weightQualifier})
+			i += len(phrase)
+			continue
+		}
+		// Dictionaries list some plurals ("eggs", "beans"), so weigh both forms
+		weight := max(s.tokenWeight(original[i]), s.tokenWeight(tokens[i]))
+		weighted = append(weighted, TokenWeight{Token: tokens[i], Weight: weight})
+		i++
 	}
 
 	return weighted
 }
 
+// qualifierPhraseAt returns the first configured phrase starting at tokens[i], or nil
+func (s *MatchingService) qualifierPhraseAt(tokens []string, i int) []string {
+	for _, phrase := range s.qualifierPhrases {
+		if i+len(phrase) > len(tokens) {
+			continue
+		}
+		matched := true
+		for j, word := range phrase {
+			if tokens[i+j] != word {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return phrase
+		}
+	}
+	return nil
+}
+
+// tokenize is tokenize with configured extra stop words removed
+func (s *MatchingService) tokenize(text string) []string {
+	tokens := tokenize(text)
+	if s.extraStopWords == nil {
+		return tokens
+	}
+
+	kept := tokens[:0]
+	for _, token := range tokens {
+		if !s.extraStopWords[token] {
+			kept = append(kept, token)
+		}
+	}
+	return kept
+}
+
+// tokenWeight is getTokenWeight with configured extra terms taken into account
+func (s *MatchingService) tokenWeight(token string) float64 {
+	if s.extraFoodTerms[token] {
+		return weightFood
+	}
+	if s.extraDescriptiveTerms[token] && !foodTerms[token] {
+		return weightDescriptive
+	}
+	return getTokenWeight(token)
+}
+
 // getTokenWeight returns the importance weight for a token
 func getTokenWeight(token string) float64 {
 	if foodTerms[token] {
@@ -393,15 +1081,16 @@ func isNumeric(s string) bool {
 	return len(s) > 0
 }
 
-// fuzzyTokenMatch checks if two tokens are similar within the edit distance threshold
-func fuzzyTokenMatch(token1, token2 string, threshold int) bool {
+// fuzzyTokenMatch checks if two tokens are similar within the edit distance threshold.
+// Tokens shorter than minLength only match exactly.
+func fuzzyTokenMatch(token1, token2 string, threshold, minLength int) bool {
 	// Identical tokens (shouldn't reach here but check anyway)
 	if token1 == token2 {
 		return true
 	}
 
-	// Only apply fuzzy matching to tokens > 4 chars to avoid false positives
-	if len(token1) < 4 || len(token2) < 4 {
+	// Only apply fuzzy matching to tokens of at least minLength chars to avoid false positives
+	if len(token1) < minLength || len(token2) < minLength {
 		return false
 	}
 
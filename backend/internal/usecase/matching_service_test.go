@@ -3,6 +3,9 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"slices"
 	"testing"
 
 	"github.com/macrolens/backend/internal/domain"
@@ -185,6 +188,476 @@ func TestFindBestMatch(t *testing.T) {
 	})
 }
 
+func TestFindBestMatch_QualifierPhrases(t *testing.T) {
+	ctx := context.Background()
+	request := &domain.SearchRequest{ProductName: "gluten free white bread"}
+	foods := []domain.USDAFood{
+		{FdcID: 1, Description: "Bread, white, fat free", DataType: "Branded"},
+		{FdcID: 2, Description: "Bread, white", DataType: "Branded"},
+		{FdcID: 3, Description: "Bread, gluten-free", DataType: "Branded"},
+	}
+
+	t.Run("ranks gluten-free candidate first when enabled", func(t *testing.T) {
+		svc := NewMatchingService(MatchConfig{MinConfidenceThreshold: 40, EnableQualifierPhrases: true})
+
+		result, err := svc.FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "3" {
+			t.Errorf("FdcID = %v (%s), want 3 (gluten-free)", result.FdcID, result.Description)
+		}
+		if !slices.Contains(result.MatchedTokens, "gluten-free") {
+			t.Errorf("MatchedTokens = %v, want to contain gluten-free", result.MatchedTokens)
+		}
+	})
+
+	t.Run("shared free token wins when disabled", func(t *testing.T) {
+		svc := NewMatchingService(MatchConfig{MinConfidenceThreshold: 40})
+
+		result, err := svc.FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "1" {
+			t.Errorf("FdcID = %v (%s), want 1 (diluted qualifier matches fat free)", result.FdcID, result.Description)
+		}
+	})
+}
+
+func TestFindBestMatch_PreferGeneric(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMatchingService(MatchConfig{MinConfidenceThreshold: 40})
+	foods := []domain.USDAFood{
+		{FdcID: 1, Description: "Milk, whole", DataType: "Branded"},
+		{FdcID: 2, Description: "Milk, whole", DataType: "Foundation"},
+	}
+
+	t.Run("branded wins by default", func(t *testing.T) {
+		result, err := svc.FindBestMatch(ctx, &domain.SearchRequest{ProductName: "whole milk"}, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "1" {
+			t.Errorf("FdcID = %v, want 1 (Branded)", result.FdcID)
+		}
+	})
+
+	t.Run("foundation wins for store-brand generics", func(t *testing.T) {
+		result, err := svc.FindBestMatch(ctx, &domain.SearchRequest{ProductName: "whole milk", PreferGeneric: true}, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "2" {
+			t.Errorf("FdcID = %v, want 2 (Foundation)", result.FdcID)
+		}
+	})
+}
+
+func TestFindBestMatch_MinConfidenceOverride(t *testing.T) {
+	ctx := context.Background()
+	foods := []domain.USDAFood{{FdcID: 1, Description: "Milk, whole"}}
+	threshold := func(v float64) *float64 { return &v }
+
+	testCases := []struct {
+		name          string
+		configured    float64
+		minConfidence *float64
+		wantErr       error
+	}{
+		{"configured default matches", 40, nil, nil},
+		{"stricter override rejects", 40, threshold(90), domain.ErrLowConfidence},
+		{"configured default rejects", 80, nil, domain.ErrLowConfidence},
+		{"looser override matches", 80, threshold(60), nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewMatchingService(MatchConfig{MinConfidenceThreshold: tc.configured})
+			request := &domain.SearchRequest{ProductName: "whole milk", MinConfidence: tc.minConfidence}
+
+			result, err := svc.FindBestMatch(ctx, request, foods)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("error = %v, want %v", err, tc.wantErr)
+			}
+			if result == nil || result.FdcID != "1" {
+				t.Errorf("result = %+v, want match 1 either way", result)
+			}
+		})
+	}
+}
+
+func TestFindBestMatch_ConfirmedMatchBonus(t *testing.T) {
+	ctx := context.Background()
+	request := &domain.SearchRequest{ProductName: "whole milk"}
+	foods := []domain.USDAFood{
+		{FdcID: 1, Description: "Whole Milk"},
+		{FdcID: 2, Description: "Milk, whole"},
+	}
+	correct := true
+	store := &mockFeedbackStore{entries: []domain.MatchFeedback{
+		{Query: "whole milk", FdcID: "2", Correct: &correct},
+	}}
+
+	t.Run("confirmed match wins a close contest", func(t *testing.T) {
+		svc := NewMatchingService(MatchConfig{MinConfidenceThreshold: 40, ConfirmedMatchBonus: 15})
+		svc.SetFeedbackStore(store)
+
+		result, err := svc.FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "2" {
+			t.Errorf("FdcID = %v, want 2 (confirmed)", result.FdcID)
+		}
+	})
+
+	t.Run("no bonus without a configured amount", func(t *testing.T) {
+		svc := NewMatchingService(MatchConfig{MinConfidenceThreshold: 40})
+		svc.SetFeedbackStore(store)
+
+		result, err := svc.FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "1" {
+			t.Errorf("FdcID = %v, want 1 (higher base score)", result.FdcID)
+		}
+	})
+
+	t.Run("confirmations for other queries are ignored", func(t *testing.T) {
+		svc := NewMatchingService(MatchConfig{MinConfidenceThreshold: 40, ConfirmedMatchBonus: 15})
+		svc.SetFeedbackStore(&mockFeedbackStore{entries: []domain.MatchFeedback{
+			{Query: "white bread", FdcID: "2", Correct: &correct},
+		}})
+
+		result, err := svc.FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "1" {
+			t.Errorf("FdcID = %v, want 1 (no confirmation for this query)", result.FdcID)
+		}
+	})
+}
+
+func TestTokenizeWithWeights_QualifierPhrases(t *testing.T) {
+	svc := NewMatchingService(MatchConfig{
+		EnableQualifierPhrases: true,
+		QualifierPhrases:       []string{"gluten free", "no sugar added"},
+	})
+
+	got := svc.tokenizeWithWeights("Gluten-Free Applesauce, No Sugar Added")
+	want := []TokenWeight{
+		{Token: "gluten-free", Weight: weightQualifier},
+		{Token: "applesauce", Weight: weightDefault},
+		{Token: "no-sugar-added", Weight: weightQualifier},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("tokenizeWithWeights() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("token[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTokenizeWithWeights_ExtraTerms(t *testing.T) {
+	svc := NewMatchingService(MatchConfig{
+		ExtraFoodTerms:        []string{"Kefir"},
+		ExtraDescriptiveTerms: []string{"sprouted"},
+		ExtraStopWords:        []string{"multipack"},
+	})
+
+	got := svc.tokenizeWithWeights("Sprouted Kefir Multipack")
+	want := []TokenWeight{
+		{Token: "sprouted", Weight: weightDescriptive},
+		{Token: "kefir", Weight: weightFood},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("tokenizeWithWeights() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("token[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTokenizeWithWeights_ExtraTermPrecedence(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config MatchConfig
+		input  string
+		want   []TokenWeight
+	}{
+		{
+			name:   "stop word beats food term",
+			config: MatchConfig{ExtraFoodTerms: []string{"kefir"}, ExtraStopWords: []string{"kefir"}},
+			input:  "plain kefir",
+			want:   []TokenWeight{{Token: "plain", Weight: weightDescriptive}},
+		},
+		{
+			name:   "food term beats descriptive term",
+			config: MatchConfig{ExtraFoodTerms: []string{"kefir"}, ExtraDescriptiveTerms: []string{"kefir"}},
+			input:  "kefir",
+			want:   []TokenWeight{{Token: "kefir", Weight: weightFood}},
+		},
+		{
+			name:   "extra food term promotes a built-in descriptive term",
+			config: MatchConfig{ExtraFoodTerms: []string{"plain"}},
+			input:  "plain",
+			want:   []TokenWeight{{Token: "plain", Weight: weightFood}},
+		},
+		{
+			name:   "extra descriptive term can't demote a built-in food term",
+			config: MatchConfig{ExtraDescriptiveTerms: []string{"milk"}},
+			input:  "milk",
+			want:   []TokenWeight{{Token: "milk", Weight: weightFood}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := NewMatchingService(tc.config).tokenizeWithWeights(tc.input)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("tokenizeWithWeights(%q) = %v, want %v", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCalculateMatchScore_ExtraFoodTerms(t *testing.T) {
+	// "kefir" is an unknown word by default, so matching it counts for less than
+	// the flavor word the candidate misses
+	builtin := NewMatchingService(MatchConfig{})
+	extended := NewMatchingService(MatchConfig{ExtraFoodTerms: []string{"kefir"}})
+
+	before, _ := builtin.calculateMatchScore("Strawberry Kefir", "", "Kefir, plain", "")
+	after, _ := extended.calculateMatchScore("Strawberry Kefir", "", "Kefir, plain", "")
+	if after <= before {
+		t.Errorf("score with kefir as a food term = %.1f, want above the built-in %.1f", after, before)
+	}
+}
+
+func TestFindTopMatches(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMatchingService(MatchConfig{MinConfidenceThreshold: 40})
+	request := &domain.SearchRequest{ProductName: "whole milk"}
+
+	t.Run("returns up to n matches sorted by score", func(t *testing.T) {
+		foods := []domain.USDAFood{
+			{FdcID: 1, Description: "Bread, white"},
+			{FdcID: 2, Description: "Milk, whole"},
+			{FdcID: 3, Description: "Milk, skim"},
+		}
+
+		matches, err := svc.FindTopMatches(ctx, request, foods, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(matches) != 2 {
+			t.Fatalf("len(matches) = %d, want 2", len(matches))
+		}
+		if matches[0].FdcID != "2" || matches[1].FdcID != "3" {
+			t.Errorf("matches = [%s, %s], want [2, 3]", matches[0].FdcID, matches[1].FdcID)
+		}
+		if matches[0].MatchScore < matches[1].MatchScore {
+			t.Errorf("scores = [%.1f, %.1f], want descending", matches[0].MatchScore, matches[1].MatchScore)
+		}
+	})
+
+	t.Run("breaks ties by data type bonus then fdcId", func(t *testing.T) {
+		// Brand and substring bonuses push every candidate to the 100 cap
+		branded := &domain.SearchRequest{ProductName: "whole milk", Brand: "Horizon"}
+		foods := []domain.USDAFood{
+			{FdcID: 30, Description: "Horizon whole milk", DataType: "Foundation"},
+			{FdcID: 40, Description: "Horizon whole milk", DataType: "Survey (FNDDS)"},
+			{FdcID: 20, Description: "Horizon whole milk", DataType: "Branded"},
+			{FdcID: 10, Description: "Horizon whole milk", DataType: "Foundation"},
+		}
+
+		matches, err := svc.FindTopMatches(ctx, branded, foods, 4)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got []string
+		for _, match := range matches {
+			if match.MatchScore != 100 {
+				t.Errorf("fdcId %s score = %.1f, want 100", match.FdcID, match.MatchScore)
+			}
+			got = append(got, match.FdcID)
+		}
+		if !slices.Equal(got, []string{"20", "40", "10", "30"}) {
+			t.Errorf("order = %v, want [20 40 10 30]", got)
+		}
+	})
+
+	t.Run("returns matches with ErrLowConfidence below threshold", func(t *testing.T) {
+		strict := NewMatchingService(MatchConfig{MinConfidenceThreshold: 99})
+		foods := []domain.USDAFood{{FdcID: 1, Description: "Milk, skim"}, {FdcID: 2, Description: "Cheese"}}
+
+		matches, err := strict.FindTopMatches(ctx, request, foods, 5)
+		if !errors.Is(err, domain.ErrLowConfidence) {
+			t.Fatalf("error = %v, want ErrLowConfidence", err)
+		}
+		if len(matches) != 2 {
+			t.Errorf("len(matches) = %d, want 2", len(matches))
+		}
+	})
+
+	t.Run("rejects non-positive n", func(t *testing.T) {
+		_, err := svc.FindTopMatches(ctx, request, []domain.USDAFood{{FdcID: 1, Description: "Milk"}}, 0)
+		if !errors.Is(err, domain.ErrInvalidRequest) {
+			t.Errorf("error = %v, want ErrInvalidRequest", err)
+		}
+	})
+}
+
+func TestFindTopMatches_Explain(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMatchingService(MatchConfig{MinConfidenceThreshold: 40})
+	foods := []domain.USDAFood{
+		{FdcID: 1, Description: "Horizon whole milk, vitamin D", DataType: "Survey (FNDDS)"},
+		{FdcID: 2, Description: "Milk, skim", DataType: "Branded"},
+	}
+
+	t.Run("breaks down each candidate's score", func(t *testing.T) {
+		request := &domain.SearchRequest{ProductName: "Organic whole milk", Brand: "Horizon", Explain: true}
+		matches, err := svc.FindTopMatches(ctx, request, foods, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		best := matches[0].Explanation
+		if best == nil {
+			t.Fatal("Explanation = nil, want a breakdown")
+		}
+		var tokens []string
+		for _, token := range best.MatchedTokens {
+			tokens = append(tokens, token.Token)
+			if token.Weight <= 0 {
+				t.Errorf("token %q weight = %v, want positive", token.Token, token.Weight)
+			}
+		}
+		if !slices.Equal(tokens, matches[0].MatchedTokens) {
+			t.Errorf("explained tokens = %v, want %v", tokens, matches[0].MatchedTokens)
+		}
+		if best.BrandBonus != brandMatchBonus || best.DataTypeBonus != dataTypeSurveyBonus || best.SubstringBonus != 0 {
+			t.Errorf("bonuses = brand %v, data type %v, substring %v; want %v, %v, 0",
+				best.BrandBonus, best.DataTypeBonus, best.SubstringBonus, brandMatchBonus, dataTypeSurveyBonus)
+		}
+		want := math.Min(best.BaseScore+best.BrandBonus+best.DataTypeBonus+best.SubstringBonus+best.PhraseBonus-best.CategoryPenalty, 100)
+		if best.Score != want || best.Score != matches[0].RawScore {
+			t.Errorf("Score = %v, want %v (the raw score %v)", best.Score, want, matches[0].RawScore)
+		}
+		if matches[1].Explanation == nil || matches[1].Explanation.DataTypeBonus != dataTypeBrandedBonus {
+			t.Errorf("runner-up Explanation = %+v, want the Branded bonus", matches[1].Explanation)
+		}
+	})
+
+	t.Run("omitted unless requested", func(t *testing.T) {
+		request := &domain.SearchRequest{ProductName: "Organic whole milk", Brand: "Horizon"}
+		result, err := svc.FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Explanation != nil {
+			t.Errorf("Explanation = %+v, want nil", result.Explanation)
+		}
+	})
+}
+
+func TestFindBestMatch_ThinPool(t *testing.T) {
+	ctx := context.Background()
+	request := &domain.SearchRequest{ProductName: "whole milk"}
+	single := []domain.USDAFood{
+		{FdcID: 1, Description: "Whole Milk", DataType: "Foundation"},
+	}
+
+	baseline := NewMatchingService(MatchConfig{MinConfidenceThreshold: 40})
+	baseResult, err := baseline.FindBestMatch(ctx, request, single)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("discounts a single-candidate pool", func(t *testing.T) {
+		svc := NewMatchingService(MatchConfig{
+			MinConfidenceThreshold: 40,
+			ThinPoolSize:           3,
+			ThinPoolDiscount:       0.2,
+		})
+
+		result, err := svc.FindBestMatch(ctx, request, single)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := baseResult.MatchScore * 0.8
+		if result.MatchScore != want {
+			t.Errorf("MatchScore = %v, want %v", result.MatchScore, want)
+		}
+	})
+
+	t.Run("does not discount a full pool", func(t *testing.T) {
+		svc := NewMatchingService(MatchConfig{
+			MinConfidenceThreshold: 40,
+			ThinPoolSize:           3,
+			ThinPoolDiscount:       0.2,
+		})
+		foods := append([]domain.USDAFood{}, single...)
+		foods = append(foods,
+			domain.USDAFood{FdcID: 2, Description: "Skim Milk", DataType: "Foundation"},
+			domain.USDAFood{FdcID: 3, Description: "Chocolate Milk", DataType: "Foundation"},
+		)
+
+		result, err := svc.FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.MatchScore != baseResult.MatchScore {
+			t.Errorf("MatchScore = %v, want undiscounted %v", result.MatchScore, baseResult.MatchScore)
+		}
+	})
+
+	t.Run("counts the pool before the candidate cap", func(t *testing.T) {
+		svc := NewMatchingService(MatchConfig{
+			MinConfidenceThreshold: 40,
+			ThinPoolSize:           3,
+			ThinPoolDiscount:       0.2,
+			MaxCandidates:          1,
+		})
+		foods := append([]domain.USDAFood{}, single...)
+		foods = append(foods,
+			domain.USDAFood{FdcID: 2, Description: "Skim Milk", DataType: "Foundation"},
+			domain.USDAFood{FdcID: 3, Description: "Chocolate Milk", DataType: "Foundation"},
+		)
+
+		result, err := svc.FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.MatchScore != baseResult.MatchScore {
+			t.Errorf("MatchScore = %v, want undiscounted %v", result.MatchScore, baseResult.MatchScore)
+		}
+	})
+
+	t.Run("discount can push a match below threshold", func(t *testing.T) {
+		svc := NewMatchingService(MatchConfig{
+			MinConfidenceThreshold: baseResult.MatchScore - 1,
+			ThinPoolSize:           2,
+			ThinPoolDiscount:       0.5,
+		})
+
+		_, err := svc.FindBestMatch(ctx, request, single)
+		if !errors.Is(err, domain.ErrLowConfidence) {
+			t.Errorf("error = %v, want ErrLowConfidence", err)
+		}
+	})
+}
+
 func TestTokenize(t *testing.T) {
 	t.Run("converts to lowercase", func(t *testing.T) {
 		tokens := tokenize("WHOLE MILK")
@@ -312,6 +785,16 @@ func TestCalculateMatchScore(t *testing.T) {
 			t.Errorf("Foundation bonus = %v, want approximately 3", diff)
 		}
 	})
+
+	t.Run("applies data type bonus for SR Legacy", func(t *testing.T) {
+		scoreSRLegacy, _ := svc.calculateMatchScore("whole milk", "", "whole milk", "SR Legacy")
+		scoreNoType, _ := svc.calculateMatchScore("whole milk", "", "whole milk", "")
+		// SR Legacy should add 4 points
+		diff := scoreSRLegacy - scoreNoType
+		if diff < 3 || diff > 5 {
+			t.Errorf("SR Legacy bonus = %v, want approximately 4", diff)
+		}
+	})
 }
 
 func TestFindIntersection(t *testing.T) {
@@ -507,7 +990,7 @@ func TestFuzzyTokenMatch(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.token1+"_"+tc.token2, func(t *testing.T) {
-			got := fuzzyTokenMatch(tc.token1, tc.token2, tc.threshold)
+			got := fuzzyTokenMatch(tc.token1, tc.token2, tc.threshold, defaultFuzzyMinTokenLength)
 			if got != tc.want {
 				t.Errorf("fuzzyTokenMatch(%q, %q, %d) = %v, want %v",
 					tc.token1, tc.token2, tc.threshold, got, tc.want)
@@ -516,6 +999,56 @@ func TestFuzzyTokenMatch(t *testing.T) {
 	}
 }
 
+func TestFuzzyTokenMatch_MinTokenLength(t *testing.T) {
+	testCases := []struct {
+		token1    string
+		token2    string
+		minLength int
+		want      bool
+	}{
+		{"pie", "pye", 3, true},   // 3-char typo matches at length 3
+		{"pie", "pye", 4, false},  // but not at the default length 4
+		{"rice", "ricd", 4, true}, // 4-char tokens match at the default
+		{"rice", "ricd", 5, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s_%s_%d", tc.token1, tc.token2, tc.minLength), func(t *testing.T) {
+			if got := fuzzyTokenMatch(tc.token1, tc.token2, 1, tc.minLength); got != tc.want {
+				t.Errorf("fuzzyTokenMatch(%q, %q, 1, %d) = %v, want %v",
+					tc.token1, tc.token2, tc.minLength, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewMatchingService_FuzzyMinTokenLength(t *testing.T) {
+	testCases := []struct {
+		configured int
+		want       int
+	}{
+		{0, defaultFuzzyMinTokenLength},
+		{3, 3},
+		{6, 6},
+		{1, fuzzyMinTokenLengthFloor}, // Raised to the floor
+	}
+
+	for _, tc := range testCases {
+		svc := NewMatchingService(MatchConfig{FuzzyMinTokenLength: tc.configured})
+		if svc.fuzzyMinTokenLength != tc.want {
+			t.Errorf("FuzzyMinTokenLength %d: got %d, want %d", tc.configured, svc.fuzzyMinTokenLength, tc.want)
+		}
+	}
+
+	// A 3-char typo only earns fuzzy credit once the minimum is lowered to 3
+	const product, usdaDesc = "rye bread", "RYA BREAD"
+	defaultScore, _ := NewMatchingService(MatchConfig{EnableFuzzyMatching: true}).scoreCandidate(product, "", usdaDesc, "", false, nil)
+	shortScore, _ := NewMatchingService(MatchConfig{EnableFuzzyMatching: true, FuzzyMinTokenLength: 3}).scoreCandidate(product, "", usdaDesc, "", false, nil)
+	if shortScore <= defaultScore {
+		t.Errorf("score with min length 3 = %.1f, want above the default's %.1f", shortScore, defaultScore)
+	}
+}
+
 func TestFuzzyMatchingEnabled(t *testing.T) {
 	t.Run("fuzzy matching finds close matches when enabled", func(t *testing.T) {
 		svc := NewMatchingService(MatchConfig{
@@ -594,6 +1127,53 @@ func TestTokenizeFiltersNumericTokens(t *testing.T) {
 	})
 }
 
+// walmartProductFixtures are realistic Walmart listings with the USDA food each should match
+var walmartProductFixtures = []struct {
+	name          string
+	productName   string
+	brand         string
+	usdaFoods     []domain.USDAFood
+	wantFdcID     string
+	minConfidence float64
+}{
+	{
+		name:        "whole milk matches correctly",
+		productName: "Whole Milk, Vitamin D, Gallon, 128 fl oz",
+		brand:       "Great Value",
+		usdaFoods: []domain.USDAFood{
+			{FdcID: 111, Description: "Skim Milk", DataType: "Foundation"},
+			{FdcID: 222, Description: "Great Value Whole Milk, Vitamin D", DataType: "Branded"},
+			{FdcID: 333, Description: "Chocolate Milk", DataType: "Foundation"},
+		},
+		wantFdcID:     "222",
+		minConfidence: 50,
+	},
+	{
+		name:        "chicken breast matches",
+		productName: "Boneless Skinless Chicken Breasts, 2.5 lb",
+		brand:       "Tyson",
+		usdaFoods: []domain.USDAFood{
+			{FdcID: 111, Description: "Tyson Boneless Skinless Chicken Breast", DataType: "Branded"},
+			{FdcID: 222, Description: "Chicken Wings", DataType: "Foundation"},
+			{FdcID: 333, Description: "Ground Beef", DataType: "Foundation"},
+		},
+		wantFdcID:     "111",
+		minConfidence: 50,
+	},
+	{
+		name:        "cereal matches",
+		productName: "Cheerios Heart Healthy Cereal, 18 oz",
+		brand:       "General Mills",
+		usdaFoods: []domain.USDAFood{
+			{FdcID: 111, Description: "Corn Flakes", DataType: "Foundation"},
+			{FdcID: 222, Description: "Cheerios, Whole Grain Oat Cereal", DataType: "Branded"},
+			{FdcID: 333, Description: "Oatmeal", DataType: "Foundation"},
+		},
+		wantFdcID:     "222",
+		minConfidence: 40,
+	},
+}
+
 func TestRealisticWalmartProducts(t *testing.T) {
 	svc := NewMatchingService(MatchConfig{
 		MinConfidenceThreshold: 40,
@@ -602,53 +1182,7 @@ func TestRealisticWalmartProducts(t *testing.T) {
 	})
 	ctx := context.Background()
 
-	testCases := []struct {
-		name          string
-		productName   string
-		brand         string
-		usdaFoods     []domain.USDAFood
-		wantFdcID     string
-		minConfidence float64
-	}{
-		{
-			name:        "whole milk matches correctly",
-			productName: "Whole Milk, Vitamin D, Gallon, 128 fl oz",
-			brand:       "Great Value",
-			usdaFoods: []domain.USDAFood{
-				{FdcID: 111, Description: "Skim Milk", DataType: "Foundation"},
-				{FdcID: 222, Description: "Great Value Whole Milk, Vitamin D", DataType: "Branded"},
-				{FdcID: 333, Description: "Chocolate Milk", DataType: "Foundation"},
-			},
-			wantFdcID:     "222",
-			minConfidence: 50,
-		},
-		{
-			name:        "chicken breast matches",
-			productName: "Boneless Skinless Chicken Breasts, 2.5 lb",
-			brand:       "Tyson",
-			usdaFoods: []domain.USDAFood{
-				{FdcID: 111, Description: "Tyson Boneless Skinless Chicken Breast", DataType: "Branded"},
-				{FdcID: 222, Description: "Chicken Wings", DataType: "Foundation"},
-				{FdcID: 333, Description: "Ground Beef", DataType: "Foundation"},
-			},
-			wantFdcID:     "111",
-			minConfidence: 50,
-		},
-		{
-			name:        "cereal matches",
-			productName: "Cheerios Heart Healthy Cereal, 18 oz",
-			brand:       "General Mills",
-			usdaFoods: []domain.USDAFood{
-				{FdcID: 111, Description: "Corn Flakes", DataType: "Foundation"},
-				{FdcID: 222, Description: "Cheerios, Whole Grain Oat Cereal", DataType: "Branded"},
-				{FdcID: 333, Description: "Oatmeal", DataType: "Foundation"},
-			},
-			wantFdcID:     "222",
-			minConfidence: 40,
-		},
-	}
-
-	for _, tc := range testCases {
+	for _, tc := range walmartProductFixtures {
 		t.Run(tc.name, func(t *testing.T) {
 			request := &domain.SearchRequest{
 				ProductName: tc.productName,
@@ -670,3 +1204,154 @@ func TestRealisticWalmartProducts(t *testing.T) {
 		})
 	}
 }
+
+func TestFindTopMatches_DataTypeBonuses(t *testing.T) {
+	ctx := context.Background()
+	foods := []domain.USDAFood{
+		{FdcID: 100, Description: "Whole Milk", DataType: "Branded"},
+		{FdcID: 200, Description: "Whole Milk", DataType: "Foundation"},
+	}
+	request := &domain.SearchRequest{ProductName: "whole milk", Explain: true}
+
+	t.Run("defaults favor Branded", func(t *testing.T) {
+		matches, err := NewMatchingService(MatchConfig{}).FindTopMatches(ctx, request, foods, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if matches[0].FdcID != "100" || matches[0].Explanation.DataTypeBonus != dataTypeBrandedBonus {
+			t.Errorf("best match = %s with data type bonus %v, want 100 with %v",
+				matches[0].FdcID, matches[0].Explanation.DataTypeBonus, dataTypeBrandedBonus)
+		}
+	})
+
+	t.Run("SR Legacy wins over Foundation", func(t *testing.T) {
+		generic := []domain.USDAFood{
+			{FdcID: 200, Description: "Whole Milk", DataType: "Foundation"},
+			{FdcID: 300, Description: "Milk, whole, 3.25% milkfat", DataType: "SR Legacy"},
+			{FdcID: 400, Description: "Whole Milk", DataType: "SR Legacy"},
+		}
+		matches, err := NewMatchingService(MatchConfig{}).FindTopMatches(ctx, request, generic, 3)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if matches[0].FdcID != "400" || matches[0].Explanation.DataTypeBonus != dataTypeSRLegacyBonus {
+			t.Errorf("best match = %s with data type bonus %v, want 400 with %v",
+				matches[0].FdcID, matches[0].Explanation.DataTypeBonus, dataTypeSRLegacyBonus)
+		}
+	})
+
+	t.Run("Foundation boosted above Branded", func(t *testing.T) {
+		svc := NewMatchingService(MatchConfig{DataTypeBonuses: map[string]float64{"Foundation": 12}})
+		matches, err := svc.FindTopMatches(ctx, request, foods, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if matches[0].FdcID != "200" || matches[0].Explanation.DataTypeBonus != 12 {
+			t.Errorf("best match = %s with data type bonus %v, want 200 with 12",
+				matches[0].FdcID, matches[0].Explanation.DataTypeBonus)
+		}
+		if matches[1].Explanation.DataTypeBonus != dataTypeBrandedBonus {
+			t.Errorf("Branded bonus = %v, want the unchanged default %v", matches[1].Explanation.DataTypeBonus, dataTypeBrandedBonus)
+		}
+	})
+
+	t.Run("preferring generic data gives Foundation the top bonus", func(t *testing.T) {
+		svc := NewMatchingService(MatchConfig{DataTypeBonuses: map[string]float64{"Survey (FNDDS)": 15}})
+		generic := &domain.SearchRequest{ProductName: "whole milk", PreferGeneric: true, Explain: true}
+		matches, err := svc.FindTopMatches(ctx, generic, foods, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if matches[0].FdcID != "200" || matches[0].Explanation.DataTypeBonus != 15 {
+			t.Errorf("best match = %s with data type bonus %v, want 200 with 15",
+				matches[0].FdcID, matches[0].Explanation.DataTypeBonus)
+		}
+	})
+}
+
+func TestScoreCandidate_PhraseBonus(t *testing.T) {
+	explain := func(svc *MatchingService, productName, description string) *domain.MatchExplanation {
+		explanation := &domain.MatchExplanation{}
+		svc.scoreCandidate(productName, "", description, "Survey (FNDDS)", false, explanation)
+		return explanation
+	}
+	svc := NewMatchingService(MatchConfig{PhraseBonus: 9})
+
+	t.Run("whole name as a phrase earns it all", func(t *testing.T) {
+		if got := explain(svc, "grilled chicken breast", "Grilled chicken breast, boneless").PhraseBonus; got != 9 {
+			t.Errorf("PhraseBonus = %v, want 9", got)
+		}
+	})
+
+	t.Run("scaled by the run's share of the name", func(t *testing.T) {
+		productName := "Tyson grilled chicken breast strips family size"
+		tokens := svc.tokenizeWithWeights(productName)
+
+		got := explain(svc, productName, "Chicken breast, strips, grilled").PhraseBonus
+		if want := 9 * 3 / float64(len(tokens)); math.Abs(got-want) > 1e-9 {
+			t.Errorf("PhraseBonus = %v, want %v for 3 of %d tokens", got, want, len(tokens))
+		}
+	})
+
+	t.Run("scattered tokens are not a phrase", func(t *testing.T) {
+		if got := explain(svc, "chicken grilled breast", "Breast, grilled, chicken").PhraseBonus; got != 0 {
+			t.Errorf("PhraseBonus = %v, want 0", got)
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		if got := explain(NewMatchingService(MatchConfig{}), "grilled chicken breast", "Grilled chicken breast, boneless").PhraseBonus; got != 0 {
+			t.Errorf("PhraseBonus = %v, want 0", got)
+		}
+	})
+}
+
+func TestScoreCandidate_SubstringMinLength(t *testing.T) {
+	explain := func(svc *MatchingService) float64 {
+		explanation := &domain.MatchExplanation{}
+		svc.scoreCandidate("milk", "", "Milk, whole", "Foundation", false, explanation)
+		return explanation.SubstringBonus
+	}
+
+	if got := explain(NewMatchingService(MatchConfig{})); got != 0 {
+		t.Errorf("default SubstringBonus = %v, want 0 for a 4-character name", got)
+	}
+	if got := explain(NewMatchingService(MatchConfig{SubstringMinLength: 4})); got != substringMatchBonus {
+		t.Errorf("SubstringBonus = %v, want %v with a 4-character minimum", got, substringMatchBonus)
+	}
+}
+
+func TestFindTopMatches_TokenF1(t *testing.T) {
+	ctx := context.Background()
+	request := &domain.SearchRequest{ProductName: "whole milk"}
+	// Same data type, and both contain every product word
+	foods := []domain.USDAFood{
+		{FdcID: 1, Description: "Cereal, granola with whole milk, raisins, almonds and honey", DataType: "Survey (FNDDS)"},
+		{FdcID: 2, Description: "Whole milk", DataType: "Survey (FNDDS)"},
+	}
+
+	rank := func(algorithm MatchAlgorithm) []*domain.MatchResult {
+		matches, err := NewMatchingService(MatchConfig{Algorithm: algorithm}).FindTopMatches(ctx, request, foods, 2)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", algorithm, err)
+		}
+		return matches
+	}
+
+	weighted := rank(AlgorithmTokenWeighted)
+	if weighted[0].MatchScore != weighted[1].MatchScore {
+		t.Fatalf("token weighted scores = %.1f/%.1f, want a tie the verbose entry can win",
+			weighted[0].MatchScore, weighted[1].MatchScore)
+	}
+
+	f1 := rank(AlgorithmTokenF1)
+	if f1[0].FdcID != "2" {
+		t.Errorf("best FdcID = %s, want the concise description 2", f1[0].FdcID)
+	}
+	if f1[0].MatchScore != weighted[0].MatchScore {
+		t.Errorf("concise score = %.1f, want the unpenalized %.1f", f1[0].MatchScore, weighted[0].MatchScore)
+	}
+	if gap := f1[0].MatchScore - f1[1].MatchScore; gap < 10 {
+		t.Errorf("verbose description scored %.1f, want well below the concise %.1f", f1[1].MatchScore, f1[0].MatchScore)
+	}
+}
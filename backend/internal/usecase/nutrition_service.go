@@ -2,14 +2,19 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/macrolens/backend/internal/domain"
 	"github.com/macrolens/backend/internal/infrastructure/usda"
+	"golang.org/x/sync/singleflight"
 )
 
 // Package-level compiled regex patterns for performance
@@ -20,10 +25,65 @@ var (
 
 // NutritionServiceConfig holds configuration for the nutrition service
 type NutritionServiceConfig struct {
-	CacheTTL               time.Duration
-	MinConfidenceThreshold float64
-	EnableFuzzyMatching    bool
-	EnableDebugLogging     bool
+	CacheTTL                time.Duration
+	MinConfidenceThreshold  float64
+	EnableFuzzyMatching     bool
+	EnableDebugLogging      bool
+	ReportRawConfidence     bool // Also score the match against the uncleaned product name
+	EnableMatchCache        bool // Cache the chosen fdcId per cleaned query to skip re-matching
+	MatchCacheTTL           time.Duration
+	NotFoundCacheTTL        time.Duration // How long a search USDA had no match for is remembered (0 disables)
+	ThinPoolSize            int
+	ThinPoolDiscount        float64
+	FetchFoodDetails        bool    // Hydrate matched foods with the full-detail endpoint
+	CacheNamespace          string  // Prefixed to all cache keys so differently-configured deployments can share a cache
+	EnableHouseholdMeasures bool    // Honor SearchRequest.Measure by scaling to USDA food portions
+	RejectControlChars      bool    // Reject NUL/ESC in input with ErrInvalidRequest instead of stripping them
+	StoreBrandGenerics      bool    // Keep store-brand generic queries specific and prefer generic USDA data for them
+	ConfirmedMatchBonus     float64 // Score bonus for fdcIds confirmed correct via feedback (needs SetFeedbackStore)
+	CategoryMismatchPenalty float64 // Score penalty when product and candidate are different kinds of food
+	BrandAwareDataTypes     bool    // Ask USDA for Branded foods first when the request has a brand
+	IncludeServingGrams     bool    // Add a best-effort servingGrams to every response, not just household measures
+	KeepHigherConfidence    bool    // Don't overwrite a cached result with a lower-confidence one
+	Calibration             []CalibrationPoint
+	EnableQualifierPhrases  bool
+	QualifierPhrases        []string // Overrides the built-in qualifier phrases when non-empty
+	CompoundFoods           []string // Overrides the built-in compound food names when non-empty
+	ExtraFoodTerms          []string // Added to the built-in term dictionaries
+	ExtraDescriptiveTerms   []string
+	ExtraStopWords          []string
+	Synonyms                map[string][]string // Overrides the built-in synonym table when non-empty
+	SynonymWeight           float64             // Fraction of normal weight for synonym matches (0 uses the default)
+	NormalizePlurals        bool                // Match "strawberries" to "strawberry"
+	Algorithm               MatchAlgorithm
+
+	PhoneticBrandMatch bool // Award a reduced brand bonus when the brand only sounds like the description
+
+	FuzzyMinTokenLength int // Shortest token fuzzy matching considers (0 uses the default 4; floor 3)
+
+	RecencyBonus float64 // Most a recently published candidate gains, to break near-ties between duplicates (0 disables)
+
+	DataTypeBonuses map[string]float64 // Overrides the built-in score bonus per USDA data type
+
+	DataTypes []string // USDA data types searched, in priority order; overrides the built-in set when non-empty
+
+	RequestTimeout time.Duration // Bounds the cache, USDA and matching phase of a search (0 disables)
+
+	MaxRequestCacheTTL time.Duration // Longest cacheTTL a request may ask for (0 caps it at CacheTTL)
+
+	ProbabilityCalibration ProbabilityCalibration // Adds calibratedConfidence to results (zero value disables)
+
+	EarlyExitScore     float64 // Stop matching at the first candidate scoring this much (0 disables)
+	MaxMatchCandidates int     // Most USDA foods scored per match (0 scores them all)
+
+	SubstringMinLength int     // Shortest product name earning the substring bonus (0 uses the default 6)
+	PhraseBonus        float64 // Most a shared run of consecutive tokens earns (0 disables)
+
+	BrandBonus   float64             // Bonus when the brand or an alias appears in the description (0 uses the default 25)
+	BrandAliases map[string][]string // Overrides the built-in brand aliases when non-empty
+
+	RetailerStoreBrands map[string][]string // Replaces a retailer's built-in store brands; unknown retailers get a new profile
+	RetailerNoiseWords  map[string][]string // Replaces a retailer's built-in noise words, keyed like RetailerStoreBrands
 }
 
 // NutritionService handles nutrition data lookup with caching
@@ -33,6 +93,52 @@ type NutritionService struct {
 	matchingService   *MatchingService
 	queryPreprocessor *QueryPreprocessor
 	cacheTTL          time.Duration
+
+	reportRawConfidence bool
+	enableMatchCache    bool
+	matchCacheTTL       time.Duration
+	notFoundCacheTTL    time.Duration // 0 disables negative caching
+	fetchFoodDetails    bool
+	cacheKeyPrefix      string // "{namespace}:{version}:" or "{version}:"
+
+	enableHouseholdMeasures bool
+	rejectControlChars      bool
+	storeBrandGenerics      bool
+	brandAwareDataTypes     bool
+	genericFirstDataTypes   []string
+	brandedFirstDataTypes   []string
+	includeServingGrams     bool
+	keepHigherConfidence    bool
+	cacheWriteMutex         sync.Mutex
+	confidenceHistogram     *ConfidenceHistogram // nil disables confidence monitoring
+	requestTimeout          time.Duration        // 0 disables the search timeout
+	maxRequestCacheTTL      time.Duration        // Cap on a request's cacheTTL
+	inflight                singleflight.Group   // Coalesces concurrent identical cache-miss searches
+	probability             ProbabilityCalibration
+}
+
+// USDA data type orders for searches. USDA weighs relevance partly by this order,
+// so branded retail products rank low unless Branded comes first.
+var (
+	genericFirstDataTypes = []string{"Survey (FNDDS)", "Foundation", "SR Legacy", "Branded"}
+	brandedFirstDataTypes = []string{"Branded", "Survey (FNDDS)", "Foundation", "SR Legacy"}
+)
+
+// maxCandidates bounds how many candidate matches a request can ask for
+const maxCandidates = 10
+
+// maxFallbackKeywords is how many food keywords the retry after an empty search uses
+const maxFallbackKeywords = 3
+
+// matchDecision is the cached outcome of a search+match for a cleaned query
+type matchDecision struct {
+	FdcID      string  `json:"fdcId"`
+	Confidence float64 `json:"confidence"`
+}
+
+// notFoundMarker is cached under a search's key when USDA had no match for it
+type notFoundMarker struct {
+	NotFound bool `json:"notFound"`
 }
 
 // NewNutritionService creates a new nutrition service with dependencies
@@ -42,87 +148,758 @@ func NewNutritionService(
 	config NutritionServiceConfig,
 ) *NutritionService {
 	matchingService := NewMatchingService(MatchConfig{
-		MinConfidenceThreshold: config.MinConfidenceThreshold,
-		EnableFuzzyMatching:    config.EnableFuzzyMatching,
-		EnableDebugLogging:     config.EnableDebugLogging,
+		MinConfidenceThreshold:  config.MinConfidenceThreshold,
+		EnableFuzzyMatching:     config.EnableFuzzyMatching,
+		EnableDebugLogging:      config.EnableDebugLogging,
+		ThinPoolSize:            config.ThinPoolSize,
+		ThinPoolDiscount:        config.ThinPoolDiscount,
+		EnableQualifierPhrases:  config.EnableQualifierPhrases,
+		QualifierPhrases:        config.QualifierPhrases,
+		CompoundFoods:           config.CompoundFoods,
+		Calibration:             config.Calibration,
+		ConfirmedMatchBonus:     config.ConfirmedMatchBonus,
+		CategoryMismatchPenalty: config.CategoryMismatchPenalty,
+		ExtraFoodTerms:          config.ExtraFoodTerms,
+		ExtraDescriptiveTerms:   config.ExtraDescriptiveTerms,
+		ExtraStopWords:          config.ExtraStopWords,
+		Synonyms:                config.Synonyms,
+		SynonymWeight:           config.SynonymWeight,
+		NormalizePlurals:        config.NormalizePlurals,
+		Algorithm:               config.Algorithm,
+
+		EnablePhoneticBrandMatch: config.PhoneticBrandMatch,
+		FuzzyMinTokenLength:      config.FuzzyMinTokenLength,
+		RecencyBonus:             config.RecencyBonus,
+		DataTypeBonuses:          config.DataTypeBonuses,
+		EarlyExitScore:           config.EarlyExitScore,
+		MaxCandidates:            config.MaxMatchCandidates,
+		SubstringMinLength:       config.SubstringMinLength,
+		PhraseBonus:              config.PhraseBonus,
+		BrandBonus:               config.BrandBonus,
+		BrandAliases:             config.BrandAliases,
 	})
 
 	queryPreprocessor := NewQueryPreprocessor(config.EnableDebugLogging)
+	queryPreprocessor.retailerProfiles = retailerProfilesWith(config.RetailerStoreBrands, config.RetailerNoiseWords)
 
 	cacheTTL := config.CacheTTL
 	if cacheTTL == 0 {
 		cacheTTL = 720 * time.Hour // Default 30 days
 	}
 
+	matchCacheTTL := config.MatchCacheTTL
+	if matchCacheTTL == 0 {
+		matchCacheTTL = cacheTTL
+	}
+
+	maxRequestCacheTTL := config.MaxRequestCacheTTL
+	if maxRequestCacheTTL == 0 {
+		maxRequestCacheTTL = cacheTTL
+	}
+
+	genericFirst, brandedFirst := dataTypeOrders(config.DataTypes)
+
 	return &NutritionService{
 		cache:             cache,
 		usdaClient:        usdaClient,
 		matchingService:   matchingService,
 		queryPreprocessor: queryPreprocessor,
 		cacheTTL:          cacheTTL,
+
+		reportRawConfidence: config.ReportRawConfidence,
+		enableMatchCache:    config.EnableMatchCache,
+		matchCacheTTL:       matchCacheTTL,
+		notFoundCacheTTL:    config.NotFoundCacheTTL,
+		fetchFoodDetails:    config.FetchFoodDetails,
+		cacheKeyPrefix:      domain.CacheKeyPrefix(config.CacheNamespace),
+
+		enableHouseholdMeasures: config.EnableHouseholdMeasures,
+		rejectControlChars:      config.RejectControlChars,
+		storeBrandGenerics:      config.StoreBrandGenerics,
+		brandAwareDataTypes:     config.BrandAwareDataTypes,
+		genericFirstDataTypes:   genericFirst,
+		brandedFirstDataTypes:   brandedFirst,
+		includeServingGrams:     config.IncludeServingGrams,
+		keepHigherConfidence:    config.KeepHigherConfidence,
+		requestTimeout:          config.RequestTimeout,
+		maxRequestCacheTTL:      maxRequestCacheTTL,
+		probability:             config.ProbabilityCalibration,
 	}
 }
 
+// Dictionaries returns the term dictionaries used to tokenize and weight queries,
+// with the store brands of the configured retailer profiles
+func (s *NutritionService) Dictionaries() Dictionaries {
+	dicts := s.matchingService.Dictionaries()
+	dicts.StoreBrands = storeBrandTable(s.queryPreprocessor.retailerProfiles)
+	return dicts
+}
+
+// SetFeedbackStore lets matching favor fdcIds that feedback confirmed correct
+// for the same query, when ConfirmedMatchBonus is configured
+func (s *NutritionService) SetFeedbackStore(store domain.FeedbackStore) {
+	s.matchingService.SetFeedbackStore(store)
+}
+
+// SetConfidenceHistogram records the confidence of every served result in h
+func (s *NutritionService) SetConfidenceHistogram(h *ConfidenceHistogram) {
+	s.confidenceHistogram = h
+}
+
 // SearchNutrition looks up nutrition data for a product.
 // Flow: check cache -> search USDA -> match best result -> cache -> return
+// When the request names a household measure, the unscaled result is scaled to it.
 func (s *NutritionService) SearchNutrition(
 	ctx context.Context,
 	request *domain.SearchRequest,
+) (*domain.NutritionData, error) {
+	if request == nil {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	// Scraped titles can carry control characters; clean them before they reach logs or USDA
+	request, err := sanitizeRequest(request, s.rejectControlChars)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMinConfidence(request.MinConfidence); err != nil {
+		return nil, err
+	}
+	if request.Candidates < 0 || request.Candidates > maxCandidates {
+		return nil, fmt.Errorf("%w: candidates must be between 0 and %d", domain.ErrInvalidRequest, maxCandidates)
+	}
+	if request.ServingSize < 0 || math.IsNaN(request.ServingSize) || math.IsInf(request.ServingSize, 0) {
+		return nil, fmt.Errorf("%w: servingSize must be a positive number of grams", domain.ErrInvalidRequest)
+	}
+	if _, err := s.requestCacheTTL(request); err != nil {
+		return nil, err
+	}
+
+	if request.Debug {
+		request.IncludeTiming = true
+	}
+
+	nutritionData, err := s.searchWithTimeout(ctx, request)
+	if nutritionData != nil && s.confidenceHistogram != nil {
+		s.confidenceHistogram.Record(nutritionData.Confidence)
+	}
+	if nutritionData != nil && request.Measure != "" && s.enableHouseholdMeasures {
+		nutritionData = s.applyMeasure(ctx, nutritionData, request.Measure)
+	}
+	// A household measure the food defines takes precedence over the label serving
+	if nutritionData != nil && request.ServingSize > 0 && nutritionData.Measure == "" {
+		nutritionData = usda.ScaleToServing(nutritionData, request.ServingSize)
+	}
+	if s.includeServingGrams {
+		usda.AttachServingGrams(nutritionData)
+	}
+	if nutritionData != nil && s.probability.enabled() {
+		// Copy so the probability is derived per response, never cached
+		calibrated := *nutritionData
+		probability := s.probability.apply(calibrated.Confidence)
+		calibrated.CalibratedConfidence = &probability
+		nutritionData = &calibrated
+	}
+	if nutritionData != nil && request.Debug {
+		// Copy so the key never lands in a cached value
+		withKey := *nutritionData
+		withKey.CacheKey = s.generateCacheKey(request)
+		nutritionData = &withKey
+	}
+	return nutritionData, err
+}
+
+// searchWithTimeout runs searchNutrition under requestTimeout. The search runs in
+// its own goroutine so a USDA call that ignores cancellation can't hold the caller
+// past the deadline; its late result is discarded.
+func (s *NutritionService) searchWithTimeout(
+	ctx context.Context,
+	request *domain.SearchRequest,
+) (*domain.NutritionData, error) {
+	if s.requestTimeout <= 0 {
+		return s.searchNutrition(ctx, request)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	type searchResult struct {
+		data *domain.NutritionData
+		err  error
+	}
+	done := make(chan searchResult, 1) // Buffered so an abandoned search can still finish
+	go func() {
+		data, err := s.searchNutrition(ctx, request)
+		done <- searchResult{data, err}
+	}()
+
+	select {
+	case result := <-done:
+		if result.data == nil && result.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, s.timeoutError()
+		}
+		return result.data, result.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, s.timeoutError()
+		}
+		return nil, fmt.Errorf("%w: %v", domain.ErrUSDAAPIFailure, ctx.Err())
+	}
+}
+
+// timeoutError reports a search that ran past requestTimeout
+func (s *NutritionService) timeoutError() error {
+	return fmt.Errorf("%w: %w after %s", domain.ErrUSDAAPIFailure, domain.ErrTimeout, s.requestTimeout)
+}
+
+// GetNutritionByID looks up nutrition data for a known USDA food by its FDC ID.
+// Flow: check cache -> fetch food details -> cache -> return
+// Confidence is 100 since there is no matching involved.
+func (s *NutritionService) GetNutritionByID(ctx context.Context, fdcID string) (*domain.NutritionData, error) {
+	fdcID = strings.TrimSpace(fdcID)
+	if id, err := strconv.Atoi(fdcID); err != nil || id <= 0 {
+		return nil, fmt.Errorf("%w: fdcId must be a positive number", domain.ErrInvalidRequest)
+	}
+
+	cacheKey := s.fdcCacheKey(fdcID)
+	if cached, err := s.getFromCache(ctx, cacheKey); err == nil && cached != nil {
+		cached.Source = "Cache"
+		if s.includeServingGrams {
+			usda.AttachServingGrams(cached)
+		}
+		return cached, nil
+	}
+
+	food, err := s.usdaClient.GetFoodDetails(ctx, fdcID)
+	if err != nil {
+		if errors.Is(err, domain.ErrProductNotFound) || errors.Is(err, domain.ErrRateLimited) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", domain.ErrUSDAAPIFailure, err)
+	}
+	if food == nil {
+		return nil, domain.ErrProductNotFound
+	}
+
+	nutritionData := usda.MapToNutritionData(food, 100)
+	if err := s.setInCache(ctx, cacheKey, nutritionData, s.cacheTTL); err != nil {
+		// Caching is best-effort
+	}
+
+	if s.includeServingGrams {
+		usda.AttachServingGrams(nutritionData)
+	}
+	return nutritionData, nil
+}
+
+// searchNutrition resolves unscaled nutrition data for a product (cached or from USDA):
+// per label serving for Branded foods that report one, otherwise per 100g
+func (s *NutritionService) searchNutrition(
+	ctx context.Context,
+	request *domain.SearchRequest,
 ) (*domain.NutritionData, error) {
 	if request == nil || request.ProductName == "" {
 		return nil, domain.ErrInvalidRequest
 	}
 
 	cacheKey := s.generateCacheKey(request)
+	cacheTTL, err := s.requestCacheTTL(request)
+	if err != nil {
+		return nil, err
+	}
 
-	// Try cache first
+	// Try cache first. Candidates and explanations aren't cached, so requests for
+	// them always re-match.
+	cacheStart := time.Now()
 	cached, err := s.getFromCache(ctx, cacheKey)
-	if err == nil && cached != nil {
+	domain.RecordPhase(ctx, domain.PhaseCache, time.Since(cacheStart))
+	if errors.Is(err, domain.ErrProductNotFound) {
+		// USDA recently had no match for this search
+		return nil, err
+	}
+	if err == nil && cached != nil && request.Candidates == 0 && !request.Explain {
 		cached.Source = "Cache"
-		return cached, nil
+		if request.IncludeTiming {
+			// No USDA round-trip or matching happened on a cache hit
+			attachTiming(cached, 0, 0)
+		}
+		return cached, s.checkRequestThreshold(request, cached)
 	}
 
-	// Cache miss - search USDA with preprocessed query
-	query := s.queryPreprocessor.PreprocessQuery(request.ProductName, request.Brand)
-	searchResult, err := s.usdaClient.SearchFoods(ctx, query)
+	// Candidates and explanations come from this request's own matching pass
+	if request.Candidates > 0 || request.Explain {
+		return s.searchUncached(ctx, request, cacheKey, cacheTTL)
+	}
+
+	// Concurrent identical searches on a cold cache share one USDA call, and its
+	// result or error
+	flight := s.inflight.DoChan(flightKey(cacheKey, request), func() (interface{}, error) {
+		return s.searchShared(ctx, request, cacheKey, cacheTTL)
+	})
+	select {
+	case result := <-flight:
+		shared, _ := result.Val.(*sharedSearch)
+		if shared == nil {
+			return nil, result.Err
+		}
+		// Every caller reports the shared call's phases in its own timings
+		for _, phase := range shared.phases {
+			domain.RecordPhase(ctx, phase.Name, phase.Duration)
+		}
+		nutritionData := shared.data
+		if nutritionData != nil && result.Shared {
+			// Each caller gets its own copy to decorate
+			copied := *nutritionData
+			nutritionData = &copied
+		}
+		return nutritionData, result.Err
+	case <-ctx.Done():
+		// The shared call carries on for the other callers
+		return nil, fmt.Errorf("%w: %v", domain.ErrUSDAAPIFailure, ctx.Err())
+	}
+}
+
+// sharedSearch is the result of a coalesced search, with the phases it recorded
+type sharedSearch struct {
+	data   *domain.NutritionData
+	phases []domain.PhaseTiming
+}
+
+// searchShared runs searchUncached for every caller coalesced behind one search.
+// It runs detached from the first caller's cancellation, under its own
+// requestTimeout, so that caller leaving doesn't fail the others.
+func (s *NutritionService) searchShared(
+	ctx context.Context,
+	request *domain.SearchRequest,
+	cacheKey string,
+	cacheTTL time.Duration,
+) (*sharedSearch, error) {
+	ctx = context.WithoutCancel(ctx)
+	if s.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.requestTimeout)
+		defer cancel()
+	}
+	timings := &domain.PhaseTimings{}
+	nutritionData, err := s.searchUncached(domain.WithPhaseTimings(ctx, timings), request, cacheKey, cacheTTL)
+	return &sharedSearch{data: nutritionData, phases: timings.Phases()}, err
+}
+
+// searchUncached resolves a search the cache couldn't answer: from a cached match
+// decision, or by searching USDA and matching. Results that meet the configured
+// threshold are cached under cacheKey for cacheTTL.
+func (s *NutritionService) searchUncached(
+	ctx context.Context,
+	request *domain.SearchRequest,
+	cacheKey string,
+	cacheTTL time.Duration,
+) (*domain.NutritionData, error) {
+	// Search USDA with preprocessed query
+	query, preferGeneric := s.searchQuery(request)
+
+	// A known-good match decision lets us fetch the food directly and skip search+match
+	if s.enableMatchCache && request.Candidates == 0 && !request.Explain {
+		usdaStart := time.Now()
+		if nutritionData, food := s.lookupMatchDecision(ctx, query); nutritionData != nil {
+			usdaLatency := time.Since(usdaStart)
+			domain.RecordPhase(ctx, domain.PhaseUSDA, usdaLatency)
+			s.attachRawConfidence(nutritionData, request, []domain.USDAFood{*food})
+			if err := s.setInCache(ctx, cacheKey, nutritionData, cacheTTL); err != nil {
+				// Caching is best-effort
+			}
+			if request.IncludeTiming {
+				attachTiming(nutritionData, usdaLatency, 0)
+			}
+			return nutritionData, s.checkRequestThreshold(request, nutritionData)
+		}
+	}
+
+	usdaStart := time.Now()
+	searchResult, err := s.searchFoods(ctx, request, query, preferGeneric, 0)
+	usdaLatency := time.Since(usdaStart)
+	domain.RecordPhase(ctx, domain.PhaseUSDA, usdaLatency)
 	if err != nil {
+		// A successful search with no results is not an upstream failure
+		if errors.Is(err, domain.ErrProductNotFound) {
+			s.storeNotFound(ctx, cacheKey)
+			return nil, domain.ErrProductNotFound
+		}
 		return nil, fmt.Errorf("%w: %v", domain.ErrUSDAAPIFailure, err)
 	}
 
-	if len(searchResult.Foods) == 0 {
+	if searchResult == nil || len(searchResult.Foods) == 0 {
+		s.storeNotFound(ctx, cacheKey)
 		return nil, domain.ErrProductNotFound
 	}
 
-	// Find best match
-	matchResult, err := s.matchingService.FindBestMatch(ctx, request, searchResult.Foods)
+	// Find best match, scoring against the cleaned product name
+	matchRequest := s.matchRequest(request, query, preferGeneric)
+
+	matchStart := time.Now()
+	matches, err := s.matchingService.FindTopMatches(ctx, &matchRequest, searchResult.Foods, max(request.Candidates, 1))
+	matchLatency := time.Since(matchStart)
+	domain.RecordPhase(ctx, domain.PhaseMatch, matchLatency)
 	if err != nil {
 		// For low confidence, still return the data with the error
-		if errors.Is(err, domain.ErrLowConfidence) && matchResult != nil {
-			nutritionData := s.mapMatchToNutrition(searchResult.Foods, matchResult)
+		if errors.Is(err, domain.ErrLowConfidence) && len(matches) > 0 {
+			nutritionData := s.mapMatchToNutrition(searchResult.Foods, matches[0])
+			s.hydrateDetails(ctx, nutritionData)
+			s.attachRawConfidence(nutritionData, request, searchResult.Foods)
 			// Don't cache low confidence results
+			if request.IncludeTiming && nutritionData != nil {
+				attachTiming(nutritionData, usdaLatency, matchLatency)
+			}
+			attachCandidates(nutritionData, request, matches)
+			attachExplanation(nutritionData, matches[0])
 			return nutritionData, err
 		}
 		return nil, err
 	}
+	matchResult := matches[0]
 
 	// Map matched food to NutritionData
 	nutritionData := s.mapMatchToNutrition(searchResult.Foods, matchResult)
+	s.hydrateDetails(ctx, nutritionData)
+	s.attachRawConfidence(nutritionData, request, searchResult.Foods)
+
+	// Cache the result, unless it only passed because of a looser per-request threshold
+	if matchResult.MatchScore >= s.matchingService.minConfidenceThreshold {
+		if err := s.setInCache(ctx, cacheKey, nutritionData, cacheTTL); err != nil {
+			// Log but don't fail if caching fails
+			// In production, this would be logged
+		}
+		if s.enableMatchCache {
+			s.storeMatchDecision(ctx, query, matchResult)
+		}
+	}
 
-	// Cache the result
-	if err := s.setInCache(ctx, cacheKey, nutritionData); err != nil {
-		// Log but don't fail if caching fails
-		// In production, this would be logged
+	// Timing, candidates and explanations are attached after caching so they are
+	// never persisted
+	if request.IncludeTiming {
+		attachTiming(nutritionData, usdaLatency, matchLatency)
 	}
+	attachCandidates(nutritionData, request, matches)
+	attachExplanation(nutritionData, matchResult)
 
 	return nutritionData, nil
 }
 
+// searchFoods searches USDA for query, asking for pageSize results (0 for the
+// client's default) and brand-aware data types when configured. A noisy query can
+// miss where its main food words alone would hit, so an empty search is retried
+// once with those.
+func (s *NutritionService) searchFoods(
+	ctx context.Context,
+	request *domain.SearchRequest,
+	query string,
+	preferGeneric bool,
+	pageSize int,
+) (*domain.USDASearchResponse, error) {
+	opts := domain.SearchOptions{PageSize: pageSize}
+	if s.brandAwareDataTypes {
+		opts.DataTypes = s.searchDataTypesFor(request.Brand, preferGeneric)
+	}
+
+	searchResult, err := s.usdaClient.SearchFoodsWithOptions(ctx, query, opts)
+	if emptySearch(searchResult, err) {
+		if fallback := s.fallbackQuery(query); fallback != "" {
+			searchResult, err = s.usdaClient.SearchFoodsWithOptions(ctx, fallback, opts)
+		}
+	}
+	return searchResult, err
+}
+
+// matchRequest returns the request USDA foods are scored against: the cleaned
+// product name, or the search query itself for store-brand generics
+func (s *NutritionService) matchRequest(request *domain.SearchRequest, query string, preferGeneric bool) domain.SearchRequest {
+	matchRequest := *request
+	if cleaned := s.queryPreprocessor.CleanProductNameForRetailer(request.ProductName, request.Retailer); cleaned != "" {
+		matchRequest.ProductName = cleaned
+	}
+	if preferGeneric {
+		matchRequest.ProductName = query
+		matchRequest.PreferGeneric = true
+	}
+	return matchRequest
+}
+
+// flightKey identifies searches that can share one upstream call: the same cache
+// entry (which already tells retailers apart), with the same per-request options
+// shaping the response
+func flightKey(cacheKey string, request *domain.SearchRequest) string {
+	key := fmt.Sprintf("%s|timing=%t|ttl=%s", cacheKey, request.IncludeTiming, request.CacheTTL)
+	if request.MinConfidence != nil {
+		key += fmt.Sprintf("|min=%g", *request.MinConfidence)
+	}
+	return key
+}
+
+// searchQuery returns the USDA query for a request, and whether it was identified
+// as a store-brand generic that should prefer generic USDA data
+func (s *NutritionService) searchQuery(request *domain.SearchRequest) (string, bool) {
+	// A store-brand staple like "Great Value Milk" would otherwise search for just "milk"
+	if s.storeBrandGenerics {
+		if genericQuery, ok := s.queryPreprocessor.PreprocessStoreBrandGeneric(request.ProductName, request.Brand, request.Retailer); ok {
+			return genericQuery, true
+		}
+	}
+	return s.queryPreprocessor.PreprocessQueryForRetailer(request.ProductName, request.Brand, request.Retailer), false
+}
+
+// fallbackQuery returns a simpler, higher-recall query made of the top food keywords
+// in query, or "" when that wouldn't drop any of its words
+func (s *NutritionService) fallbackQuery(query string) string {
+	keywords := s.queryPreprocessor.ExtractFoodKeywords(query)
+	if len(keywords) <= maxFallbackKeywords {
+		return ""
+	}
+	return strings.Join(keywords[:maxFallbackKeywords], " ")
+}
+
+// emptySearch reports whether a USDA search came back without any foods
+func emptySearch(result *domain.USDASearchResponse, err error) bool {
+	if err != nil {
+		return errors.Is(err, domain.ErrProductNotFound)
+	}
+	return result == nil || len(result.Foods) == 0
+}
+
+// searchDataTypesFor orders USDA data types for a search: Branded first when the
+// request names a brand, unless the product was identified as a store-brand generic
+func (s *NutritionService) searchDataTypesFor(brand string, preferGeneric bool) []string {
+	if strings.TrimSpace(brand) != "" && !preferGeneric {
+		return s.brandedFirstDataTypes
+	}
+	return s.genericFirstDataTypes
+}
+
+// dataTypeOrders returns the generic-first and branded-first search orders for the
+// configured data types: generic-first keeps the configured order and branded-first
+// moves Branded to the front. No data types uses the built-in orders.
+func dataTypeOrders(dataTypes []string) (genericFirst, brandedFirst []string) {
+	if len(dataTypes) == 0 {
+		return genericFirstDataTypes, brandedFirstDataTypes
+	}
+	brandedFirst = make([]string, 0, len(dataTypes))
+	for _, dataType := range dataTypes {
+		if dataType == "Branded" {
+			brandedFirst = append(brandedFirst, dataType)
+		}
+	}
+	for _, dataType := range dataTypes {
+		if dataType != "Branded" {
+			brandedFirst = append(brandedFirst, dataType)
+		}
+	}
+	return dataTypes, brandedFirst
+}
+
+// checkRequestThreshold applies a per-request confidence override to a result that
+// skipped matching (cache hit or cached match decision). Those results already met
+// the configured threshold, so only a stricter override can reject them.
+func (s *NutritionService) checkRequestThreshold(request *domain.SearchRequest, data *domain.NutritionData) error {
+	if request.MinConfidence != nil && data.Confidence < *request.MinConfidence {
+		return domain.ErrLowConfidence
+	}
+	return nil
+}
+
+// matchCacheKey creates the cache key for a match decision.
+// Format: "[{namespace}:]{version}:match:{normalized_query}"
+func (s *NutritionService) matchCacheKey(query string) string {
+	return s.cacheKeyPrefix + "match:" + normalizeForCacheKey(query)
+}
+
+// lookupMatchDecision returns nutrition data and the food for a previously matched
+// fdcId, or nil when there is no usable decision for the query
+func (s *NutritionService) lookupMatchDecision(ctx context.Context, query string) (*domain.NutritionData, *domain.USDAFood) {
+	value, err := s.cache.Get(ctx, s.matchCacheKey(query))
+	if err != nil {
+		return nil, nil
+	}
+
+	var decision matchDecision
+	switch v := value.(type) {
+	case *matchDecision:
+		decision = *v
+	case map[string]interface{}:
+		decision.FdcID, _ = v["fdcId"].(string)
+		decision.Confidence, _ = v["confidence"].(float64)
+	default:
+		return nil, nil
+	}
+	if decision.FdcID == "" {
+		return nil, nil
+	}
+
+	food, err := s.usdaClient.GetFoodDetails(ctx, decision.FdcID)
+	if err != nil || food == nil {
+		// Fall back to a full search
+		return nil, nil
+	}
+
+	return usda.MapToNutritionData(food, decision.Confidence), food
+}
+
+// storeMatchDecision caches the chosen fdcId for a query. Failures are ignored.
+func (s *NutritionService) storeMatchDecision(ctx context.Context, query string, match *domain.MatchResult) {
+	decision := &matchDecision{FdcID: match.FdcID, Confidence: match.MatchScore}
+	_ = s.cache.Set(ctx, s.matchCacheKey(query), decision, s.matchCacheTTL)
+}
+
+// applyMeasure scales nutrition data to one unit of a household measure using the
+// food's portion definitions. Results stay cached unscaled; scaling happens per request.
+// If the food has no such portion, the data is returned unchanged.
+func (s *NutritionService) applyMeasure(ctx context.Context, data *domain.NutritionData, measure string) *domain.NutritionData {
+	food, err := s.usdaClient.GetFoodDetails(ctx, data.FdcID)
+	if err != nil || food == nil {
+		return data
+	}
+
+	portion := usda.FindPortion(food.Portions, measure)
+	if portion == nil {
+		return data
+	}
+
+	return usda.ScaleToPortion(data, portion, strings.ToLower(strings.TrimSpace(measure)))
+}
+
+// hydrateDetails replaces search-derived nutrients with those from the full-detail
+// endpoint when enabled. Detail failures never fail the lookup: the search-response
+// macros are kept, and any macro missing from the details is filled from search data.
+func (s *NutritionService) hydrateDetails(ctx context.Context, data *domain.NutritionData) {
+	if !s.fetchFoodDetails || data == nil {
+		return
+	}
+
+	fetched := false
+	data.DetailsFetched = &fetched
+
+	detailsStart := time.Now()
+	food, err := s.usdaClient.GetFoodDetails(ctx, data.FdcID)
+	domain.RecordPhase(ctx, domain.PhaseUSDA, time.Since(detailsStart))
+	if err != nil || food == nil {
+		return
+	}
+
+	detailed := usda.MapToNutritionData(food, data.Confidence)
+	if detailed.Nutrients == (domain.Nutrients{}) {
+		// Details came back without usable nutrients
+		return
+	}
+
+	data.Nutrients = mergeNutrients(detailed.Nutrients, data.Nutrients)
+	fetched = true
+}
+
+// mergeNutrients returns primary with any zero-valued nutrient filled from fallback
+func mergeNutrients(primary, fallback domain.Nutrients) domain.Nutrients {
+	if primary.Calories == 0 {
+		primary.Calories = fallback.Calories
+	}
+	if primary.Protein == 0 {
+		primary.Protein = fallback.Protein
+	}
+	if primary.Carbohydrates == 0 {
+		primary.Carbohydrates = fallback.Carbohydrates
+	}
+	if primary.TotalFat == 0 {
+		primary.TotalFat = fallback.TotalFat
+	}
+	if primary.Fiber == 0 {
+		primary.Fiber = fallback.Fiber
+	}
+	if primary.Sugar == 0 {
+		primary.Sugar = fallback.Sugar
+	}
+	if primary.Sodium == 0 {
+		primary.Sodium = fallback.Sodium
+	}
+	if primary.SaturatedFat == 0 {
+		primary.SaturatedFat = fallback.SaturatedFat
+	}
+	if primary.Cholesterol == 0 {
+		primary.Cholesterol = fallback.Cholesterol
+	}
+	return primary
+}
+
+// attachRawConfidence scores the matched food against the original, uncleaned
+// product name when enabled. Cleaning removes tokens, which can make the cleaned
+// confidence look more certain than the raw title warrants.
+func (s *NutritionService) attachRawConfidence(data *domain.NutritionData, request *domain.SearchRequest, foods []domain.USDAFood) {
+	if !s.reportRawConfidence || data == nil {
+		return
+	}
+	for _, food := range foods {
+		if fmt.Sprintf("%d", food.FdcID) == data.FdcID {
+			raw := s.matchingService.ScoreFood(request.ProductName, request.Brand, food)
+			data.RawConfidence = &raw
+			return
+		}
+	}
+}
+
+// attachTiming sets the optional latency fields on a response
+func attachTiming(data *domain.NutritionData, usdaLatency, matchLatency time.Duration) {
+	usdaMs := durationToMs(usdaLatency)
+	matchMs := durationToMs(matchLatency)
+	data.USDALatencyMs = &usdaMs
+	data.MatchLatencyMs = &matchMs
+}
+
+// attachCandidates lists the top matches on a response when the request asked for them
+func attachCandidates(data *domain.NutritionData, request *domain.SearchRequest, matches []*domain.MatchResult) {
+	if data == nil || request.Candidates == 0 {
+		return
+	}
+	data.Candidates = make([]domain.MatchCandidate, len(matches))
+	for i, match := range matches {
+		data.Candidates[i] = domain.MatchCandidate{
+			FdcID:       match.FdcID,
+			Description: match.Description,
+			Confidence:  match.MatchScore,
+		}
+	}
+}
+
+// attachExplanation copies the chosen match's score breakdown, set only when the
+// request asked for one, onto a response
+func attachExplanation(data *domain.NutritionData, match *domain.MatchResult) {
+	if data == nil {
+		return
+	}
+	data.Explanation = match.Explanation
+}
+
+// durationToMs converts a duration to fractional milliseconds
+func durationToMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000.0
+}
+
 // generateCacheKey creates a normalized cache key from search request.
-// Format: "nutrition:{normalized_product_name}:{brand}"
+// Format: "[{namespace}:]{version}:nutrition:search:{normalized_product_name}:{brand}[:{retailer}]"
+// Searches have their own segment so no product name can produce another lookup's
+// key, such as a barcode's "nutrition:upc:{upc}".
+// The key is built from the request as sent, not the cleaned query, so changes to
+// query cleaning leave existing keys valid (bump domain.CacheKeyVersion if they should not).
+// Retailers clean names differently, so the resolved retailer profile is part of
+// the key; the default retailer's keys omit it and keep their original format.
 func (s *NutritionService) generateCacheKey(request *domain.SearchRequest) string {
 	normalizedName := normalizeForCacheKey(request.ProductName)
 	normalizedBrand := normalizeForCacheKey(request.Brand)
-	return fmt.Sprintf("nutrition:%s:%s", normalizedName, normalizedBrand)
+	key := fmt.Sprintf("%snutrition:search:%s:%s", s.cacheKeyPrefix, normalizedName, normalizedBrand)
+	if retailer := s.queryPreprocessor.retailerProfile(request.Retailer).Name; retailer != DefaultRetailer {
+		key += ":" + normalizeForCacheKey(retailer)
+	}
+	return key
+}
+
+// fdcCacheKey creates the cache key for a direct FDC ID lookup. It sits outside the
+// "nutrition:" segment so no search (e.g. productName "fdc") can produce it.
+// Format: "[{namespace}:]{version}:fdc:{fdcId}"
+func (s *NutritionService) fdcCacheKey(fdcID string) string {
+	return s.cacheKeyPrefix + "fdc:" + fdcID
 }
 
 // normalizeForCacheKey normalizes a string for use as cache key component.
@@ -137,16 +914,8 @@ func normalizeForCacheKey(s string) string {
 	return strings.TrimSpace(result)
 }
 
-// buildSearchQuery builds a search query string from the request
-func buildSearchQuery(request *domain.SearchRequest) string {
-	query := request.ProductName
-	if request.Brand != "" {
-		query = request.Brand + " " + query
-	}
-	return query
-}
-
-// getFromCache retrieves nutrition data from cache
+// getFromCache retrieves nutrition data from cache. A cached not-found marker is
+// returned as ErrProductNotFound.
 func (s *NutritionService) getFromCache(ctx context.Context, key string) (*domain.NutritionData, error) {
 	value, err := s.cache.Get(ctx, key)
 	if err != nil {
@@ -155,9 +924,19 @@ func (s *NutritionService) getFromCache(ctx context.Context, key string) (*domai
 
 	nutritionData, ok := value.(*domain.NutritionData)
 	if !ok {
+		if marker, ok := value.(*notFoundMarker); ok && marker.NotFound {
+			return nil, domain.ErrProductNotFound
+		}
 		// Try to handle if stored as map
 		if dataMap, ok := value.(map[string]interface{}); ok {
-			return mapToNutritionData(dataMap), nil
+			if notFound, _ := dataMap["notFound"].(bool); notFound {
+				return nil, domain.ErrProductNotFound
+			}
+			result, err := mapToNutritionData(dataMap)
+			if err != nil {
+				return nil, domain.ErrCacheMiss
+			}
+			return result, nil
 		}
 		return nil, domain.ErrCacheMiss
 	}
@@ -165,10 +944,44 @@ func (s *NutritionService) getFromCache(ctx context.Context, key string) (*domai
 	return nutritionData, nil
 }
 
-// setInCache stores nutrition data in cache
-func (s *NutritionService) setInCache(ctx context.Context, key string, data *domain.NutritionData) error {
+// storeNotFound caches a not-found marker for a search, so repeats fail fast until
+// notFoundCacheTTL passes and USDA is asked again. Failures are ignored.
+func (s *NutritionService) storeNotFound(ctx context.Context, key string) {
+	if s.notFoundCacheTTL <= 0 {
+		return
+	}
+	_ = s.cache.Set(ctx, key, &notFoundMarker{NotFound: true}, s.notFoundCacheTTL)
+}
+
+// setInCache stores nutrition data in cache for ttl. Concurrent writes to the same key are
+// last-writer-wins, unless keepHigherConfidence is set: then a write that would
+// replace an entry with a higher confidence is skipped.
+func (s *NutritionService) setInCache(ctx context.Context, key string, data *domain.NutritionData, ttl time.Duration) error {
+	if s.keepHigherConfidence {
+		// Serialize the read-compare-write so two writers can't both pass the check
+		s.cacheWriteMutex.Lock()
+		defer s.cacheWriteMutex.Unlock()
+
+		if existing, err := s.getFromCache(ctx, key); err == nil && existing != nil && existing.Confidence > data.Confidence {
+			return nil
+		}
+	}
+
 	data.CachedAt = time.Now()
-	return s.cache.Set(ctx, key, data, s.cacheTTL)
+	return s.cache.Set(ctx, key, data, ttl)
+}
+
+// requestCacheTTL returns how long to cache a request's result: its cacheTTL
+// clamped to maxRequestCacheTTL, or the configured cacheTTL when it names none
+func (s *NutritionService) requestCacheTTL(request *domain.SearchRequest) (time.Duration, error) {
+	if request.CacheTTL == "" {
+		return s.cacheTTL, nil
+	}
+	ttl, err := time.ParseDuration(request.CacheTTL)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("%w: cacheTTL must be a positive duration like \"6h\"", domain.ErrInvalidRequest)
+	}
+	return min(ttl, s.maxRequestCacheTTL), nil
 }
 
 // mapMatchToNutrition finds the matched food and converts it to NutritionData
@@ -182,43 +995,16 @@ func (s *NutritionService) mapMatchToNutrition(foods []domain.USDAFood, match *d
 	return nil
 }
 
-// mapToNutritionData converts a map (from JSON cache) to NutritionData
-func mapToNutritionData(data map[string]interface{}) *domain.NutritionData {
-	result := &domain.NutritionData{}
-
-	if v, ok := data["fdcId"].(string); ok {
-		result.FdcID = v
-	}
-	if v, ok := data["productName"].(string); ok {
-		result.ProductName = v
-	}
-	if v, ok := data["servingSize"].(string); ok {
-		result.ServingSize = v
-	}
-	if v, ok := data["servingSizeUnit"].(string); ok {
-		result.ServingSizeUnit = v
-	}
-	if v, ok := data["confidence"].(float64); ok {
-		result.Confidence = v
-	}
-	if v, ok := data["source"].(string); ok {
-		result.Source = v
+// mapToNutritionData converts a map (from JSON cache) to NutritionData. It decodes
+// through the struct's JSON tags so every field written by setInCache comes back.
+func mapToNutritionData(data map[string]interface{}) (*domain.NutritionData, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
 	}
-
-	if nutrients, ok := data["nutrients"].(map[string]interface{}); ok {
-		if v, ok := nutrients["calories"].(float64); ok {
-			result.Nutrients.Calories = v
-		}
-		if v, ok := nutrients["protein"].(float64); ok {
-			result.Nutrients.Protein = v
-		}
-		if v, ok := nutrients["carbohydrates"].(float64); ok {
-			result.Nutrients.Carbohydrates = v
-		}
-		if v, ok := nutrients["totalFat"].(float64); ok {
-			result.Nutrients.TotalFat = v
-		}
+	result := &domain.NutritionData{}
+	if err := json.Unmarshal(encoded, result); err != nil {
+		return nil, err
 	}
-
-	return result
+	return result, nil
 }
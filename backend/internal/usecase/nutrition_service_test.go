@@ -2,11 +2,18 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/macrolens/backend/internal/domain"
+	"github.com/macrolens/backend/internal/infrastructure/cache"
 )
 
 // MockCacheRepository is a mock implementation of domain.CacheRepository
@@ -57,9 +64,19 @@ func (m *MockCacheRepository) Exists(ctx context.Context, key string) (bool, err
 // MockUSDAClient is a mock implementation of domain.USDAClient
 type MockUSDAClient struct {
 	searchResult *domain.USDASearchResponse
+	byQuery      map[string]*domain.USDASearchResponse // Per-query search results, overriding searchResult
 	searchError  error
+	searchDelay  time.Duration
+	searchCalls  int
+	queries      []string
+	lastQuery    string
+	lastTypes    []string
 	foodResult   *domain.USDAFood
 	foodError    error
+	foodCalls    int
+	upcResult    *domain.USDAFood
+	upcError     error
+	upcCalls     int
 }
 
 func NewMockUSDAClient() *MockUSDAClient {
@@ -67,19 +84,45 @@ func NewMockUSDAClient() *MockUSDAClient {
 }
 
 func (m *MockUSDAClient) SearchFoods(ctx context.Context, query string) (*domain.USDASearchResponse, error) {
+	return m.SearchFoodsWithOptions(ctx, query, domain.SearchOptions{})
+}
+
+func (m *MockUSDAClient) SearchFoodsWithOptions(ctx context.Context, query string, opts domain.SearchOptions) (*domain.USDASearchResponse, error) {
+	m.searchCalls++
+	m.queries = append(m.queries, query)
+	m.lastQuery = query
+	m.lastTypes = opts.DataTypes
+	if m.searchDelay > 0 {
+		time.Sleep(m.searchDelay)
+	}
 	if m.searchError != nil {
 		return nil, m.searchError
 	}
+	if m.byQuery != nil {
+		return m.byQuery[query], nil
+	}
 	return m.searchResult, nil
 }
 
 func (m *MockUSDAClient) GetFoodDetails(ctx context.Context, fdcID string) (*domain.USDAFood, error) {
+	m.foodCalls++
 	if m.foodError != nil {
 		return nil, m.foodError
 	}
 	return m.foodResult, nil
 }
 
+func (m *MockUSDAClient) SearchByUPC(ctx context.Context, upc string) (*domain.USDAFood, error) {
+	m.upcCalls++
+	if m.upcError != nil {
+		return nil, m.upcError
+	}
+	if m.upcResult == nil {
+		return nil, domain.ErrProductNotFound
+	}
+	return m.upcResult, nil
+}
+
 func TestNewNutritionService(t *testing.T) {
 	cache := NewMockCacheRepository()
 	client := NewMockUSDAClient()
@@ -142,7 +185,7 @@ func TestSearchNutrition(t *testing.T) {
 			Confidence: 85,
 			Source:     "USDA",
 		}
-		cache.data["nutrition:whole milk:"] = cachedData
+		cache.data["v2:nutrition:search:whole milk:"] = cachedData
 
 		client := NewMockUSDAClient()
 		svc := NewNutritionService(cache, client, NutritionServiceConfig{})
@@ -211,6 +254,24 @@ func TestSearchNutrition(t *testing.T) {
 		}
 	})
 
+	t.Run("does not report client not-found as USDA failure", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		cache.getError = domain.ErrCacheMiss
+
+		client := NewMockUSDAClient()
+		client.searchError = domain.ErrProductNotFound
+
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{})
+
+		_, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk"})
+		if !errors.Is(err, domain.ErrProductNotFound) {
+			t.Errorf("error = %v, want ErrProductNotFound", err)
+		}
+		if errors.Is(err, domain.ErrUSDAAPIFailure) {
+			t.Errorf("error = %v, should not be ErrUSDAAPIFailure", err)
+		}
+	})
+
 	t.Run("returns error when no products found", func(t *testing.T) {
 		cache := NewMockCacheRepository()
 		cache.getError = domain.ErrCacheMiss
@@ -327,8 +388,8 @@ func TestGenerateCacheKey(t *testing.T) {
 
 	t.Run("generates key with product name only", func(t *testing.T) {
 		key := svc.generateCacheKey(&domain.SearchRequest{ProductName: "Whole Milk"})
-		if key != "nutrition:whole milk:" {
-			t.Errorf("key = %v, want nutrition:whole milk:", key)
+		if key != "v2:nutrition:search:whole milk:" {
+			t.Errorf("key = %v, want v2:nutrition:search:whole milk:", key)
 		}
 	})
 
@@ -337,8 +398,8 @@ func TestGenerateCacheKey(t *testing.T) {
 			ProductName: "Whole Milk",
 			Brand:       "Great Value",
 		})
-		if key != "nutrition:whole milk:great value" {
-			t.Errorf("key = %v, want nutrition:whole milk:great value", key)
+		if key != "v2:nutrition:search:whole milk:great value" {
+			t.Errorf("key = %v, want v2:nutrition:search:whole milk:great value", key)
 		}
 	})
 
@@ -348,8 +409,92 @@ func TestGenerateCacheKey(t *testing.T) {
 			Brand:       "Store-Brand!",
 		})
 		// Should remove special chars and normalize
-		if key != "nutrition:2 milk vitamin d:storebrand" {
-			t.Errorf("key = %v, want nutrition:2 milk vitamin d:storebrand", key)
+		if key != "v2:nutrition:search:2 milk vitamin d:storebrand" {
+			t.Errorf("key = %v, want v2:nutrition:search:2 milk vitamin d:storebrand", key)
+		}
+	})
+
+	t.Run("includes a non-default retailer", func(t *testing.T) {
+		key := svc.generateCacheKey(&domain.SearchRequest{ProductName: "Whole Milk", Retailer: " Target "})
+		if key != "v2:nutrition:search:whole milk::target" {
+			t.Errorf("key = %v, want v2:nutrition:search:whole milk::target", key)
+		}
+	})
+
+	t.Run("default and unknown retailers share the plain key", func(t *testing.T) {
+		for _, retailer := range []string{"", "Walmart", "aldi"} {
+			key := svc.generateCacheKey(&domain.SearchRequest{ProductName: "Whole Milk", Retailer: retailer})
+			if key != "v2:nutrition:search:whole milk:" {
+				t.Errorf("retailer %q: key = %v, want v2:nutrition:search:whole milk:", retailer, key)
+			}
+		}
+	})
+}
+
+func TestSearchNutrition_RetailerCacheKey(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMockCacheRepository()
+	client := NewMockUSDAClient()
+	client.searchResult = &domain.USDASearchResponse{
+		Foods: []domain.USDAFood{{FdcID: 456, Description: "Whole Milk"}},
+	}
+	svc := NewNutritionService(cache, client, NutritionServiceConfig{})
+
+	for _, retailer := range []string{"target", "walmart"} {
+		if _, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "Whole Milk", Retailer: retailer}); err != nil {
+			t.Fatalf("retailer %s: unexpected error: %v", retailer, err)
+		}
+	}
+	if client.searchCalls != 2 {
+		t.Errorf("searchCalls = %d, want 2 (one per retailer)", client.searchCalls)
+	}
+}
+
+func TestGenerateCacheKey_Namespace(t *testing.T) {
+	request := &domain.SearchRequest{ProductName: "Whole Milk", Brand: "Great Value"}
+	newService := func(namespace string) *NutritionService {
+		return NewNutritionService(NewMockCacheRepository(), NewMockUSDAClient(), NutritionServiceConfig{
+			CacheNamespace: namespace,
+		})
+	}
+
+	t.Run("prefixes namespace before version", func(t *testing.T) {
+		svc := newService("tenant-a")
+
+		key := svc.generateCacheKey(request)
+		if key != "tenant-a:v2:nutrition:search:whole milk:great value" {
+			t.Errorf("key = %v, want tenant-a:v2:nutrition:search:whole milk:great value", key)
+		}
+		if matchKey := svc.matchCacheKey("great value whole milk"); matchKey != "tenant-a:v2:match:great value whole milk" {
+			t.Errorf("matchCacheKey = %v, want tenant-a:v2:match:great value whole milk", matchKey)
+		}
+	})
+
+	t.Run("keys differ across namespaces", func(t *testing.T) {
+		keyA := newService("tenant-a").generateCacheKey(request)
+		keyB := newService("tenant-b").generateCacheKey(request)
+		if keyA == keyB {
+			t.Errorf("keys should differ across namespaces, both = %v", keyA)
+		}
+	})
+
+	t.Run("namespaces do not share cached results", func(t *testing.T) {
+		ctx := context.Background()
+		shared := NewMockCacheRepository()
+		shared.data["tenant-a:v2:nutrition:search:whole milk:great value"] = &domain.NutritionData{FdcID: "111", Confidence: 90}
+
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{
+			Foods: []domain.USDAFood{{FdcID: 222, Description: "Great Value Whole Milk", DataType: "Branded"}},
+		}
+		svc := NewNutritionService(shared, client, NutritionServiceConfig{CacheNamespace: "tenant-b"})
+
+		result, err := svc.SearchNutrition(ctx, request)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "222" || result.Source != "USDA" {
+			t.Errorf("result = %s from %s, want 222 from USDA (tenant-a entry must not be served)", result.FdcID, result.Source)
 		}
 	})
 }
@@ -391,23 +536,52 @@ func TestNormalizeForCacheKey(t *testing.T) {
 	})
 }
 
-func TestBuildSearchQuery(t *testing.T) {
-	t.Run("uses product name only when no brand", func(t *testing.T) {
-		query := buildSearchQuery(&domain.SearchRequest{ProductName: "whole milk"})
-		if query != "whole milk" {
-			t.Errorf("query = %v, want 'whole milk'", query)
-		}
-	})
+func TestSearchNutrition_SearchQuery(t *testing.T) {
+	ctx := context.Background()
 
-	t.Run("prepends brand to product name", func(t *testing.T) {
-		query := buildSearchQuery(&domain.SearchRequest{
-			ProductName: "whole milk",
-			Brand:       "Great Value",
+	tests := []struct {
+		name    string
+		request *domain.SearchRequest
+		want    string
+	}{
+		{
+			name:    "product name only when no brand",
+			request: &domain.SearchRequest{ProductName: "whole milk"},
+			want:    "whole milk",
+		},
+		{
+			name:    "brand included once",
+			request: &domain.SearchRequest{ProductName: "Tyson Tyson Chicken", Brand: "Tyson"},
+			want:    "tyson chicken",
+		},
+		{
+			name:    "pack counts and sizes stripped",
+			request: &domain.SearchRequest{ProductName: "Chobani Greek Yogurt Vanilla 5.3 oz, 4 ct"},
+			want:    "chobani greek yogurt vanilla",
+		},
+		{
+			name:    "multipacks stripped",
+			request: &domain.SearchRequest{ProductName: "Coca-Cola Classic Soda 12 pack cans", Brand: "Coca-Cola"},
+			want:    "coca-cola classic soda",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewMockUSDAClient()
+			client.searchResult = &domain.USDASearchResponse{
+				Foods: []domain.USDAFood{{FdcID: 456, Description: tt.want}},
+			}
+			svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+			if _, err := svc.SearchNutrition(ctx, tt.request); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if client.lastQuery != tt.want {
+				t.Errorf("USDA query = %q, want %q", client.lastQuery, tt.want)
+			}
 		})
-		if query != "Great Value whole milk" {
-			t.Errorf("query = %v, want 'Great Value whole milk'", query)
-		}
-	})
+	}
 }
 
 func TestGetFromCache(t *testing.T) {
@@ -482,6 +656,31 @@ func TestGetFromCache(t *testing.T) {
 		}
 	})
 
+	t.Run("round-trips label nutrients through JSON", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		client := NewMockUSDAClient()
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{})
+
+		nutrients := domain.Nutrients{
+			Calories: 61, Protein: 3.2, Carbohydrates: 4.8, TotalFat: 3.3,
+			Fiber: 0.5, Sugar: 5.1, Sodium: 43, SaturatedFat: 1.9, Cholesterol: 10,
+		}
+		encoded, _ := json.Marshal(&domain.NutritionData{FdcID: "789", Nutrients: nutrients})
+		var dataMap map[string]interface{}
+		if err := json.Unmarshal(encoded, &dataMap); err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+		cache.data["json-key"] = dataMap
+
+		result, err := svc.getFromCache(ctx, "json-key")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Nutrients != nutrients {
+			t.Errorf("Nutrients = %+v, want %+v", result.Nutrients, nutrients)
+		}
+	})
+
 	t.Run("returns ErrCacheMiss for invalid type", func(t *testing.T) {
 		cache := NewMockCacheRepository()
 		client := NewMockUSDAClient()
@@ -496,6 +695,68 @@ func TestGetFromCache(t *testing.T) {
 	})
 }
 
+func TestGetFromCache_RoundTripsEveryField(t *testing.T) {
+	ctx := context.Background()
+	svc := NewNutritionService(cache.NewMemoryCache(), NewMockUSDAClient(), NutritionServiceConfig{})
+
+	rawConfidence, calibrated := 71.5, 83.0
+	matchLatency, usdaLatency, servingGrams := 1.25, 42.0, 240.0
+	detailsFetched := true
+	want := &domain.NutritionData{
+		FdcID:           "2345",
+		ProductName:     "Whole Milk",
+		ServingSize:     "240",
+		ServingSizeUnit: "ml",
+		Nutrients: domain.Nutrients{
+			Calories: 61, Protein: 3.2, Carbohydrates: 4.8, TotalFat: 3.3,
+			Fiber: 0.5, Sugar: 5.1, Sodium: 43, SaturatedFat: 1.9, Cholesterol: 10,
+		},
+		Confidence:            88.5,
+		RawConfidence:         &rawConfidence,
+		Source:                "USDA",
+		DetailsFetched:        &detailsFetched,
+		Measure:               "cup",
+		HouseholdServing:      "1 cup",
+		CalibratedConfidence:  &calibrated,
+		MatchLatencyMs:        &matchLatency,
+		USDALatencyMs:         &usdaLatency,
+		CacheKey:              "v2:whole milk",
+		ServingGrams:          &servingGrams,
+		ServingGramsEstimated: true,
+		Candidates:            []domain.MatchCandidate{{FdcID: "2345", Description: "Milk, whole", Confidence: 88.5}},
+		Explanation: &domain.MatchExplanation{
+			BaseScore: 70, TotalWeight: 2, MatchedTokens: []domain.MatchedToken{{Token: "milk", Weight: 1}},
+			BrandBonus: 1, DataTypeBonus: 2, SubstringBonus: 3, PhraseBonus: 4, CategoryPenalty: -5,
+			ConfirmedBonus: 6, RecencyBonus: 7, Score: 88.5,
+		},
+		Truncated: true,
+	}
+
+	if err := svc.setInCache(ctx, "round-trip", want, time.Hour); err != nil {
+		t.Fatalf("setInCache: %v", err)
+	}
+
+	// Every field must be set, so a field added later without cache support fails here
+	fields := reflect.ValueOf(*want)
+	for i := 0; i < fields.NumField(); i++ {
+		if fields.Field(i).IsZero() {
+			t.Fatalf("fixture leaves %s unset", fields.Type().Field(i).Name)
+		}
+	}
+
+	got, err := svc.getFromCache(ctx, "round-trip")
+	if err != nil {
+		t.Fatalf("getFromCache: %v", err)
+	}
+	if !got.CachedAt.Equal(want.CachedAt) {
+		t.Errorf("CachedAt = %v, want %v", got.CachedAt, want.CachedAt)
+	}
+	got.CachedAt = want.CachedAt
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cached value = %+v, want %+v", got, want)
+	}
+}
+
 func TestMapMatchToNutrition(t *testing.T) {
 	cache := NewMockCacheRepository()
 	client := NewMockUSDAClient()
@@ -539,3 +800,1218 @@ func TestMapMatchToNutrition(t *testing.T) {
 		}
 	})
 }
+
+func TestSearchNutrition_Timing(t *testing.T) {
+	ctx := context.Background()
+
+	newClient := func() *MockUSDAClient {
+		client := NewMockUSDAClient()
+		client.searchDelay = 20 * time.Millisecond
+		client.searchResult = &domain.USDASearchResponse{
+			Foods: []domain.USDAFood{
+				{FdcID: 456, Description: "Whole Milk"},
+			},
+		}
+		return client
+	}
+
+	t.Run("omits timing when not requested", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		svc := NewNutritionService(cache, newClient(), NutritionServiceConfig{})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.USDALatencyMs != nil || result.MatchLatencyMs != nil {
+			t.Errorf("expected no timing fields, got usda=%v match=%v", result.USDALatencyMs, result.MatchLatencyMs)
+		}
+	})
+
+	t.Run("reports USDA latency on cache miss", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		svc := NewNutritionService(cache, newClient(), NutritionServiceConfig{})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{
+			ProductName:   "whole milk",
+			IncludeTiming: true,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.USDALatencyMs == nil || result.MatchLatencyMs == nil {
+			t.Fatal("expected timing fields to be populated")
+		}
+		if *result.USDALatencyMs < 20 {
+			t.Errorf("USDALatencyMs = %v, want >= 20", *result.USDALatencyMs)
+		}
+		if *result.MatchLatencyMs < 0 {
+			t.Errorf("MatchLatencyMs = %v, want >= 0", *result.MatchLatencyMs)
+		}
+	})
+
+	t.Run("reports zero USDA latency on cache hit", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		cache.data["v2:nutrition:search:whole milk:"] = &domain.NutritionData{FdcID: "123", Confidence: 85}
+		svc := NewNutritionService(cache, newClient(), NutritionServiceConfig{})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{
+			ProductName:   "whole milk",
+			IncludeTiming: true,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.USDALatencyMs == nil || *result.USDALatencyMs != 0 {
+			t.Errorf("USDALatencyMs = %v, want 0 on cache hit", result.USDALatencyMs)
+		}
+		if result.MatchLatencyMs == nil || *result.MatchLatencyMs != 0 {
+			t.Errorf("MatchLatencyMs = %v, want 0 on cache hit", result.MatchLatencyMs)
+		}
+	})
+}
+
+func TestSearchNutrition_KeywordFallback(t *testing.T) {
+	ctx := context.Background()
+	const fullQuery = "horizon organic whole milk vitamin d ultra pasteurized"
+	request := &domain.SearchRequest{ProductName: "Horizon Organic Whole Milk Vitamin D Ultra Pasteurized Carton"}
+
+	t.Run("retries with the top food keywords", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.byQuery = map[string]*domain.USDASearchResponse{
+			"milk organic whole": {Foods: []domain.USDAFood{{FdcID: 456, Description: "Milk, whole, organic"}}},
+		}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		result, err := svc.SearchNutrition(ctx, request)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "456" {
+			t.Errorf("FdcID = %s, want 456", result.FdcID)
+		}
+		if !slices.Equal(client.queries, []string{fullQuery, "milk organic whole"}) {
+			t.Errorf("queries = %q, want the full query then the keywords", client.queries)
+		}
+	})
+
+	t.Run("retries only once", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.byQuery = map[string]*domain.USDASearchResponse{}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		if _, err := svc.SearchNutrition(ctx, request); !errors.Is(err, domain.ErrProductNotFound) {
+			t.Errorf("error = %v, want ErrProductNotFound", err)
+		}
+		if client.searchCalls != 2 {
+			t.Errorf("searchCalls = %d, want 2", client.searchCalls)
+		}
+	})
+
+	t.Run("no retry when the keywords are the whole query", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.byQuery = map[string]*domain.USDASearchResponse{}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		if _, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk"}); !errors.Is(err, domain.ErrProductNotFound) {
+			t.Errorf("error = %v, want ErrProductNotFound", err)
+		}
+		if client.searchCalls != 1 {
+			t.Errorf("searchCalls = %d, want 1", client.searchCalls)
+		}
+	})
+}
+
+func TestSearchNutrition_RawConfidence(t *testing.T) {
+	ctx := context.Background()
+
+	newClient := func() *MockUSDAClient {
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{
+			Foods: []domain.USDAFood{
+				{FdcID: 321, Description: "Chicken Breast, grilled"},
+			},
+		}
+		return client
+	}
+	request := func() *domain.SearchRequest {
+		return &domain.SearchRequest{ProductName: "Premium Select Family Size Chicken Breast"}
+	}
+
+	t.Run("omits raw confidence when disabled", func(t *testing.T) {
+		svc := NewNutritionService(NewMockCacheRepository(), newClient(), NutritionServiceConfig{})
+
+		result, err := svc.SearchNutrition(ctx, request())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.RawConfidence != nil {
+			t.Errorf("RawConfidence = %v, want nil", *result.RawConfidence)
+		}
+	})
+
+	t.Run("reports lower raw confidence for noisy title", func(t *testing.T) {
+		svc := NewNutritionService(NewMockCacheRepository(), newClient(), NutritionServiceConfig{
+			ReportRawConfidence: true,
+		})
+
+		result, err := svc.SearchNutrition(ctx, request())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.RawConfidence == nil {
+			t.Fatal("expected RawConfidence to be populated")
+		}
+		// Marketing tokens dilute the raw score but are stripped before matching
+		if result.Confidence-*result.RawConfidence < 10 {
+			t.Errorf("Confidence = %.1f, RawConfidence = %.1f, want cleaned to exceed raw by >= 10",
+				result.Confidence, *result.RawConfidence)
+		}
+	})
+}
+
+// TestMatchRequest_CleanedNameRanking scores real retail titles against the raw name
+// (before cleaning was applied to matching) and the cleaned name (after): the
+// ranking must not change, only confidence may rise as noise tokens drop out
+func TestMatchRequest_CleanedNameRanking(t *testing.T) {
+	ctx := context.Background()
+	svc := NewNutritionService(NewMockCacheRepository(), NewMockUSDAClient(), NutritionServiceConfig{
+		MinConfidenceThreshold: 40,
+		EnableFuzzyMatching:    true,
+	})
+
+	type rankingCase struct {
+		productName string
+		brand       string
+		usdaFoods   []domain.USDAFood
+		wantFdcID   string
+	}
+	cases := []rankingCase{
+		{
+			productName: "Fairlife 2% Reduced Fat Ultra-Filtered Milk, 52 fl oz",
+			brand:       "Fairlife",
+			usdaFoods: []domain.USDAFood{
+				{FdcID: 1, Description: "Milk, reduced fat, fluid, 2% milkfat", DataType: "SR Legacy"},
+				{FdcID: 2, Description: "Milk, whole, 3.25% milkfat", DataType: "SR Legacy"},
+				{FdcID: 3, Description: "Milk, lowfat, fluid, 1% milkfat", DataType: "SR Legacy"},
+			},
+			wantFdcID: "1",
+		},
+		{
+			productName: "Tyson All Natural Boneless Skinless Chicken Breasts, 2.5 lb Bag (Frozen)",
+			brand:       "Tyson",
+			usdaFoods: []domain.USDAFood{
+				{FdcID: 1, Description: "Chicken, broilers or fryers, breast, skinless, boneless, meat only, raw", DataType: "SR Legacy"},
+				{FdcID: 2, Description: "Chicken, broilers or fryers, thigh, meat only, raw", DataType: "SR Legacy"},
+				{FdcID: 3, Description: "Chicken nuggets, frozen", DataType: "Survey (FNDDS)"},
+			},
+			wantFdcID: "1",
+		},
+		{
+			productName: "Chobani Non-Fat Greek Yogurt, Plain, 32 oz Tub",
+			brand:       "Chobani",
+			usdaFoods: []domain.USDAFood{
+				{FdcID: 1, Description: "Yogurt, Greek, plain, nonfat", DataType: "SR Legacy"},
+				{FdcID: 2, Description: "Yogurt, Greek, strawberry, nonfat", DataType: "SR Legacy"},
+				{FdcID: 3, Description: "Yogurt, plain, whole milk", DataType: "SR Legacy"},
+			},
+			wantFdcID: "1",
+		},
+		{
+			productName: "Great Value Creamy Peanut Butter, 40 oz Jar, 2 Pack",
+			brand:       "Great Value",
+			usdaFoods: []domain.USDAFood{
+				{FdcID: 1, Description: "Peanut butter, smooth style, with salt", DataType: "SR Legacy"},
+				{FdcID: 2, Description: "Peanut butter, chunk style, with salt", DataType: "SR Legacy"},
+				{FdcID: 3, Description: "Butter, salted", DataType: "SR Legacy"},
+			},
+			wantFdcID: "1",
+		},
+	}
+	for _, fixture := range walmartProductFixtures {
+		cases = append(cases, rankingCase{fixture.productName, fixture.brand, fixture.usdaFoods, fixture.wantFdcID})
+	}
+
+	ranking := func(request *domain.SearchRequest, foods []domain.USDAFood) []*domain.MatchResult {
+		matches, err := svc.matchingService.FindTopMatches(ctx, request, foods, len(foods))
+		if err != nil {
+			t.Fatalf("FindTopMatches(%q): unexpected error: %v", request.ProductName, err)
+		}
+		return matches
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.productName, func(t *testing.T) {
+			raw := &domain.SearchRequest{ProductName: tc.productName, Brand: tc.brand}
+			cleaned := svc.matchRequest(raw, "", false)
+
+			before := ranking(raw, tc.usdaFoods)
+			after := ranking(&cleaned, tc.usdaFoods)
+
+			if after[0].FdcID != tc.wantFdcID {
+				t.Errorf("best match = %s, want %s", after[0].FdcID, tc.wantFdcID)
+			}
+			for i := range before {
+				if before[i].FdcID != after[i].FdcID {
+					t.Errorf("rank %d: raw name ranks %s, cleaned name ranks %s", i+1, before[i].FdcID, after[i].FdcID)
+				}
+			}
+			if after[0].MatchScore < before[0].MatchScore {
+				t.Errorf("best match confidence fell from %.1f to %.1f after cleaning", before[0].MatchScore, after[0].MatchScore)
+			}
+		})
+	}
+}
+
+func TestSearchNutrition_MatchCache(t *testing.T) {
+	ctx := context.Background()
+
+	milk := domain.USDAFood{
+		FdcID:       456,
+		Description: "Whole Milk",
+		Nutrients: []domain.USDANutrient{
+			{NutrientID: 1008, Value: 150},
+		},
+	}
+
+	t.Run("bypasses search and match when a decision is cached", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{Foods: []domain.USDAFood{milk}}
+		client.foodResult = &milk
+
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{EnableMatchCache: true})
+		request := &domain.SearchRequest{ProductName: "whole milk"}
+
+		first, err := svc.SearchNutrition(ctx, request)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := cache.data["v2:match:whole milk"]; !ok {
+			t.Fatal("expected match decision to be cached")
+		}
+
+		// Evict the nutrition entry but keep the match decision
+		delete(cache.data, svc.generateCacheKey(request))
+
+		second, err := svc.SearchNutrition(ctx, request)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.searchCalls != 1 {
+			t.Errorf("searchCalls = %d, want 1 (second lookup should skip search)", client.searchCalls)
+		}
+		if client.foodCalls != 1 {
+			t.Errorf("foodCalls = %d, want 1", client.foodCalls)
+		}
+		if second.FdcID != "456" {
+			t.Errorf("FdcID = %v, want 456", second.FdcID)
+		}
+		if second.Confidence != first.Confidence {
+			t.Errorf("Confidence = %v, want cached %v", second.Confidence, first.Confidence)
+		}
+	})
+
+	t.Run("falls back to search when detail fetch fails", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		cache.data["v2:match:whole milk"] = &matchDecision{FdcID: "456", Confidence: 90}
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{Foods: []domain.USDAFood{milk}}
+		client.foodError = domain.ErrUSDAAPIFailure
+
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{EnableMatchCache: true})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.searchCalls != 1 {
+			t.Errorf("searchCalls = %d, want 1", client.searchCalls)
+		}
+		if result.FdcID != "456" {
+			t.Errorf("FdcID = %v, want 456", result.FdcID)
+		}
+	})
+
+	t.Run("reads decisions stored as maps", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		cache.data["v2:match:whole milk"] = map[string]interface{}{"fdcId": "456", "confidence": 88.0}
+		client := NewMockUSDAClient()
+		client.foodResult = &milk
+
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{EnableMatchCache: true})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.searchCalls != 0 {
+			t.Errorf("searchCalls = %d, want 0", client.searchCalls)
+		}
+		if result.Confidence != 88 {
+			t.Errorf("Confidence = %v, want 88", result.Confidence)
+		}
+	})
+
+	t.Run("does not consult decisions when disabled", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		cache.data["v2:match:whole milk"] = &matchDecision{FdcID: "456", Confidence: 90}
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{Foods: []domain.USDAFood{milk}}
+		client.foodResult = &milk
+
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{})
+
+		if _, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.foodCalls != 0 {
+			t.Errorf("foodCalls = %d, want 0", client.foodCalls)
+		}
+	})
+}
+
+func TestSearchNutrition_FetchFoodDetails(t *testing.T) {
+	ctx := context.Background()
+
+	searchFood := domain.USDAFood{
+		FdcID:       456,
+		Description: "Whole Milk",
+		Nutrients: []domain.USDANutrient{
+			{NutrientID: 1008, Value: 150},
+			{NutrientID: 1003, Value: 8},
+			{NutrientID: 1005, Value: 12},
+			{NutrientID: 1004, Value: 8},
+		},
+	}
+	newClient := func() *MockUSDAClient {
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{Foods: []domain.USDAFood{searchFood}}
+		return client
+	}
+
+	t.Run("uses detail nutrients when fetch succeeds", func(t *testing.T) {
+		client := newClient()
+		client.foodResult = &domain.USDAFood{
+			FdcID:       456,
+			Description: "Whole Milk",
+			Nutrients: []domain.USDANutrient{
+				{NutrientID: 1008, Value: 149},
+				{NutrientID: 1003, Value: 7.7},
+				{NutrientID: 1005, Value: 11.7},
+			},
+		}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{FetchFoodDetails: true})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.DetailsFetched == nil || !*result.DetailsFetched {
+			t.Errorf("DetailsFetched = %v, want true", result.DetailsFetched)
+		}
+		if result.Nutrients.Calories != 149 {
+			t.Errorf("Calories = %v, want 149 from details", result.Nutrients.Calories)
+		}
+		// Missing from details, filled from search
+		if result.Nutrients.TotalFat != 8 {
+			t.Errorf("TotalFat = %v, want 8 from search", result.Nutrients.TotalFat)
+		}
+	})
+
+	t.Run("falls back to search macros when fetch errors", func(t *testing.T) {
+		client := newClient()
+		client.foodError = domain.ErrUSDAAPIFailure
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{FetchFoodDetails: true})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk"})
+		if err != nil {
+			t.Fatalf("detail fetch failure should not fail lookup: %v", err)
+		}
+		if result.DetailsFetched == nil || *result.DetailsFetched {
+			t.Errorf("DetailsFetched = %v, want false", result.DetailsFetched)
+		}
+		want := domain.Nutrients{Calories: 150, Protein: 8, Carbohydrates: 12, TotalFat: 8}
+		if result.Nutrients != want {
+			t.Errorf("Nutrients = %+v, want %+v", result.Nutrients, want)
+		}
+	})
+
+	t.Run("falls back when details have no nutrients", func(t *testing.T) {
+		client := newClient()
+		client.foodResult = &domain.USDAFood{FdcID: 456, Description: "Whole Milk"}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{FetchFoodDetails: true})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.DetailsFetched == nil || *result.DetailsFetched {
+			t.Errorf("DetailsFetched = %v, want false", result.DetailsFetched)
+		}
+		if result.Nutrients.Calories != 150 {
+			t.Errorf("Calories = %v, want 150 from search", result.Nutrients.Calories)
+		}
+	})
+
+	t.Run("does not fetch details when disabled", func(t *testing.T) {
+		client := newClient()
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.foodCalls != 0 {
+			t.Errorf("foodCalls = %d, want 0", client.foodCalls)
+		}
+		if result.DetailsFetched != nil {
+			t.Errorf("DetailsFetched = %v, want nil", *result.DetailsFetched)
+		}
+	})
+}
+
+func TestSearchNutrition_HouseholdMeasure(t *testing.T) {
+	ctx := context.Background()
+
+	newClient := func() *MockUSDAClient {
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{
+			Foods: []domain.USDAFood{{
+				FdcID:       456,
+				Description: "Whole Milk",
+				Nutrients: []domain.USDANutrient{
+					{NutrientID: 1008, Value: 60},
+					{NutrientID: 1003, Value: 3.2},
+					{NutrientID: 1005, Value: 4.8},
+					{NutrientID: 1004, Value: 3.3},
+				},
+			}},
+		}
+		client.foodResult = &domain.USDAFood{
+			FdcID:       456,
+			Description: "Whole Milk",
+			Portions: []domain.USDAFoodPortion{
+				{Amount: 1, GramWeight: 244, Modifier: "cup"},
+			},
+		}
+		return client
+	}
+	config := NutritionServiceConfig{EnableHouseholdMeasures: true}
+
+	t.Run("scales nutrients to a defined cup portion", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		svc := NewNutritionService(cache, newClient(), config)
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", Measure: "cup"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Measure != "cup" || result.ServingSizeUnit != "cup" {
+			t.Errorf("Measure = %q, ServingSizeUnit = %q, want cup", result.Measure, result.ServingSizeUnit)
+		}
+		if math.Abs(result.Nutrients.Calories-146.4) > 0.001 {
+			t.Errorf("Calories = %v, want 146.4 (60 kcal/100g x 244g)", result.Nutrients.Calories)
+		}
+
+		// The cache keeps the per-100g result
+		cached := cache.data["v2:nutrition:search:whole milk:"].(*domain.NutritionData)
+		if cached.Nutrients.Calories != 60 || cached.Measure != "" {
+			t.Errorf("cached = %v kcal (measure %q), want per-100g 60", cached.Nutrients.Calories, cached.Measure)
+		}
+	})
+
+	t.Run("falls back to per-100g when measure is undefined", func(t *testing.T) {
+		svc := NewNutritionService(NewMockCacheRepository(), newClient(), config)
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", Measure: "tablespoon"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Measure != "" || result.ServingSize != "100" || result.Nutrients.Calories != 60 {
+			t.Errorf("result = %s%s %v kcal (measure %q), want per-100g", result.ServingSize, result.ServingSizeUnit, result.Nutrients.Calories, result.Measure)
+		}
+	})
+
+	t.Run("falls back to per-100g when details fail", func(t *testing.T) {
+		client := newClient()
+		client.foodError = domain.ErrUSDAAPIFailure
+		svc := NewNutritionService(NewMockCacheRepository(), client, config)
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", Measure: "cup"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Nutrients.Calories != 60 {
+			t.Errorf("Calories = %v, want per-100g 60", result.Nutrients.Calories)
+		}
+	})
+
+	t.Run("scales to a label serving size", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		svc := NewNutritionService(cache, newClient(), NutritionServiceConfig{})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", ServingSize: 240})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.ServingSize != "240" || result.ServingSizeUnit != "g" {
+			t.Errorf("serving = %s %s, want 240 g", result.ServingSize, result.ServingSizeUnit)
+		}
+		if math.Abs(result.Nutrients.Calories-144) > 0.001 {
+			t.Errorf("Calories = %v, want 144 (60 kcal/100g x 240g)", result.Nutrients.Calories)
+		}
+
+		// The cache keeps the per-100g result
+		cached := cache.data["v2:nutrition:search:whole milk:"].(*domain.NutritionData)
+		if cached.Nutrients.Calories != 60 || cached.ServingSize != "100" {
+			t.Errorf("cached = %v kcal per %s g, want per-100g 60", cached.Nutrients.Calories, cached.ServingSize)
+		}
+	})
+
+	t.Run("measure takes precedence over serving size", func(t *testing.T) {
+		svc := NewNutritionService(NewMockCacheRepository(), newClient(), config)
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", Measure: "cup", ServingSize: 240})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.ServingSizeUnit != "cup" || math.Abs(result.Nutrients.Calories-146.4) > 0.001 {
+			t.Errorf("result = %s %s, %v kcal, want 1 cup at 146.4 kcal", result.ServingSize, result.ServingSizeUnit, result.Nutrients.Calories)
+		}
+	})
+
+	t.Run("rejects a negative serving size", func(t *testing.T) {
+		svc := NewNutritionService(NewMockCacheRepository(), newClient(), NutritionServiceConfig{})
+
+		_, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", ServingSize: -1})
+		if !errors.Is(err, domain.ErrInvalidRequest) {
+			t.Errorf("err = %v, want ErrInvalidRequest", err)
+		}
+	})
+
+	t.Run("ignores measure when disabled", func(t *testing.T) {
+		client := newClient()
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", Measure: "cup"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Measure != "" || client.foodCalls != 0 {
+			t.Errorf("Measure = %q, foodCalls = %d, want no scaling", result.Measure, client.foodCalls)
+		}
+	})
+}
+
+func TestSearchNutrition_ControlCharacters(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rejects null bytes before calling USDA", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{RejectControlChars: true})
+
+		_, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole\x00milk"})
+		if !errors.Is(err, domain.ErrInvalidRequest) {
+			t.Errorf("error = %v, want ErrInvalidRequest", err)
+		}
+		if client.searchCalls != 0 {
+			t.Errorf("searchCalls = %d, want 0", client.searchCalls)
+		}
+	})
+
+	t.Run("rejects names that are only control characters", func(t *testing.T) {
+		svc := NewNutritionService(NewMockCacheRepository(), NewMockUSDAClient(), NutritionServiceConfig{})
+
+		_, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "\t\r\n"})
+		if !errors.Is(err, domain.ErrInvalidRequest) {
+			t.Errorf("error = %v, want ErrInvalidRequest", err)
+		}
+	})
+
+	t.Run("strips benign control characters and shares the cache key", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		cache.data["v2:nutrition:search:whole milk:"] = &domain.NutritionData{FdcID: "123", Confidence: 85}
+		svc := NewNutritionService(cache, NewMockUSDAClient(), NutritionServiceConfig{RejectControlChars: true})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "Whole\tMilk\r\n"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "123" || result.Source != "Cache" {
+			t.Errorf("result = %s from %s, want cached 123", result.FdcID, result.Source)
+		}
+	})
+}
+
+func TestSearchNutrition_StoreBrandGenerics(t *testing.T) {
+	ctx := context.Background()
+	request := &domain.SearchRequest{ProductName: "Great Value Milk, 128 fl oz"}
+	foods := []domain.USDAFood{
+		{FdcID: 1, Description: "Milk, whole", DataType: "Branded"},
+		{FdcID: 2, Description: "Milk, whole", DataType: "Foundation"},
+	}
+
+	t.Run("searches a specific query and prefers generic data", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{Foods: foods}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{
+			MinConfidenceThreshold: 40,
+			StoreBrandGenerics:     true,
+		})
+
+		result, err := svc.SearchNutrition(ctx, request)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.lastQuery != "whole milk" {
+			t.Errorf("query = %q, want %q", client.lastQuery, "whole milk")
+		}
+		if result.FdcID != "2" {
+			t.Errorf("FdcID = %s, want 2 (Foundation)", result.FdcID)
+		}
+	})
+
+	t.Run("disabled keeps the stripped query", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{Foods: foods}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{MinConfidenceThreshold: 40})
+
+		if _, err := svc.SearchNutrition(ctx, request); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.lastQuery != "milk" {
+			t.Errorf("query = %q, want %q", client.lastQuery, "milk")
+		}
+	})
+}
+
+func TestSearchNutrition_MinConfidence(t *testing.T) {
+	ctx := context.Background()
+	threshold := func(v float64) *float64 { return &v }
+	foods := []domain.USDAFood{{FdcID: 1, Description: "Milk, whole"}}
+
+	t.Run("rejects out-of-range overrides", func(t *testing.T) {
+		for _, v := range []float64{0, 5, 100.5, math.NaN()} {
+			client := NewMockUSDAClient()
+			svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+			_, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", MinConfidence: threshold(v)})
+			if !errors.Is(err, domain.ErrInvalidRequest) {
+				t.Errorf("minConfidence=%v: error = %v, want ErrInvalidRequest", v, err)
+			}
+			if client.searchCalls != 0 {
+				t.Errorf("minConfidence=%v: searchCalls = %d, want 0", v, client.searchCalls)
+			}
+		}
+	})
+
+	t.Run("looser override matches without caching", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{Foods: foods}
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{MinConfidenceThreshold: 80, EnableMatchCache: true})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", MinConfidence: threshold(60)})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "1" {
+			t.Errorf("FdcID = %s, want 1", result.FdcID)
+		}
+		if len(cache.data) != 0 {
+			t.Errorf("cache has %d entries, want none for a result below the configured threshold", len(cache.data))
+		}
+
+		// The configured threshold still applies to requests without an override
+		_, err = svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk"})
+		if !errors.Is(err, domain.ErrLowConfidence) {
+			t.Errorf("error = %v, want ErrLowConfidence", err)
+		}
+	})
+
+	t.Run("stricter override applies to cached results", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		cache.data["v2:nutrition:search:whole milk:"] = &domain.NutritionData{FdcID: "1", Confidence: 70}
+		svc := NewNutritionService(cache, NewMockUSDAClient(), NutritionServiceConfig{MinConfidenceThreshold: 40})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", MinConfidence: threshold(90)})
+		if !errors.Is(err, domain.ErrLowConfidence) {
+			t.Errorf("error = %v, want ErrLowConfidence", err)
+		}
+		if result == nil || result.FdcID != "1" {
+			t.Errorf("result = %+v, want cached data alongside the warning", result)
+		}
+
+		if _, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", MinConfidence: threshold(60)}); err != nil {
+			t.Errorf("unexpected error with a looser override: %v", err)
+		}
+	})
+}
+
+func TestSearchNutrition_BrandAwareDataTypes(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name      string
+		enabled   bool
+		request   *domain.SearchRequest
+		wantTypes []string
+	}{
+		{"brand puts Branded first", true, &domain.SearchRequest{ProductName: "Whole Milk", Brand: "Horizon"}, brandedFirstDataTypes},
+		{"no brand keeps generic order", true, &domain.SearchRequest{ProductName: "Whole Milk"}, genericFirstDataTypes},
+		{"disabled leaves the client default", false, &domain.SearchRequest{ProductName: "Whole Milk", Brand: "Horizon"}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewMockUSDAClient()
+			client.searchResult = &domain.USDASearchResponse{Foods: []domain.USDAFood{{FdcID: 1, Description: "Milk, whole"}}}
+			svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{BrandAwareDataTypes: tc.enabled})
+
+			_, _ = svc.SearchNutrition(ctx, tc.request)
+			if !slices.Equal(client.lastTypes, tc.wantTypes) {
+				t.Errorf("data types = %v, want %v", client.lastTypes, tc.wantTypes)
+			}
+		})
+	}
+
+	t.Run("store-brand generics keep generic order", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{Foods: []domain.USDAFood{{FdcID: 1, Description: "Milk, whole"}}}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{
+			BrandAwareDataTypes: true,
+			StoreBrandGenerics:  true,
+		})
+
+		_, _ = svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "Great Value Milk", Brand: "Great Value"})
+		if !slices.Equal(client.lastTypes, genericFirstDataTypes) {
+			t.Errorf("data types = %v, want %v", client.lastTypes, genericFirstDataTypes)
+		}
+	})
+
+	t.Run("configured data types are reordered", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{Foods: []domain.USDAFood{{FdcID: 1, Description: "Milk, whole"}}}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{
+			BrandAwareDataTypes: true,
+			DataTypes:           []string{"SR Legacy", "Foundation", "Branded"},
+		})
+
+		_, _ = svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "Whole Milk", Brand: "Horizon"})
+		if want := []string{"Branded", "SR Legacy", "Foundation"}; !slices.Equal(client.lastTypes, want) {
+			t.Errorf("branded data types = %v, want %v", client.lastTypes, want)
+		}
+		_, _ = svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "Skim Milk"})
+		if want := []string{"SR Legacy", "Foundation", "Branded"}; !slices.Equal(client.lastTypes, want) {
+			t.Errorf("generic data types = %v, want %v", client.lastTypes, want)
+		}
+	})
+}
+
+func TestSearchNutrition_ConfidenceHistogram(t *testing.T) {
+	ctx := context.Background()
+	client := NewMockUSDAClient()
+	client.searchResult = &domain.USDASearchResponse{Foods: []domain.USDAFood{{FdcID: 1, Description: "Whole Milk"}}}
+	svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{MinConfidenceThreshold: 40})
+	histogram := NewConfidenceHistogram(time.Hour)
+	svc.SetConfidenceHistogram(histogram)
+
+	// One USDA lookup and one cache hit are both served results
+	for i := 0; i < 2; i++ {
+		if _, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	client.searchError = domain.ErrProductNotFound
+	_, _ = svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "dragon fruit"})
+
+	snapshot := histogram.Snapshot()
+	if snapshot.Total != 2 || snapshot.Buckets[4].Count != 2 {
+		t.Errorf("snapshot = %s, want two results in 80-100", snapshot)
+	}
+}
+
+func TestSearchNutrition_Candidates(t *testing.T) {
+	ctx := context.Background()
+	foods := []domain.USDAFood{
+		{FdcID: 1, Description: "Milk, skim"},
+		{FdcID: 2, Description: "Milk, whole"},
+		{FdcID: 3, Description: "Cheese, cheddar"},
+	}
+
+	t.Run("lists top matches best first", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{Foods: foods}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", Candidates: 2})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(result.Candidates) != 2 {
+			t.Fatalf("len(Candidates) = %d, want 2", len(result.Candidates))
+		}
+		if result.Candidates[0].FdcID != "2" || result.Candidates[0].Confidence != result.Confidence {
+			t.Errorf("Candidates[0] = %+v, want the best match (fdcId 2, confidence %.1f)", result.Candidates[0], result.Confidence)
+		}
+	})
+
+	t.Run("omitted unless requested", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{Foods: foods}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Candidates != nil {
+			t.Errorf("Candidates = %v, want nil", result.Candidates)
+		}
+	})
+
+	t.Run("bypasses the cache", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		cache.data["v2:nutrition:search:whole milk:"] = &domain.NutritionData{FdcID: "2", Confidence: 90}
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{Foods: foods}
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", Candidates: 3})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.searchCalls != 1 || len(result.Candidates) != 3 {
+			t.Errorf("searchCalls = %d, candidates = %d; want a fresh search listing 3", client.searchCalls, len(result.Candidates))
+		}
+	})
+
+	t.Run("rejects out-of-range counts", func(t *testing.T) {
+		for _, n := range []int{-1, 11} {
+			svc := NewNutritionService(NewMockCacheRepository(), NewMockUSDAClient(), NutritionServiceConfig{})
+			_, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", Candidates: n})
+			if !errors.Is(err, domain.ErrInvalidRequest) {
+				t.Errorf("candidates=%d: error = %v, want ErrInvalidRequest", n, err)
+			}
+		}
+	})
+}
+
+func TestSearchNutrition_Explain(t *testing.T) {
+	ctx := context.Background()
+	foods := []domain.USDAFood{
+		{FdcID: 1, Description: "Milk, skim"},
+		{FdcID: 2, Description: "Milk, whole"},
+	}
+
+	t.Run("attaches the best match's breakdown", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{Foods: foods}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", Explain: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Explanation == nil || len(result.Explanation.MatchedTokens) != 2 {
+			t.Errorf("Explanation = %+v, want both tokens of the whole milk match", result.Explanation)
+		}
+	})
+
+	t.Run("bypasses the cache", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		cache.data["v2:nutrition:search:whole milk:"] = &domain.NutritionData{FdcID: "2", Confidence: 90}
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{Foods: foods}
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{})
+
+		result, err := svc.SearchNutrition(ctx, &domain.SearchRequest{ProductName: "whole milk", Explain: true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.searchCalls != 1 || result.Explanation == nil {
+			t.Errorf("searchCalls = %d, explanation = %v; want a fresh search with a breakdown", client.searchCalls, result.Explanation)
+		}
+	})
+}
+
+func TestSearchNutrition_Debug(t *testing.T) {
+	ctx := context.Background()
+	const key = "v2:nutrition:search:whole milk:"
+
+	cache := NewMockCacheRepository()
+	client := NewMockUSDAClient()
+	client.searchResult = &domain.USDASearchResponse{
+		Foods: []domain.USDAFood{{FdcID: 456, Description: "Whole Milk"}},
+	}
+	svc := NewNutritionService(cache, client, NutritionServiceConfig{})
+	request := &domain.SearchRequest{ProductName: "Whole Milk", Debug: true}
+
+	miss, err := svc.SearchNutrition(ctx, request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if miss.CacheKey != key || miss.USDALatencyMs == nil {
+		t.Errorf("cache miss: cacheKey = %q, usdaLatencyMs = %v; want %q with timing", miss.CacheKey, miss.USDALatencyMs, key)
+	}
+	if cached := cache.data[key].(*domain.NutritionData); cached.CacheKey != "" {
+		t.Errorf("cached value has cacheKey %q, want it left out", cached.CacheKey)
+	}
+
+	hit, err := svc.SearchNutrition(ctx, request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hit.Source != "Cache" || hit.CacheKey != key || hit.USDALatencyMs == nil || *hit.USDALatencyMs != 0 {
+		t.Errorf("cache hit: source = %s, cacheKey = %q, usdaLatencyMs = %v; want Cache, %q, 0", hit.Source, hit.CacheKey, hit.USDALatencyMs, key)
+	}
+
+	plain, _ := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{}).
+		SearchNutrition(ctx, &domain.SearchRequest{ProductName: "Whole Milk"})
+	if plain.CacheKey != "" || plain.USDALatencyMs != nil {
+		t.Errorf("non-debug response has cacheKey %q, usdaLatencyMs %v; want neither", plain.CacheKey, plain.USDALatencyMs)
+	}
+}
+
+func TestSearchNutrition_NotFoundCache(t *testing.T) {
+	ctx := context.Background()
+	key := "v2:nutrition:search:dragon fruit:"
+	request := &domain.SearchRequest{ProductName: "dragon fruit"}
+
+	t.Run("answers repeats from the cache", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		client := NewMockUSDAClient()
+		client.searchError = domain.ErrProductNotFound
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{NotFoundCacheTTL: time.Hour})
+
+		for i := 0; i < 2; i++ {
+			if _, err := svc.SearchNutrition(ctx, request); !errors.Is(err, domain.ErrProductNotFound) {
+				t.Fatalf("search %d: error = %v, want ErrProductNotFound", i+1, err)
+			}
+		}
+		if client.searchCalls != 1 {
+			t.Errorf("searchCalls = %d, want 1", client.searchCalls)
+		}
+	})
+
+	t.Run("recognizes a deserialized marker", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		cache.data[key] = map[string]interface{}{"notFound": true}
+		client := NewMockUSDAClient()
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{NotFoundCacheTTL: time.Hour})
+
+		if _, err := svc.SearchNutrition(ctx, request); !errors.Is(err, domain.ErrProductNotFound) {
+			t.Errorf("error = %v, want ErrProductNotFound", err)
+		}
+		if client.searchCalls != 0 {
+			t.Errorf("searchCalls = %d, want 0", client.searchCalls)
+		}
+	})
+
+	t.Run("a later match replaces the marker", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		cache.data[key] = &notFoundMarker{NotFound: true}
+		svc := NewNutritionService(cache, NewMockUSDAClient(), NutritionServiceConfig{KeepHigherConfidence: true})
+
+		if err := svc.setInCache(ctx, key, &domain.NutritionData{FdcID: "1", Confidence: 80}, time.Hour); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := cache.data[key].(*domain.NutritionData); !ok {
+			t.Errorf("cached value = %#v, want nutrition data", cache.data[key])
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		client := NewMockUSDAClient()
+		client.searchError = domain.ErrProductNotFound
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{})
+
+		_, _ = svc.SearchNutrition(ctx, request)
+		if _, ok := cache.data[key]; ok {
+			t.Errorf("cache has %#v, want nothing cached", cache.data[key])
+		}
+	})
+}
+
+func TestSearchNutrition_RequestTimeout(t *testing.T) {
+	ctx := context.Background()
+	request := &domain.SearchRequest{ProductName: "whole milk"}
+
+	t.Run("gives up on a USDA call that runs past the timeout", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.searchDelay = 500 * time.Millisecond // Ignores ctx, like a hung connection
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{RequestTimeout: 20 * time.Millisecond})
+
+		start := time.Now()
+		_, err := svc.SearchNutrition(ctx, request)
+		if !errors.Is(err, domain.ErrTimeout) || !errors.Is(err, domain.ErrUSDAAPIFailure) {
+			t.Errorf("error = %v, want ErrTimeout wrapped in ErrUSDAAPIFailure", err)
+		}
+		if elapsed := time.Since(start); elapsed >= client.searchDelay {
+			t.Errorf("search took %s, want it cut off well before the %s USDA delay", elapsed, client.searchDelay)
+		}
+	})
+
+	t.Run("returns results that arrive in time", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		client.searchResult = &domain.USDASearchResponse{
+			Foods: []domain.USDAFood{{FdcID: 456, Description: "Whole Milk"}},
+		}
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{RequestTimeout: time.Second})
+
+		result, err := svc.SearchNutrition(ctx, request)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "456" {
+			t.Errorf("FdcID = %s, want 456", result.FdcID)
+		}
+	})
+}
+
+func TestSetInCache_KeepHigherConfidence(t *testing.T) {
+	ctx := context.Background()
+	key := "v2:nutrition:search:whole milk:"
+
+	t.Run("rejects a lower-confidence overwrite", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		cache.data[key] = &domain.NutritionData{FdcID: "1", Confidence: 90}
+		svc := NewNutritionService(cache, NewMockUSDAClient(), NutritionServiceConfig{KeepHigherConfidence: true})
+
+		if err := svc.setInCache(ctx, key, &domain.NutritionData{FdcID: "2", Confidence: 60}, time.Hour); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := cache.data[key].(*domain.NutritionData); got.FdcID != "1" {
+			t.Errorf("cached FdcID = %s, want 1 (higher confidence kept)", got.FdcID)
+		}
+	})
+
+	t.Run("accepts a higher-confidence overwrite", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		cache.data[key] = &domain.NutritionData{FdcID: "1", Confidence: 60}
+		svc := NewNutritionService(cache, NewMockUSDAClient(), NutritionServiceConfig{KeepHigherConfidence: true})
+
+		if err := svc.setInCache(ctx, key, &domain.NutritionData{FdcID: "2", Confidence: 90}, time.Hour); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := cache.data[key].(*domain.NutritionData); got.FdcID != "2" {
+			t.Errorf("cached FdcID = %s, want 2", got.FdcID)
+		}
+	})
+
+	t.Run("last writer wins when disabled", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		cache.data[key] = &domain.NutritionData{FdcID: "1", Confidence: 90}
+		svc := NewNutritionService(cache, NewMockUSDAClient(), NutritionServiceConfig{})
+
+		if err := svc.setInCache(ctx, key, &domain.NutritionData{FdcID: "2", Confidence: 60}, time.Hour); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := cache.data[key].(*domain.NutritionData); got.FdcID != "2" {
+			t.Errorf("cached FdcID = %s, want 2", got.FdcID)
+		}
+	})
+
+	t.Run("concurrent writers keep the highest confidence", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		svc := NewNutritionService(cache, NewMockUSDAClient(), NutritionServiceConfig{KeepHigherConfidence: true})
+
+		var wg sync.WaitGroup
+		for i := 1; i <= 20; i++ {
+			wg.Add(1)
+			go func(confidence float64) {
+				defer wg.Done()
+				svc.setInCache(ctx, key, &domain.NutritionData{FdcID: fmt.Sprintf("%.0f", confidence), Confidence: confidence}, time.Hour)
+			}(float64(i * 5))
+		}
+		wg.Wait()
+
+		if got := cache.data[key].(*domain.NutritionData); got.Confidence != 100 {
+			t.Errorf("cached confidence = %.0f, want 100", got.Confidence)
+		}
+	})
+}
+
+func TestGetNutritionByID(t *testing.T) {
+	ctx := context.Background()
+	food := &domain.USDAFood{
+		FdcID:       746782,
+		Description: "Milk, whole",
+		Nutrients:   []domain.USDANutrient{{NutrientID: 1008, Value: 61}},
+	}
+
+	t.Run("fetches, maps with full confidence and caches", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		client := NewMockUSDAClient()
+		client.foodResult = food
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{})
+
+		result, err := svc.GetNutritionByID(ctx, "746782")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.FdcID != "746782" || result.Confidence != 100 || result.Nutrients.Calories != 61 {
+			t.Errorf("result = %+v, want fdcId 746782 at confidence 100 with 61 kcal", result)
+		}
+		if _, ok := cache.data["v2:fdc:746782"]; !ok {
+			t.Error("expected result cached under v2:fdc:746782")
+		}
+
+		cached, err := svc.GetNutritionByID(ctx, "746782")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cached.Source != "Cache" || client.foodCalls != 1 {
+			t.Errorf("Source = %s after %d fetches, want a cache hit after 1", cached.Source, client.foodCalls)
+		}
+	})
+
+	t.Run("never shares a key with a search", func(t *testing.T) {
+		cache := NewMockCacheRepository()
+		client := NewMockUSDAClient()
+		client.foodResult = food
+		svc := NewNutritionService(cache, client, NutritionServiceConfig{})
+
+		if _, err := svc.GetNutritionByID(ctx, "746782"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		request := &domain.SearchRequest{ProductName: "fdc", Brand: "746782"}
+		if key := svc.generateCacheKey(request); key == svc.fdcCacheKey("746782") {
+			t.Fatalf("search key %q collides with the FDC ID key", key)
+		}
+		if result, err := svc.SearchNutrition(ctx, request); !errors.Is(err, domain.ErrProductNotFound) {
+			t.Errorf("SearchNutrition() = %+v, %v, want ErrProductNotFound rather than the cached food", result, err)
+		}
+	})
+
+	t.Run("rejects empty and non-numeric ids", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		for _, id := range []string{"", "abc", "-5", "12a"} {
+			if _, err := svc.GetNutritionByID(ctx, id); !errors.Is(err, domain.ErrInvalidRequest) {
+				t.Errorf("GetNutritionByID(%q) error = %v, want ErrInvalidRequest", id, err)
+			}
+		}
+		if client.foodCalls != 0 {
+			t.Errorf("foodCalls = %d, want 0", client.foodCalls)
+		}
+	})
+
+	t.Run("passes not found through and wraps other failures", func(t *testing.T) {
+		client := NewMockUSDAClient()
+		svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+		client.foodError = domain.ErrProductNotFound
+		if _, err := svc.GetNutritionByID(ctx, "1"); !errors.Is(err, domain.ErrProductNotFound) {
+			t.Errorf("error = %v, want ErrProductNotFound", err)
+		}
+
+		client.foodError = errors.New("connection reset")
+		if _, err := svc.GetNutritionByID(ctx, "1"); !errors.Is(err, domain.ErrUSDAAPIFailure) {
+			t.Errorf("error = %v, want ErrUSDAAPIFailure", err)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package usecase
+
+import "strings"
+
+// irregularPlurals maps plurals the suffix rules get wrong to their singular
+var irregularPlurals = map[string]string{
+	"cookies":   "cookie",
+	"brownies":  "brownie",
+	"smoothies": "smoothie",
+	"veggies":   "veggie",
+	"pies":      "pie",
+	"leaves":    "leaf",
+	"halves":    "half",
+	"loaves":    "loaf",
+}
+
+// uncountableWords end in "s" but aren't plurals, so they're left unchanged
+var uncountableWords = map[string]bool{
+	"molasses": true, "hummus": true, "couscous": true, "asparagus": true,
+	"citrus": true, "swiss": true, "grits": true, "series": true, "species": true,
+}
+
+// singularize normalizes a common English plural to its singular form
+// ("strawberries" -> "strawberry", "tomatoes" -> "tomato", "eggs" -> "egg").
+// It is deliberately simple: short words and words ending in "ss", "us" or "is"
+// are left alone, as are known irregulars and uncountables.
+func singularize(word string) string {
+	if singular, ok := irregularPlurals[word]; ok {
+		return singular
+	}
+	if len(word) <= 3 || uncountableWords[word] || !strings.HasSuffix(word, "s") {
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "zes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"), strings.HasSuffix(word, "oes"):
+		return strings.TrimSuffix(word, "es")
+	}
+	return strings.TrimSuffix(word, "s")
+}
+
+// singularizeAll applies singularize to each token in place
+func singularizeAll(tokens []string) []string {
+	for i, token := range tokens {
+		tokens[i] = singularize(token)
+	}
+	return tokens
+}
@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+func TestSingularize(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"eggs", "egg"},
+		{"strawberries", "strawberry"},
+		{"tomatoes", "tomato"},
+		{"peaches", "peach"},
+		{"boxes", "box"},
+		{"cheeses", "cheese"},
+		{"cookies", "cookie"},
+		{"leaves", "leaf"},
+		{"milk", "milk"},
+		{"hummus", "hummus"},
+		{"molasses", "molasses"},
+		{"swiss", "swiss"},
+		{"less", "less"},
+		{"peas", "pea"},
+		{"gas", "gas"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := singularize(tt.word); got != tt.want {
+				t.Errorf("singularize(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindBestMatch_NormalizePlurals(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		productName string
+		description string
+		token       string
+	}{
+		{"strawberries matches strawberry", "fresh strawberries", "Strawberry, fresh", "strawberry"},
+		{"tomatoes matches tomato", "roma tomatoes", "Tomato, roma", "tomato"},
+		{"eggs matches egg", "large eggs", "Egg, whole, large", "egg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &domain.SearchRequest{ProductName: tt.productName}
+			foods := []domain.USDAFood{{FdcID: 1, Description: tt.description, DataType: "Foundation"}}
+
+			normalized, err := NewMatchingService(MatchConfig{NormalizePlurals: true}).FindBestMatch(ctx, request, foods)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Contains(normalized.MatchedTokens, tt.token) {
+				t.Errorf("MatchedTokens = %v, want to contain %q", normalized.MatchedTokens, tt.token)
+			}
+
+			// Without normalization the plural only matches fuzzily, if at all
+			plain, err := NewMatchingService(MatchConfig{}).FindBestMatch(ctx, request, foods)
+			if err == nil && plain.MatchScore >= normalized.MatchScore {
+				t.Errorf("score without normalization = %.1f, want below %.1f", plain.MatchScore, normalized.MatchScore)
+			}
+		})
+	}
+}
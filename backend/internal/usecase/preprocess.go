@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"strings"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// Preprocessed shows how a product is rewritten before it's searched
+type Preprocessed struct {
+	Query             string   `json:"query"`             // What USDA is searched for
+	Keywords          []string `json:"keywords"`          // The query's food keywords, most important first
+	StoreBrandGeneric bool     `json:"storeBrandGeneric"` // Searched as a store-brand staple, preferring generic data
+}
+
+// Preprocess runs a product name through the same cleaning as a search without
+// calling USDA, so integrators can preview the query their titles produce
+func (s *NutritionService) Preprocess(request *domain.SearchRequest) (*Preprocessed, error) {
+	if request == nil {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	request, err := sanitizeRequest(request, s.rejectControlChars)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(request.ProductName) == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	query, storeBrandGeneric := s.searchQuery(request)
+	keywords := s.queryPreprocessor.ExtractFoodKeywords(query)
+	if keywords == nil {
+		keywords = []string{}
+	}
+	return &Preprocessed{Query: query, Keywords: keywords, StoreBrandGeneric: storeBrandGeneric}, nil
+}
@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+func TestPreprocess(t *testing.T) {
+	client := NewMockUSDAClient()
+	svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{StoreBrandGenerics: true})
+
+	t.Run("matches the search query", func(t *testing.T) {
+		request := &domain.SearchRequest{ProductName: "Horizon Organic Whole Milk Vitamin D, 64 fl oz", Retailer: "walmart"}
+
+		preprocessed, err := svc.Preprocess(request)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if query, _ := svc.searchQuery(request); preprocessed.Query != query {
+			t.Errorf("Query = %q, want the search query %q", preprocessed.Query, query)
+		}
+		if !slices.Equal(preprocessed.Keywords[:3], []string{"milk", "organic", "whole"}) {
+			t.Errorf("Keywords = %v, want milk, organic, whole first", preprocessed.Keywords)
+		}
+		if client.searchCalls != 0 {
+			t.Errorf("searchCalls = %d, want no USDA search", client.searchCalls)
+		}
+	})
+
+	t.Run("flags store-brand generics", func(t *testing.T) {
+		preprocessed, err := svc.Preprocess(&domain.SearchRequest{ProductName: "Great Value Milk", Brand: "Great Value", Retailer: "walmart"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !preprocessed.StoreBrandGeneric {
+			t.Errorf("StoreBrandGeneric = false for %q, want true", preprocessed.Query)
+		}
+	})
+
+	t.Run("rejects an empty name", func(t *testing.T) {
+		if _, err := svc.Preprocess(&domain.SearchRequest{ProductName: "  "}); !errors.Is(err, domain.ErrInvalidRequest) {
+			t.Errorf("error = %v, want ErrInvalidRequest", err)
+		}
+	})
+}
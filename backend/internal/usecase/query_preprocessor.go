@@ -3,21 +3,56 @@ package usecase
 import (
 	"log"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
 // QueryPreprocessor handles cleaning and extracting keywords from product names
 type QueryPreprocessor struct {
 	enableDebugLogging bool
+	retailerProfiles   map[string]*RetailerProfile // Keyed by lowercase retailer name
+}
+
+// Building blocks for the size and pack patterns. A range like "2-3" or "1.5-2"
+// is matched as a whole so no stray digits or hyphens are left behind.
+const (
+	quantityRange = `\d+\.?\d*(?:\s*-\s*\d+\.?\d*)?`
+	countRange    = `(?:\d+\s*-\s*)?\d+`
+	sizeUnits     = `(?:(fl\s*)?oz|(fl\s*)?ounces?|lbs?|pounds?|ml|cl|dl|liters?|litres?|litros?|l|gallons?|quarts?|pints?|kg|kilos?|grams?|gramos?|grs?|g)`
+	packWords     = `(?:pack|pk|count|ct|cans?|bottles?|pouches?|bars?|pieces?)`
+)
+
+// quantityWords are the spelled-out quantities rewritten as digits before a unit
+var quantityWords = map[string]string{
+	"one": "1", "two": "2", "three": "3", "four": "4", "five": "5", "six": "6",
+	"seven": "7", "eight": "8", "nine": "9", "ten": "10", "eleven": "11", "twelve": "12",
+	"half": "0.5", "quarter": "0.25",
+}
+
+// unicodeFractions are the vulgar fractions rewritten as decimals
+var unicodeFractions = map[string]float64{
+	"½": 0.5, "¼": 0.25, "¾": 0.75, "⅓": 0.33, "⅔": 0.67,
 }
 
 // Compiled regex patterns for query preprocessing
 var (
-	// Matches size/quantity patterns like "128 fl oz", "12 oz", "1.5 liter", "2 lb"
-	sizeQuantityPattern = regexp.MustCompile(`\b\d+\.?\d*\s*(fl\s*)?oz\b|\b\d+\.?\d*\s*(fl\s*)?ounces?\b|\b\d+\.?\d*\s*lbs?\b|\b\d+\.?\d*\s*pounds?\b|\b\d+\.?\d*\s*ml\b|\b\d+\.?\d*\s*liters?\b|\b\d+\.?\d*\s*gallons?\b|\b\d+\.?\d*\s*quarts?\b|\b\d+\.?\d*\s*pints?\b|\b\d+\.?\d*\s*kg\b|\b\d+\.?\d*\s*grams?\b|\b\d+\.?\d*\s*g\b`)
+	// Matches size/quantity patterns like "128 fl oz", "12 oz", "1.5 liter", "2 lb",
+	// "500 g" or "1.5 L", including hyphenated ranges like "2-3 lb"
+	sizeQuantityPattern = regexp.MustCompile(`(?i)\b` + quantityRange + `\s*` + sizeUnits + `\b`)
+
+	// Matches a spelled-out quantity directly before a size unit or pack word, like
+	// "half gallon" or "six pack". Number words anywhere else are left alone.
+	quantityWordPattern = regexp.MustCompile(`(?i)\b(?:an?\s+)?(one|two|three|four|five|six|seven|eight|nine|ten|eleven|twelve|half|quarter)[\s-]+(` + sizeUnits + `|` + packWords + `)\b`)
+
+	// Matches a dozen count like "a dozen", "one dozen", "half dozen" or "2 dozen"
+	dozenPattern = regexp.MustCompile(`(?i)\b(?:(?:an?|one|two|three|half|\d+)[\s-]+)?dozen\b`)
 
-	// Matches pack/count patterns like "12 pack", "pack of 6", "6-pack", "24 count", "6 ct", "12 pack cans"
-	packCountPattern = regexp.MustCompile(`\b\d+[-\s]*(pack|pk|count|ct)(\s+\w+)?\b|\bpack\s*of\s*\d+\b|\b\d+\s*cans?\b|\b\d+\s*bottles?\b|\b\d+\s*pouches?\b|\b\d+\s*bars?\b|\b\d+\s*pieces?\b`)
+	// Matches a unicode fraction with an optional whole number, like "½" or "1½"
+	unicodeFractionPattern = regexp.MustCompile(`(\d*)\s*([½¼¾⅓⅔])`)
+
+	// Matches pack/count patterns like "12 pack", "pack of 6", "6-pack", "24 count", "6 ct", "12 pack cans",
+	// including hyphenated ranges like "6-12 ct"
+	packCountPattern = regexp.MustCompile(`\b` + countRange + `[-\s]*(pack|pk|count|ct)(\s+\w+)?\b|\bpack\s*of\s*\d+\b|\b` + countRange + `\s*cans?\b|\b` + countRange + `\s*bottles?\b|\b` + countRange + `\s*pouches?\b|\b` + countRange + `\s*bars?\b|\b` + countRange + `\s*pieces?\b`)
 
 	// Matches standalone numbers with no unit (e.g., ", 128", "- 12")
 	standaloneNumberPattern = regexp.MustCompile(`[,\-]\s*\d+\.?\d*\s*$|^\d+\.?\d*\s*[,\-]`)
@@ -80,24 +115,87 @@ var queryNoiseWords = map[string]bool{
 	"brand":   true,
 }
 
+// genericDescriptors give a single-word staple the default variety USDA uses for its
+// generic entry, so a store-brand title like "Great Value Milk" searches for
+// "whole milk" rather than the overly broad "milk"
+var genericDescriptors = map[string]string{
+	"milk":   "whole",
+	"bread":  "white",
+	"eggs":   "whole",
+	"egg":    "whole",
+	"rice":   "white",
+	"butter": "salted",
+	"yogurt": "plain",
+	"flour":  "all-purpose",
+	"sugar":  "granulated",
+	"oats":   "rolled",
+}
+
 // NewQueryPreprocessor creates a new query preprocessor
 func NewQueryPreprocessor(enableDebugLogging bool) *QueryPreprocessor {
 	return &QueryPreprocessor{
 		enableDebugLogging: enableDebugLogging,
+		retailerProfiles:   retailerProfiles,
 	}
 }
 
 // PreprocessQuery cleans a product name for USDA API search
 // Removes size/quantity info, pack counts, marketing terms, and normalizes whitespace
 func (p *QueryPreprocessor) PreprocessQuery(productName, brand string) string {
+	return p.PreprocessQueryForRetailer(productName, brand, DefaultRetailer)
+}
+
+// PreprocessQueryForRetailer is PreprocessQuery using the given retailer's profile
+// for store brands and noise words
+func (p *QueryPreprocessor) PreprocessQueryForRetailer(productName, brand, retailer string) string {
 	if productName == "" {
 		return ""
 	}
 
 	original := productName
 
-	// Step 1: Remove size/quantity patterns (e.g., "128 fl oz", "1.5 liter")
-	cleaned := sizeQuantityPattern.ReplaceAllString(productName, " ")
+	// Steps 1-6: Strip sizes, pack counts, store brands, and noise words
+	cleaned := p.CleanProductNameForRetailer(productName, retailer)
+
+	// Step 7: Prepend brand if provided and not already in the cleaned name (case-insensitive check),
+	// collapsing repeated brand occurrences (e.g., "Tyson Tyson Chicken") so it appears once
+	cleaned = withBrand(cleaned, brand)
+
+	// Step 8: Limit query length to avoid USDA API issues
+	if len(cleaned) > 100 {
+		cleaned = cleaned[:100]
+		// Try to cut at word boundary
+		if lastSpace := strings.LastIndex(cleaned, " "); lastSpace > 50 {
+			cleaned = cleaned[:lastSpace]
+		}
+	}
+
+	if p.enableDebugLogging {
+		log.Printf("[PREPROCESS] Input: %q → Output: %q", original, cleaned)
+	}
+
+	return cleaned
+}
+
+// CleanProductName strips size/quantity info, pack counts, and noise words from a
+// product name without adding the brand or truncating. This is the name the
+// matching service scores against.
+func (p *QueryPreprocessor) CleanProductName(productName string) string {
+	return p.CleanProductNameForRetailer(productName, DefaultRetailer)
+}
+
+// CleanProductNameForRetailer is CleanProductName using the given retailer's profile
+func (p *QueryPreprocessor) CleanProductNameForRetailer(productName, retailer string) string {
+	if productName == "" {
+		return ""
+	}
+
+	profile := p.retailerProfile(retailer)
+
+	// Step 1: Write spelled-out quantities as digits (e.g., "half gallon" -> "0.5 gallon"),
+	// then remove size/quantity patterns (e.g., "128 fl oz", "1.5 liter")
+	cleaned := normalizeQuantityWords(productName)
+	cleaned = sizeQuantityPattern.ReplaceAllString(cleaned, " ")
 
 	// Step 2: Remove pack/count patterns (e.g., "12 pack", "pack of 6")
 	cleaned = packCountPattern.ReplaceAllString(cleaned, " ")
@@ -105,43 +203,148 @@ func (p *QueryPreprocessor) PreprocessQuery(productName, brand string) string {
 	// Step 3: Remove standalone numbers at boundaries
 	cleaned = standaloneNumberPattern.ReplaceAllString(cleaned, " ")
 
-	// Step 4: Remove noise words
-	cleaned = p.removeNoiseWords(cleaned)
+	// Step 4: Remove the retailer's store brands and noise words
+	cleaned = profile.StripStoreBrands(cleaned)
+	cleaned = p.removeNoiseWordsWith(cleaned, profile.NoiseWords)
 
 	// Step 5: Clean up punctuation that's now orphaned
 	cleaned = cleanOrphanedPunctuation(cleaned)
 
 	// Step 6: Normalize whitespace
 	cleaned = multiSpacePattern.ReplaceAllString(cleaned, " ")
-	cleaned = strings.TrimSpace(cleaned)
-
-	// Step 7: Prepend brand if provided and not already in the cleaned name (case-insensitive check)
-	if brand != "" {
-		cleanedLower := strings.ToLower(cleaned)
-		brandLower := strings.ToLower(brand)
-		if !strings.Contains(cleanedLower, brandLower) {
-			cleaned = brand + " " + cleaned
-		}
+	return strings.TrimSpace(cleaned)
+}
+
+// normalizeQuantityWords rewrites spelled-out quantities and unicode fractions as
+// digits so the size and pack patterns can strip them, and drops dozen counts.
+// Number words not followed by a unit ("half and half") are kept.
+func normalizeQuantityWords(s string) string {
+	s = unicodeFractionPattern.ReplaceAllStringFunc(s, func(match string) string {
+		parts := unicodeFractionPattern.FindStringSubmatch(match)
+		whole, _ := strconv.ParseFloat(parts[1], 64) // 0 when there is no whole number
+		return strconv.FormatFloat(whole+unicodeFractions[parts[2]], 'f', -1, 64)
+	})
+
+	s = quantityWordPattern.ReplaceAllStringFunc(s, func(match string) string {
+		parts := quantityWordPattern.FindStringSubmatch(match)
+		return " " + quantityWords[strings.ToLower(parts[1])] + " " + strings.ToLower(parts[2])
+	})
+
+	return dozenPattern.ReplaceAllString(s, " ")
+}
+
+// retailerProfile returns this preprocessor's profile for a retailer (case-insensitive),
+// falling back to the Walmart profile for empty or unknown retailers
+func (p *QueryPreprocessor) retailerProfile(retailer string) *RetailerProfile {
+	return lookupRetailerProfile(p.retailerProfiles, retailer)
+}
+
+// PreprocessStoreBrandGeneric builds a query for a store-brand product whose cleaned
+// name collapses to a single word (e.g., "Great Value Milk" -> "milk"). The store
+// brand is left out, and a default descriptor is added for known staples. ok is false
+// when the product is not a store-brand generic, in which case PreprocessQuery applies.
+func (p *QueryPreprocessor) PreprocessStoreBrandGeneric(productName, brand, retailer string) (query string, ok bool) {
+	profile := p.retailerProfile(retailer)
+	if !profile.IsStoreBrand(brand) && !profile.ContainsStoreBrand(productName) {
+		return "", false
 	}
 
-	// Step 8: Limit query length to avoid USDA API issues
-	if len(cleaned) > 100 {
-		cleaned = cleaned[:100]
-		// Try to cut at word boundary
-		if lastSpace := strings.LastIndex(cleaned, " "); lastSpace > 50 {
-			cleaned = cleaned[:lastSpace]
-		}
+	// Strip the brand the same way it is stripped from the name
+	cleaned := profile.StripStoreBrands(p.CleanProductNameForRetailer(productName, retailer))
+	words := strings.Fields(cleaned)
+	if len(words) != 1 {
+		return "", false
+	}
+
+	query = words[0]
+	if descriptor, found := genericDescriptors[query]; found {
+		query = descriptor + " " + query
 	}
 
 	if p.enableDebugLogging {
-		log.Printf("[PREPROCESS] Input: %q → Output: %q", original, cleaned)
+		log.Printf("[PREPROCESS] Store-brand generic: %q → %q", productName, query)
 	}
 
-	return cleaned
+	return query, true
+}
+
+// withBrand ensures the brand appears exactly once in the query. Repeated occurrences
+// are dropped (keeping the first), and the brand is prepended when missing.
+func withBrand(name, brand string) string {
+	brand = strings.TrimSpace(brand)
+	if brand == "" {
+		return name
+	}
+
+	name = dedupeBrand(name, brand)
+	if !strings.Contains(strings.ToLower(name), strings.ToLower(brand)) {
+		name = brand + " " + name
+	}
+	return name
+}
+
+// dedupeBrand removes every occurrence of brand after the first (case-insensitive,
+// whole words), along with a "by" right before it, so "Tyson Nuggets by Tyson"
+// keeps only the leading "Tyson"
+func dedupeBrand(name, brand string) string {
+	matches := brandOccurrences(name, brand)
+	if len(matches) < 2 {
+		return name
+	}
+
+	var b strings.Builder
+	last := matches[0][1]
+	b.WriteString(name[:last])
+	for _, m := range matches[1:] {
+		start := m[0]
+		if before := strings.TrimRight(name[last:start], " "); hasWordSuffix(before, "by") {
+			start = last + len(before) - len("by")
+		}
+		b.WriteString(name[last:start])
+		last = m[1]
+	}
+	b.WriteString(name[last:])
+
+	result := multiSpacePattern.ReplaceAllString(b.String(), " ")
+	return cleanOrphanedPunctuation(strings.TrimSpace(result))
+}
+
+// brandOccurrences returns the byte ranges of brand in name, ignoring case. An
+// occurrence must not run into a neighboring word, so "Dole" doesn't match "Doles".
+func brandOccurrences(name, brand string) [][2]int {
+	var found [][2]int
+	for i := 0; i+len(brand) <= len(name); i++ {
+		end := i + len(brand)
+		if !strings.EqualFold(name[i:end], brand) {
+			continue
+		}
+		if i > 0 && isWordByte(name[i-1]) && isWordByte(brand[0]) {
+			continue
+		}
+		if end < len(name) && isWordByte(name[end]) && isWordByte(brand[len(brand)-1]) {
+			continue
+		}
+		found = append(found, [2]int{i, end})
+		i = end - 1
+	}
+	return found
+}
+
+// hasWordSuffix reports whether s ends with word as a whole word, ignoring case
+func hasWordSuffix(s, word string) bool {
+	if len(s) < len(word) || !strings.EqualFold(s[len(s)-len(word):], word) {
+		return false
+	}
+	return len(s) == len(word) || !isWordByte(s[len(s)-len(word)-1])
 }
 
 // removeNoiseWords removes marketing and generic terms from the query
 func (p *QueryPreprocessor) removeNoiseWords(s string) string {
+	return p.removeNoiseWordsWith(s, nil)
+}
+
+// removeNoiseWordsWith removes the global noise words plus any extra (retailer-specific) ones
+func (p *QueryPreprocessor) removeNoiseWordsWith(s string, extra map[string]bool) string {
 	words := strings.Fields(strings.ToLower(s))
 	var kept []string
 
@@ -149,7 +352,7 @@ func (p *QueryPreprocessor) removeNoiseWords(s string) string {
 		// Clean punctuation from word for checking
 		cleanWord := strings.Trim(word, ",.!?;:-'\"")
 
-		if !queryNoiseWords[cleanWord] {
+		if !queryNoiseWords[cleanWord] && !extra[cleanWord] {
 			// Preserve original word (with punctuation)
 			kept = append(kept, word)
 		}
@@ -150,6 +150,221 @@ func TestPreprocessQuery(t *testing.T) {
 	}
 }
 
+func TestPreprocessQuery_QuantityRanges(t *testing.T) {
+	p := NewQueryPreprocessor(false)
+
+	testCases := []struct {
+		name        string
+		productName string
+		want        string
+	}{
+		{
+			name:        "strips weight range",
+			productName: "Chicken Thighs, 2-3 lb",
+			want:        "chicken thighs",
+		},
+		{
+			name:        "strips weight range with spaces around hyphen",
+			productName: "Russet Potatoes 5 - 10 lbs",
+			want:        "russet potatoes",
+		},
+		{
+			name:        "strips decimal weight range",
+			productName: "Bananas, 2.5-3 lb",
+			want:        "bananas",
+		},
+		{
+			name:        "strips count range",
+			productName: "Brown Eggs, 6-12 ct",
+			want:        "brown eggs",
+		},
+		{
+			name:        "strips pack range",
+			productName: "Sparkling Water 8-12 pack",
+			want:        "sparkling water",
+		},
+		{
+			name:        "strips range mid-title",
+			productName: "Ground Beef 1-2 lb Lean",
+			want:        "ground beef lean",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := p.PreprocessQuery(tc.productName, "")
+			if got != tc.want {
+				t.Errorf("PreprocessQuery(%q) = %q, want %q", tc.productName, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPreprocessQuery_MetricUnits(t *testing.T) {
+	p := NewQueryPreprocessor(false)
+
+	testCases := []struct {
+		name        string
+		productName string
+		want        string
+	}{
+		{"uppercase liter abbreviation", "Leche Entera, 1.5 L", "leche entera"},
+		{"unit attached to the number", "Coca-Cola 2L", "coca-cola"},
+		{"spelled-out Spanish liter", "Agua Purificada 1 Litro", "agua purificada"},
+		{"centiliters", "Jus d'orange 75 cl", "jus d'orange"},
+		{"gram abbreviation", "Queso Fresco 400 gr", "queso fresco"},
+		{"uppercase ounces", "Whole Milk 12 OZ", "whole milk"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := p.PreprocessQuery(tc.productName, ""); got != tc.want {
+				t.Errorf("PreprocessQuery(%q) = %q, want %q", tc.productName, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPreprocessQuery_QuantityWords(t *testing.T) {
+	p := NewQueryPreprocessor(false)
+
+	testCases := []struct {
+		name        string
+		productName string
+		want        string
+	}{
+		{
+			name:        "strips half gallon",
+			productName: "half gallon whole milk",
+			want:        "whole milk",
+		},
+		{
+			name:        "strips hyphenated, capitalized quantity",
+			productName: "Half-Gallon Whole Milk",
+			want:        "whole milk",
+		},
+		{
+			name:        "strips a quarter pound",
+			productName: "Deli Turkey Breast, a quarter pound",
+			want:        "deli turkey breast",
+		},
+		{
+			name:        "strips spelled-out pack count",
+			productName: "Coca-Cola Six Pack",
+			want:        "coca-cola",
+		},
+		{
+			name:        "strips a dozen",
+			productName: "A Dozen Brown Eggs",
+			want:        "brown eggs",
+		},
+		{
+			name:        "strips one dozen",
+			productName: "Organic Eggs, One Dozen",
+			want:        "organic eggs",
+		},
+		{
+			name:        "strips unicode fraction",
+			productName: "Unsalted Butter, ½ lb",
+			want:        "unsalted butter",
+		},
+		{
+			name:        "strips whole number with unicode fraction",
+			productName: "Chicken Breast 1½ lb",
+			want:        "chicken breast",
+		},
+		{
+			name:        "keeps number words without a unit",
+			productName: "Half and Half",
+			want:        "half and half",
+		},
+		{
+			name:        "keeps number words in product names",
+			productName: "Pepsi One, 12 fl oz",
+			want:        "pepsi one",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := p.PreprocessQuery(tc.productName, "")
+			if got != tc.want {
+				t.Errorf("PreprocessQuery(%q) = %q, want %q", tc.productName, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPreprocessQuery_DuplicatedBrand(t *testing.T) {
+	p := NewQueryPreprocessor(false)
+
+	testCases := []struct {
+		name        string
+		productName string
+		brand       string
+		want        string
+	}{
+		{
+			name:        "collapses doubled leading brand",
+			productName: "Tyson Tyson Chicken Breasts",
+			brand:       "Tyson",
+			want:        "tyson chicken breasts",
+		},
+		{
+			name:        "collapses repeated brand later in title",
+			productName: "Tyson Chicken Nuggets by Tyson",
+			brand:       "Tyson",
+			want:        "tyson chicken nuggets",
+		},
+		{
+			name:        "keeps by before a single brand",
+			productName: "Chicken Nuggets by Tyson",
+			brand:       "Tyson",
+			want:        "chicken nuggets by tyson",
+		},
+		{
+			name:        "keeps words ending in by",
+			productName: "Tyson Chicken Nuggets Derby Tyson",
+			brand:       "Tyson",
+			want:        "tyson chicken nuggets derby",
+		},
+		{
+			name:        "collapses multi-word brand",
+			productName: "Kraft Heinz Kraft Heinz Ketchup",
+			brand:       "Kraft Heinz",
+			want:        "kraft heinz ketchup",
+		},
+		{
+			name:        "does not touch brand as part of another word",
+			productName: "Doles Dole Pineapple",
+			brand:       "Dole",
+			want:        "doles dole pineapple",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := p.PreprocessQuery(tc.productName, tc.brand)
+			if got != tc.want {
+				t.Errorf("PreprocessQuery(%q, %q) = %q, want %q", tc.productName, tc.brand, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCleanProductName(t *testing.T) {
+	p := NewQueryPreprocessor(false)
+
+	got := p.CleanProductName("Premium Whole Milk, Vitamin D, 128 fl oz")
+	if got != "whole milk, vitamin d" {
+		t.Errorf("CleanProductName() = %q, want %q", got, "whole milk, vitamin d")
+	}
+
+	if got := p.CleanProductName(""); got != "" {
+		t.Errorf("CleanProductName(\"\") = %q, want empty", got)
+	}
+}
+
 func TestPreprocessQuery_LongInput(t *testing.T) {
 	p := NewQueryPreprocessor(false)
 
@@ -220,6 +435,37 @@ func TestRemoveNoiseWords(t *testing.T) {
 	}
 }
 
+func TestPreprocessStoreBrandGeneric(t *testing.T) {
+	p := NewQueryPreprocessor(false)
+
+	testCases := []struct {
+		name        string
+		productName string
+		brand       string
+		retailer    string
+		wantQuery   string
+		wantOK      bool
+	}{
+		{"store brand in title", "Great Value Milk", "", "", "whole milk", true},
+		{"store brand as brand field", "Milk, 128 fl oz", "Great Value", "", "whole milk", true},
+		{"target store brand", "Good & Gather Eggs, 12 ct", "", "target", "whole eggs", true},
+		{"no descriptor keeps the word", "Great Value Ketchup", "", "", "ketchup", true},
+		{"already specific", "Great Value 2% Reduced Fat Milk", "", "", "", false},
+		{"national brand", "Horizon Organic Milk", "Horizon", "", "", false},
+		{"other retailer's store brand", "Great Value Milk", "", "kroger", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := p.PreprocessStoreBrandGeneric(tc.productName, tc.brand, tc.retailer)
+			if ok != tc.wantOK || got != tc.wantQuery {
+				t.Errorf("PreprocessStoreBrandGeneric(%q, %q, %q) = (%q, %v), want (%q, %v)",
+					tc.productName, tc.brand, tc.retailer, got, ok, tc.wantQuery, tc.wantOK)
+			}
+		})
+	}
+}
+
 func TestCleanOrphanedPunctuation(t *testing.T) {
 	testCases := []struct {
 		input string
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+// publicationDateLayouts are the date formats USDA uses: ISO on search results,
+// US-style on food details
+var publicationDateLayouts = []string{"2006-01-02", "1/2/2006"}
+
+// publicationTime returns when a USDA food was published, or false when it carries
+// no recognizable date
+func publicationTime(food domain.USDAFood) (time.Time, bool) {
+	for _, value := range []string{food.PublishedDate, food.PublicationDate} {
+		if value == "" {
+			continue
+		}
+		for _, layout := range publicationDateLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// recencyBonuses returns a bonus per food, scaled linearly from 0 for the oldest
+// dated candidate to maxBonus for the newest. Undated foods get nothing, and so
+// does every food when fewer than two distinct dates are present.
+func recencyBonuses(foods []domain.USDAFood, maxBonus float64) []float64 {
+	bonuses := make([]float64, len(foods))
+	if maxBonus <= 0 {
+		return bonuses
+	}
+
+	dates := make([]time.Time, len(foods))
+	var oldest, newest time.Time
+	for i, food := range foods {
+		published, ok := publicationTime(food)
+		if !ok {
+			continue
+		}
+		dates[i] = published
+		if oldest.IsZero() || published.Before(oldest) {
+			oldest = published
+		}
+		if published.After(newest) {
+			newest = published
+		}
+	}
+
+	span := newest.Sub(oldest)
+	if span <= 0 {
+		return bonuses
+	}
+	for i, published := range dates {
+		if !published.IsZero() {
+			bonuses[i] = maxBonus * float64(published.Sub(oldest)) / float64(span)
+		}
+	}
+	return bonuses
+}
@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+func TestPublicationTime(t *testing.T) {
+	want := time.Date(2021, time.October, 28, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		food   domain.USDAFood
+		wantOK bool
+	}{
+		{"search result date", domain.USDAFood{PublishedDate: "2021-10-28"}, true},
+		{"food details date", domain.USDAFood{PublicationDate: "10/28/2021"}, true},
+		{"no date", domain.USDAFood{}, false},
+		{"unparseable date", domain.USDAFood{PublishedDate: "last year"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := publicationTime(tt.food)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !got.Equal(want) {
+				t.Errorf("publicationTime = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestRecencyBonuses(t *testing.T) {
+	foods := []domain.USDAFood{
+		{PublishedDate: "2020-01-01"},
+		{PublishedDate: "2022-01-01"},
+		{},
+		{PublishedDate: "2021-01-01"},
+	}
+
+	got := recencyBonuses(foods, 2)
+	if got[0] != 0 || got[1] != 2 || got[2] != 0 {
+		t.Errorf("bonuses = %v, want oldest 0, newest 2, undated 0", got)
+	}
+	if got[3] <= 0 || got[3] >= 2 {
+		t.Errorf("middle bonus = %v, want between 0 and 2", got[3])
+	}
+
+	single := recencyBonuses([]domain.USDAFood{{PublishedDate: "2020-01-01"}, {}}, 2)
+	if single[0] != 0 || single[1] != 0 {
+		t.Errorf("bonuses with one dated food = %v, want none", single)
+	}
+}
+
+func TestFindTopMatches_RecencyBonus(t *testing.T) {
+	// Identical entries: the older one has the lower fdcId, so it wins without the bonus
+	foods := []domain.USDAFood{
+		{FdcID: 100, Description: "Chobani Greek Yogurt", DataType: "Branded", PublishedDate: "2019-04-01"},
+		{FdcID: 200, Description: "Chobani Greek Yogurt", DataType: "Branded", PublishedDate: "2023-08-15"},
+	}
+	request := &domain.SearchRequest{ProductName: "greek yogurt", Brand: "Chobani", Explain: true}
+
+	t.Run("disabled keeps the fdcId tie-break", func(t *testing.T) {
+		matches, err := NewMatchingService(MatchConfig{}).FindTopMatches(context.Background(), request, foods, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if matches[0].FdcID != "100" {
+			t.Errorf("best match = %s, want 100", matches[0].FdcID)
+		}
+	})
+
+	t.Run("favors the newer entry", func(t *testing.T) {
+		matches, err := NewMatchingService(MatchConfig{RecencyBonus: 2}).FindTopMatches(context.Background(), request, foods, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if matches[0].FdcID != "200" {
+			t.Errorf("best match = %s, want the newer 200", matches[0].FdcID)
+		}
+		if got := matches[0].Explanation.RecencyBonus; got != 2 {
+			t.Errorf("explained recency bonus = %v, want 2", got)
+		}
+	})
+
+	t.Run("does not overturn a clearly better match", func(t *testing.T) {
+		foods := []domain.USDAFood{
+			{FdcID: 100, Description: "Chobani Greek Yogurt", DataType: "Branded", PublishedDate: "2019-04-01"},
+			{FdcID: 200, Description: "Chobani Oat Milk", DataType: "Branded", PublishedDate: "2023-08-15"},
+		}
+		matches, err := NewMatchingService(MatchConfig{RecencyBonus: 2}).FindTopMatches(context.Background(), request, foods, 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if matches[0].FdcID != "100" {
+			t.Errorf("best match = %s, want the better-scoring 100", matches[0].FdcID)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultRetailer is the profile used when a request names no retailer or an unknown one
+const DefaultRetailer = "walmart"
+
+// RetailerProfile holds retailer-specific preprocessing rules. Store brands are
+// house labels that carry no information for USDA matching (e.g., Target's
+// "Good & Gather"); noise words are listing terms specific to that retailer's site.
+type RetailerProfile struct {
+	Name        string
+	StoreBrands []string
+	NoiseWords  map[string]bool
+
+	storeBrandPatterns []*regexp.Regexp
+}
+
+// retailerProfiles is the whitelist of supported retailers, keyed by lowercase name
+var retailerProfiles = map[string]*RetailerProfile{
+	"walmart": newRetailerProfile("walmart",
+		[]string{"great value", "sam's choice", "marketside", "equate", "freshness guaranteed"},
+		[]string{"rollback", "clearance"},
+	),
+	"target": newRetailerProfile("target",
+		[]string{"good & gather", "market pantry", "favorite day", "archer farms", "up&up"},
+		[]string{"circle", "exclusive"},
+	),
+	"kroger": newRetailerProfile("kroger",
+		[]string{"kroger", "simple truth organic", "simple truth", "private selection", "heritage farm"},
+		[]string{"digital", "coupon"},
+	),
+	"costco": newRetailerProfile("costco",
+		[]string{"kirkland signature", "kirkland"},
+		[]string{"membership", "savings"},
+	),
+}
+
+// newRetailerProfile builds a profile and precompiles its store brand patterns
+func newRetailerProfile(name string, storeBrands, noiseWords []string) *RetailerProfile {
+	profile := &RetailerProfile{
+		Name:        name,
+		StoreBrands: storeBrands,
+		NoiseWords:  make(map[string]bool, len(noiseWords)),
+	}
+	for _, word := range noiseWords {
+		profile.NoiseWords[word] = true
+	}
+	for _, brand := range storeBrands {
+		profile.storeBrandPatterns = append(profile.storeBrandPatterns, phrasePattern(brand))
+	}
+	return profile
+}
+
+// retailerProfilesWith returns the built-in profiles with configured store brands
+// and noise words replacing a retailer's own, keyed by lowercase retailer name. A
+// retailer without a built-in profile gets a new one. Store brands are tried
+// longest first, so "kirkland signature" is stripped whole before "kirkland".
+func retailerProfilesWith(storeBrands, noiseWords map[string][]string) map[string]*RetailerProfile {
+	if len(storeBrands) == 0 && len(noiseWords) == 0 {
+		return retailerProfiles
+	}
+
+	profiles := make(map[string]*RetailerProfile, len(retailerProfiles)+len(storeBrands))
+	for name, profile := range retailerProfiles {
+		profiles[name] = profile
+	}
+	for _, overrides := range []map[string][]string{storeBrands, noiseWords} {
+		for retailer := range overrides {
+			name := strings.ToLower(strings.TrimSpace(retailer))
+			var brands, words []string
+			if builtIn, ok := profiles[name]; ok {
+				brands = builtIn.StoreBrands
+				for word := range builtIn.NoiseWords {
+					words = append(words, word)
+				}
+			}
+			if configured, ok := storeBrands[retailer]; ok {
+				brands = slices.Clone(configured)
+				slices.SortStableFunc(brands, func(a, b string) int { return cmp.Compare(len(b), len(a)) })
+			}
+			if configured, ok := noiseWords[retailer]; ok {
+				words = configured
+			}
+			profiles[name] = newRetailerProfile(name, brands, words)
+		}
+	}
+	return profiles
+}
+
+// GetRetailerProfile returns the profile for a retailer (case-insensitive),
+// falling back to the Walmart profile for empty or unknown retailers
+func GetRetailerProfile(retailer string) *RetailerProfile {
+	return lookupRetailerProfile(retailerProfiles, retailer)
+}
+
+// lookupRetailerProfile returns a retailer's profile from profiles, or the default
+// retailer's for empty or unknown retailers
+func lookupRetailerProfile(profiles map[string]*RetailerProfile, retailer string) *RetailerProfile {
+	if profile, ok := profiles[strings.ToLower(strings.TrimSpace(retailer))]; ok {
+		return profile
+	}
+	return profiles[DefaultRetailer]
+}
+
+// ParseRetailerTerms parses per-retailer store brands or noise words like
+// "costco:kirkland signature|kirkland,aldi:simply nature" (comma-separated
+// retailer:terms entries, terms separated by "|"). An empty spec returns nil.
+func ParseRetailerTerms(spec string) (map[string][]string, error) {
+	return parseTermTable(spec, "retailer term", "retailer:term|term")
+}
+
+// StripStoreBrands removes this retailer's house brands from a product name
+func (r *RetailerProfile) StripStoreBrands(s string) string {
+	for _, pattern := range r.storeBrandPatterns {
+		s = pattern.ReplaceAllString(s, " ")
+	}
+	return s
+}
+
+// IsStoreBrand reports whether brand is one of this retailer's house brands
+func (r *RetailerProfile) IsStoreBrand(brand string) bool {
+	brand = strings.ToLower(strings.TrimSpace(brand))
+	for _, storeBrand := range r.StoreBrands {
+		if brand == storeBrand {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsStoreBrand reports whether a product name mentions one of this retailer's house brands
+func (r *RetailerProfile) ContainsStoreBrand(s string) bool {
+	for _, pattern := range r.storeBrandPatterns {
+		if pattern.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// phrasePattern matches a phrase case-insensitively as whole words. Word
+// boundaries are only applied at ends that are word characters, so brands
+// like "up&up" or "good & gather" still match cleanly.
+func phrasePattern(phrase string) *regexp.Regexp {
+	quoted := regexp.QuoteMeta(phrase)
+	prefix, suffix := `(?:^|\s)`, `(?:\s|$|,)`
+	if isWordByte(phrase[0]) {
+		prefix = `\b`
+	}
+	if isWordByte(phrase[len(phrase)-1]) {
+		suffix = `\b`
+	}
+	return regexp.MustCompile(`(?i)` + prefix + quoted + suffix)
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
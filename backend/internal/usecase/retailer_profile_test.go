@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+func TestGetRetailerProfile(t *testing.T) {
+	testCases := []struct {
+		retailer string
+		want     string
+	}{
+		{"walmart", "walmart"},
+		{"Target", "target"},
+		{" KROGER ", "kroger"},
+		{"Costco", "costco"},
+		{"", DefaultRetailer},
+		{"aldi", DefaultRetailer}, // not whitelisted
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.retailer, func(t *testing.T) {
+			if got := GetRetailerProfile(tc.retailer).Name; got != tc.want {
+				t.Errorf("GetRetailerProfile(%q).Name = %q, want %q", tc.retailer, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCleanProductNameForRetailer(t *testing.T) {
+	p := NewQueryPreprocessor(false)
+
+	testCases := []struct {
+		name        string
+		productName string
+		retailer    string
+		want        string
+	}{
+		{
+			name:        "target strips its house brand",
+			productName: "Good & Gather Organic Whole Milk, 128 fl oz",
+			retailer:    "target",
+			want:        "organic whole milk",
+		},
+		{
+			name:        "walmart keeps target house brand",
+			productName: "Good & Gather Organic Whole Milk, 128 fl oz",
+			retailer:    "walmart",
+			want:        "good & gather organic whole milk",
+		},
+		{
+			name:        "default profile keeps target house brand",
+			productName: "Good & Gather Organic Whole Milk",
+			retailer:    "",
+			want:        "good & gather organic whole milk",
+		},
+		{
+			name:        "target strips brand with punctuation",
+			productName: "up&up Baby Wipes",
+			retailer:    "target",
+			want:        "baby wipes",
+		},
+		{
+			name:        "kroger strips longest house brand",
+			productName: "Simple Truth Organic Peanut Butter",
+			retailer:    "kroger",
+			want:        "peanut butter",
+		},
+		{
+			name:        "walmart strips its house brand and noise words",
+			productName: "Marketside Caesar Salad Kit Rollback",
+			retailer:    "walmart",
+			want:        "caesar salad kit",
+		},
+		{
+			name:        "costco strips kirkland signature whole",
+			productName: "Kirkland Signature Organic Whole Milk, 64 fl oz",
+			retailer:    "costco",
+			want:        "organic whole milk",
+		},
+		{
+			name:        "walmart keeps costco house brand",
+			productName: "Kirkland Signature Organic Whole Milk",
+			retailer:    "walmart",
+			want:        "kirkland signature organic whole milk",
+		},
+		{
+			name:        "retailer noise words only apply to that retailer",
+			productName: "Circle Cereal",
+			retailer:    "walmart",
+			want:        "circle cereal",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := p.CleanProductNameForRetailer(tc.productName, tc.retailer)
+			if got != tc.want {
+				t.Errorf("CleanProductNameForRetailer(%q, %q) = %q, want %q", tc.productName, tc.retailer, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPreprocessQueryForRetailer_StillPrependsBrand(t *testing.T) {
+	p := NewQueryPreprocessor(false)
+
+	got := p.PreprocessQueryForRetailer("Good & Gather Whole Milk", "Good & Gather", "target")
+	want := "Good & Gather whole milk"
+	if got != want {
+		t.Errorf("PreprocessQueryForRetailer() = %q, want %q", got, want)
+	}
+}
+
+func TestSearchNutrition_CostcoRequest(t *testing.T) {
+	client := NewMockUSDAClient()
+	client.searchResult = &domain.USDASearchResponse{Foods: []domain.USDAFood{{FdcID: 1, Description: "Milk, whole"}}}
+	svc := NewNutritionService(NewMockCacheRepository(), client, NutritionServiceConfig{})
+
+	_, _ = svc.SearchNutrition(context.Background(), &domain.SearchRequest{
+		ProductName: "Kirkland Signature Whole Milk",
+		Retailer:    "costco",
+	})
+	if client.lastQuery != "whole milk" {
+		t.Errorf("query = %q, want the Kirkland Signature brand stripped", client.lastQuery)
+	}
+}
+
+func TestRetailerProfilesWith(t *testing.T) {
+	storeBrands, err := ParseRetailerTerms("costco:kirkland|kirkland signature,aldi:simply nature")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	noiseWords, err := ParseRetailerTerms("aldi:aldi finds")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	svc := NewNutritionService(NewMockCacheRepository(), NewMockUSDAClient(), NutritionServiceConfig{
+		RetailerStoreBrands: storeBrands,
+		RetailerNoiseWords:  noiseWords,
+	})
+	p := svc.queryPreprocessor
+
+	testCases := []struct {
+		name        string
+		productName string
+		retailer    string
+		want        string
+	}{
+		{"overridden brands are tried longest first", "Kirkland Signature Almond Butter", "costco", "almond butter"},
+		{"overriding brands keeps built-in noise words", "Kirkland Greek Yogurt Savings", "costco", "greek yogurt"},
+		{"new retailer gets a profile", "Simply Nature Organic Kale Chips", "aldi", "organic kale chips"},
+		{"other retailers keep the built-ins", "Great Value Whole Milk", "walmart", "whole milk"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := p.CleanProductNameForRetailer(tc.productName, tc.retailer); got != tc.want {
+				t.Errorf("CleanProductNameForRetailer(%q, %q) = %q, want %q", tc.productName, tc.retailer, got, tc.want)
+			}
+		})
+	}
+
+	if got := svc.Vocabulary("").StoreBrands["aldi"]; len(got) != 1 || got[0] != "simply nature" {
+		t.Errorf("aldi vocabulary store brands = %v, want the configured simply nature", got)
+	}
+	if GetRetailerProfile("aldi").Name != DefaultRetailer {
+		t.Error("overrides leaked into the built-in profiles")
+	}
+}
+
+func TestParseRetailerTerms(t *testing.T) {
+	if table, err := ParseRetailerTerms(""); err != nil || table != nil {
+		t.Errorf("ParseRetailerTerms(\"\") = %v, %v, want nil, nil", table, err)
+	}
+	if _, err := ParseRetailerTerms("costco"); err == nil {
+		t.Error("ParseRetailerTerms without terms: error = nil, want error")
+	}
+}
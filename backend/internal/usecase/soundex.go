@@ -0,0 +1,101 @@
+package usecase
+
+import "strings"
+
+// phoneticBrandBonus is the reduced brand bonus for a brand that only sounds like
+// a word in the USDA description ("Craft" for "Kraft")
+const phoneticBrandBonus = 15.0
+
+// minPhoneticWordLength skips short brand words, whose Soundex codes collide too often
+const minPhoneticWordLength = 3
+
+// soundexCodes maps consonants to their Soundex digit. Vowels and Y separate
+// letters; H and W are skipped without separating.
+var soundexCodes = map[rune]byte{
+	'b': '1', 'f': '1', 'p': '1', 'v': '1',
+	'c': '2', 'g': '2', 'j': '2', 'k': '2', 'q': '2', 's': '2', 'x': '2', 'z': '2',
+	'd': '3', 't': '3',
+	'l': '4',
+	'm': '5', 'n': '5',
+	'r': '6',
+}
+
+// soundex returns the American Soundex code of a word, e.g. "kraft" -> "K613",
+// or "" when it has no letters
+func soundex(word string) string {
+	var code []byte
+	var last byte
+	for _, r := range strings.ToLower(word) {
+		if r < 'a' || r > 'z' {
+			continue
+		}
+		digit := soundexCodes[r]
+		if len(code) == 0 {
+			code = append(code, byte(r-'a'+'A'))
+			last = digit
+			continue
+		}
+		switch {
+		case r == 'h' || r == 'w':
+			continue
+		case digit == 0:
+			last = 0 // Vowels separate letters with the same code
+		case digit != last:
+			code = append(code, digit)
+			last = digit
+			if len(code) == 4 {
+				return string(code)
+			}
+		}
+	}
+	if len(code) == 0 {
+		return ""
+	}
+	return string(code) + strings.Repeat("0", 4-len(code))
+}
+
+// soundsAlike reports whether two words share a Soundex code. Leading letters that
+// sound the same ("K" and "C") count as equal, which plain Soundex misses.
+func soundsAlike(a, b string) bool {
+	codeA, codeB := soundex(a), soundex(b)
+	if codeA == "" || codeB == "" || codeA[1:] != codeB[1:] {
+		return false
+	}
+	if codeA[0] == codeB[0] {
+		return true
+	}
+	digitA := soundexCodes[rune(codeA[0]-'A'+'a')]
+	return digitA != 0 && digitA == soundexCodes[rune(codeB[0]-'A'+'a')]
+}
+
+// phoneticBrandMatch reports whether every significant word of brand sounds like
+// a word in the USDA description
+func phoneticBrandMatch(brand, usdaDesc string) bool {
+	descWords := phoneticWords(usdaDesc)
+
+	matched := false
+	for _, word := range phoneticWords(brand) {
+		if len(word) < minPhoneticWordLength {
+			continue
+		}
+		found := false
+		for _, descWord := range descWords {
+			if len(descWord) >= minPhoneticWordLength && soundsAlike(word, descWord) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// phoneticWords splits text into lowercase words, keeping possessives whole
+// ("Jerry's" -> "jerrys") so they sound like USDA's unpunctuated spelling
+func phoneticWords(text string) []string {
+	text = strings.ReplaceAll(strings.ToLower(text), "'", "")
+	return strings.Fields(punctuationRegex.ReplaceAllString(text, " "))
+}
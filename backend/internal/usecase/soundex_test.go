@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"testing"
+)
+
+func TestSoundex(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"Robert", "R163"},
+		{"Rupert", "R163"},
+		{"Ashcraft", "A261"},
+		{"Tymczak", "T522"},
+		{"Pfister", "P236"},
+		{"Lee", "L000"},
+		{"Kraft", "K613"},
+		{"123", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := soundex(tt.word); got != tt.want {
+				t.Errorf("soundex(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPhoneticBrandMatch(t *testing.T) {
+	tests := []struct {
+		brand    string
+		usdaDesc string
+		want     bool
+	}{
+		{"Kraft", "CRAFT Macaroni & Cheese Dinner", true},
+		{"Smuckers", "SMUKERS Strawberry Jam", true},
+		{"Quaker", "KWAKER Instant Oatmeal", true},
+		{"Ben & Jerry's", "BEN & JERRYS Ice Cream", true},
+		{"Kraft", "HEINZ Tomato Ketchup", false},
+		{"Ben & Jerry's", "BEN'S Original Rice", false},
+		{"A1", "Steak sauce", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.brand+"/"+tt.usdaDesc, func(t *testing.T) {
+			if got := phoneticBrandMatch(tt.brand, tt.usdaDesc); got != tt.want {
+				t.Errorf("phoneticBrandMatch(%q, %q) = %v, want %v", tt.brand, tt.usdaDesc, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateMatchScore_PhoneticBrandMatch(t *testing.T) {
+	const product, usdaDesc = "Macaroni and Cheese", "CRAFT Macaroni & Cheese Dinner"
+
+	plain, _ := NewMatchingService(MatchConfig{}).scoreCandidate(product, "Kraft", usdaDesc, "", false, nil)
+	phonetic, _ := NewMatchingService(MatchConfig{EnablePhoneticBrandMatch: true}).scoreCandidate(product, "Kraft", usdaDesc, "", false, nil)
+	if phonetic-plain != phoneticBrandBonus {
+		t.Errorf("phonetic score %.1f - plain score %.1f = %.1f, want the %.0f point reduced bonus", phonetic, plain, phonetic-plain, phoneticBrandBonus)
+	}
+
+	// An exact brand match still earns the full bonus
+	svc := NewMatchingService(MatchConfig{EnablePhoneticBrandMatch: true})
+	exact, _ := svc.scoreCandidate(product, "Kraft", "KRAFT Macaroni & Cheese Dinner", "", false, nil)
+	if exact-plain != brandMatchBonus {
+		t.Errorf("exact score %.1f - plain score %.1f = %.1f, want the %.0f point bonus", exact, plain, exact-plain, brandMatchBonus)
+	}
+}
@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultSynonymWeight is the fraction of normal weight a synonym match earns,
+// matching the discount applied to fuzzy matches
+const defaultSynonymWeight = fuzzyWeightFactor
+
+// defaultSynonyms maps retail vocabulary to the terms USDA descriptions use.
+// Values may be multi-word; single-word values also map back to their key.
+var defaultSynonyms = map[string][]string{
+	// Beverages
+	"soda":    {"soft drink", "carbonated beverage", "cola"},
+	"pop":     {"soda", "soft drink", "cola"},
+	"seltzer": {"carbonated water", "sparkling water"},
+	// Produce
+	"garbanzo":  {"chickpeas", "chickpea"},
+	"scallion":  {"green onion"},
+	"scallions": {"green onions"},
+	"cilantro":  {"coriander"},
+	"aubergine": {"eggplant"},
+	"courgette": {"zucchini"},
+	"yam":       {"sweet potato"},
+	// Proteins
+	"prawn":     {"shrimp"},
+	"prawns":    {"shrimp"},
+	"hotdog":    {"frankfurter"},
+	"franks":    {"frankfurter"},
+	"hamburger": {"ground beef"},
+	// Pantry
+	"yoghurt":  {"yogurt"},
+	"catsup":   {"ketchup"},
+	"mayo":     {"mayonnaise"},
+	"crisps":   {"chips"},
+	"jelly":    {"jam", "preserves"},
+	"oatmeal":  {"oats"},
+	"biscuits": {"cookies"},
+}
+
+// synonymTable maps a token to the tokenized phrases it may match
+type synonymTable map[string][][]string
+
+// newSynonymTable tokenizes synonyms like product names so they line up with
+// USDA tokens, adding the reverse mapping for single-word synonyms.
+// normalizePlurals singularizes them to match singularized tokens.
+func newSynonymTable(synonyms map[string][]string, normalizePlurals bool) synonymTable {
+	table := make(synonymTable)
+	add := func(token string, phrase []string) {
+		for _, existing := range table[token] {
+			if strings.Join(existing, " ") == strings.Join(phrase, " ") {
+				return
+			}
+		}
+		table[token] = append(table[token], phrase)
+	}
+
+	for term, alternatives := range synonyms {
+		keys := tokenize(term)
+		if normalizePlurals {
+			keys = singularizeAll(keys)
+		}
+		if len(keys) != 1 {
+			continue // Product tokens are single words
+		}
+		key := keys[0]
+		for _, alternative := range alternatives {
+			phrase := tokenize(alternative)
+			if normalizePlurals {
+				phrase = singularizeAll(phrase)
+			}
+			if len(phrase) == 0 || (len(phrase) == 1 && phrase[0] == key) {
+				continue
+			}
+			add(key, phrase)
+			if len(phrase) == 1 {
+				add(phrase[0], []string{key})
+			}
+		}
+	}
+	return table
+}
+
+// ParseSynonyms parses a synonym table like "soda:soft drink|pop,garbanzo:chickpea"
+// (comma-separated term:alternatives entries, alternatives separated by "|").
+// An empty spec returns nil.
+func ParseSynonyms(spec string) (map[string][]string, error) {
+	return parseTermTable(spec, "synonym", "term:alternative|alternative")
+}
+
+// parseTermTable parses comma-separated key:value|value entries into a lowercased
+// map. kind and format name the table in errors. An empty spec returns nil.
+func parseTermTable(spec, kind, format string) (map[string][]string, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	table := make(map[string][]string)
+	for _, entry := range strings.Split(spec, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid %s entry %q: want %s", kind, entry, format)
+		}
+
+		key := strings.ToLower(strings.TrimSpace(parts[0]))
+		for _, value := range strings.Split(parts[1], "|") {
+			if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+				table[key] = append(table[key], value)
+			}
+		}
+		if len(table[key]) == 0 {
+			return nil, fmt.Errorf("invalid %s entry %q: no alternatives", kind, entry)
+		}
+	}
+	return table, nil
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/macrolens/backend/internal/domain"
+)
+
+func TestParseSynonyms(t *testing.T) {
+	t.Run("parses entries", func(t *testing.T) {
+		got, err := ParseSynonyms(" Soda:soft drink|pop , garbanzo:chickpea")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(got["soda"], []string{"soft drink", "pop"}) {
+			t.Errorf("synonyms[soda] = %v, want [soft drink pop]", got["soda"])
+		}
+		if !slices.Equal(got["garbanzo"], []string{"chickpea"}) {
+			t.Errorf("synonyms[garbanzo] = %v, want [chickpea]", got["garbanzo"])
+		}
+	})
+
+	t.Run("empty spec keeps the built-in table", func(t *testing.T) {
+		got, err := ParseSynonyms("")
+		if err != nil || got != nil {
+			t.Errorf("ParseSynonyms(\"\") = %v, %v, want nil, nil", got, err)
+		}
+	})
+
+	for _, spec := range []string{"soda", ":cola", "soda:", "soda:|"} {
+		t.Run("rejects "+spec, func(t *testing.T) {
+			if _, err := ParseSynonyms(spec); err == nil {
+				t.Errorf("ParseSynonyms(%q) error = nil, want error", spec)
+			}
+		})
+	}
+}
+
+func TestNewSynonymTable(t *testing.T) {
+	table := newSynonymTable(map[string][]string{
+		"soda":     {"soft drink", "cola"},
+		"garbanzo": {"chickpeas"},
+	}, false)
+
+	if !slices.ContainsFunc(table["soda"], func(p []string) bool { return slices.Equal(p, []string{"soft", "drink"}) }) {
+		t.Errorf("table[soda] = %v, want to contain [soft drink]", table["soda"])
+	}
+	if !slices.ContainsFunc(table["chickpeas"], func(p []string) bool { return slices.Equal(p, []string{"garbanzo"}) }) {
+		t.Errorf("table[chickpeas] = %v, want the reverse mapping to garbanzo", table["chickpeas"])
+	}
+	if _, ok := table["soft"]; ok {
+		t.Error("multi-word synonyms should not map back to their key")
+	}
+}
+
+func TestFindBestMatch_Synonyms(t *testing.T) {
+	ctx := context.Background()
+	request := &domain.SearchRequest{ProductName: "garbanzo beans"}
+	foods := []domain.USDAFood{{FdcID: 1, Description: "Chickpeas (garbanzo beans, bengal gram), mature seeds, raw", DataType: "Foundation"}}
+
+	t.Run("synonym match is reported and weighted", func(t *testing.T) {
+		request := &domain.SearchRequest{ProductName: "cola soda"}
+		foods := []domain.USDAFood{{FdcID: 1, Description: "Beverages, carbonated, cola, soft drink", DataType: "Survey (FNDDS)"}}
+
+		full, err := NewMatchingService(MatchConfig{SynonymWeight: 1}).FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Contains(full.MatchedTokens, "soda=soft drink") {
+			t.Errorf("MatchedTokens = %v, want to contain soda=soft drink", full.MatchedTokens)
+		}
+
+		reduced, err := NewMatchingService(MatchConfig{SynonymWeight: 0.5}).FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if reduced.MatchScore >= full.MatchScore {
+			t.Errorf("score at weight 0.5 = %.1f, want below %.1f at weight 1", reduced.MatchScore, full.MatchScore)
+		}
+	})
+
+	t.Run("exact matches take precedence over synonyms", func(t *testing.T) {
+		result, err := NewMatchingService(MatchConfig{}).FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Contains(result.MatchedTokens, "garbanzo") || slices.Contains(result.MatchedTokens, "garbanzo=chickpeas") {
+			t.Errorf("MatchedTokens = %v, want the exact garbanzo match only", result.MatchedTokens)
+		}
+	})
+
+	t.Run("config table overrides the built-in one", func(t *testing.T) {
+		request := &domain.SearchRequest{ProductName: "pop"}
+		foods := []domain.USDAFood{{FdcID: 1, Description: "Fizzy water", DataType: "Foundation"}}
+
+		if _, err := NewMatchingService(MatchConfig{}).FindBestMatch(ctx, request, foods); err == nil {
+			t.Error("expected no match with the built-in table")
+		}
+
+		svc := NewMatchingService(MatchConfig{Synonyms: map[string][]string{"pop": {"fizzy"}}})
+		result, err := svc.FindBestMatch(ctx, request, foods)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Contains(result.MatchedTokens, "pop=fizzy") {
+			t.Errorf("MatchedTokens = %v, want to contain pop=fizzy", result.MatchedTokens)
+		}
+	})
+}
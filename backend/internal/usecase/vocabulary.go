@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"sort"
+	"strings"
+)
+
+// Vocabulary lists the words query cleaning strips, so integrators can predict
+// how a product name will be rewritten before it's searched
+type Vocabulary struct {
+	StoreBrands          map[string][]string `json:"storeBrands"`      // House labels, keyed by retailer
+	RetailNoiseWords     map[string][]string `json:"retailNoiseWords"` // Listing terms, keyed by retailer
+	NoiseWords           []string            `json:"noiseWords"`       // Marketing terms removed for every retailer
+	StopWords            []string            `json:"stopWords"`
+	FoodTermCount        int                 `json:"foodTermCount"`
+	DescriptiveTermCount int                 `json:"descriptiveTermCount"`
+}
+
+// Vocabulary returns the active preprocessing vocabulary. A non-empty filter keeps
+// only the words containing it (case-insensitive); the term counts are unfiltered.
+func (s *NutritionService) Vocabulary(filter string) Vocabulary {
+	dicts := s.matchingService.Dictionaries()
+	profiles := s.queryPreprocessor.retailerProfiles
+	filter = strings.ToLower(strings.TrimSpace(filter))
+
+	vocabulary := Vocabulary{
+		StoreBrands:          make(map[string][]string, len(profiles)),
+		RetailNoiseWords:     make(map[string][]string, len(profiles)),
+		NoiseWords:           filterTerms(mergeTerms(queryNoiseWords), filter),
+		StopWords:            filterTerms(dicts.StopWords, filter),
+		FoodTermCount:        len(dicts.FoodTerms),
+		DescriptiveTermCount: len(dicts.DescriptiveTerms),
+	}
+	for name, profile := range profiles {
+		brands := append([]string(nil), profile.StoreBrands...)
+		sort.Strings(brands)
+		vocabulary.StoreBrands[name] = filterTerms(brands, filter)
+		vocabulary.RetailNoiseWords[name] = filterTerms(mergeTerms(profile.NoiseWords), filter)
+	}
+	return vocabulary
+}
+
+// filterTerms returns the terms containing filter, or all of them when it's empty
+func filterTerms(terms []string, filter string) []string {
+	if filter == "" {
+		return terms
+	}
+	matched := []string{}
+	for _, term := range terms {
+		if strings.Contains(term, filter) {
+			matched = append(matched, term)
+		}
+	}
+	return matched
+}
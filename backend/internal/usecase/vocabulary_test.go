@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestVocabulary(t *testing.T) {
+	svc := NewNutritionService(NewMockCacheRepository(), NewMockUSDAClient(), NutritionServiceConfig{
+		ExtraStopWords: []string{"multipack"},
+	})
+
+	t.Run("lists the active words", func(t *testing.T) {
+		vocabulary := svc.Vocabulary("")
+
+		if !slices.Contains(vocabulary.StoreBrands["target"], "good & gather") {
+			t.Errorf("target store brands = %v, want good & gather", vocabulary.StoreBrands["target"])
+		}
+		if !slices.Contains(vocabulary.RetailNoiseWords["walmart"], "rollback") {
+			t.Errorf("walmart noise words = %v, want rollback", vocabulary.RetailNoiseWords["walmart"])
+		}
+		if !slices.Contains(vocabulary.NoiseWords, "premium") {
+			t.Errorf("noise words = %v, want premium", vocabulary.NoiseWords)
+		}
+		if !slices.Contains(vocabulary.StopWords, "multipack") {
+			t.Errorf("stop words = %v, want the configured multipack", vocabulary.StopWords)
+		}
+		if vocabulary.FoodTermCount == 0 || vocabulary.DescriptiveTermCount == 0 {
+			t.Errorf("term counts = %d/%d, want both non-zero", vocabulary.FoodTermCount, vocabulary.DescriptiveTermCount)
+		}
+	})
+
+	t.Run("filters words but not counts", func(t *testing.T) {
+		all := svc.Vocabulary("")
+		vocabulary := svc.Vocabulary(" Truth ")
+
+		if !slices.Equal(vocabulary.StoreBrands["kroger"], []string{"simple truth", "simple truth organic"}) {
+			t.Errorf("kroger store brands = %v, want the simple truth brands", vocabulary.StoreBrands["kroger"])
+		}
+		if len(vocabulary.StoreBrands["walmart"]) != 0 || len(vocabulary.NoiseWords) != 0 {
+			t.Errorf("unmatched lists = %v, %v, want empty", vocabulary.StoreBrands["walmart"], vocabulary.NoiseWords)
+		}
+		if vocabulary.FoodTermCount != all.FoodTermCount {
+			t.Errorf("FoodTermCount = %d, want the unfiltered %d", vocabulary.FoodTermCount, all.FoodTermCount)
+		}
+	})
+}